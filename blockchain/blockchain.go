@@ -33,6 +33,9 @@ type TransferData struct {
 	Error      string    `json:"error,omitempty"`
 	Duration   string    `json:"duration,omitempty"`
 	Timestamp  time.Time `json:"timestamp"`
+
+	// Verification is the integrity check outcome: "verified", "failed" or "unverified"
+	Verification string `json:"verification,omitempty"`
 }
 
 // Blockchain represents the entire chain
@@ -41,9 +44,14 @@ type Blockchain struct {
 	currentHash string
 	mutex       sync.RWMutex
 	dbPath      string
+	lockPath    string // see ledgerLockFile
+	readOnly    bool
 }
 
-// NewBlockchain creates a new blockchain or loads existing one
+// NewBlockchain creates a new blockchain or loads existing one. Other
+// processes using the same data directory are kept out while it loads and
+// while each block is added; ErrLedgerInUse is returned if one holds the
+// ledger for too long.
 func NewBlockchain(dataDir string) (*Blockchain, error) {
 	// Use provided data directory instead of current working directory
 	blockchainDir := filepath.Join(dataDir, "blockchain_data")
@@ -51,11 +59,13 @@ func NewBlockchain(dataDir string) (*Blockchain, error) {
 		return nil, fmt.Errorf("failed to create blockchain directory: %w", err)
 	}
 
-	dbPath := filepath.Join(blockchainDir, "ledger.json")
-	bc := &Blockchain{
-		dbPath: dbPath,
-		blocks: make([]Block, 0),
+	bc := newLedger(blockchainDir)
+
+	lock, err := lockLedger(bc.lockPath, true)
+	if err != nil {
+		return nil, err
 	}
+	defer lock.Unlock()
 
 	// Try to load existing blockchain
 	if err := bc.loadFromDisk(); err != nil {
@@ -73,6 +83,41 @@ func NewBlockchain(dataDir string) (*Blockchain, error) {
 	return bc, nil
 }
 
+// OpenReadOnly loads an existing ledger for inspection. It never creates or
+// writes the ledger, and AddBlock on it fails with ErrLedgerReadOnly.
+func OpenReadOnly(dataDir string) (*Blockchain, error) {
+	blockchainDir := filepath.Join(dataDir, "blockchain_data")
+	if _, err := os.Stat(blockchainDir); err != nil {
+		return nil, fmt.Errorf("no ledger in %s: %w", dataDir, err)
+	}
+
+	bc := newLedger(blockchainDir)
+	bc.readOnly = true
+
+	lock, err := lockLedger(bc.lockPath, false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if err := bc.loadFromDisk(); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no ledger in %s: %w", dataDir, err)
+		}
+		return nil, fmt.Errorf("failed to load blockchain: %w", err)
+	}
+	return bc, nil
+}
+
+// newLedger returns an empty chain stored in blockchainDir
+func newLedger(blockchainDir string) *Blockchain {
+	return &Blockchain{
+		dbPath:   filepath.Join(blockchainDir, "ledger.json"),
+		lockPath: filepath.Join(blockchainDir, ledgerLockFile),
+		blocks:   make([]Block, 0),
+	}
+}
+
 // createGenesisBlock creates the first block in the blockchain
 func (bc *Blockchain) createGenesisBlock() {
 	genesis := Block{
@@ -113,15 +158,32 @@ func (bc *Blockchain) calculateHash(block Block) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// AddBlock adds a new transfer record to the blockchain
+// AddBlock adds a new transfer record to the blockchain. Blocks added by
+// other processes since the ledger was loaded are picked up first, so the new
+// block extends the chain on disk.
 func (bc *Blockchain) AddBlock(data TransferData) error {
+	if bc.readOnly {
+		return ErrLedgerReadOnly
+	}
+
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 
-	// Create new block
+	lock, err := lockLedger(bc.lockPath, true)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := bc.loadFromDisk(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to reload blockchain: %w", err)
+	}
+
+	// Create new block. The timestamp drops its monotonic clock reading, which
+	// is part of its String form but not saved, so the hash survives a reload.
 	newBlock := Block{
 		Index:        int64(len(bc.blocks)),
-		Timestamp:    time.Now(),
+		Timestamp:    time.Now().Round(0),
 		Data:         data,
 		PreviousHash: bc.currentHash,
 		Nonce:        0,
@@ -296,21 +358,44 @@ type TransferEntry struct {
 	TotalSize    int64     `json:"total_size"`
 	Success      bool      `json:"success"`
 	Transport    string    `json:"transport"`
+	Verification string    `json:"verification"` // One of the Verification* outcomes
+
+	// Filled in when an entry is read back from the chain
+	BlockIndex int64  `json:"block_index,omitempty"`
+	BlockHash  string `json:"block_hash,omitempty"`
+	FileName   string `json:"file_name,omitempty"`
+	Direction  string `json:"direction,omitempty"`
+	Status     string `json:"status,omitempty"`
+	PeerID     string `json:"peer_id,omitempty"`
+	Error      string `json:"error,omitempty"`
 }
 
+// Integrity verification outcomes recorded with a transfer
+const (
+	VerificationPassed  = "verified"   // Checksums were checked and matched
+	VerificationFailed  = "failed"     // Checksums were checked and did not match
+	VerificationSkipped = "unverified" // Nothing was available to check against
+)
+
 // AddTransferEntry adds a transfer entry (adapter for bulletproof manager)
 func (bc *Blockchain) AddTransferEntry(entry TransferEntry) error {
 	// Convert TransferEntry to TransferData
 	data := TransferData{
-		TransferID: entry.TransferCode,
-		PeerID:     "bulletproof-system",
-		FileName:   fmt.Sprintf("%d files", entry.FileCount),
-		FileSize:   entry.TotalSize,
-		FileHash:   "bulletproof-entry",
-		Direction:  "bulletproof",
-		Status:     map[bool]string{true: "success", false: "failed"}[entry.Success],
-		Duration:   "",
-		Timestamp:  entry.Timestamp,
+		TransferID:   entry.TransferCode,
+		PeerID:       "bulletproof-system",
+		FileName:     fmt.Sprintf("%d files", entry.FileCount),
+		FileSize:     entry.TotalSize,
+		FileHash:     "bulletproof-entry",
+		Direction:    "bulletproof",
+		Status:       map[bool]string{true: "success", false: "failed"}[entry.Success],
+		Duration:     "",
+		Timestamp:    entry.Timestamp,
+		Verification: entry.Verification,
+	}
+	if entry.Error != "" {
+		data.Error = entry.Error
+	} else if entry.Verification == VerificationFailed {
+		data.Error = "integrity verification failed"
 	}
 
 	return bc.AddBlock(data)
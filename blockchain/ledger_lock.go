@@ -0,0 +1,69 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ledgerLockFile sits beside ledger.json and is locked by every process that
+// reads or writes the ledger
+const ledgerLockFile = "ledger.lock"
+
+// How long to wait for another process to finish with the ledger, and how
+// often to check
+const (
+	ledgerLockTimeout = 5 * time.Second
+	ledgerLockRetry   = 50 * time.Millisecond
+)
+
+var (
+	// ErrLedgerInUse is returned when another process kept the ledger locked
+	// for longer than ledgerLockTimeout
+	ErrLedgerInUse = errors.New("ledger is in use by another TrustDrop process")
+
+	// ErrLedgerReadOnly is returned when adding to a ledger opened with OpenReadOnly
+	ErrLedgerReadOnly = errors.New("ledger was opened read-only")
+)
+
+// errLockHeld is returned by tryLockFile when another process holds the lock
+var errLockHeld = errors.New("lock held by another process")
+
+// ledgerLock is a held lock on the ledger's lock file
+type ledgerLock struct {
+	file *os.File
+}
+
+// lockLedger locks the ledger for this process, shared for reading or
+// exclusive for writing. Other processes are waited for up to
+// ledgerLockTimeout before giving up with ErrLedgerInUse.
+func lockLedger(path string, exclusive bool) (*ledgerLock, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger lock: %w", err)
+	}
+
+	deadline := time.Now().Add(ledgerLockTimeout)
+	for {
+		err := tryLockFile(file, exclusive)
+		if err == nil {
+			return &ledgerLock{file: file}, nil
+		}
+		if !errors.Is(err, errLockHeld) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock ledger: %w", err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, ErrLedgerInUse
+		}
+		time.Sleep(ledgerLockRetry)
+	}
+}
+
+// Unlock releases the lock
+func (l *ledgerLock) Unlock() {
+	unlockFile(l.file)
+	l.file.Close()
+}
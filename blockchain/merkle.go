@@ -0,0 +1,171 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Merkle tree domain separation prefixes, as in RFC 6962, so a leaf can never
+// be passed off as an interior node
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// Proof shows that a block is part of a ledger whose Merkle root is Root,
+// without revealing any other block. A third party checks it with VerifyProof
+// against a root hash published separately.
+type Proof struct {
+	Block    Block       `json:"block"`     // The entry being proven
+	TreeSize int         `json:"tree_size"` // Number of blocks the root covers
+	Path     []ProofStep `json:"path"`      // Sibling hashes from the leaf up to the root
+	Root     string      `json:"root"`      // Merkle root the proof was generated against
+}
+
+// ProofStep is one sibling hash on the path from a leaf to the root
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // Whether the sibling sits to the left of the running hash
+}
+
+// MerkleRoot returns the Merkle root over every block in the chain and the
+// number of blocks it covers
+func (bc *Blockchain) MerkleRoot() (string, int, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	leaves, err := merkleLeaves(bc.blocks)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(merkleTreeHash(leaves)), len(leaves), nil
+}
+
+// ProveBlock builds a Merkle inclusion proof for the block at index
+func (bc *Blockchain) ProveBlock(index int64) (Proof, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if index < 0 || index >= int64(len(bc.blocks)) {
+		return Proof{}, fmt.Errorf("block %d does not exist (chain has %d blocks)", index, len(bc.blocks))
+	}
+
+	leaves, err := merkleLeaves(bc.blocks)
+	if err != nil {
+		return Proof{}, err
+	}
+
+	return Proof{
+		Block:    bc.blocks[index],
+		TreeSize: len(leaves),
+		Path:     merklePath(int(index), leaves),
+		Root:     hex.EncodeToString(merkleTreeHash(leaves)),
+	}, nil
+}
+
+// VerifyProof checks that proof places its block under root. root should come
+// from a trusted publication rather than from the proof itself.
+func VerifyProof(proof Proof, root string) error {
+	expected, err := hex.DecodeString(root)
+	if err != nil {
+		return fmt.Errorf("invalid root hash: %w", err)
+	}
+
+	hash, err := merkleLeafHash(proof.Block)
+	if err != nil {
+		return err
+	}
+
+	for i, step := range proof.Path {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return fmt.Errorf("invalid hash at proof step %d: %w", i, err)
+		}
+		if step.Left {
+			hash = merkleNodeHash(sibling, hash)
+		} else {
+			hash = merkleNodeHash(hash, sibling)
+		}
+	}
+
+	if !bytes.Equal(hash, expected) {
+		return fmt.Errorf("proof for block %d does not match root %s", proof.Block.Index, root)
+	}
+	return nil
+}
+
+// merkleLeaves hashes every block into a Merkle leaf
+func merkleLeaves(blocks []Block) ([][]byte, error) {
+	leaves := make([][]byte, 0, len(blocks))
+	for _, block := range blocks {
+		leaf, err := merkleLeafHash(block)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, nil
+}
+
+// merkleLeafHash hashes a block's canonical JSON, so the leaf commits to
+// everything recorded in the block including its chain hash
+func merkleLeafHash(block Block) ([]byte, error) {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode block %d: %w", block.Index, err)
+	}
+
+	sum := sha256.Sum256(append([]byte{merkleLeafPrefix}, data...))
+	return sum[:], nil
+}
+
+// merkleNodeHash hashes two child nodes into their parent
+func merkleNodeHash(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, merkleNodePrefix)
+	data = append(data, left...)
+	data = append(data, right...)
+
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// merkleTreeHash computes the root over leaves, splitting at the largest power
+// of two below the leaf count as in RFC 6962
+func merkleTreeHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+
+	k := merkleSplit(len(leaves))
+	return merkleNodeHash(merkleTreeHash(leaves[:k]), merkleTreeHash(leaves[k:]))
+}
+
+// merklePath returns the sibling hashes from leaf m up to the root
+func merklePath(m int, leaves [][]byte) []ProofStep {
+	if len(leaves) <= 1 {
+		return nil
+	}
+
+	k := merkleSplit(len(leaves))
+	if m < k {
+		return append(merklePath(m, leaves[:k]), ProofStep{Hash: hex.EncodeToString(merkleTreeHash(leaves[k:]))})
+	}
+	return append(merklePath(m-k, leaves[k:]), ProofStep{Hash: hex.EncodeToString(merkleTreeHash(leaves[:k])), Left: true})
+}
+
+// merkleSplit returns the largest power of two smaller than n
+func merkleSplit(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
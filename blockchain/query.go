@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Filter selects ledger entries. Zero-valued fields match everything.
+type Filter struct {
+	TransferCode string    // Exact transfer code, case-insensitive
+	Since        time.Time // Entries at or after this time
+	Until        time.Time // Entries before this time
+	Direction    string    // "send", "receive" or "bulletproof"
+	Status       string    // "success", "failed" or "cancelled"
+}
+
+// matches reports whether a block's transfer data passes the filter
+func (f Filter) matches(data TransferData) bool {
+	if code := strings.TrimSpace(f.TransferCode); code != "" && !strings.EqualFold(data.TransferID, code) {
+		return false
+	}
+	if !f.Since.IsZero() && data.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !data.Timestamp.Before(f.Until) {
+		return false
+	}
+	if f.Direction != "" && !strings.EqualFold(data.Direction, f.Direction) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(data.Status, f.Status) {
+		return false
+	}
+	return true
+}
+
+// Query returns the blocks matching filter, oldest first, skipping the genesis block
+func (bc *Blockchain) Query(filter Filter) []Block {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	var matches []Block
+	for i := 1; i < len(bc.blocks); i++ { // Skip genesis block
+		if filter.matches(bc.blocks[i].Data) {
+			matches = append(matches, bc.blocks[i])
+		}
+	}
+	return matches
+}
+
+// FindByTransferID returns every block recording transferID, oldest first.
+// A transfer code can appear more than once, for example after a retry.
+func (bc *Blockchain) FindByTransferID(transferID string) []Block {
+	return bc.Query(Filter{TransferCode: transferID})
+}
+
+// EntryFromBlock converts a stored block back into a TransferEntry
+func EntryFromBlock(block Block) TransferEntry {
+	entry := TransferEntry{
+		TransferCode: block.Data.TransferID,
+		Timestamp:    block.Data.Timestamp,
+		TotalSize:    block.Data.FileSize,
+		Success:      block.Data.Status == "success",
+		Verification: block.Data.Verification,
+		BlockIndex:   block.Index,
+		BlockHash:    block.Hash,
+		FileName:     block.Data.FileName,
+		Direction:    block.Data.Direction,
+		Status:       block.Data.Status,
+		PeerID:       block.Data.PeerID,
+		Error:        block.Data.Error,
+	}
+
+	// Manager entries store the file count as "N files"; anything else is one file
+	if _, err := fmt.Sscanf(block.Data.FileName, "%d files", &entry.FileCount); err != nil {
+		entry.FileCount = 1
+	}
+	return entry
+}
@@ -0,0 +1,79 @@
+package blockchain
+
+// systemPeerIDs are placeholder peers that say nothing about who was on the other end
+var systemPeerIDs = map[string]bool{
+	"":                   true,
+	"system":             true,
+	"bulletproof-system": true,
+}
+
+// Stats summarizes a set of ledger entries
+type Stats struct {
+	Transfers    int     `json:"transfers"`
+	Succeeded    int     `json:"succeeded"`
+	Failed       int     `json:"failed"`
+	Cancelled    int     `json:"cancelled"`
+	SuccessRate  float64 `json:"success_rate"` // Succeeded / Transfers, 0 when there are none
+	TotalBytes   int64   `json:"total_bytes"`
+	AverageBytes int64   `json:"average_bytes"`
+	TotalFiles   int     `json:"total_files"`
+
+	ByDirection map[string]DirectionStats `json:"by_direction"`
+
+	BusiestPeer          string `json:"busiest_peer,omitempty"`
+	BusiestPeerTransfers int    `json:"busiest_peer_transfers,omitempty"`
+}
+
+// DirectionStats summarizes the entries for one transfer direction
+type DirectionStats struct {
+	Transfers int   `json:"transfers"`
+	Succeeded int   `json:"succeeded"`
+	Failed    int   `json:"failed"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// ComputeStats aggregates entries into Stats
+func ComputeStats(entries []TransferEntry) Stats {
+	stats := Stats{ByDirection: make(map[string]DirectionStats)}
+	peers := make(map[string]int)
+
+	for _, entry := range entries {
+		stats.Transfers++
+		stats.TotalBytes += entry.TotalSize
+		stats.TotalFiles += entry.FileCount
+
+		direction := stats.ByDirection[entry.Direction]
+		direction.Transfers++
+		direction.Bytes += entry.TotalSize
+
+		switch {
+		case entry.Success:
+			stats.Succeeded++
+			direction.Succeeded++
+		case entry.Status == "cancelled":
+			stats.Cancelled++
+		default:
+			stats.Failed++
+			direction.Failed++
+		}
+		stats.ByDirection[entry.Direction] = direction
+
+		if !systemPeerIDs[entry.PeerID] {
+			peers[entry.PeerID]++
+		}
+	}
+
+	if stats.Transfers > 0 {
+		stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Transfers)
+		stats.AverageBytes = stats.TotalBytes / int64(stats.Transfers)
+	}
+
+	for peer, count := range peers {
+		if count > stats.BusiestPeerTransfers || (count == stats.BusiestPeerTransfers && peer < stats.BusiestPeer) {
+			stats.BusiestPeer = peer
+			stats.BusiestPeerTransfers = count
+		}
+	}
+
+	return stats
+}
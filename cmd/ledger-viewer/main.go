@@ -0,0 +1,244 @@
+// Command ledger-viewer inspects the TrustDrop audit ledger.
+//
+//	ledger-viewer [-data dir] [filters] -find <transfer code>
+//	ledger-viewer [-data dir] [filters] -stats
+//	ledger-viewer [-data dir] -root
+//	ledger-viewer [-data dir] -prove <block index> > proof.json
+//	ledger-viewer -verify proof.json -expect-root <published root>
+//
+// Filters are -since and -until (YYYY-MM-DD), -direction and -status.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"trustdrop-bulletproof/blockchain"
+	"trustdrop-bulletproof/logging"
+)
+
+func main() {
+	dataDir := flag.String("data", defaultDataDir(), "TrustDrop data directory containing blockchain_data")
+	find := flag.String("find", "", "print every ledger entry recorded for this transfer code")
+	stats := flag.Bool("stats", false, "print summary statistics for the matching entries")
+	since := flag.String("since", "", "only entries on or after this date (YYYY-MM-DD)")
+	until := flag.String("until", "", "only entries before this date (YYYY-MM-DD)")
+	direction := flag.String("direction", "", "only entries with this direction (send, receive, bulletproof)")
+	status := flag.String("status", "", "only entries with this status (success, failed, cancelled)")
+	root := flag.Bool("root", false, "print the ledger's Merkle root for publication")
+	prove := flag.Int64("prove", -1, "print a JSON inclusion proof for the entry at this block index")
+	verify := flag.String("verify", "", "check a proof file produced by -prove")
+	expectRoot := flag.String("expect-root", "", "published Merkle root to check -verify against")
+	flag.Parse()
+
+	// Verifying needs only the proof, not a ledger
+	if *verify != "" {
+		if err := runVerify(*verify, *expectRoot); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *find == "" && !*stats && !*root && *prove < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	filter, err := buildFilter(*since, *until, *direction, *status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	filter.TransferCode = *find
+
+	// Read-only, so a running app can keep recording while the ledger is inspected
+	logger, err := logging.NewReadOnlyLogger(*dataDir)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "No ledger found in %s: %v\n", *dataDir, err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open ledger in %s: %v\n", *dataDir, err)
+		os.Exit(1)
+	}
+	defer logger.Close()
+
+	var runErr error
+	switch {
+	case *stats:
+		runStats(logger, filter)
+	case *find != "":
+		runErr = runFind(logger, filter)
+	case *root:
+		runErr = runRoot(logger)
+	default:
+		runErr = runProve(logger, *prove)
+	}
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+		os.Exit(1)
+	}
+}
+
+// buildFilter turns the filter flags into a ledger filter
+func buildFilter(since, until, direction, status string) (blockchain.Filter, error) {
+	filter := blockchain.Filter{Direction: direction, Status: status}
+
+	var err error
+	if since != "" {
+		if filter.Since, err = time.ParseInLocation("2006-01-02", since, time.Local); err != nil {
+			return filter, fmt.Errorf("invalid -since date %q: %w", since, err)
+		}
+	}
+	if until != "" {
+		if filter.Until, err = time.ParseInLocation("2006-01-02", until, time.Local); err != nil {
+			return filter, fmt.Errorf("invalid -until date %q: %w", until, err)
+		}
+	}
+	return filter, nil
+}
+
+// runFind prints the entries recorded for a transfer code
+func runFind(logger *logging.Logger, filter blockchain.Filter) error {
+	if _, err := logger.FindByTransferCode(filter.TransferCode); err != nil {
+		return err
+	}
+
+	entries := logger.Query(filter)
+	if len(entries) == 0 {
+		fmt.Printf("No ledger entries for transfer code %q\n", filter.TransferCode)
+		return nil
+	}
+
+	fmt.Printf("Found %d ledger entries for transfer code %q\n", len(entries), filter.TransferCode)
+	for _, entry := range entries {
+		printEntry(entry)
+	}
+	return nil
+}
+
+// runStats prints a summary of the entries matching filter
+func runStats(logger *logging.Logger, filter blockchain.Filter) {
+	stats := logger.Stats(filter)
+
+	fmt.Printf("Transfers:     %d\n", stats.Transfers)
+	if stats.Transfers == 0 {
+		return
+	}
+	fmt.Printf("Succeeded:     %d (%.1f%%)\n", stats.Succeeded, stats.SuccessRate*100)
+	fmt.Printf("Failed:        %d\n", stats.Failed)
+	fmt.Printf("Cancelled:     %d\n", stats.Cancelled)
+	fmt.Printf("Files:         %d\n", stats.TotalFiles)
+	fmt.Printf("Total size:    %d bytes\n", stats.TotalBytes)
+	fmt.Printf("Average size:  %d bytes\n", stats.AverageBytes)
+	if stats.BusiestPeer != "" {
+		fmt.Printf("Busiest peer:  %s (%d transfers)\n", stats.BusiestPeer, stats.BusiestPeerTransfers)
+	}
+
+	directions := make([]string, 0, len(stats.ByDirection))
+	for direction := range stats.ByDirection {
+		directions = append(directions, direction)
+	}
+	sort.Strings(directions)
+
+	fmt.Printf("\nBy direction:\n")
+	for _, direction := range directions {
+		d := stats.ByDirection[direction]
+		fmt.Printf("  %-12s %d transfers, %d succeeded, %d failed, %d bytes\n",
+			direction, d.Transfers, d.Succeeded, d.Failed, d.Bytes)
+	}
+}
+
+// runRoot prints the current Merkle root and how many entries it covers
+func runRoot(logger *logging.Logger) error {
+	root, size, err := logger.LedgerRoot()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Merkle root: %s\n", root)
+	fmt.Printf("Entries:     %d\n", size)
+	return nil
+}
+
+// runProve writes the inclusion proof for a block as JSON to stdout
+func runProve(logger *logging.Logger, index int64) error {
+	proof, err := logger.ProveEntry(index)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(proof)
+}
+
+// runVerify checks a proof file against a published root. Without one it
+// falls back to the root inside the proof, which only shows the proof is
+// self-consistent.
+func runVerify(path, expectRoot string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read proof: %w", err)
+	}
+
+	var proof blockchain.Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return fmt.Errorf("failed to parse proof: %w", err)
+	}
+
+	if expectRoot == "" {
+		fmt.Printf("Warning: no -expect-root given; checking against the root stored in the proof\n")
+		expectRoot = proof.Root
+	}
+
+	if err := blockchain.VerifyProof(proof, expectRoot); err != nil {
+		return err
+	}
+	fmt.Printf("Proof valid: block #%d (transfer %s) is in the ledger with root %s\n",
+		proof.Block.Index, proof.Block.Data.TransferID, expectRoot)
+	return nil
+}
+
+// printEntry prints every recorded detail of a ledger entry
+func printEntry(entry blockchain.TransferEntry) {
+	fmt.Printf("\nBlock #%d\n", entry.BlockIndex)
+	fmt.Printf("  Hash:          %s\n", entry.BlockHash)
+	fmt.Printf("  Transfer code: %s\n", entry.TransferCode)
+	fmt.Printf("  Time:          %s\n", entry.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("  Direction:     %s\n", entry.Direction)
+	fmt.Printf("  Files:         %s (%d)\n", entry.FileName, entry.FileCount)
+	fmt.Printf("  Size:          %d bytes\n", entry.TotalSize)
+	fmt.Printf("  Status:        %s\n", entry.Status)
+	if entry.PeerID != "" {
+		fmt.Printf("  Peer:          %s\n", entry.PeerID)
+	}
+	if entry.Verification != "" {
+		fmt.Printf("  Verification:  %s\n", entry.Verification)
+	}
+	if entry.Error != "" {
+		fmt.Printf("  Error:         %s\n", entry.Error)
+	}
+}
+
+// defaultDataDir mirrors where the app keeps its data
+func defaultDataDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+
+	for _, parent := range []string{"Documents", "Desktop"} {
+		dir := filepath.Join(homeDir, parent, "TrustDrop International")
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return "."
+}
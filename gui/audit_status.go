@@ -0,0 +1,35 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createAuditStatusLabel builds the main-view notice shown when transfers are
+// not being recorded in the audit ledger. It stays hidden while logging works.
+func (ba *BulletproofApp) createAuditStatusLabel() *widget.Label {
+	ba.auditStatusLabel = widget.NewLabel("")
+	ba.auditStatusLabel.Alignment = fyne.TextAlignCenter
+	ba.auditStatusLabel.Wrapping = fyne.TextWrapWord
+	ba.refreshAuditStatus()
+	return ba.auditStatusLabel
+}
+
+// refreshAuditStatus shows or hides the audit notice to match the manager
+func (ba *BulletproofApp) refreshAuditStatus() {
+	if ba.transferManager.AuditLoggingDisabled() {
+		ba.auditStatusLabel.SetText("Audit logging is turned off, so transfers are not being recorded.")
+		ba.auditStatusLabel.Show()
+		return
+	}
+	if ba.transferManager.LoggingAvailable() {
+		ba.auditStatusLabel.Hide()
+		return
+	}
+
+	ba.auditStatusLabel.SetText(fmt.Sprintf("⚠️ Audit logging is unavailable, so transfers are not being recorded: %v",
+		ba.transferManager.LoggingError()))
+	ba.auditStatusLabel.Show()
+}
@@ -1,9 +1,10 @@
 package gui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -20,7 +21,9 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"trustdrop-bulletproof/assets"
+	"trustdrop-bulletproof/i18n"
 	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
 	"trustdrop-bulletproof/transfer"
 	"trustdrop-bulletproof/transport"
 )
@@ -42,23 +45,34 @@ type BulletproofApp struct {
 
 	// Send elements
 	codeDisplay  *widget.Label
+	codeStrength *widget.Label
 	copyButton   *widget.Button
 	selectButton *widget.Button
 	waitingLabel *widget.Label
 
+	// Custom code elements
+	customCodeCheck   *widget.Check
+	customCodeEntry   *widget.Entry
+	customCodeWarning *widget.Label
+
 	// Receive elements
 	codeEntry     *widget.Entry
 	receiveButton *widget.Button
 
 	// Progress elements
-	statusLabel  *widget.Label
-	detailLabel  *widget.Label
-	cancelButton *widget.Button
+	statusLabel     *widget.Label
+	detailLabel     *widget.Label
+	fileProgress    *widget.ProgressBar
+	overallProgress *widget.ProgressBar
+	throughput      *throughputGraph
+	fileStatus      *fileStatusList
+	cancelButton    *widget.Button
 
 	// Success elements
 	successMessage *widget.Label
 	locationLabel  *widget.Label
 	openFolderBtn  *widget.Button
+	reverifyBtn    *widget.Button
 	doneButton     *widget.Button
 
 	// Error elements
@@ -68,21 +82,49 @@ type BulletproofApp struct {
 	helpButton    *widget.Button
 	backFromError *widget.Button
 
+	// Send queue elements
+	queueCard     *widget.Card
+	queueList     *widget.List
+	queueStartBtn *widget.Button
+	queue         sendQueue
+
+	// Receive session elements
+	sessionCheck   *widget.Check
+	sessionCard    *widget.Card
+	sessionStatus  *widget.Label
+	sessionList    *widget.List
+	sessionStopBtn *widget.Button
+	session        receiveSession
+
 	// Network status elements
 	networkStatusLabel *widget.Label
 	networkStatusIcon  *widget.Label
+	restrictionsBanner *widget.Label
+	auditStatusLabel   *widget.Label
 
 	// State
 	currentView     string
 	mutex           sync.Mutex
 	isTransferring  bool
 	currentCode     string
+	generatedCode   string // Random code to fall back to when no custom code is used
 	selectionDialog *dialog.CustomDialog
 	lastError       error
 	lastOperation   string // "send" or "receive"
 	lastPaths       []string
 	lastReceiveCode string
+	lastReceiveDir  string // Folder the last receive was saved into
 	networkInfo     NetworkInfo
+	uiQueue         chan func() // widget updates from background goroutines, see runOnUI
+
+	// offeredClipboardCode is the clipboard code last offered for
+	// receiving, so each code is offered once
+	offeredClipboardCode string
+
+	// transportOverride limits transfers to the transports chosen in
+	// transportSelect; empty uses automatic failover
+	transportSelect   *widget.Select
+	transportOverride []string
 }
 
 // NetworkInfo holds current network status information
@@ -91,17 +133,22 @@ type NetworkInfo struct {
 	IsRestrictive        bool
 	AvailableTransports  int
 	RecommendedTransport string
+	VPNActive            bool
+	BlockedDirection     string // "send" or "receive" when the network only allows the other
 	Restrictions         []string
 	LastUpdated          time.Time
 }
 
+// appID identifies the app to Fyne, which keys saved preferences by it. It
+// matches the bundle ID in build.sh.
+const appID = "com.trustdrop.app"
+
 // NewAppWithBulletproofManager creates a new bulletproof app with enhanced UX and network awareness
 func NewAppWithBulletproofManager(transferManager *transfer.BulletproofTransferManager, targetDataDir string) *BulletproofApp {
 	bulletproofApp := &BulletproofApp{
-		app:             app.New(),
+		app:             app.NewWithID(appID),
 		transferManager: transferManager,
 		targetDataDir:   targetDataDir,
-		currentCode:     generateTransferCode(),
 		networkInfo: NetworkInfo{
 			Type:                 "analyzing",
 			IsRestrictive:        false,
@@ -112,6 +159,10 @@ func NewAppWithBulletproofManager(transferManager *transfer.BulletproofTransferM
 		},
 	}
 
+	bulletproofApp.generatedCode = generateTransferCode()
+	bulletproofApp.currentCode = bulletproofApp.generatedCode
+
+	bulletproofApp.startUIDispatcher()
 	bulletproofApp.setupUI()
 	bulletproofApp.setupCallbacks()
 	bulletproofApp.startNetworkMonitoring()
@@ -121,6 +172,10 @@ func NewAppWithBulletproofManager(transferManager *transfer.BulletproofTransferM
 
 // Run starts the bulletproof application
 func (ba *BulletproofApp) Run() {
+	ba.warnIfNotReady()
+	ba.offerPendingSend()
+	// The clipboard can only be read once the app is running
+	ba.app.Lifecycle().SetOnStarted(ba.offerClipboardCode)
 	ba.window.ShowAndRun()
 }
 
@@ -130,6 +185,9 @@ func (ba *BulletproofApp) setupUI() {
 	ba.window.Resize(fyne.NewSize(560, 500)) // Larger for network status
 	ba.window.CenterOnScreen()
 
+	ba.applySavedTheme()
+	ba.applySavedLocale()
+
 	// Set the app icon from embedded assets
 	ba.app.SetIcon(assets.GetAppIcon())
 	ba.window.SetIcon(assets.GetAppIcon())
@@ -141,6 +199,8 @@ func (ba *BulletproofApp) setupUI() {
 	ba.createProgressView()
 	ba.createSuccessView()
 	ba.createErrorView()
+	ba.createQueueView()
+	ba.createSessionView()
 
 	// Start with main view
 	ba.showMainView()
@@ -169,6 +229,12 @@ func (ba *BulletproofApp) createMainView() {
 	receiveBtn.Importance = widget.MediumImportance
 	receiveBtn.Icon = theme.DownloadIcon()
 
+	// Queue for sending several separate items, each with its own code
+	queueBtn := widget.NewButton("Send Multiple Items", func() {
+		ba.showQueueView()
+	})
+	queueBtn.Icon = theme.ListIcon()
+
 	// Network status section with international context
 	networkStatus := ba.createInternationalNetworkStatusWidget()
 
@@ -184,11 +250,16 @@ func (ba *BulletproofApp) createMainView() {
 			container.NewGridWithColumns(1,
 				container.NewPadded(sendBtn),
 				container.NewPadded(receiveBtn),
+				container.NewPadded(queueBtn),
 			),
 			layout.NewSpacer(),
 		)),
 		widget.NewSeparator(),
 		networkStatus,
+		ba.createRestrictionsBanner(),
+		ba.createAuditStatusLabel(),
+		ba.createRelayStatusLabel(),
+		widget.NewForm(ba.themeFormItem(), ba.languageFormItem(), ba.clipboardFormItem(), ba.openFolderFormItem(), ba.transportFormItem()),
 	)
 
 	ba.mainContent = container.NewCenter(content)
@@ -219,7 +290,16 @@ func (ba *BulletproofApp) startNetworkMonitoring() {
 
 		for {
 			status := ba.transferManager.GetNetworkStatus()
-			ba.updateNetworkStatusDisplay(status)
+			ba.runOnUI(func() {
+				ba.updateNetworkStatusDisplay(status)
+			})
+
+			// Check often until the first analysis lands, so restrictions
+			// show up as soon as they are known
+			if analyzed, _ := status["analysis_complete"].(bool); !analyzed {
+				time.Sleep(2 * time.Second)
+				continue
+			}
 			time.Sleep(15 * time.Second) // Update every 15 seconds
 		}
 	}()
@@ -234,6 +314,13 @@ func (ba *BulletproofApp) updateNetworkStatusDisplay(status map[string]interface
 		ba.networkInfo.Type = profile.NetworkType
 		ba.networkInfo.IsRestrictive = profile.IsRestrictive
 		ba.networkInfo.RecommendedTransport = profile.PreferredTransport
+		ba.networkInfo.VPNActive = profile.VPNDetected
+		ba.networkInfo.BlockedDirection = ""
+		for _, direction := range []string{"send", "receive"} {
+			if profile.Directional.Impaired(direction) {
+				ba.networkInfo.BlockedDirection = direction
+			}
+		}
 		ba.networkInfo.LastUpdated = time.Now()
 
 		// Update main status
@@ -270,11 +357,16 @@ func (ba *BulletproofApp) updateNetworkStatusDisplay(status map[string]interface
 			ba.networkInfo.AvailableTransports = availableCount
 			statusText += fmt.Sprintf(" • %d methods ready", availableCount)
 		}
+
+		if profile.VPNDetected {
+			statusText += " • VPN active (may route this transfer)"
+		}
 	}
 
 	// Update UI elements
 	ba.networkStatusIcon.SetText(icon)
 	ba.networkStatusLabel.SetText(statusText)
+	ba.updateRestrictionsBanner(status)
 }
 
 // createSendView creates the send workflow with enhanced network awareness
@@ -284,14 +376,19 @@ func (ba *BulletproofApp) createSendView() {
 		ba.currentCode,
 		fyne.TextAlignCenter,
 		fyne.TextStyle{Monospace: true, Bold: true})
+	ba.codeStrength = widget.NewLabel("")
+	ba.codeStrength.Alignment = fyne.TextAlignCenter
+	ba.showCodeStrength(ba.currentCode)
 
 	ba.copyButton = widget.NewButtonWithIcon("Copy Code", theme.ContentCopyIcon(), func() {
 		ba.window.Clipboard().SetContent(ba.currentCode)
 		ba.copyButton.SetText("Copied!")
 		ba.copyButton.SetIcon(theme.ConfirmIcon())
 		time.AfterFunc(2*time.Second, func() {
-			ba.copyButton.SetText("Copy Code")
-			ba.copyButton.SetIcon(theme.ContentCopyIcon())
+			ba.runOnUI(func() {
+				ba.copyButton.SetText("Copy Code")
+				ba.copyButton.SetIcon(theme.ContentCopyIcon())
+			})
 		})
 	})
 
@@ -336,7 +433,9 @@ func (ba *BulletproofApp) createSendView() {
 	codeCard := widget.NewCard("", "Your Transfer Code:",
 		container.NewVBox(
 			container.NewPadded(ba.codeDisplay),
+			ba.codeStrength,
 			ba.copyButton,
+			ba.createCustomCodeControls(),
 		))
 
 	content := container.NewVBox(
@@ -403,12 +502,19 @@ func (ba *BulletproofApp) createReceiveView() {
 			ba.showError("Invalid Code", "Please enter the sender's code", nil, false)
 			return
 		}
+		if ba.sessionCheck.Checked {
+			ba.onStartSession(code)
+			return
+		}
 		ba.onStartReceive(code)
 	})
 	ba.receiveButton.Importance = widget.HighImportance
 	ba.receiveButton.Icon = theme.DownloadIcon()
 	ba.receiveButton.Disable() // Disabled until valid code entered
 
+	// Session mode keeps the code active for several sends
+	ba.sessionCheck = widget.NewCheck("Keep receiving until I stop", nil)
+
 	// Back button
 	backBtn := widget.NewButtonWithIcon("Back", theme.NavigateBackIcon(), func() {
 		ba.showMainView()
@@ -432,6 +538,7 @@ func (ba *BulletproofApp) createReceiveView() {
 		widget.NewSeparator(),
 		container.NewPadded(container.NewVBox(
 			ba.codeEntry,
+			ba.sessionCheck,
 			ba.receiveButton,
 		)),
 	)
@@ -450,6 +557,14 @@ func (ba *BulletproofApp) createProgressView() {
 	ba.detailLabel.Alignment = fyne.TextAlignCenter
 	ba.detailLabel.Wrapping = fyne.TextWrapWord
 
+	// Separate bars for the current file and the whole transfer
+	ba.fileProgress = widget.NewProgressBar()
+	ba.overallProgress = widget.NewProgressBar()
+	ba.throughput = newThroughputGraph()
+	go ba.runThroughputSampler()
+	ba.fileStatus = newFileStatusList()
+	go ba.runFileStatusRefresher()
+
 	// Cancel button
 	ba.cancelButton = widget.NewButton("Cancel", func() {
 		dialog.ShowConfirm("Cancel Transfer?",
@@ -457,6 +572,9 @@ func (ba *BulletproofApp) createProgressView() {
 			func(cancel bool) {
 				if cancel {
 					ba.transferManager.Cancel()
+					if ba.lastOperation == "send" {
+						ba.resetSendView()
+					}
 					ba.resetTransferState()
 					ba.showMainView()
 				}
@@ -482,6 +600,13 @@ func (ba *BulletproofApp) createProgressView() {
 			ba.statusLabel,
 			layout.NewSpacer(),
 			ba.detailLabel,
+			widget.NewLabel("Current file"),
+			ba.fileProgress,
+			widget.NewLabel("Overall"),
+			ba.overallProgress,
+			widget.NewLabel("Speed"),
+			ba.throughput.object(),
+			ba.fileStatus.object(),
 			layout.NewSpacer(),
 			networkStatusDuringTransfer,
 			layout.NewSpacer(),
@@ -545,6 +670,7 @@ func (ba *BulletproofApp) createSuccessView() {
 		widget.NewSeparator(),
 		container.NewPadded(container.NewVBox(
 			ba.openFolderBtn,
+			ba.createReverifyButton(),
 			ba.doneButton,
 		)),
 	)
@@ -609,7 +735,7 @@ func (ba *BulletproofApp) showError(title, message string, err error, showNetwor
 
 	if err != nil {
 		details.WriteString("\n\n")
-		details.WriteString(err.Error())
+		details.WriteString(transfer.FormatErrorMessage(err))
 	}
 
 	// Add network context if error appears network-related
@@ -618,6 +744,9 @@ func (ba *BulletproofApp) showError(title, message string, err error, showNetwor
 		details.WriteString(fmt.Sprintf("\n• Type: %s", ba.networkInfo.Type))
 		details.WriteString(fmt.Sprintf("\n• Restrictive: %t", ba.networkInfo.IsRestrictive))
 		details.WriteString(fmt.Sprintf("\n• Available Methods: %d", ba.networkInfo.AvailableTransports))
+		if ba.networkInfo.VPNActive {
+			details.WriteString("\n• VPN: active - your VPN may be routing this transfer")
+		}
 
 		if len(ba.networkInfo.Restrictions) > 0 {
 			details.WriteString(fmt.Sprintf("\n• Network Restrictions: %d detected", len(ba.networkInfo.Restrictions)))
@@ -643,6 +772,10 @@ func (ba *BulletproofApp) isNetworkRelatedError(err error) bool {
 		return false
 	}
 
+	if errors.Is(err, transfer.ErrNetworkRestricted) || errors.Is(err, transfer.ErrTimeout) || errors.Is(err, transfer.ErrTransportFailed) {
+		return true
+	}
+
 	errorStr := strings.ToLower(err.Error())
 	networkIndicators := []string{
 		"network", "connection", "timeout", "refused", "unreachable",
@@ -663,98 +796,65 @@ func (ba *BulletproofApp) isNetworkRelatedError(err error) bool {
 // showNetworkHelp displays comprehensive network troubleshooting information
 func (ba *BulletproofApp) showNetworkHelp() {
 	var helpText strings.Builder
-	helpText.WriteString("Network Troubleshooting Guide\n\n")
+	helpText.WriteString(i18n.T("help.title"))
 
 	// Current network status
-	helpText.WriteString(fmt.Sprintf("Current Network: %s", ba.networkInfo.Type))
+	helpText.WriteString(i18n.T("help.current_network", ba.networkInfo.Type))
 	if ba.networkInfo.IsRestrictive {
-		helpText.WriteString(" (Restrictive)")
+		helpText.WriteString(i18n.T("help.restrictive"))
 	}
 	helpText.WriteString("\n\n")
 
 	// Network-specific guidance
 	switch ba.networkInfo.Type {
-	case "corporate":
-		helpText.WriteString("🏢 Corporate Network Detected\n\n")
-		helpText.WriteString("Your company network has security policies that may block peer-to-peer file transfers. ")
-		helpText.WriteString("TrustDrop automatically uses enterprise-compatible methods, but some restrictions may still apply.\n\n")
-
-		helpText.WriteString("Solutions:\n")
-		helpText.WriteString("• Contact your IT department about approved file transfer methods\n")
-		helpText.WriteString("• Try from a different network (mobile hotspot, home WiFi)\n")
-		helpText.WriteString("• Use a personal device with mobile data if company policy permits\n")
-		helpText.WriteString("• Ask IT about whitelisting TrustDrop's HTTPS-based transfer method\n")
-
-	case "university":
-		helpText.WriteString("🎓 University Network Detected\n\n")
-		helpText.WriteString("Educational networks often have strict security policies for student safety and compliance. ")
-		helpText.WriteString("TrustDrop uses education-friendly methods, but some restrictions may still apply.\n\n")
-
-		helpText.WriteString("Solutions:\n")
-		helpText.WriteString("• Try from the campus library or a different network zone\n")
-		helpText.WriteString("• Use your mobile data connection if available\n")
-		helpText.WriteString("• Contact IT support about student file transfer options\n")
-		helpText.WriteString("• Try from an off-campus network (coffee shop, home)\n")
-
-	case "institutional":
-		helpText.WriteString("🔒 Institutional Network Detected\n\n")
-		helpText.WriteString("This appears to be a managed network with comprehensive security policies. ")
-		helpText.WriteString("TrustDrop is using maximum compatibility mode, but transfers may still be restricted.\n\n")
-
-		helpText.WriteString("Solutions:\n")
-		helpText.WriteString("• Contact network administrators about file transfer policies\n")
-		helpText.WriteString("• Try from an unmanaged network (mobile hotspot, public WiFi)\n")
-		helpText.WriteString("• Use organization-approved file sharing alternatives\n")
-		helpText.WriteString("• Request temporary network access for file transfers\n")
+	case "corporate", "university", "institutional":
+		helpText.WriteString(i18n.T("help." + ba.networkInfo.Type + ".title"))
+		helpText.WriteString(i18n.T("help." + ba.networkInfo.Type + ".body"))
+		helpText.WriteString(i18n.T("help." + ba.networkInfo.Type + ".solutions"))
 
 	default:
 		if ba.networkInfo.IsRestrictive {
-			helpText.WriteString("🔒 Restricted Network Detected\n\n")
-			helpText.WriteString("Your network has restrictions that may interfere with file transfers. ")
-			helpText.WriteString("TrustDrop has automatically adjusted its methods for compatibility.\n\n")
+			helpText.WriteString(i18n.T("help.restricted.title"))
+			helpText.WriteString(i18n.T("help.restricted.body"))
 		} else {
-			helpText.WriteString("🌐 Open Network Detected\n\n")
-			helpText.WriteString("Your network appears to be open, but transfers are still failing.\n\n")
+			helpText.WriteString(i18n.T("help.open.title"))
+			helpText.WriteString(i18n.T("help.open.body"))
 		}
+		helpText.WriteString(i18n.T("help.general.solutions"))
+	}
 
-		helpText.WriteString("Solutions:\n")
-		helpText.WriteString("• Check your internet connection stability\n")
-		helpText.WriteString("• Verify the transfer code is correct\n")
-		helpText.WriteString("• Try restarting your router/modem\n")
-		helpText.WriteString("• Disable VPN temporarily if using one\n")
-		helpText.WriteString("• Check if antivirus software is blocking connections\n")
+	if ba.networkInfo.BlockedDirection != "" {
+		helpText.WriteString(i18n.T("help.one_way." + ba.networkInfo.BlockedDirection))
 	}
 
 	// Transport method status
-	helpText.WriteString("\nTransport Method Status:\n")
+	helpText.WriteString(i18n.T("help.transports.title"))
 	if ba.networkInfo.AvailableTransports > 0 {
-		helpText.WriteString(fmt.Sprintf("✅ %d transfer methods available\n", ba.networkInfo.AvailableTransports))
-		helpText.WriteString(fmt.Sprintf("📡 Recommended: %s\n", strings.Title(ba.networkInfo.RecommendedTransport)))
+		helpText.WriteString(i18n.T("help.transports.available", ba.networkInfo.AvailableTransports))
+		helpText.WriteString(i18n.T("help.transports.recommended", strings.Title(ba.networkInfo.RecommendedTransport)))
 	} else {
-		helpText.WriteString("❌ No transfer methods currently available\n")
-		helpText.WriteString("This indicates a severe network restriction or connectivity issue.\n")
+		helpText.WriteString(i18n.T("help.transports.none"))
 	}
 
 	// Network restrictions
 	if len(ba.networkInfo.Restrictions) > 0 {
-		helpText.WriteString("\nDetected Network Restrictions:\n")
+		helpText.WriteString(i18n.T("help.restrictions.title"))
 		for i, restriction := range ba.networkInfo.Restrictions {
 			if i < 3 { // Show max 3 restrictions to avoid clutter
 				helpText.WriteString(fmt.Sprintf("• %s\n", restriction))
 			}
 		}
 		if len(ba.networkInfo.Restrictions) > 3 {
-			helpText.WriteString(fmt.Sprintf("• ... and %d more restrictions\n", len(ba.networkInfo.Restrictions)-3))
+			helpText.WriteString(i18n.T("help.restrictions.more", len(ba.networkInfo.Restrictions)-3))
 		}
 	}
 
 	// Additional help
-	helpText.WriteString("\nAdditional Help:\n")
-	helpText.WriteString("• Last network check: " + ba.networkInfo.LastUpdated.Format("15:04:05") + "\n")
-	helpText.WriteString("• For persistent issues, try TrustDrop from a different device or network\n")
-	helpText.WriteString("• Consider using your organization's approved file sharing platform\n")
+	helpText.WriteString(i18n.T("help.additional"))
+	helpText.WriteString(i18n.T("help.last_check", ba.networkInfo.LastUpdated.Format("15:04:05")))
+	helpText.WriteString(i18n.T("help.additional.tips"))
 
-	dialog.ShowInformation("Network Troubleshooting", helpText.String(), ba.window)
+	dialog.ShowInformation(i18n.T("help.dialog_title"), helpText.String(), ba.window)
 }
 
 // retryLastOperation attempts to retry the last failed operation
@@ -770,35 +870,44 @@ func (ba *BulletproofApp) retryLastOperation() {
 	}
 }
 
-// openReceivedFolder opens the folder containing received files
-func (ba *BulletproofApp) openReceivedFolder() {
-	receivedPath := filepath.Join(ba.targetDataDir, "received")
-
-	var cmd string
-	var args []string
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = "explorer"
-		args = []string{receivedPath}
-	case "darwin":
-		cmd = "open"
-		args = []string{receivedPath}
-	case "linux":
-		cmd = "xdg-open"
-		args = []string{receivedPath}
-	default:
-		dialog.ShowInformation("Folder Location",
-			fmt.Sprintf("Files saved to:\n%s", receivedPath), ba.window)
-		return
+// describeSender formats a sender's identity, flagging senders not seen before
+func describeSender(sender *transfer.SenderInfo) string {
+	name := sender.Fingerprint
+	if sender.DisplayName != "" {
+		name = fmt.Sprintf("%s (%s)", sender.DisplayName, sender.Fingerprint)
+	}
+
+	if sender.Known {
+		return "From known sender: " + name
 	}
+	return "From new sender: " + name + " - check this fingerprint with the sender"
+}
+
+// maxListedBlockedFiles caps how many blocked files the success view names
+const maxListedBlockedFiles = 5
+
+// blockedFileNames lists files the file type policy blocked for the success view
+func blockedFileNames(files []transfer.MissingFile) string {
+	names := make([]string, 0, min(len(files), maxListedBlockedFiles))
+	for _, file := range files[:min(len(files), maxListedBlockedFiles)] {
+		names = append(names, file.Path)
+	}
+	if extra := len(files) - len(names); extra > 0 {
+		names = append(names, fmt.Sprintf("and %d more", extra))
+	}
+	return strings.Join(names, ", ")
+}
+
+// receivedFolder returns the folder the last receive used, falling back to the
+// top-level received folder
+func (ba *BulletproofApp) receivedFolder() string {
+	ba.mutex.Lock()
+	defer ba.mutex.Unlock()
 
-	// Try to open the folder
-	if err := exec.Command(cmd, args...).Start(); err != nil {
-		dialog.ShowInformation("Folder Location",
-			fmt.Sprintf("Files saved to:\n%s\n\nCould not open folder automatically.", receivedPath),
-			ba.window)
+	if ba.lastReceiveDir != "" {
+		return ba.lastReceiveDir
 	}
+	return filepath.Join(ba.targetDataDir, "received")
 }
 
 // View management functions
@@ -815,10 +924,12 @@ func (ba *BulletproofApp) showSendView() {
 func (ba *BulletproofApp) showReceiveView() {
 	ba.currentView = "receive"
 	ba.window.SetContent(container.NewCenter(ba.receiveCard))
+	ba.offerClipboardCode()
 }
 
 func (ba *BulletproofApp) showProgressView() {
 	ba.currentView = "progress"
+	ba.throughput.reset()
 	ba.window.SetContent(container.NewCenter(ba.progressCard))
 }
 
@@ -830,12 +941,18 @@ func (ba *BulletproofApp) showSuccessView(message string) {
 
 // Enhanced event handlers with better error handling and network awareness
 func (ba *BulletproofApp) onSelectFiles() {
+	ba.chooseSendItems(ba.confirmSend)
+}
+
+// chooseSendItems asks whether to pick a file or a folder and passes the
+// chosen path to onChosen
+func (ba *BulletproofApp) chooseSendItems(onChosen func(paths []string)) {
 	// Create a choice dialog with clear options and network context
 	filesBtn := widget.NewButtonWithIcon("Select File(s)", theme.DocumentIcon(), func() {
 		if ba.selectionDialog != nil {
 			ba.selectionDialog.Hide()
 		}
-		ba.selectSingleFile()
+		ba.selectSingleFile(onChosen)
 	})
 	filesBtn.Importance = widget.HighImportance
 
@@ -843,7 +960,7 @@ func (ba *BulletproofApp) onSelectFiles() {
 		if ba.selectionDialog != nil {
 			ba.selectionDialog.Hide()
 		}
-		ba.selectFolder()
+		ba.selectFolder(onChosen)
 	})
 	folderBtn.Importance = widget.MediumImportance
 
@@ -868,7 +985,7 @@ func (ba *BulletproofApp) onSelectFiles() {
 	ba.selectionDialog.Show()
 }
 
-func (ba *BulletproofApp) selectSingleFile() {
+func (ba *BulletproofApp) selectSingleFile(onChosen func(paths []string)) {
 	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil || reader == nil {
 			return
@@ -880,7 +997,7 @@ func (ba *BulletproofApp) selectSingleFile() {
 			path = path[1:]
 		}
 
-		ba.startSend([]string{path})
+		onChosen([]string{path})
 	}, ba.window)
 
 	// Set initial location to user's home directory
@@ -893,7 +1010,7 @@ func (ba *BulletproofApp) selectSingleFile() {
 	fileDialog.Show()
 }
 
-func (ba *BulletproofApp) selectFolder() {
+func (ba *BulletproofApp) selectFolder(onChosen func(paths []string)) {
 	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
 		if err != nil || uri == nil {
 			if err != nil && strings.Contains(err.Error(), "operation not permitted") {
@@ -914,7 +1031,7 @@ func (ba *BulletproofApp) selectFolder() {
 			return
 		}
 
-		ba.startSend([]string{path})
+		onChosen([]string{path})
 	}, ba.window)
 }
 
@@ -928,6 +1045,7 @@ func (ba *BulletproofApp) startSend(paths []string) {
 	ba.lastOperation = "send"
 	ba.lastPaths = paths
 	ba.mutex.Unlock()
+	ba.fileStatus.reset()
 
 	// Show what we're sending with network context
 	path := paths[0]
@@ -950,43 +1068,65 @@ func (ba *BulletproofApp) startSend(paths []string) {
 	ba.waitingLabel.Show()
 	ba.waitingLabel.SetText(waitingMsg)
 	ba.selectButton.Disable()
+	ba.customCodeCheck.Disable()
+	ba.customCodeEntry.Disable()
+
+	// Keep the code and files so the send survives the app closing while it waits
+	code := ba.currentCode
+	if err := savePendingSend(code, paths); err != nil {
+		logging.Warnf("Failed to save pending send: %v", err)
+	}
 
 	// Start transfer in background with enhanced error handling
+	// Every send started here was confirmed in confirmSend, or resumes one that was
+	ctx := ba.transferContext(transfer.ConfirmFolderLimits(context.Background()))
 	go func() {
-		result, err := ba.transferManager.SendFiles(paths, ba.currentCode)
+		result, err := ba.transferManager.SendFilesContext(ctx, paths, code)
+		clearPendingSend()
 
 		ba.mutex.Lock()
 		ba.isTransferring = false
 		ba.mutex.Unlock()
 
-		if err != nil {
-			// Enhanced error handling with network context
-			ba.waitingLabel.Hide()
-			ba.selectButton.Enable()
-
-			// Check if this is a network-related error
-			if ba.isNetworkRelatedError(err) {
-				ba.showError("Network Transfer Failed",
-					"The transfer failed due to network restrictions or connectivity issues.",
-					err, true) // Show network help
-			} else {
-				ba.showError("Transfer Failed",
-					"The file transfer could not be completed.",
-					err, false)
-			}
-		} else {
-			// Success - show results with transfer details
-			var successMsg string
-			if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
-				successMsg = fmt.Sprintf("Sent folder '%s' successfully!", filepath.Base(paths[0]))
+		ba.runOnUI(func() {
+			if err != nil {
+				// Enhanced error handling with network context
+				ba.waitingLabel.Hide()
+				ba.selectButton.Enable()
+				ba.customCodeCheck.Enable()
+				ba.customCodeEntry.Enable()
+
+				// Check if this is a network-related error
+				if ba.isNetworkRelatedError(err) {
+					ba.showError("Network Transfer Failed",
+						"The transfer failed due to network restrictions or connectivity issues.",
+						err, true) // Show network help
+				} else {
+					ba.showError("Transfer Failed",
+						"The file transfer could not be completed.",
+						err, false)
+				}
 			} else {
-				successMsg = fmt.Sprintf("Sent %d file(s) successfully!", len(result.TransferredFiles))
-			}
+				// Success - show results with transfer details
+				var successMsg string
+				if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
+					successMsg = fmt.Sprintf("Sent folder '%s' successfully!", filepath.Base(paths[0]))
+				} else {
+					successMsg = fmt.Sprintf("Sent %d file(s) successfully!", len(result.TransferredFiles))
+				}
 
-			// Update success view with transfer details
-			ba.updateSuccessView(result)
-			ba.showSuccessView(successMsg)
-		}
+				if len(result.UnsentFiles) > 0 {
+					successMsg += fmt.Sprintf(" (%d files were left out)", len(result.UnsentFiles))
+				}
+
+				// Update success view with transfer details
+				ba.updateSuccessView(result)
+				ba.showSuccessView(successMsg)
+				if len(result.UnsentFiles) > 0 {
+					ba.showUnsentFiles(result.UnsentFiles)
+				}
+			}
+		})
 	}()
 }
 
@@ -995,38 +1135,58 @@ func (ba *BulletproofApp) onStartReceive(code string) {
 	ba.lastOperation = "receive"
 	ba.lastReceiveCode = code
 	ba.mutex.Unlock()
+	ba.fileStatus.reset()
 
 	ba.showProgressView()
 	ba.statusLabel.SetText("Connecting to sender...")
 	ba.detailLabel.SetText("Analyzing network and choosing best connection method...")
 
 	go func() {
-		result, err := ba.transferManager.ReceiveFiles(code)
+		result, err := ba.transferManager.ReceiveFilesContext(ba.transferContext(context.Background()), code)
 
 		ba.mutex.Lock()
 		ba.isTransferring = false
 		ba.mutex.Unlock()
 
-		if err != nil {
-			// Enhanced error handling for receive
-			if ba.isNetworkRelatedError(err) {
-				ba.showError("Network Connection Failed",
-					"Could not connect to the sender due to network restrictions.",
-					err, true)
-			} else {
-				ba.showError("Receive Failed",
-					"Could not receive files from the sender.",
+		ba.runOnUI(func() {
+			if errors.Is(err, transfer.ErrIncompleteTransfer) {
+				// The files that did arrive were kept, so the folder can still be opened
+				ba.mutex.Lock()
+				ba.lastReceiveDir = result.DestinationDir
+				ba.mutex.Unlock()
+				ba.showError("Some Files Missing",
+					fmt.Sprintf("Received %d files, but %d files from the sender are missing.", len(result.TransferredFiles), len(result.MissingFiles)),
 					err, false)
+			} else if err != nil {
+				// Enhanced error handling for receive
+				if ba.isNetworkRelatedError(err) {
+					ba.showError("Network Connection Failed",
+						"Could not connect to the sender due to network restrictions.",
+						err, true)
+				} else {
+					ba.showError("Receive Failed",
+						"Could not receive files from the sender.",
+						err, false)
+				}
+			} else {
+				// Success
+				ba.mutex.Lock()
+				ba.lastReceiveDir = result.DestinationDir
+				ba.mutex.Unlock()
+				ba.locationLabel.SetText(fmt.Sprintf("Files saved to: %s", ba.receivedFolder()))
+
+				// Update success view with transfer details
+				ba.updateSuccessView(result)
+				ba.reverifyBtn.Show()
+				ba.showSuccessView(fmt.Sprintf("Received %d files successfully!", len(result.TransferredFiles)))
+				if ba.openFolderOnReceive() {
+					ba.openReceivedFolder()
+				}
+				if result.Degraded {
+					dialog.ShowInformation("Received Without a File List", transfer.RawRecoveryNote, ba.window)
+				}
 			}
-		} else {
-			// Success
-			receivedPath := filepath.Join(ba.targetDataDir, "received")
-			ba.locationLabel.SetText(fmt.Sprintf("Files saved to: %s", receivedPath))
-
-			// Update success view with transfer details
-			ba.updateSuccessView(result)
-			ba.showSuccessView(fmt.Sprintf("Received %d files successfully!", len(result.TransferredFiles)))
-		}
+		})
 	}()
 }
 
@@ -1042,10 +1202,23 @@ func (ba *BulletproofApp) updateSuccessView(result *transfer.TransferResult) {
 			if innerVBox, ok := vbox.Objects[0].(*fyne.Container); ok {
 				// Add transfer summary if not already present
 				if len(innerVBox.Objects) >= 3 {
-					summaryText := fmt.Sprintf("Transfer Details:\n• Transport: %s\n• Duration: %v\n• Network: %s",
+					summaryText := fmt.Sprintf("Transfer Details:\n• Transport: %s\n• Size: %s\n• Duration: %v\n• Network: %s",
 						strings.Title(result.TransportUsed),
+						result.DescribeSize(),
 						result.Duration.Round(time.Second),
 						ba.networkInfo.Type)
+					if !result.NamesPreserved {
+						summaryText += "\n• Note: original file names were not included, generated names were used"
+					}
+					if result.Degraded {
+						summaryText += "\n• Warning: saved as a single file without a file list; names may be lost and the contents were not verified"
+					}
+					if len(result.BlockedFiles) > 0 {
+						summaryText += "\n• Not saved (blocked file types): " + blockedFileNames(result.BlockedFiles)
+					}
+					if result.Sender != nil {
+						summaryText += "\n• " + describeSender(result.Sender)
+					}
 
 					if len(innerVBox.Objects) > 3 {
 						if label, ok := innerVBox.Objects[3].(*widget.Label); ok {
@@ -1063,8 +1236,12 @@ func (ba *BulletproofApp) resetSendView() {
 	ba.isTransferring = false
 	ba.waitingLabel.Hide()
 	ba.selectButton.Enable()
-	ba.currentCode = generateTransferCode()
-	ba.codeDisplay.SetText(ba.currentCode)
+	ba.customCodeCheck.Enable()
+	ba.customCodeEntry.Enable()
+	ba.generatedCode = generateTransferCode()
+	ba.currentCode = ba.generatedCode
+	ba.showCodeStrength(ba.currentCode)
+	ba.resetCustomCode()
 }
 
 func (ba *BulletproofApp) resetTransferState() {
@@ -1073,22 +1250,67 @@ func (ba *BulletproofApp) resetTransferState() {
 	ba.lastOperation = ""
 	ba.lastPaths = nil
 	ba.lastReceiveCode = ""
+	ba.reverifyBtn.Hide()
 }
 
 // setupCallbacks sets up transfer callbacks with enhanced progress reporting
 func (ba *BulletproofApp) setupCallbacks() {
+	// Both callbacks fire on transfer goroutines
 	ba.transferManager.SetStatusCallback(func(status string) {
-		if ba.currentView == "progress" {
-			ba.statusLabel.SetText(status)
+		ba.runOnUI(func() {
+			switch ba.currentView {
+			case "progress":
+				ba.statusLabel.SetText(status)
+			case "session":
+				ba.sessionStatus.SetText(status)
+			}
+		})
+	})
+
+	ba.transferManager.SetProgressCallback(func(progress transfer.TransferProgress) {
+		detail := fmt.Sprintf("Processing: %s", filepath.Base(progress.FileName))
+		if progress.Phase == transfer.PhaseReceiving {
+			detail = fmt.Sprintf("Received %s of %s", transfer.FormatBytes(progress.OverallBytes), transfer.FormatBytes(progress.OverallSize))
+		} else if progress.FilesTotal > 0 {
+			detail += fmt.Sprintf(" (%d of %d files done)", progress.FilesCompleted, progress.FilesTotal)
+		}
+		if progress.BytesPerSecond > 0 {
+			detail += fmt.Sprintf(" • %s/s", transfer.FormatBytes(int64(progress.BytesPerSecond)))
 		}
+		ba.throughput.report(progress.OverallBytes)
+
+		ba.runOnUI(func() {
+			if ba.currentView != "progress" {
+				return
+			}
+			ba.fileProgress.SetValue(progress.FileFraction())
+			ba.overallProgress.SetValue(progress.OverallFraction())
+			ba.detailLabel.SetText(detail)
+		})
 	})
 
-	ba.transferManager.SetProgressCallback(func(current, total int64, fileName string) {
-		if ba.currentView == "progress" && total > 0 {
-			progress := float64(current) / float64(total)
-			ba.detailLabel.SetText(fmt.Sprintf("Processing: %s (%.1f%%)",
-				filepath.Base(fileName), progress*100))
+	ba.transferManager.SetFileStatusCallback(ba.fileStatus.update)
+
+	// Senders wait on the send view until the receiver joins, then follow
+	// the transfer on the progress view
+	ba.transferManager.SetPeerConnectedCallback(func(sending bool) {
+		if !sending {
+			return
 		}
+		ba.runOnUI(func() {
+			ba.mutex.Lock()
+			sendInFlight := ba.isTransferring && ba.lastOperation == "send"
+			ba.mutex.Unlock()
+			if ba.currentView != "send" || !sendInFlight {
+				return
+			}
+
+			ba.fileProgress.SetValue(0)
+			ba.overallProgress.SetValue(0)
+			ba.statusLabel.SetText(transfer.StatusReceiverConnected)
+			ba.detailLabel.SetText("The receiver joined and the secure channel is up.")
+			ba.showProgressView()
+		})
 	})
 }
 
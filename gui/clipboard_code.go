@@ -0,0 +1,82 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/internal"
+)
+
+// clipboardCodesPreferenceKey is where the choice to look for transfer codes
+// in the clipboard is persisted in the app preferences
+const clipboardCodesPreferenceKey = "clipboard_codes"
+
+// maxClipboardCodeLength bounds the clipboard text considered a code, so a
+// large clipboard is not scanned
+const maxClipboardCodeLength = 64
+
+// clipboardCodesEnabled reports whether the clipboard may be inspected for
+// transfer codes. It is on unless turned off on the main view.
+func (ba *BulletproofApp) clipboardCodesEnabled() bool {
+	return ba.app.Preferences().BoolWithFallback(clipboardCodesPreferenceKey, true)
+}
+
+// clipboardCode returns the transfer code in the clipboard, or "" when it
+// holds anything else. Only generated codes are recognized, so passwords and
+// other text copied by the user are never offered as codes.
+func (ba *BulletproofApp) clipboardCode() string {
+	text := strings.TrimSpace(ba.window.Clipboard().Content())
+	if len(text) > maxClipboardCodeLength || !internal.IsGeneratedCode(text) {
+		return ""
+	}
+	// A code this app generated was copied to give to a receiver
+	if text == ba.currentCode || text == ba.generatedCode {
+		return ""
+	}
+	return text
+}
+
+// offerClipboardCode asks whether to receive with the transfer code in the
+// clipboard. Each code is offered once, and never while a transfer runs or
+// a code has already been typed. Call it on the UI thread.
+func (ba *BulletproofApp) offerClipboardCode() {
+	if !ba.clipboardCodesEnabled() || strings.TrimSpace(ba.codeEntry.Text) != "" {
+		return
+	}
+	ba.mutex.Lock()
+	busy := ba.isTransferring
+	ba.mutex.Unlock()
+	if busy {
+		return
+	}
+
+	code := ba.clipboardCode()
+	if code == "" || code == ba.offeredClipboardCode {
+		return
+	}
+	ba.offeredClipboardCode = code
+
+	dialog.ShowConfirm("Receive from Clipboard?",
+		fmt.Sprintf("Receive from clipboard code: %s?", code),
+		func(receive bool) {
+			if !receive {
+				return
+			}
+			ba.codeEntry.SetText(code)
+			ba.showReceiveView()
+			ba.onStartReceive(code)
+		}, ba.window)
+}
+
+// clipboardFormItem builds the main-view setting that turns clipboard
+// inspection off, for users who do not want the app reading it
+func (ba *BulletproofApp) clipboardFormItem() *widget.FormItem {
+	check := widget.NewCheck("Offer transfer codes found in the clipboard", func(enabled bool) {
+		ba.app.Preferences().SetBool(clipboardCodesPreferenceKey, enabled)
+	})
+	check.SetChecked(ba.clipboardCodesEnabled())
+	return widget.NewFormItem("Clipboard", check)
+}
@@ -0,0 +1,102 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// minCustomCodeLength matches croc's own minimum code length
+const minCustomCodeLength = 6
+
+// validateCustomCode checks a user-chosen transfer code. It returns an error
+// if the code cannot be used, including when it is too weak to be accepted
+// for sending, and a warning if it is usable but easy to guess.
+func validateCustomCode(code string, minBits float64) (warning string, err error) {
+	if len(code) < minCustomCodeLength {
+		return "", fmt.Errorf("code must be at least %d characters", minCustomCodeLength)
+	}
+	if strings.IndexFunc(code, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("code cannot contain spaces")
+	}
+
+	switch strength, bits := transfer.CodeStrength(code, minBits); strength {
+	case transfer.CodeStrengthWeak:
+		return "", fmt.Errorf("code is too weak to send with (about %.0f bits, at least %.0f needed); use a longer code mixing words, digits and symbols", bits, minBits)
+	case transfer.CodeStrengthFair:
+		return fmt.Sprintf("This code is only fair (about %.0f bits) and could be guessed with effort. A longer code is safer.", bits), nil
+	}
+	return "", nil
+}
+
+// showCodeStrength displays code and how hard it is to guess
+func (ba *BulletproofApp) showCodeStrength(code string) {
+	ba.codeDisplay.SetText(code)
+	strength, bits := transfer.CodeStrength(code, ba.transferManager.MinCodeBits())
+	ba.codeStrength.SetText(fmt.Sprintf("Code strength: %s (about %.0f bits)", strength, bits))
+}
+
+// createCustomCodeControls builds the "use my own code" option for the send view
+func (ba *BulletproofApp) createCustomCodeControls() fyne.CanvasObject {
+	ba.customCodeEntry = widget.NewEntry()
+	ba.customCodeEntry.SetPlaceHolder("Type the code you agreed on with the receiver")
+	ba.customCodeEntry.Hide()
+
+	ba.customCodeWarning = widget.NewLabel("")
+	ba.customCodeWarning.Wrapping = fyne.TextWrapWord
+	ba.customCodeWarning.Hide()
+
+	ba.customCodeEntry.OnChanged = func(text string) {
+		ba.applyCustomCode(strings.TrimSpace(text))
+	}
+
+	ba.customCodeCheck = widget.NewCheck("Use my own code", func(enabled bool) {
+		if enabled {
+			ba.customCodeEntry.Show()
+			ba.applyCustomCode(strings.TrimSpace(ba.customCodeEntry.Text))
+			return
+		}
+		ba.customCodeEntry.Hide()
+		ba.customCodeWarning.Hide()
+		ba.currentCode = ba.generatedCode
+		ba.showCodeStrength(ba.currentCode)
+		ba.selectButton.Enable()
+	})
+
+	return container.NewVBox(ba.customCodeCheck, ba.customCodeEntry, ba.customCodeWarning)
+}
+
+// applyCustomCode validates a typed custom code and uses it as the transfer
+// code, disabling file selection while it is invalid
+func (ba *BulletproofApp) applyCustomCode(code string) {
+	warning, err := validateCustomCode(code, ba.transferManager.MinCodeBits())
+	if err != nil {
+		ba.customCodeWarning.SetText(err.Error())
+		ba.customCodeWarning.Show()
+		ba.selectButton.Disable()
+		return
+	}
+
+	ba.currentCode = code
+	ba.showCodeStrength(code)
+	ba.selectButton.Enable()
+
+	if warning != "" {
+		ba.customCodeWarning.SetText(warning)
+		ba.customCodeWarning.Show()
+	} else {
+		ba.customCodeWarning.Hide()
+	}
+}
+
+// resetCustomCode turns the custom code option off
+func (ba *BulletproofApp) resetCustomCode() {
+	ba.customCodeEntry.SetText("")
+	ba.customCodeCheck.SetChecked(false)
+}
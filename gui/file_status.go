@@ -0,0 +1,147 @@
+package gui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// fileStatusRefreshInterval caps how often the file list redraws, so folders
+// of thousands of files don't redraw once per file
+const fileStatusRefreshInterval = 250 * time.Millisecond
+
+// fileStateLabels are the words shown for each file state
+var fileStateLabels = map[string]string{
+	transfer.FileStatePending:    "Pending",
+	transfer.FileStateInProgress: "In progress",
+	transfer.FileStateDone:       "Done",
+	transfer.FileStateFailed:     "Failed",
+}
+
+// fileStatusList is the state of every file in the folder transfer on the
+// progress view. Updates arrive on transfer goroutines and only mark the list
+// changed; the refresher redraws it on the UI thread.
+type fileStatusList struct {
+	mutex   sync.Mutex
+	files   []transfer.FileStatus
+	index   map[string]int // Path -> position in files
+	changed bool
+
+	list    *widget.List
+	summary *widget.Label
+	box     *fyne.Container
+}
+
+// newFileStatusList creates an empty, hidden list
+func newFileStatusList() *fileStatusList {
+	l := &fileStatusList{index: make(map[string]int), summary: widget.NewLabel("")}
+	l.list = widget.NewList(
+		func() int {
+			l.mutex.Lock()
+			defer l.mutex.Unlock()
+			return len(l.files)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			l.mutex.Lock()
+			defer l.mutex.Unlock()
+			if id < len(l.files) {
+				object.(*widget.Label).SetText(fileStatusText(l.files[id]))
+			}
+		},
+	)
+	l.box = container.NewBorder(l.summary, nil, nil, nil,
+		container.NewGridWrap(fyne.NewSize(420, 160), l.list))
+	l.box.Hide()
+	return l
+}
+
+// object returns the list with its summary line
+func (l *fileStatusList) object() fyne.CanvasObject {
+	return l.box
+}
+
+// update records the latest state of one file. It may be called from any
+// goroutine.
+func (l *fileStatusList) update(status transfer.FileStatus) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if i, ok := l.index[status.Path]; ok {
+		l.files[i] = status
+	} else {
+		l.index[status.Path] = len(l.files)
+		l.files = append(l.files, status)
+	}
+	l.changed = true
+}
+
+// reset empties the list for a new transfer. Call it on the UI thread.
+func (l *fileStatusList) reset() {
+	l.mutex.Lock()
+	l.files = nil
+	l.index = make(map[string]int)
+	l.changed = false
+	l.mutex.Unlock()
+
+	l.box.Hide()
+	l.list.Refresh()
+}
+
+// refresh redraws the list if it changed since the last refresh. Call it on
+// the UI thread.
+func (l *fileStatusList) refresh() {
+	l.mutex.Lock()
+	if !l.changed {
+		l.mutex.Unlock()
+		return
+	}
+	l.changed = false
+	counts := make(map[string]int)
+	for _, file := range l.files {
+		counts[file.State]++
+	}
+	total := len(l.files)
+	l.mutex.Unlock()
+
+	l.summary.SetText(fmt.Sprintf("Files: %d done, %d in progress, %d pending, %d failed (%d total)",
+		counts[transfer.FileStateDone], counts[transfer.FileStateInProgress],
+		counts[transfer.FileStatePending], counts[transfer.FileStateFailed], total))
+	l.box.Show()
+	l.list.Refresh()
+}
+
+// fileStatusText is the list row for one file
+func fileStatusText(status transfer.FileStatus) string {
+	text := fmt.Sprintf("%s: %s", fileStateLabels[status.State], status.Path)
+	if status.Size > 0 {
+		text += fmt.Sprintf(" (%s)", transfer.FormatBytes(status.Size))
+	}
+	if status.Reason != "" {
+		text += " - " + status.Reason
+	}
+	return text
+}
+
+// runFileStatusRefresher redraws the file list while the progress view is
+// showing
+func (ba *BulletproofApp) runFileStatusRefresher() {
+	ticker := time.NewTicker(fileStatusRefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ba.runOnUI(func() {
+			if ba.currentView == "progress" {
+				ba.fileStatus.refresh()
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/i18n"
+)
+
+// localePreferenceKey is where the chosen language is persisted in the app preferences
+const localePreferenceKey = "locale"
+
+// applySavedLocale applies the language saved from a previous launch, or the
+// system language the first time
+func (ba *BulletproofApp) applySavedLocale() {
+	i18n.SetLocale(ba.app.Preferences().StringWithFallback(localePreferenceKey, i18n.DetectLocale()))
+}
+
+// languageFormItem builds the language picker shown on the main view
+func (ba *BulletproofApp) languageFormItem() *widget.FormItem {
+	locales := make(map[string]string)
+	var names []string
+	for _, locale := range i18n.Supported() {
+		name := i18n.Name(locale)
+		locales[name] = locale
+		names = append(names, name)
+	}
+
+	selector := widget.NewSelect(names, func(name string) {
+		locale := i18n.SetLocale(locales[name])
+		ba.app.Preferences().SetString(localePreferenceKey, locale)
+	})
+	selector.SetSelected(i18n.Name(i18n.Locale()))
+
+	return widget.NewFormItem(i18n.T("settings.language"), selector)
+}
@@ -0,0 +1,64 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// restrictionImpacts says what each kind of detected restriction means for
+// the user's transfers
+var restrictionImpacts = map[string]string{
+	"firewall":                            "peer-to-peer ports are blocked, so transfers go through the relay",
+	"domain_block":                        "some relay servers may be unreachable",
+	"dpi":                                 "transfers may be slower or interrupted",
+	"proxy":                               "transfers pass through the proxy and may be slower",
+	"whitelist":                           "only approved services may be reachable, so transfers may fail",
+	"vpn":                                 "transfers may be slower over the VPN",
+	transport.RestrictionEndpointSecurity: "transfers may be blocked or slowed while they are scanned",
+	transport.RestrictionAsymmetric:       "one transfer direction will fail on this network",
+}
+
+// createRestrictionsBanner builds the main-view notice summarizing the
+// restrictions found on the current network, so the user hears about them
+// before starting a transfer. It stays hidden until the network analysis
+// finds some.
+func (ba *BulletproofApp) createRestrictionsBanner() *widget.Label {
+	ba.restrictionsBanner = widget.NewLabel("")
+	ba.restrictionsBanner.Wrapping = fyne.TextWrapWord
+	ba.restrictionsBanner.Hide()
+	return ba.restrictionsBanner
+}
+
+// updateRestrictionsBanner shows the restrictions in a network status, or
+// hides the banner when there are none
+func (ba *BulletproofApp) updateRestrictionsBanner(status map[string]interface{}) {
+	restrictions, _ := status["network_restrictions"].([]transport.NetworkRestriction)
+	if analyzed, _ := status["analysis_complete"].(bool); !analyzed || len(restrictions) == 0 {
+		ba.restrictionsBanner.Hide()
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("⚠️ Restrictions detected on this network")
+	if expected, _ := status["expected_transport"].(string); expected != "" {
+		fmt.Fprintf(&text, " — transfers will use %s", expected)
+	}
+	text.WriteString(":")
+	for _, restriction := range restrictions {
+		fmt.Fprintf(&text, "\n• %s (%s)", restriction.Description, restriction.Severity)
+		if impact := restrictionImpacts[restriction.Type]; impact != "" {
+			fmt.Fprintf(&text, " — %s", impact)
+		}
+		if restriction.Workaround != "" {
+			fmt.Fprintf(&text, ". %s", restriction.Workaround)
+		}
+	}
+
+	ba.restrictionsBanner.SetText(text.String())
+	ba.restrictionsBanner.Show()
+}
@@ -0,0 +1,85 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// openFolderPreferenceKey is where the choice to open the received folder
+// after a receive is persisted in the app preferences
+const openFolderPreferenceKey = "open_received_folder"
+
+// openFolderOnReceive reports whether the received folder opens by itself
+// when a receive succeeds. It is off unless turned on on the main view, so
+// unattended machines never have windows appear.
+func (ba *BulletproofApp) openFolderOnReceive() bool {
+	return ba.app.Preferences().BoolWithFallback(openFolderPreferenceKey, false)
+}
+
+// openFolderFormItem builds the main-view setting that opens the received
+// folder after each successful receive
+func (ba *BulletproofApp) openFolderFormItem() *widget.FormItem {
+	check := widget.NewCheck("Open the folder when a receive completes", func(enabled bool) {
+		ba.app.Preferences().SetBool(openFolderPreferenceKey, enabled)
+	})
+	check.SetChecked(ba.openFolderOnReceive())
+	return widget.NewFormItem("Received Files", check)
+}
+
+// folderOpenCommand returns the installed command that shows a folder in the
+// platform's file manager, with the arguments that go before the folder
+func folderOpenCommand() (string, []string, bool) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = [][]string{{"explorer"}}
+	case "darwin":
+		candidates = [][]string{{"open"}}
+	default:
+		// Desktops without xdg-utils usually have GLib's gio
+		candidates = [][]string{{"xdg-open"}, {"gio", "open"}}
+	}
+
+	for _, candidate := range candidates {
+		if path, err := exec.LookPath(candidate[0]); err == nil {
+			return path, candidate[1:], true
+		}
+	}
+	return "", nil, false
+}
+
+// openReceivedFolder opens the folder the last transfer was received into,
+// showing its path instead when it cannot be opened
+func (ba *BulletproofApp) openReceivedFolder() {
+	receivedPath := ba.receivedFolder()
+	if _, err := os.Stat(receivedPath); err != nil {
+		ba.showFolderLocation(receivedPath, "The folder could not be found.")
+		return
+	}
+
+	command, args, ok := folderOpenCommand()
+	if !ok {
+		ba.showFolderLocation(receivedPath, "No file manager was found to open it.")
+		return
+	}
+
+	cmd := exec.Command(command, append(args, receivedPath)...)
+	if err := cmd.Start(); err != nil {
+		ba.showFolderLocation(receivedPath, "Could not open folder automatically.")
+		return
+	}
+	// Reap the process; explorer exits non-zero even when it opened the folder
+	go cmd.Wait()
+}
+
+// showFolderLocation shows where files were saved, with why the folder was
+// not opened
+func (ba *BulletproofApp) showFolderLocation(path, reason string) {
+	dialog.ShowInformation("Folder Location",
+		fmt.Sprintf("Files saved to:\n%s\n\n%s", path, reason), ba.window)
+}
@@ -0,0 +1,155 @@
+package gui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
+)
+
+// pendingSendTTL is how long after it started a send still waiting for its
+// receiver is offered for resuming; the receiver has usually given up by then
+const pendingSendTTL = internal.PendingSendMaxAge
+
+// maxListedPendingPaths caps how many files the resume prompt names
+const maxListedPendingPaths = 5
+
+// pendingSend is a send that was waiting for its receiver, saved so it can be
+// resumed with the same code if the app closes before the receiver connects
+type pendingSend struct {
+	Code      string    `json:"code"`
+	Generated bool      `json:"generated,omitempty"` // The app generated Code, so it keeps a generated code's strength
+	Paths     []string  `json:"paths"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// pendingSendPath returns where the pending send is saved
+func pendingSendPath() (string, error) {
+	dir, err := internal.EnsureStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, internal.StatePendingSendFile), nil
+}
+
+// savePendingSend records a send that is about to wait for its receiver
+func savePendingSend(code string, paths []string) error {
+	path, err := pendingSendPath()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data, err := json.MarshalIndent(pendingSend{
+		Code:      code,
+		Generated: internal.WasGenerated(code),
+		Paths:     paths,
+		StartedAt: now,
+		ExpiresAt: now.Add(pendingSendTTL),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pending send: %w", err)
+	}
+
+	// The code is a secret, so keep the file private and never half-written
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save pending send: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save pending send: %w", err)
+	}
+	return nil
+}
+
+// loadPendingSend returns the saved pending send, or nil if there is none or
+// it has expired. Files that no longer exist are dropped from it.
+func loadPendingSend() (*pendingSend, error) {
+	path, err := pendingSendPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending send: %w", err)
+	}
+
+	var pending pendingSend
+	if err := json.Unmarshal(data, &pending); err != nil {
+		clearPendingSend()
+		return nil, fmt.Errorf("failed to parse pending send: %w", err)
+	}
+
+	var paths []string
+	for _, p := range pending.Paths {
+		if _, err := os.Stat(p); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	pending.Paths = paths
+
+	if pending.Code == "" || len(pending.Paths) == 0 || time.Now().After(pending.ExpiresAt) {
+		clearPendingSend()
+		return nil, nil
+	}
+	return &pending, nil
+}
+
+// clearPendingSend forgets the saved pending send
+func clearPendingSend() {
+	if path, err := pendingSendPath(); err == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logging.Warnf("Failed to remove pending send: %v", err)
+		}
+	}
+}
+
+// offerPendingSend asks whether to resume waiting for the receiver of a send
+// that was interrupted when the app last closed
+func (ba *BulletproofApp) offerPendingSend() {
+	pending, err := loadPendingSend()
+	if err != nil {
+		logging.Warnf("Could not restore pending send: %v", err)
+		return
+	}
+	if pending == nil {
+		return
+	}
+
+	names := make([]string, 0, min(len(pending.Paths), maxListedPendingPaths))
+	for _, p := range pending.Paths[:min(len(pending.Paths), maxListedPendingPaths)] {
+		names = append(names, "• "+filepath.Base(p))
+	}
+	if extra := len(pending.Paths) - len(names); extra > 0 {
+		names = append(names, fmt.Sprintf("• and %d more", extra))
+	}
+
+	message := fmt.Sprintf("TrustDrop closed while a send started %s was waiting for its receiver:\n%s\n\nCode: %s\n\nResume waiting with the same code?",
+		pending.StartedAt.Format("Jan 2 15:04"), strings.Join(names, "\n"), pending.Code)
+	dialog.ShowConfirm("Resume Pending Send?", message, func(resume bool) {
+		if !resume {
+			clearPendingSend()
+			return
+		}
+		if pending.Generated {
+			internal.MarkGenerated(pending.Code)
+		}
+		ba.currentCode = pending.Code
+		ba.showCodeStrength(pending.Code)
+		ba.showSendView()
+		ba.startSend(pending.Paths)
+	}, ba.window)
+}
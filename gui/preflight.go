@@ -0,0 +1,55 @@
+package gui
+
+import (
+	"errors"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// preflightConfirmThreshold is the estimated send time above which the user
+// is asked to confirm before the send starts
+const preflightConfirmThreshold = time.Minute
+
+// confirmSend estimates the send and, when it looks long, its folders pass
+// the folder limits or some items cannot be read, shows the estimate and
+// waits for confirmation before starting it
+func (ba *BulletproofApp) confirmSend(paths []string) {
+	if len(paths) == 0 {
+		dialog.ShowInformation("Nothing Selected", "Choose a file or folder to send.", ba.window)
+		return
+	}
+
+	go func() {
+		estimate, err := ba.transferManager.PreflightSend(paths)
+
+		ba.runOnUI(func() {
+			if errors.Is(err, transfer.ErrNothingToSend) {
+				ba.showError("Nothing to Send", "None of the selected items can be read", err, false)
+				return
+			}
+			// The send reports any other problem reading the files itself
+			if err != nil || (estimate.Duration < preflightConfirmThreshold && estimate.FolderLimit == nil && estimate.Unreadable == 0) {
+				ba.startSend(paths)
+				return
+			}
+
+			title, message := "Start Long Transfer?", estimate.Summary()+"\n\nStart the transfer?"
+			if estimate.Unreadable > 0 {
+				title, message = "Send Readable Items?", estimate.Summary()+"\n\nSend the items that can be read?"
+			}
+			if estimate.FolderLimit != nil {
+				title = "Send Large Folder?"
+				message = estimate.Summary() + "\n\nWarning: " + estimate.FolderLimit.Error() + ".\n\nSend it anyway?"
+			}
+			dialog.ShowConfirm(title, message,
+				func(start bool) {
+					if start {
+						ba.startSend(paths)
+					}
+				}, ba.window)
+		})
+	}()
+}
@@ -0,0 +1,157 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// receiveSession is a running session receive: the transfers that have
+// arrived so far and how to stop waiting for more
+type receiveSession struct {
+	mutex  sync.Mutex
+	items  []string
+	cancel context.CancelFunc
+}
+
+// createSessionView creates the view shown while a receive session runs
+func (ba *BulletproofApp) createSessionView() {
+	ba.sessionStatus = widget.NewLabel("")
+	ba.sessionStatus.Alignment = fyne.TextAlignCenter
+	ba.sessionStatus.Wrapping = fyne.TextWrapWord
+
+	ba.sessionList = widget.NewList(
+		func() int {
+			ba.session.mutex.Lock()
+			defer ba.session.mutex.Unlock()
+			return len(ba.session.items)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			ba.session.mutex.Lock()
+			defer ba.session.mutex.Unlock()
+			if id < 0 || id >= len(ba.session.items) {
+				return
+			}
+			object.(*widget.Label).SetText(ba.session.items[id])
+		},
+	)
+
+	openBtn := widget.NewButtonWithIcon("Open Folder", theme.FolderOpenIcon(), ba.openReceivedFolder)
+
+	// Stops a running session, and leaves the view once it has ended
+	ba.sessionStopBtn = widget.NewButtonWithIcon("Stop Receiving", theme.MediaStopIcon(), func() {
+		ba.session.mutex.Lock()
+		cancel := ba.session.cancel
+		ba.session.mutex.Unlock()
+		if cancel == nil {
+			ba.resetTransferState()
+			ba.showMainView()
+			return
+		}
+		ba.sessionStopBtn.Disable()
+		cancel()
+	})
+	ba.sessionStopBtn.Importance = widget.DangerImportance
+
+	help := widget.NewLabel("The code stays active and every transfer the sender makes with it is saved to the received folder. Stop when the sender is done.")
+	help.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(
+		help,
+		ba.sessionStatus,
+		container.NewGridWrap(fyne.NewSize(480, 200), ba.sessionList),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2, openBtn, ba.sessionStopBtn),
+	)
+
+	ba.sessionCard = widget.NewCard("Receive Session", "", content)
+}
+
+// showSessionView shows the running receive session
+func (ba *BulletproofApp) showSessionView() {
+	ba.currentView = "session"
+	ba.sessionList.Refresh()
+	ba.window.SetContent(container.NewCenter(ba.sessionCard))
+}
+
+// onStartSession receives transfers sent with code one after another until
+// the user stops the session
+func (ba *BulletproofApp) onStartSession(code string) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ba.mutex.Lock()
+	ba.isTransferring = true
+	ba.lastOperation = "receive"
+	ba.lastReceiveCode = code
+	ba.mutex.Unlock()
+
+	ba.session.mutex.Lock()
+	ba.session.items = nil
+	ba.session.cancel = func() {
+		cancel()
+		ba.transferManager.Cancel()
+	}
+	ba.session.mutex.Unlock()
+
+	ba.sessionCard.SetSubTitle(fmt.Sprintf("Code: %s", code))
+	ba.sessionStatus.SetText("Waiting for the sender...")
+	ba.sessionStopBtn.SetText("Stop Receiving")
+	ba.sessionStopBtn.Enable()
+	ba.showSessionView()
+
+	go func() {
+		received, err := ba.transferManager.ReceiveSessionContext(ba.transferContext(ctx), code, func(result *transfer.TransferResult, err error) {
+			if result == nil {
+				ba.runOnUI(func() {
+					ba.sessionStatus.SetText(fmt.Sprintf("Nothing received, still waiting: %v", err))
+				})
+				return
+			}
+
+			ba.mutex.Lock()
+			ba.lastReceiveDir = result.DestinationDir
+			ba.mutex.Unlock()
+
+			ba.session.mutex.Lock()
+			item := fmt.Sprintf("%d. %d files (%s)", len(ba.session.items)+1,
+				len(result.TransferredFiles), transfer.FormatBytes(result.TotalBytes))
+			if len(result.MissingFiles) > 0 {
+				item += fmt.Sprintf(" • %d missing", len(result.MissingFiles))
+			}
+			ba.session.items = append(ba.session.items, item)
+			ba.session.mutex.Unlock()
+			ba.runOnUI(ba.sessionList.Refresh)
+		})
+		cancel()
+
+		ba.mutex.Lock()
+		ba.isTransferring = false
+		ba.mutex.Unlock()
+
+		ba.session.mutex.Lock()
+		ba.session.cancel = nil
+		ba.session.mutex.Unlock()
+
+		ba.runOnUI(func() {
+			ba.sessionStopBtn.SetText("Done")
+			ba.sessionStopBtn.Enable()
+			if err != nil {
+				ba.sessionStatus.SetText(err.Error())
+				dialog.ShowError(err, ba.window)
+				return
+			}
+			ba.sessionStatus.SetText(fmt.Sprintf("Session ended. %d transfers received.", received))
+		})
+	}()
+}
@@ -0,0 +1,31 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createRelayStatusLabel builds the main-view notice listing configured relays
+// that were skipped as invalid. It stays hidden when the relay config is fine.
+func (ba *BulletproofApp) createRelayStatusLabel() *widget.Label {
+	label := widget.NewLabel("")
+	label.Alignment = fyne.TextAlignCenter
+	label.Wrapping = fyne.TextWrapWord
+
+	problems := ba.transferManager.RelayConfigProblems()
+	if len(problems) == 0 {
+		label.Hide()
+		return label
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "⚠️ %d relay entries in the config are invalid and were skipped:", len(problems))
+	for _, problem := range problems {
+		fmt.Fprintf(&text, "\n%v", problem)
+	}
+	label.SetText(text.String())
+	return label
+}
@@ -0,0 +1,73 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// maxListedVerifyFailures caps how many failed files the re-verify dialog lists
+const maxListedVerifyFailures = 10
+
+// createReverifyButton builds the success-view button that re-checks the
+// files of the last receive. It is only shown after a receive.
+func (ba *BulletproofApp) createReverifyButton() *widget.Button {
+	ba.reverifyBtn = widget.NewButton("Re-verify Integrity", func() {
+		ba.reverifyLastReceive()
+	})
+	ba.reverifyBtn.Icon = theme.ConfirmIcon()
+	ba.reverifyBtn.Hide()
+	return ba.reverifyBtn
+}
+
+// reverifyLastReceive checks the last received files against the checksums
+// recorded when they arrived and shows the outcome
+func (ba *BulletproofApp) reverifyLastReceive() {
+	ba.mutex.Lock()
+	code := ba.lastReceiveCode
+	ba.mutex.Unlock()
+	if code == "" {
+		return
+	}
+
+	ba.reverifyBtn.Disable()
+	go func() {
+		report, err := ba.transferManager.VerifyReceived(code)
+
+		ba.runOnUI(func() {
+			defer ba.reverifyBtn.Enable()
+
+			if err != nil {
+				dialog.ShowError(err, ba.window)
+				return
+			}
+
+			if report.OK() {
+				dialog.ShowInformation("Files Verified",
+					fmt.Sprintf("All %d files still match what was received.", report.Passed), ba.window)
+				return
+			}
+
+			var details strings.Builder
+			fmt.Fprintf(&details, "%d of %d files no longer match what was received:\n\n", report.Failed, len(report.Files))
+			listed := 0
+			for _, file := range report.Files {
+				if file.Status == transfer.VerifyStatusOK {
+					continue
+				}
+				if listed == maxListedVerifyFailures {
+					fmt.Fprintf(&details, "• … %d more\n", report.Failed-listed)
+					break
+				}
+				fmt.Fprintf(&details, "• %s (%s)\n", file.Path, file.Status)
+				listed++
+			}
+			dialog.ShowInformation("Verification Failed", details.String(), ba.window)
+		})
+	}()
+}
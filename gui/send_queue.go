@@ -0,0 +1,272 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Send queue item states
+const (
+	queueStatusQueued  = "Queued"
+	queueStatusSending = "Sending"
+	queueStatusSent    = "Sent"
+	queueStatusFailed  = "Failed"
+)
+
+// queueItem is one file or folder waiting in the send queue, with its own code
+type queueItem struct {
+	Path   string
+	Code   string
+	Status string
+	Err    error
+}
+
+// sendQueue holds items to send one after another. A failed item is marked
+// and the queue moves on to the next one.
+type sendQueue struct {
+	mutex   sync.Mutex
+	items   []*queueItem
+	running bool
+}
+
+// createQueueView creates the view for managing and running the send queue
+func (ba *BulletproofApp) createQueueView() {
+	selected := -1
+
+	ba.queueList = widget.NewList(
+		func() int {
+			ba.queue.mutex.Lock()
+			defer ba.queue.mutex.Unlock()
+			return len(ba.queue.items)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Wrapping = fyne.TextWrapWord
+			return label
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			ba.queue.mutex.Lock()
+			defer ba.queue.mutex.Unlock()
+			if id < 0 || id >= len(ba.queue.items) {
+				return
+			}
+			object.(*widget.Label).SetText(describeQueueItem(ba.queue.items[id]))
+		},
+	)
+	ba.queueList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+	ba.queueList.OnUnselected = func(widget.ListItemID) {
+		selected = -1
+	}
+
+	addBtn := widget.NewButtonWithIcon("Add Item", theme.ContentAddIcon(), func() {
+		ba.chooseSendItems(ba.enqueueSend)
+	})
+
+	upBtn := widget.NewButtonWithIcon("Up", theme.MoveUpIcon(), func() {
+		if ba.moveQueueItem(selected, -1) {
+			selected--
+			ba.queueList.Select(selected)
+		}
+	})
+	downBtn := widget.NewButtonWithIcon("Down", theme.MoveDownIcon(), func() {
+		if ba.moveQueueItem(selected, 1) {
+			selected++
+			ba.queueList.Select(selected)
+		}
+	})
+	removeBtn := widget.NewButtonWithIcon("Remove", theme.DeleteIcon(), func() {
+		if ba.removeQueueItem(selected) {
+			ba.queueList.UnselectAll()
+		}
+	})
+	copyBtn := widget.NewButtonWithIcon("Copy Code", theme.ContentCopyIcon(), func() {
+		ba.queue.mutex.Lock()
+		defer ba.queue.mutex.Unlock()
+		if selected >= 0 && selected < len(ba.queue.items) {
+			ba.window.Clipboard().SetContent(ba.queue.items[selected].Code)
+		}
+	})
+
+	ba.queueStartBtn = widget.NewButtonWithIcon("Send All", theme.MailSendIcon(), ba.runSendQueue)
+	ba.queueStartBtn.Importance = widget.HighImportance
+
+	backBtn := widget.NewButtonWithIcon("Back", theme.NavigateBackIcon(), func() {
+		ba.showMainView()
+	})
+
+	help := widget.NewLabel("Each item is sent with its own code. Share each code with the receiver; items are sent in order and a failed item doesn't stop the rest.")
+	help.Wrapping = fyne.TextWrapWord
+
+	controls := container.NewGridWithColumns(4, upBtn, downBtn, removeBtn, copyBtn)
+	listArea := container.NewGridWrap(fyne.NewSize(480, 220), ba.queueList)
+
+	content := container.NewVBox(
+		container.NewBorder(nil, nil, backBtn, nil),
+		help,
+		listArea,
+		controls,
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2, addBtn, ba.queueStartBtn),
+	)
+
+	ba.queueCard = widget.NewCard("Send Queue", "", content)
+}
+
+// showQueueView shows the send queue
+func (ba *BulletproofApp) showQueueView() {
+	ba.currentView = "queue"
+	ba.queueList.Refresh()
+	ba.window.SetContent(container.NewCenter(ba.queueCard))
+}
+
+// enqueueSend adds paths to the end of the queue, each with a fresh code
+func (ba *BulletproofApp) enqueueSend(paths []string) {
+	ba.queue.mutex.Lock()
+	for _, path := range paths {
+		ba.queue.items = append(ba.queue.items, &queueItem{
+			Path:   path,
+			Code:   generateTransferCode(),
+			Status: queueStatusQueued,
+		})
+	}
+	ba.queue.mutex.Unlock()
+
+	ba.queueList.Refresh()
+}
+
+// moveQueueItem moves the item at index by delta positions. Items can be
+// reordered while the queue runs; the next item sent is always the first
+// one still queued.
+func (ba *BulletproofApp) moveQueueItem(index, delta int) bool {
+	ba.queue.mutex.Lock()
+	target := index + delta
+	moved := index >= 0 && index < len(ba.queue.items) && target >= 0 && target < len(ba.queue.items)
+	if moved {
+		ba.queue.items[index], ba.queue.items[target] = ba.queue.items[target], ba.queue.items[index]
+	}
+	ba.queue.mutex.Unlock()
+
+	if moved {
+		ba.queueList.Refresh()
+	}
+	return moved
+}
+
+// removeQueueItem removes the item at index unless it is being sent
+func (ba *BulletproofApp) removeQueueItem(index int) bool {
+	ba.queue.mutex.Lock()
+	removed := index >= 0 && index < len(ba.queue.items) && ba.queue.items[index].Status != queueStatusSending
+	if removed {
+		ba.queue.items = append(ba.queue.items[:index], ba.queue.items[index+1:]...)
+	}
+	ba.queue.mutex.Unlock()
+
+	if removed {
+		ba.queueList.Refresh()
+	}
+	return removed
+}
+
+// nextQueuedItem marks the first queued item as sending and returns it, or nil
+// when nothing is left
+func (ba *BulletproofApp) nextQueuedItem() *queueItem {
+	ba.queue.mutex.Lock()
+	defer ba.queue.mutex.Unlock()
+
+	for _, item := range ba.queue.items {
+		if item.Status == queueStatusQueued {
+			item.Status = queueStatusSending
+			return item
+		}
+	}
+	return nil
+}
+
+// runSendQueue sends every queued item in order in the background
+func (ba *BulletproofApp) runSendQueue() {
+	ba.queue.mutex.Lock()
+	if ba.queue.running {
+		ba.queue.mutex.Unlock()
+		return
+	}
+	ba.queue.running = true
+	ba.queue.mutex.Unlock()
+
+	ba.mutex.Lock()
+	busy := ba.isTransferring
+	ba.mutex.Unlock()
+	if busy {
+		ba.queue.mutex.Lock()
+		ba.queue.running = false
+		ba.queue.mutex.Unlock()
+		dialog.ShowInformation("Transfer in Progress", "Wait for the current transfer to finish before sending the queue.", ba.window)
+		return
+	}
+
+	ba.queueStartBtn.Disable()
+
+	go func() {
+		sent, failed := 0, 0
+		for item := ba.nextQueuedItem(); item != nil; item = ba.nextQueuedItem() {
+			ba.runOnUI(ba.queueList.Refresh)
+
+			ba.mutex.Lock()
+			ba.isTransferring = true
+			ba.mutex.Unlock()
+
+			_, err := ba.transferManager.SendFilesContext(ba.transferContext(context.Background()), []string{item.Path}, item.Code)
+
+			ba.mutex.Lock()
+			ba.isTransferring = false
+			ba.mutex.Unlock()
+
+			ba.queue.mutex.Lock()
+			if err != nil {
+				item.Status = queueStatusFailed
+				item.Err = err
+				failed++
+			} else {
+				item.Status = queueStatusSent
+				sent++
+			}
+			ba.queue.mutex.Unlock()
+			ba.runOnUI(ba.queueList.Refresh)
+		}
+
+		ba.queue.mutex.Lock()
+		ba.queue.running = false
+		ba.queue.mutex.Unlock()
+
+		ba.runOnUI(func() {
+			ba.queueStartBtn.Enable()
+			dialog.ShowInformation("Queue Finished",
+				fmt.Sprintf("%d item(s) sent, %d failed.", sent, failed), ba.window)
+		})
+	}()
+}
+
+// describeQueueItem formats a queue item for the list
+func describeQueueItem(item *queueItem) string {
+	text := fmt.Sprintf("%s\nCode: %s • %s", filepath.Base(item.Path), item.Code, item.Status)
+	if item.Err != nil {
+		text += ": " + simplifyQueueError(item.Err)
+	}
+	return text
+}
+
+// simplifyQueueError keeps the first line of an error for the compact list view
+func simplifyQueueError(err error) string {
+	firstLine, _, _ := strings.Cut(err.Error(), "\n")
+	return firstLine
+}
@@ -0,0 +1,112 @@
+package gui
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Theme settings
+const (
+	ThemeSystem = "system" // Follow the OS light/dark appearance
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// themePreferenceKey is where the chosen theme is persisted in the app preferences
+const themePreferenceKey = "theme"
+
+// trustDropTheme is the default Fyne theme with TrustDrop's brand colors. The
+// brand colors are picked per variant so they keep their contrast in both
+// light and dark mode.
+type trustDropTheme struct {
+	setting string // One of the Theme* settings
+}
+
+// Brand colors per variant
+var (
+	brandPrimaryLight = color.NRGBA{R: 0x1f, G: 0x5f, B: 0xbf, A: 0xff}
+	brandPrimaryDark  = color.NRGBA{R: 0x5b, G: 0x9b, B: 0xf5, A: 0xff}
+	brandFocusLight   = color.NRGBA{R: 0x1f, G: 0x5f, B: 0xbf, A: 0x7f}
+	brandFocusDark    = color.NRGBA{R: 0x5b, G: 0x9b, B: 0xf5, A: 0x7f}
+)
+
+// Color returns the brand colors for primary and focus and defers to the
+// default theme otherwise. A forced light or dark setting overrides the
+// variant Fyne derives from the OS.
+func (t *trustDropTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch t.setting {
+	case ThemeLight:
+		variant = theme.VariantLight
+	case ThemeDark:
+		variant = theme.VariantDark
+	}
+
+	switch name {
+	case theme.ColorNamePrimary, theme.ColorNameHyperlink:
+		if variant == theme.VariantDark {
+			return brandPrimaryDark
+		}
+		return brandPrimaryLight
+	case theme.ColorNameFocus:
+		if variant == theme.VariantDark {
+			return brandFocusDark
+		}
+		return brandFocusLight
+	}
+	return theme.DefaultTheme().Color(name, variant)
+}
+
+// Font defers to the default theme
+func (t *trustDropTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return theme.DefaultTheme().Font(style)
+}
+
+// Icon defers to the default theme
+func (t *trustDropTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return theme.DefaultTheme().Icon(name)
+}
+
+// Size defers to the default theme
+func (t *trustDropTheme) Size(name fyne.ThemeSizeName) float32 {
+	return theme.DefaultTheme().Size(name)
+}
+
+// applySavedTheme applies the theme setting saved from a previous launch
+func (ba *BulletproofApp) applySavedTheme() {
+	ba.applyTheme(ba.app.Preferences().StringWithFallback(themePreferenceKey, ThemeSystem))
+}
+
+// applyTheme switches the app to a theme setting and saves it for next launch
+func (ba *BulletproofApp) applyTheme(setting string) {
+	switch setting {
+	case ThemeSystem, ThemeLight, ThemeDark:
+	default:
+		setting = ThemeSystem
+	}
+
+	ba.app.Settings().SetTheme(&trustDropTheme{setting: setting})
+	ba.app.Preferences().SetString(themePreferenceKey, setting)
+}
+
+// themeFormItem builds the theme picker shown on the main view
+func (ba *BulletproofApp) themeFormItem() *widget.FormItem {
+	labels := map[string]string{
+		ThemeSystem: "System",
+		ThemeLight:  "Light",
+		ThemeDark:   "Dark",
+	}
+	settings := map[string]string{}
+	for setting, label := range labels {
+		settings[label] = setting
+	}
+
+	selector := widget.NewSelect([]string{labels[ThemeSystem], labels[ThemeLight], labels[ThemeDark]}, func(label string) {
+		ba.applyTheme(settings[label])
+	})
+	selector.SetSelected(labels[ba.app.Preferences().StringWithFallback(themePreferenceKey, ThemeSystem)])
+
+	return widget.NewFormItem("Theme", selector)
+}
@@ -0,0 +1,148 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// throughputSampleInterval is how often the throughput graph takes a sample,
+// which also caps how often it redraws
+const throughputSampleInterval = time.Second
+
+// throughputSamples is how many samples the graph shows, a minute's worth
+const throughputSamples = 60
+
+// throughputGraph is a sparkline of transfer speed, so throttling and stalls
+// show up as dips rather than hiding in an average
+type throughputGraph struct {
+	mutex     sync.Mutex
+	bytes     int64 // Latest overall byte count reported
+	lastBytes int64 // Byte count at the previous sample
+	lastTime  time.Time
+	samples   [throughputSamples]float64 // Ring buffer of bytes per second
+	next      int
+	count     int
+	peak      float64
+
+	raster *canvas.Raster
+	label  *widget.Label
+}
+
+// newThroughputGraph creates an empty graph
+func newThroughputGraph() *throughputGraph {
+	g := &throughputGraph{label: widget.NewLabel("")}
+	g.label.Alignment = fyne.TextAlignCenter
+	g.raster = canvas.NewRaster(g.draw)
+	g.raster.SetMinSize(fyne.NewSize(320, 48))
+	return g
+}
+
+// object returns the graph with its caption
+func (g *throughputGraph) object() fyne.CanvasObject {
+	return container.NewVBox(g.raster, g.label)
+}
+
+// reset clears the graph for a new transfer. Call it on the UI thread.
+func (g *throughputGraph) reset() {
+	g.mutex.Lock()
+	g.bytes, g.lastBytes, g.lastTime = 0, 0, time.Time{}
+	g.samples = [throughputSamples]float64{}
+	g.next, g.count, g.peak = 0, 0, 0
+	g.mutex.Unlock()
+
+	g.label.SetText("")
+	g.raster.Refresh()
+}
+
+// report records the overall byte count from a progress update. It may be
+// called from any goroutine and is cheap; drawing waits for the next sample.
+func (g *throughputGraph) report(overallBytes int64) {
+	g.mutex.Lock()
+	g.bytes = overallBytes
+	g.mutex.Unlock()
+}
+
+// sample adds the speed since the previous sample and redraws. Call it on
+// the UI thread.
+func (g *throughputGraph) sample(now time.Time) {
+	g.mutex.Lock()
+	if g.lastTime.IsZero() {
+		g.lastTime, g.lastBytes = now, g.bytes
+		g.mutex.Unlock()
+		return
+	}
+
+	var rate float64
+	// The count restarts when a transfer reconnects; that interval is not a speed
+	if elapsed := now.Sub(g.lastTime).Seconds(); elapsed > 0 && g.bytes >= g.lastBytes {
+		rate = float64(g.bytes-g.lastBytes) / elapsed
+	}
+	g.lastTime, g.lastBytes = now, g.bytes
+
+	g.samples[g.next] = rate
+	g.next = (g.next + 1) % throughputSamples
+	g.count = min(g.count+1, throughputSamples)
+	g.peak = max(g.peak, rate)
+	caption := fmt.Sprintf("Now %s/s • Peak %s/s",
+		transfer.FormatBytes(int64(rate)), transfer.FormatBytes(int64(g.peak)))
+	g.mutex.Unlock()
+
+	g.label.SetText(caption)
+	g.raster.Refresh()
+}
+
+// draw renders the samples as a filled area scaled to the peak, newest at
+// the right
+func (g *throughputGraph) draw(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w <= 0 || h <= 0 {
+		return img
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.count == 0 || g.peak <= 0 {
+		return img
+	}
+
+	fill := color.RGBAModel.Convert(theme.PrimaryColor()).(color.RGBA)
+	for x := 0; x < w; x++ {
+		// Map each column onto the last throughputSamples samples
+		slot := x * throughputSamples / w
+		age := throughputSamples - 1 - slot
+		if age >= g.count {
+			continue
+		}
+		rate := g.samples[(g.next-1-age+throughputSamples)%throughputSamples]
+		top := h - int(rate/g.peak*float64(h-1)) - 1
+		for y := max(top, 0); y < h; y++ {
+			img.SetRGBA(x, y, fill)
+		}
+	}
+	return img
+}
+
+// runThroughputSampler samples the throughput graph while the progress view
+// is showing
+func (ba *BulletproofApp) runThroughputSampler() {
+	ticker := time.NewTicker(throughputSampleInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		ba.runOnUI(func() {
+			if ba.currentView == "progress" {
+				ba.throughput.sample(now)
+			}
+		})
+	}
+}
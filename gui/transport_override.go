@@ -0,0 +1,56 @@
+package gui
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// automaticTransport is the transport choice that keeps automatic failover
+const automaticTransport = "Automatic"
+
+// transferContext returns ctx limited to the transports chosen on the main
+// view, if any. The choice lasts until the app closes, so a forgotten
+// override never breaks transfers on another network later.
+func (ba *BulletproofApp) transferContext(ctx context.Context) context.Context {
+	ba.mutex.Lock()
+	names := ba.transportOverride
+	ba.mutex.Unlock()
+	if len(names) == 0 {
+		return ctx
+	}
+	return transfer.WithTransports(ctx, names...)
+}
+
+// SetTransportOverride limits the transfers started from the app to the
+// named transports, tried in order. No names restores automatic failover.
+func (ba *BulletproofApp) SetTransportOverride(names []string) {
+	label := automaticTransport
+	if len(names) > 0 {
+		label = strings.Join(names, ", ")
+		if !slices.Contains(ba.transportSelect.Options, label) {
+			ba.transportSelect.Options = append(ba.transportSelect.Options, label)
+		}
+	}
+	ba.transportSelect.SetSelected(label)
+}
+
+// transportFormItem builds the advanced main-view choice of transport
+func (ba *BulletproofApp) transportFormItem() *widget.FormItem {
+	options := append([]string{automaticTransport}, ba.transferManager.TransportNames()...)
+	ba.transportSelect = widget.NewSelect(options, func(label string) {
+		var names []string
+		if label != automaticTransport {
+			names = strings.Split(label, ", ")
+		}
+		ba.mutex.Lock()
+		ba.transportOverride = names
+		ba.mutex.Unlock()
+	})
+	ba.transportSelect.SetSelected(automaticTransport)
+	return widget.NewFormItem("Transport", ba.transportSelect)
+}
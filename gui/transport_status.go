@@ -0,0 +1,22 @@
+package gui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// warnIfNotReady tells the user at startup when no transport could be set
+// up, rather than letting them find out from a failed transfer
+func (ba *BulletproofApp) warnIfNotReady() {
+	ready, failures := ba.transferManager.Ready()
+	if ready {
+		return
+	}
+
+	message := "No transports available — transfers will fail.\n\n" +
+		"None of TrustDrop's connection methods could be set up on this device:\n• " +
+		strings.Join(failures, "\n• ") +
+		"\n\nCheck that TrustDrop may use the network and restart it."
+	dialog.ShowInformation("Transfers Unavailable", message, ba.window)
+}
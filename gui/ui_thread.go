@@ -0,0 +1,25 @@
+package gui
+
+// uiQueueSize is how many UI updates may be pending before callers wait
+const uiQueueSize = 256
+
+// startUIDispatcher starts the goroutine that applies UI updates queued by
+// runOnUI. Fyne 2.4 has no public way to run code on its main thread, so
+// updates from transfer goroutines are funnelled through this one goroutine
+// instead: they never race each other and are applied in the order they were
+// made. Once Fyne is upgraded to 2.6 or later runOnUI becomes fyne.Do.
+func (ba *BulletproofApp) startUIDispatcher() {
+	ba.uiQueue = make(chan func(), uiQueueSize)
+	go func() {
+		for update := range ba.uiQueue {
+			update()
+		}
+	}()
+}
+
+// runOnUI queues a widget update made from a background goroutine. Code that
+// already runs in a Fyne callback updates widgets directly. It must not be
+// called from inside a queued update.
+func (ba *BulletproofApp) runOnUI(update func()) {
+	ba.uiQueue <- update
+}
@@ -0,0 +1,28 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"trustdrop-bulletproof/transfer"
+)
+
+// maxListedUnsentFiles caps how many left-out files the send dialog lists
+const maxListedUnsentFiles = 10
+
+// showUnsentFiles lists folder files that were left out of a send, so the user
+// knows the folder arrived without them
+func (ba *BulletproofApp) showUnsentFiles(files []transfer.MissingFile) {
+	var details strings.Builder
+	fmt.Fprintf(&details, "%d files were not sent:\n\n", len(files))
+	for i, file := range files {
+		if i == maxListedUnsentFiles {
+			fmt.Fprintf(&details, "• … %d more\n", len(files)-i)
+			break
+		}
+		fmt.Fprintf(&details, "• %s: %s\n", file.Path, file.Reason)
+	}
+	dialog.ShowInformation("Some Files Not Sent", details.String(), ba.window)
+}
@@ -0,0 +1,144 @@
+package i18n
+
+// english is the reference catalog; every key must be present here
+var english = map[string]string{
+	// Transfer error guidance
+	"error.restricted.title":      "Transfer failed due to institutional network restrictions.\n\n",
+	"error.restricted.corporate":  "Your corporate network has strict security policies that block peer-to-peer file transfer protocols.\n\n",
+	"error.restricted.university": "Your university network has academic security policies that restrict direct file transfer protocols.\n\n",
+	"error.restricted.managed":    "Your managed network has IT policies that block direct file transfer protocols.\n\n",
+	"error.restricted.detected":   "Detected network restrictions:\n",
+	"error.restricted.solutions": "Recommended solutions:\n" +
+		"• Try from a different network (mobile hotspot, home WiFi)\n" +
+		"• Contact your IT department about approved file transfer methods\n" +
+		"• Use a personal device with mobile data if permitted by policy\n" +
+		"• Consider using your organization's approved file sharing platform\n" +
+		"• Temporarily connect via mobile hotspot if policies allow\n",
+	"error.network": "Network connectivity issue detected.\n\n" +
+		"Troubleshooting steps:\n" +
+		"• Verify your internet connection is stable\n" +
+		"• Check if your firewall or antivirus is blocking the connection\n" +
+		"• Try again in a few minutes in case of temporary network issues\n" +
+		"• Restart your network adapter or router if problems persist\n",
+	"error.endpoint_security": "Antivirus or endpoint security software on this device appears to have " +
+		"blocked the transfer, for example by quarantining its temporary files.\n\n" +
+		"Recommended steps:\n" +
+		"• Add TrustDrop to your antivirus or endpoint protection allow list\n" +
+		"• Ask your IT department to allow TrustDrop and its temp folder\n" +
+		"• Check your antivirus quarantine for recently blocked files\n",
+	"error.one_way.receive": "You can send but not receive on this network.\n\n" +
+		"The network lets this device create transfers but blocks joining one to download files.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the other person to receive from you instead, if that suits the transfer\n" +
+		"• Receive on a different network, such as a mobile hotspot or home connection\n" +
+		"• Ask your IT department to allow downloads through the transfer relay\n",
+	"error.one_way.send": "You can receive but not send on this network.\n\n" +
+		"The network lets this device join transfers but blocks creating one to upload files.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the other person to send to you instead, if that suits the transfer\n" +
+		"• Send from a different network, such as a mobile hotspot or home connection\n" +
+		"• Ask your IT department to allow uploads through the transfer relay\n",
+	"error.relay_rejected": "A relay was reached but rejected the connection, which usually means the relay password is wrong. Retrying will not help until it is fixed.\n\n" +
+		"Recommended steps:\n" +
+		"• Check the relay password matches the one the private relay was started with\n" +
+		"• Check the relay address points at the relay you expect\n" +
+		"• Remove the custom relay settings to use the public relays\n",
+	"error.integrity": "The received files did not match the checksums sent with them, so they were not saved.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the sender to send the files again\n" +
+		"• Check that nothing on this device (such as antivirus) modifies downloads\n",
+	"error.version.newer": "The sender is running a newer version of TrustDrop (protocol %d; this app speaks %d), so this transfer could not be read.\n\n" +
+		"Recommended steps:\n" +
+		"• Update TrustDrop on this device, then receive again\n",
+	"error.version.older": "The sender is running an older version of TrustDrop (protocol %d; this app speaks %d), so this transfer could not be read.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the sender to update TrustDrop and send again\n",
+	"error.version.manifest": "The sender is running a newer version of TrustDrop that lists folders in a new format (manifest version %d; this app reads up to %d), so this transfer could not be read.\n\n" +
+		"Recommended steps:\n" +
+		"• Update TrustDrop on this device, then receive again\n",
+	"error.file_type_blocked": "The transfer was refused because it contains a file type this device is set not to accept, so nothing was saved.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the sender to leave out the blocked file and send again\n" +
+		"• To accept it, change the file_type_policy setting in trustdrop.json\n",
+	"error.too_large": "The transfer was refused because it is larger than this device is set to accept, so nothing was saved.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the sender to split the files into smaller transfers\n" +
+		"• To accept it, raise the max_receive_size setting in trustdrop.json\n",
+	"error.quarantined": "The files arrived but did not pass the quarantine check, so they were kept in the quarantine folder instead of being released.\n\n" +
+		"Recommended steps:\n" +
+		"• Read quarantine-report.txt in the quarantine folder to see why\n" +
+		"• Ask the sender to send again if the files were incomplete or unverified\n",
+	"error.corrupted": "The transfer reached this device damaged, so nothing was saved. The connection worked; the data changed on the way.\n\n" +
+		"Recommended steps:\n" +
+		"• Ask the sender to send the files again\n" +
+		"• If it keeps happening, try another network or turn off any proxy or VPN that inspects traffic\n",
+	"error.missing_files": "%d of %d files in this folder were not received. The files that did arrive were saved.\n\n" +
+		"%s\n" +
+		"Recommended steps:\n" +
+		"• Ask the sender to send the missing files again\n" +
+		"• Send very large files on their own rather than inside a folder\n",
+	"error.incomplete": "Transfer did not complete: %s",
+	"error.failed":     "Transfer failed: %s",
+	"error.general_steps": "\n\nGeneral troubleshooting steps:\n" +
+		"• Verify the transfer code is correct and hasn't expired\n" +
+		"• Ensure both devices are connected to the internet\n" +
+		"• Try restarting the application\n" +
+		"• Check available disk space on the receiving device\n",
+	"error.vpn":          "\nA VPN is active - your VPN may be routing this transfer. If problems continue, try disconnecting it or enabling split tunneling.\n",
+	"error.file":         "\nFile: %s",
+	"error.network_type": "\nNetwork Type: %s",
+	"error.technical":    "\nTechnical Details: %v",
+
+	// Network troubleshooting dialog
+	"help.dialog_title":        "Network Troubleshooting",
+	"help.title":               "Network Troubleshooting Guide\n\n",
+	"help.current_network":     "Current Network: %s",
+	"help.restrictive":         " (Restrictive)",
+	"help.corporate.title":     "🏢 Corporate Network Detected\n\n",
+	"help.corporate.body":      "Your company network has security policies that may block peer-to-peer file transfers. TrustDrop automatically uses enterprise-compatible methods, but some restrictions may still apply.\n\n",
+	"help.university.title":    "🎓 University Network Detected\n\n",
+	"help.university.body":     "Educational networks often have strict security policies for student safety and compliance. TrustDrop uses education-friendly methods, but some restrictions may still apply.\n\n",
+	"help.institutional.title": "🔒 Institutional Network Detected\n\n",
+	"help.institutional.body":  "This appears to be a managed network with comprehensive security policies. TrustDrop is using maximum compatibility mode, but transfers may still be restricted.\n\n",
+	"help.restricted.title":    "🔒 Restricted Network Detected\n\n",
+	"help.restricted.body":     "Your network has restrictions that may interfere with file transfers. TrustDrop has automatically adjusted its methods for compatibility.\n\n",
+	"help.open.title":          "🌐 Open Network Detected\n\n",
+	"help.open.body":           "Your network appears to be open, but transfers are still failing.\n\n",
+	"help.corporate.solutions": "Solutions:\n" +
+		"• Contact your IT department about approved file transfer methods\n" +
+		"• Try from a different network (mobile hotspot, home WiFi)\n" +
+		"• Use a personal device with mobile data if company policy permits\n" +
+		"• Ask IT about whitelisting TrustDrop's HTTPS-based transfer method\n",
+	"help.university.solutions": "Solutions:\n" +
+		"• Try from the campus library or a different network zone\n" +
+		"• Use your mobile data connection if available\n" +
+		"• Contact IT support about student file transfer options\n" +
+		"• Try from an off-campus network (coffee shop, home)\n",
+	"help.institutional.solutions": "Solutions:\n" +
+		"• Contact network administrators about file transfer policies\n" +
+		"• Try from an unmanaged network (mobile hotspot, public WiFi)\n" +
+		"• Use organization-approved file sharing alternatives\n" +
+		"• Request temporary network access for file transfers\n",
+	"help.general.solutions": "Solutions:\n" +
+		"• Check your internet connection stability\n" +
+		"• Verify the transfer code is correct\n" +
+		"• Try restarting your router/modem\n" +
+		"• Disable VPN temporarily if using one\n" +
+		"• Check if antivirus software is blocking connections\n",
+	"help.one_way.receive":        "\n⚠️ One-way network: you can send but not receive on this network.\n",
+	"help.one_way.send":           "\n⚠️ One-way network: you can receive but not send on this network.\n",
+	"help.transports.title":       "\nTransport Method Status:\n",
+	"help.transports.available":   "✅ %d transfer methods available\n",
+	"help.transports.recommended": "📡 Recommended: %s\n",
+	"help.transports.none": "❌ No transfer methods currently available\n" +
+		"This indicates a severe network restriction or connectivity issue.\n",
+	"help.restrictions.title": "\nDetected Network Restrictions:\n",
+	"help.restrictions.more":  "• ... and %d more restrictions\n",
+	"help.additional":         "\nAdditional Help:\n",
+	"help.last_check":         "• Last network check: %s\n",
+	"help.additional.tips": "• For persistent issues, try TrustDrop from a different device or network\n" +
+		"• Consider using your organization's approved file sharing platform\n",
+
+	// Settings
+	"settings.language": "Language",
+}
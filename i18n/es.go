@@ -0,0 +1,144 @@
+package i18n
+
+// spanish translates the error guidance and network help
+var spanish = map[string]string{
+	// Transfer error guidance
+	"error.restricted.title":      "La transferencia falló por restricciones de la red institucional.\n\n",
+	"error.restricted.corporate":  "Su red corporativa tiene políticas de seguridad estrictas que bloquean los protocolos de transferencia de archivos entre pares.\n\n",
+	"error.restricted.university": "Su red universitaria tiene políticas de seguridad académicas que restringen los protocolos de transferencia directa de archivos.\n\n",
+	"error.restricted.managed":    "Su red administrada tiene políticas de TI que bloquean los protocolos de transferencia directa de archivos.\n\n",
+	"error.restricted.detected":   "Restricciones de red detectadas:\n",
+	"error.restricted.solutions": "Soluciones recomendadas:\n" +
+		"• Inténtelo desde otra red (punto de acceso móvil, WiFi de casa)\n" +
+		"• Consulte a su departamento de TI sobre los métodos de transferencia aprobados\n" +
+		"• Use un dispositivo personal con datos móviles si la política lo permite\n" +
+		"• Considere usar la plataforma de intercambio de archivos aprobada por su organización\n" +
+		"• Conéctese temporalmente mediante un punto de acceso móvil si las políticas lo permiten\n",
+	"error.network": "Se detectó un problema de conectividad de red.\n\n" +
+		"Pasos para solucionarlo:\n" +
+		"• Verifique que su conexión a internet sea estable\n" +
+		"• Compruebe si su cortafuegos o antivirus está bloqueando la conexión\n" +
+		"• Vuelva a intentarlo en unos minutos por si se trata de un problema temporal\n" +
+		"• Reinicie su adaptador de red o router si el problema persiste\n",
+	"error.endpoint_security": "Parece que el antivirus o el software de seguridad de este equipo " +
+		"bloqueó la transferencia, por ejemplo poniendo en cuarentena sus archivos temporales.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Añada TrustDrop a la lista de permitidos de su antivirus o protección de equipos\n" +
+		"• Pida a su departamento de TI que permita TrustDrop y su carpeta temporal\n" +
+		"• Revise la cuarentena de su antivirus en busca de archivos bloqueados recientemente\n",
+	"error.one_way.receive": "Puede enviar pero no recibir en esta red.\n\n" +
+		"La red permite a este equipo crear transferencias pero bloquea unirse a una para descargar archivos.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida a la otra persona que reciba de usted, si la transferencia lo permite\n" +
+		"• Reciba desde otra red, como un punto de acceso móvil o la conexión de casa\n" +
+		"• Pida a su departamento de TI que permita descargas a través del relé de transferencia\n",
+	"error.one_way.send": "Puede recibir pero no enviar en esta red.\n\n" +
+		"La red permite a este equipo unirse a transferencias pero bloquea crear una para subir archivos.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida a la otra persona que le envíe a usted, si la transferencia lo permite\n" +
+		"• Envíe desde otra red, como un punto de acceso móvil o la conexión de casa\n" +
+		"• Pida a su departamento de TI que permita subidas a través del relé de transferencia\n",
+	"error.relay_rejected": "Se llegó a un relé, pero rechazó la conexión, lo que suele indicar que la contraseña del relé es incorrecta. Reintentar no servirá hasta corregirla.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Compruebe que la contraseña del relé coincide con la que se usó al iniciar el relé privado\n" +
+		"• Compruebe que la dirección del relé apunta al relé esperado\n" +
+		"• Quite la configuración de relés personalizados para usar los relés públicos\n",
+	"error.integrity": "Los archivos recibidos no coincidían con las sumas de verificación enviadas, por lo que no se guardaron.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida al remitente que vuelva a enviar los archivos\n" +
+		"• Compruebe que nada en este equipo (como un antivirus) modifique las descargas\n",
+	"error.version.newer": "El remitente usa una versión más reciente de TrustDrop (protocolo %d; esta aplicación usa %d), por lo que no se pudo leer la transferencia.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Actualice TrustDrop en este equipo y vuelva a recibir\n",
+	"error.version.older": "El remitente usa una versión anterior de TrustDrop (protocolo %d; esta aplicación usa %d), por lo que no se pudo leer la transferencia.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida al remitente que actualice TrustDrop y vuelva a enviar\n",
+	"error.version.manifest": "El remitente usa una versión más reciente de TrustDrop que describe las carpetas en un formato nuevo (manifiesto versión %d; esta aplicación lee hasta la %d), por lo que no se pudo leer la transferencia.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Actualice TrustDrop en este equipo y vuelva a recibir\n",
+	"error.file_type_blocked": "La transferencia se rechazó porque contiene un tipo de archivo que este equipo está configurado para no aceptar, por lo que no se guardó nada.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida al remitente que excluya el archivo bloqueado y vuelva a enviar\n" +
+		"• Para aceptarlo, cambie la opción file_type_policy en trustdrop.json\n",
+	"error.too_large": "La transferencia se rechazó porque es más grande de lo que este equipo está configurado para aceptar, por lo que no se guardó nada.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida al remitente que divida los archivos en transferencias más pequeñas\n" +
+		"• Para aceptarla, aumente la opción max_receive_size en trustdrop.json\n",
+	"error.quarantined": "Los archivos llegaron pero no superaron la comprobación de cuarentena, por lo que se conservaron en la carpeta de cuarentena en lugar de liberarse.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Lea quarantine-report.txt en la carpeta de cuarentena para ver el motivo\n" +
+		"• Pida al remitente que vuelva a enviar si los archivos estaban incompletos o sin verificar\n",
+	"error.corrupted": "La transferencia llegó dañada a este equipo, por lo que no se guardó nada. La conexión funcionó; los datos cambiaron por el camino.\n\n" +
+		"Pasos recomendados:\n" +
+		"• Pida al remitente que vuelva a enviar los archivos\n" +
+		"• Si sigue ocurriendo, pruebe otra red o desactive cualquier proxy o VPN que inspeccione el tráfico\n",
+	"error.missing_files": "No se recibieron %d de los %d archivos de esta carpeta. Los archivos que sí llegaron se guardaron.\n\n" +
+		"%s\n" +
+		"Pasos recomendados:\n" +
+		"• Pida al remitente que vuelva a enviar los archivos que faltan\n" +
+		"• Envíe los archivos muy grandes por separado en lugar de dentro de una carpeta\n",
+	"error.incomplete": "La transferencia no se completó: %s",
+	"error.failed":     "La transferencia falló: %s",
+	"error.general_steps": "\n\nPasos generales para solucionarlo:\n" +
+		"• Verifique que el código de transferencia sea correcto y no haya caducado\n" +
+		"• Asegúrese de que ambos equipos estén conectados a internet\n" +
+		"• Intente reiniciar la aplicación\n" +
+		"• Compruebe el espacio disponible en el equipo receptor\n",
+	"error.vpn":          "\nHay una VPN activa y podría estar enrutando esta transferencia. Si los problemas continúan, desconéctela o active el túnel dividido.\n",
+	"error.file":         "\nArchivo: %s",
+	"error.network_type": "\nTipo de red: %s",
+	"error.technical":    "\nDetalles técnicos: %v",
+
+	// Network troubleshooting dialog
+	"help.dialog_title":        "Solución de problemas de red",
+	"help.title":               "Guía de solución de problemas de red\n\n",
+	"help.current_network":     "Red actual: %s",
+	"help.restrictive":         " (Restrictiva)",
+	"help.corporate.title":     "🏢 Red corporativa detectada\n\n",
+	"help.corporate.body":      "La red de su empresa tiene políticas de seguridad que pueden bloquear las transferencias de archivos entre pares. TrustDrop usa automáticamente métodos compatibles con entornos empresariales, pero pueden seguir aplicándose algunas restricciones.\n\n",
+	"help.university.title":    "🎓 Red universitaria detectada\n\n",
+	"help.university.body":     "Las redes educativas suelen tener políticas de seguridad estrictas por la seguridad de los estudiantes y el cumplimiento normativo. TrustDrop usa métodos adaptados a entornos educativos, pero pueden seguir aplicándose algunas restricciones.\n\n",
+	"help.institutional.title": "🔒 Red institucional detectada\n\n",
+	"help.institutional.body":  "Parece una red administrada con políticas de seguridad amplias. TrustDrop usa el modo de máxima compatibilidad, pero las transferencias aún podrían estar restringidas.\n\n",
+	"help.restricted.title":    "🔒 Red restringida detectada\n\n",
+	"help.restricted.body":     "Su red tiene restricciones que pueden interferir con las transferencias. TrustDrop ha ajustado automáticamente sus métodos para ser compatible.\n\n",
+	"help.open.title":          "🌐 Red abierta detectada\n\n",
+	"help.open.body":           "Su red parece abierta, pero las transferencias siguen fallando.\n\n",
+	"help.corporate.solutions": "Soluciones:\n" +
+		"• Consulte a su departamento de TI sobre los métodos de transferencia aprobados\n" +
+		"• Inténtelo desde otra red (punto de acceso móvil, WiFi de casa)\n" +
+		"• Use un dispositivo personal con datos móviles si la política de la empresa lo permite\n" +
+		"• Pida a TI que autorice el método de transferencia de TrustDrop basado en HTTPS\n",
+	"help.university.solutions": "Soluciones:\n" +
+		"• Inténtelo desde la biblioteca del campus u otra zona de la red\n" +
+		"• Use su conexión de datos móviles si está disponible\n" +
+		"• Consulte al soporte de TI sobre opciones de transferencia para estudiantes\n" +
+		"• Inténtelo desde una red fuera del campus (cafetería, casa)\n",
+	"help.institutional.solutions": "Soluciones:\n" +
+		"• Consulte a los administradores de red sobre las políticas de transferencia de archivos\n" +
+		"• Inténtelo desde una red no administrada (punto de acceso móvil, WiFi pública)\n" +
+		"• Use alternativas de intercambio de archivos aprobadas por la organización\n" +
+		"• Solicite acceso temporal a la red para transferir archivos\n",
+	"help.general.solutions": "Soluciones:\n" +
+		"• Compruebe la estabilidad de su conexión a internet\n" +
+		"• Verifique que el código de transferencia sea correcto\n" +
+		"• Intente reiniciar su router o módem\n" +
+		"• Desactive temporalmente la VPN si usa una\n" +
+		"• Compruebe si un antivirus está bloqueando las conexiones\n",
+	"help.one_way.receive":        "\n⚠️ Red de un solo sentido: puede enviar pero no recibir en esta red.\n",
+	"help.one_way.send":           "\n⚠️ Red de un solo sentido: puede recibir pero no enviar en esta red.\n",
+	"help.transports.title":       "\nEstado de los métodos de transferencia:\n",
+	"help.transports.available":   "✅ %d métodos de transferencia disponibles\n",
+	"help.transports.recommended": "📡 Recomendado: %s\n",
+	"help.transports.none": "❌ No hay métodos de transferencia disponibles\n" +
+		"Esto indica una restricción de red grave o un problema de conectividad.\n",
+	"help.restrictions.title": "\nRestricciones de red detectadas:\n",
+	"help.restrictions.more":  "• ... y %d restricciones más\n",
+	"help.additional":         "\nAyuda adicional:\n",
+	"help.last_check":         "• Última comprobación de red: %s\n",
+	"help.additional.tips": "• Si el problema persiste, pruebe TrustDrop desde otro equipo u otra red\n" +
+		"• Considere usar la plataforma de intercambio de archivos aprobada por su organización\n",
+
+	// Settings
+	"settings.language": "Idioma",
+}
@@ -0,0 +1,113 @@
+// Package i18n holds the message catalogs for user-facing text and the T
+// helper that looks messages up in the current locale.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used for missing translations and unknown locales
+const DefaultLocale = "en"
+
+// catalogs maps a locale to its messages. English is the reference catalog;
+// other locales may leave keys out and fall back to it.
+var catalogs = map[string]map[string]string{
+	"en": english,
+	"es": spanish,
+}
+
+// localeNames are the names shown when picking a language
+var localeNames = map[string]string{
+	"en": "English",
+	"es": "Español",
+}
+
+var (
+	mutex   sync.RWMutex
+	current = DefaultLocale
+)
+
+// T returns the message for key in the current locale, formatted with args
+// like fmt.Sprintf. Keys missing from the locale fall back to English, and
+// unknown keys are returned as-is so they show up during development.
+func T(key string, args ...interface{}) string {
+	mutex.RLock()
+	locale := current
+	mutex.RUnlock()
+
+	message, ok := catalogs[locale][key]
+	if !ok {
+		if message, ok = catalogs[DefaultLocale][key]; !ok {
+			message = key
+		}
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// SetLocale switches the locale used by T. Regional variants such as "es_MX"
+// use their base language; unsupported locales fall back to English. It
+// returns the locale actually used.
+func SetLocale(locale string) string {
+	locale = normalize(locale)
+	if _, ok := catalogs[locale]; !ok {
+		locale = DefaultLocale
+	}
+
+	mutex.Lock()
+	current = locale
+	mutex.Unlock()
+	return locale
+}
+
+// Locale returns the locale used by T
+func Locale() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// Supported returns the available locales, sorted
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Name returns the display name of a locale
+func Name(locale string) string {
+	if name, ok := localeNames[locale]; ok {
+		return name
+	}
+	return locale
+}
+
+// DetectLocale reads the user's locale from the standard environment
+// variables, returning DefaultLocale if none is set
+func DetectLocale() string {
+	for _, variable := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(variable); value != "" && value != "C" && value != "POSIX" {
+			return normalize(value)
+		}
+	}
+	return DefaultLocale
+}
+
+// normalize reduces a locale such as "es_MX.UTF-8" to its language, "es"
+func normalize(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
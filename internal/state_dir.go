@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateDirEnv overrides where TrustDrop keeps its own state, e.g. for a
+// portable install or separate users sharing one account
+const StateDirEnv = "TRUSTDROP_STATE_DIR"
+
+// Subdirectories of the state directory
+const (
+	StateJournalsDir = "journals" // Resume journals for interrupted transfers
+	StateLogsDir     = "logs"     // Application logs, one file per day
+	StateTracesDir   = "traces"   // Protocol traces, one file per run with tracing on
+)
+
+// StatePendingSendFile records a send waiting for its receiver, so it can be
+// resumed after a restart
+const StatePendingSendFile = "pending-send.json"
+
+// PendingSendMaxAge is how long a pending send is kept
+const PendingSendMaxAge = 24 * time.Hour
+
+// stateDirPerm keeps app state private to the user
+const stateDirPerm = 0700
+
+// staleStateRule removes files matching pattern, relative to the state
+// directory, once they are older than maxAge
+type staleStateRule struct {
+	pattern string
+	maxAge  time.Duration
+}
+
+// staleStateRules lists what CleanStaleState removes. Coordination files only
+// matter while a transfer is starting, journals while it can still be resumed.
+var staleStateRules = []staleStateRule{
+	{"*.coord", 24 * time.Hour},
+	{".trustdrop-write-test-*", time.Hour},
+	{StatePendingSendFile, PendingSendMaxAge},
+	{filepath.Join(StateJournalsDir, "*"), 7 * 24 * time.Hour},
+	{filepath.Join(StateLogsDir, "*.log"), 30 * 24 * time.Hour},
+	{filepath.Join(StateTracesDir, "*.log"), 7 * 24 * time.Hour},
+}
+
+// StateDir returns the directory for app state such as config, coordination
+// files, resume journals and logs. It is $TRUSTDROP_STATE_DIR when set and
+// ~/.trustdrop otherwise. Received files and the audit ledger stay in the data
+// directory.
+func StateDir() string {
+	if dir := os.Getenv(StateDirEnv); dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			return abs
+		}
+		return dir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "trustdrop")
+	}
+	return filepath.Join(homeDir, ".trustdrop")
+}
+
+// EnsureStateDir creates the state directory, or the named subdirectory of
+// it, readable only by the current user, and returns its path
+func EnsureStateDir(subdir ...string) (string, error) {
+	dir := filepath.Join(append([]string{StateDir()}, subdir...)...)
+	if err := os.MkdirAll(dir, stateDirPerm); err != nil {
+		return "", fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// CleanStaleState removes expired coordination files, resume journals and
+// logs from the state directory and returns how many files were removed.
+// Files that cannot be removed are skipped.
+func CleanStaleState() (int, error) {
+	dir := StateDir()
+	now := time.Now()
+	removed := 0
+
+	for _, rule := range staleStateRules {
+		matches, err := filepath.Glob(filepath.Join(dir, rule.pattern))
+		if err != nil {
+			return removed, fmt.Errorf("failed to scan state directory: %w", err)
+		}
+
+		for _, path := range matches {
+			info, err := os.Lstat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if now.Sub(info.ModTime()) < rule.maxAge {
+				continue
+			}
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
@@ -7,8 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/schollz/croc/v10/src/mnemonicode"
 )
 
 // EnsureDataDirectory creates only the essential received directory
@@ -140,19 +144,64 @@ func SanitizePath(path string) string {
 	return filepath.Join(dir, sanitizedFilename)
 }
 
-// GetRandomName generates a random transfer code using simple word combinations
+// GetRandomName generates a random transfer code of three words and a
+// four-digit number, such as "company-star-pyramid-0417". The words encode 32
+// random bits and the number about 13 more, around 45 bits in all.
 func GetRandomName() string {
-	// Simple word lists for transfer codes
-	adjectives := []string{"quick", "bright", "calm", "bold", "swift", "clear", "smart", "safe", "fast", "cool"}
-	nouns := []string{"tiger", "eagle", "wolf", "bear", "lion", "hawk", "fox", "deer", "owl", "cat"}
+	wordBytes := make([]byte, 4)
+	rand.Read(wordBytes)
+	words := mnemonicode.EncodeWordList(nil, wordBytes)
+
+	num, _ := rand.Int(rand.Reader, big.NewInt(10000))
+
+	code := fmt.Sprintf("%s-%04d", strings.Join(words, "-"), num.Int64())
+	MarkGenerated(code)
+	return code
+}
 
-	// Generate random indices
-	adjIdx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(adjectives))))
-	nounIdx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(nouns))))
+// generatedCodes holds the codes known to come from GetRandomName
+var generatedCodes = struct {
+	sync.Mutex
+	codes map[string]bool
+}{codes: make(map[string]bool)}
+
+// MarkGenerated records code as generated by GetRandomName, for a generated
+// code restored from an earlier run
+func MarkGenerated(code string) {
+	generatedCodes.Lock()
+	defer generatedCodes.Unlock()
+	generatedCodes.codes[code] = true
+}
 
-	// Generate random 3-digit number
-	numIdx, _ := rand.Int(rand.Reader, big.NewInt(900))
-	num := numIdx.Int64() + 100 // Ensures 3 digits (100-999)
+// WasGenerated reports whether GetRandomName returned code in this process,
+// or it was marked with MarkGenerated.
+// Unlike IsGeneratedCode it cannot be fooled by a typed code of the same form.
+func WasGenerated(code string) bool {
+	generatedCodes.Lock()
+	defer generatedCodes.Unlock()
+	return generatedCodes.codes[code]
+}
 
-	return fmt.Sprintf("%s-%s-%d", adjectives[adjIdx.Int64()], nouns[nounIdx.Int64()], num)
+// IsGeneratedCode reports whether code has the form GetRandomName produces:
+// three words from the code word list and a four-digit number
+func IsGeneratedCode(code string) bool {
+	parts := strings.Split(code, "-")
+	if len(parts) != 4 {
+		return false
+	}
+	for _, word := range parts[:3] {
+		if !slices.Contains(mnemonicode.WordList, word) {
+			return false
+		}
+	}
+	number := parts[3]
+	if len(number) != 4 {
+		return false
+	}
+	for _, digit := range number {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the minimum severity written by the leveled logging functions
+type Level int32
+
+// Log levels, from most to least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelOff
+)
+
+// logLevelEnv overrides the default level, e.g. TRUSTDROP_LOG_LEVEL=debug
+const logLevelEnv = "TRUSTDROP_LOG_LEVEL"
+
+var (
+	currentLevel atomic.Int32
+	output       = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+func init() {
+	currentLevel.Store(int32(LevelInfo))
+	if value := os.Getenv(logLevelEnv); value != "" {
+		if level, err := ParseLevel(value); err == nil {
+			SetLevel(level)
+		}
+	}
+}
+
+// SetLevel sets the minimum level that is written
+func SetLevel(level Level) {
+	currentLevel.Store(int32(level))
+}
+
+// GetLevel returns the minimum level that is written
+func GetLevel() Level {
+	return Level(currentLevel.Load())
+}
+
+// SetOutput sends log lines to w instead of standard error
+func SetOutput(w io.Writer) {
+	output.SetOutput(w)
+}
+
+// ParseLevel converts "debug", "info", "warn", "error" or "off" into a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "off", "none":
+		return LevelOff, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// Debugf logs diagnostic detail, hidden unless the level is LevelDebug
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, "[DEBUG] ", format, args...)
+}
+
+// Infof logs a notable event
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, "[INFO] ", format, args...)
+}
+
+// Warnf logs a recoverable problem
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, "[WARNING] ", format, args...)
+}
+
+// Errorf logs a failure
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, "[ERROR] ", format, args...)
+}
+
+// logf writes a message if level is enabled
+func logf(level Level, prefix, format string, args ...interface{}) {
+	if level < GetLevel() {
+		return
+	}
+	output.Print(prefix + strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
@@ -53,19 +52,30 @@ func NewLogger(dataDir string, filename ...string) (*Logger, error) {
 	}, nil
 }
 
+// NewReadOnlyLogger opens the ledger in dataDir for reading only, for tools
+// that inspect it while the app may be running. It fails if there is no
+// ledger, and LogTransfer on it fails with blockchain.ErrLedgerReadOnly.
+func NewReadOnlyLogger(dataDir string) (*Logger, error) {
+	bc, err := blockchain.OpenReadOnly(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{blockchain: bc}, nil
+}
+
 // LogInfo logs an info message (compatibility for bulletproof manager)
 func (l *Logger) LogInfo(message string) {
-	log.Printf("[INFO] %s", message)
+	Infof("%s", message)
 }
 
 // LogError logs an error message (compatibility for bulletproof manager)
 func (l *Logger) LogError(message string) {
-	log.Printf("[ERROR] %s", message)
+	Errorf("%s", message)
 }
 
 // LogWarning logs a warning message (compatibility for bulletproof manager)
 func (l *Logger) LogWarning(message string) {
-	log.Printf("[WARNING] %s", message)
+	Warnf("%s", message)
 }
 
 // Close closes the logger (compatibility for bulletproof manager)
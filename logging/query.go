@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+
+	"trustdrop-bulletproof/blockchain"
+)
+
+// Query returns the ledger entries matching filter, oldest first. Use
+// VerifyLedger to check the chain the entries came from.
+func (l *Logger) Query(filter blockchain.Filter) []blockchain.TransferEntry {
+	blocks := l.blockchain.Query(filter)
+	entries := make([]blockchain.TransferEntry, 0, len(blocks))
+	for _, block := range blocks {
+		entries = append(entries, blockchain.EntryFromBlock(block))
+	}
+	return entries
+}
+
+// FindByTransferCode returns every ledger entry recorded for a transfer code,
+// oldest first
+func (l *Logger) FindByTransferCode(code string) ([]blockchain.TransferEntry, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("transfer code is required")
+	}
+	return l.Query(blockchain.Filter{TransferCode: code}), nil
+}
+
+// Stats aggregates the ledger entries matching filter: counts, sizes, success
+// rate, a per-direction breakdown and the busiest peer
+func (l *Logger) Stats(filter blockchain.Filter) blockchain.Stats {
+	return blockchain.ComputeStats(l.Query(filter))
+}
+
+// LedgerRoot returns the ledger's current Merkle root and the number of
+// entries it covers. Publishing it lets third parties check proofs from ProveEntry.
+func (l *Logger) LedgerRoot() (string, int, error) {
+	return l.blockchain.MerkleRoot()
+}
+
+// ProveEntry builds a compact proof that the ledger entry at index is in the
+// ledger, checkable with blockchain.VerifyProof without the rest of the chain
+func (l *Logger) ProveEntry(index int64) (blockchain.Proof, error) {
+	return l.blockchain.ProveBlock(index)
+}
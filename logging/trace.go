@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceEnv turns on the protocol trace when set to a true value, e.g.
+// TRUSTDROP_TRACE=1
+const TraceEnv = "TRUSTDROP_TRACE"
+
+// tracer writes the protocol trace, a timeline of transfer phases kept apart
+// from the leveled log so a stall shows up as a gap between two lines
+var tracer struct {
+	mutex sync.Mutex
+	file  *os.File
+	start time.Time
+	last  time.Time
+}
+
+// TraceRequested reports whether TraceEnv asks for a protocol trace
+func TraceRequested() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(TraceEnv))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// StartTrace begins writing the protocol trace to path, replacing any trace
+// already running
+func StartTrace(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %w", err)
+	}
+
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	if tracer.file != nil {
+		tracer.file.Close()
+	}
+	now := time.Now()
+	tracer.file, tracer.start, tracer.last = file, now, now
+	fmt.Fprintf(file, "# TrustDrop protocol trace started %s\n", now.Format(time.RFC3339Nano))
+	return nil
+}
+
+// StopTrace stops the protocol trace and closes its file
+func StopTrace() error {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	if tracer.file == nil {
+		return nil
+	}
+	err := tracer.file.Close()
+	tracer.file = nil
+	return err
+}
+
+// TracePath returns the file the protocol trace is written to, or "" when
+// tracing is off
+func TracePath() string {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	if tracer.file == nil {
+		return ""
+	}
+	return tracer.file.Name()
+}
+
+// Tracing reports whether the protocol trace is on, so callers can skip
+// building costly trace messages
+func Tracing() bool {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	return tracer.file != nil
+}
+
+// Tracef adds a line to the protocol trace for phase, such as "relay" or
+// "handshake". Each line carries the time since the trace started and since
+// the previous line. It does nothing while tracing is off.
+func Tracef(phase, format string, args ...interface{}) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	if tracer.file == nil {
+		return
+	}
+	now := time.Now()
+	fmt.Fprintf(tracer.file, "%s +%s (+%s) [%s] %s\n",
+		now.Format("15:04:05.000000"),
+		now.Sub(tracer.start).Round(time.Microsecond),
+		now.Sub(tracer.last).Round(time.Microsecond),
+		phase, strings.TrimSpace(fmt.Sprintf(format, args...)))
+	tracer.last = now
+}
+
+// TraceSpan traces the start of a step in phase and returns a function that
+// traces its end with how long it took and, when err is not nil, why it failed
+func TraceSpan(phase, format string, args ...interface{}) func(err error) {
+	if !Tracing() {
+		return func(error) {}
+	}
+	step := strings.TrimSpace(fmt.Sprintf(format, args...))
+	Tracef(phase, "%s: start", step)
+	started := time.Now()
+	return func(err error) {
+		took := time.Since(started).Round(time.Microsecond)
+		if err != nil {
+			Tracef(phase, "%s: failed after %s: %v", step, took, err)
+			return
+		}
+		Tracef(phase, "%s: done in %s", step, took)
+	}
+}
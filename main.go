@@ -1,18 +1,34 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"trustdrop-bulletproof/gui"
 	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
 	"trustdrop-bulletproof/transfer"
 )
 
 func main() {
+	transports := flag.String("transport", "", "use only these transports, comma separated, instead of automatic failover (e.g. simple-croc)")
+	flag.Parse()
+	if flag.Arg(0) == "selftest" {
+		os.Exit(runSelfTest())
+	}
+
 	fmt.Println("🌍 TrustDrop Bulletproof Edition - International Lab Transfer System")
 
+	// App state (config, coordination files, journals, logs) lives apart
+	// from received files
+	stateDir := prepareStateDir()
+
 	// Create TrustDrop Downloads folder with international naming
 	var targetDataDir string
 	var err error
@@ -57,15 +73,33 @@ func main() {
 	}
 	defer transferManager.Close()
 
+	for _, problem := range transferManager.RelayConfigProblems() {
+		fmt.Printf("Warning: invalid relay config: %v\n", problem)
+	}
+
+	// Optional operator settings, such as retry tuning
+	config, err := transfer.LoadConfig(configPath(stateDir, targetDataDir))
+	if err == nil {
+		err = transferManager.ApplyConfig(config)
+	}
+	if err != nil {
+		fmt.Printf("Warning: ignoring config: %v\n", err)
+	}
+
+	// Attach this device's fingerprint to sent transfers so receivers can
+	// recognize returning senders
+	if err := transferManager.SetSenderIdentity(true, ""); err != nil {
+		fmt.Printf("Warning: sender identity unavailable: %v\n", err)
+	}
+
 	// Set international-optimized callbacks
 	transferManager.SetStatusCallback(func(status string) {
 		fmt.Printf("🌍 International Status: %s\n", status)
 	})
 
-	transferManager.SetProgressCallback(func(current, total int64, fileName string) {
-		if total > 0 {
-			progress := float64(current) / float64(total) * 100
-			fmt.Printf("🌍 International Progress: %.1f%% - %s\n", progress, fileName)
+	transferManager.SetProgressCallback(func(progress transfer.TransferProgress) {
+		if progress.OverallSize > 0 {
+			fmt.Printf("🌍 International Progress: %.1f%% - %s\n", progress.OverallFraction()*100, progress.FileName)
 		}
 	})
 
@@ -80,6 +114,16 @@ func main() {
 	}
 	fmt.Printf("✅ International GUI ready\n")
 
+	if *transports != "" {
+		names := strings.Split(*transports, ",")
+		for i := range names {
+			names[i] = strings.TrimSpace(names[i])
+		}
+		app.SetTransportOverride(names)
+		fmt.Printf("🔧 Transfers limited to transports: %s (available: %s)\n",
+			strings.Join(names, ", "), strings.Join(transferManager.TransportNames(), ", "))
+	}
+
 	// Show international network status
 	status := transferManager.GetNetworkStatus()
 	fmt.Printf("🌍 International Network Status:\n")
@@ -94,3 +138,66 @@ func main() {
 	// Run the application
 	app.Run()
 }
+
+// prepareStateDir creates the state directory, removes expired state and
+// starts writing the day's log file there. It returns the directory, or ""
+// when it could not be created.
+func prepareStateDir() string {
+	stateDir, err := internal.EnsureStateDir()
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return ""
+	}
+
+	if removed, err := internal.CleanStaleState(); err != nil {
+		fmt.Printf("Warning: could not clean state directory: %v\n", err)
+	} else if removed > 0 {
+		fmt.Printf("🧹 Removed %d expired files from %s\n", removed, stateDir)
+	}
+
+	logsDir, err := internal.EnsureStateDir(internal.StateLogsDir)
+	if err == nil {
+		logPath := filepath.Join(logsDir, fmt.Sprintf("trustdrop-%s.log", time.Now().Format("2006-01-02")))
+		var logFile *os.File
+		logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err == nil {
+			logging.SetOutput(io.MultiWriter(os.Stderr, logFile))
+		}
+	}
+	if err != nil {
+		fmt.Printf("Warning: logging to standard error only: %v\n", err)
+	}
+	return stateDir
+}
+
+// configPath returns the config file in the state directory, or the one in
+// the data directory where older installs kept it
+func configPath(stateDir, dataDir string) string {
+	if stateDir != "" {
+		path := filepath.Join(stateDir, transfer.ConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dataDir, transfer.ConfigFileName)
+}
+
+// runSelfTest runs "trustdrop selftest", a loopback transfer through the full
+// send and receive path, and returns the process exit code
+func runSelfTest() int {
+	result := transfer.SelfTest(context.Background())
+	if !result.Passed {
+		fmt.Printf("selftest: FAIL after %v: %v\n", result.Duration.Round(time.Millisecond), result.Error)
+		return 1
+	}
+
+	fmt.Printf("selftest: PASS (%d files, %d bytes; send %v, receive %v, total %v)\n",
+		result.Files, result.Bytes,
+		result.SendDuration.Round(time.Millisecond),
+		result.ReceiveDuration.Round(time.Millisecond),
+		result.Duration.Round(time.Millisecond))
+	for _, measured := range result.EncryptionThroughput {
+		fmt.Printf("selftest: %s encrypts at %s/s\n", measured.Mode, transfer.FormatBytes(int64(measured.BytesPerSecond())))
+	}
+	return 0
+}
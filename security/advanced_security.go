@@ -2,6 +2,7 @@ package security
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -9,12 +10,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/hkdf"
 	"golang.org/x/crypto/pbkdf2"
 )
 
+// ErrAADUnsupported is returned when associated data is passed to a mode that
+// cannot authenticate it
+var ErrAADUnsupported = errors.New("AES-256-CBC cannot authenticate associated data")
+
 // EncryptionMode represents different encryption algorithms
 type EncryptionMode int
 
@@ -43,6 +50,11 @@ func (m EncryptionMode) String() string {
 // AdvancedSecurity provides institutional-grade encryption with multiple modes
 type AdvancedSecurity struct {
 	preferredMode EncryptionMode
+
+	// Encryption rates measured on this device, by mode
+	mutex         sync.Mutex
+	throughput    map[EncryptionMode]ModeThroughput
+	calibrateOnce sync.Once
 }
 
 func NewAdvancedSecurity() *AdvancedSecurity {
@@ -51,8 +63,15 @@ func NewAdvancedSecurity() *AdvancedSecurity {
 	}
 }
 
-// EncryptWithBestMode automatically selects the best encryption mode based on data characteristics
-func (as *AdvancedSecurity) EncryptWithBestMode(data, key []byte) ([]byte, EncryptionMode, error) {
+// EncryptWithBestMode automatically selects the best encryption mode based on data characteristics.
+// aad, which may be nil, is authenticated along with data but not encrypted.
+func (as *AdvancedSecurity) EncryptWithBestMode(data, key, aad []byte) ([]byte, EncryptionMode, error) {
+	return as.EncryptWithBestModeContext(context.Background(), data, key, aad)
+}
+
+// EncryptWithBestModeContext is EncryptWithBestMode, stopping between steps
+// once ctx ends and returning its error
+func (as *AdvancedSecurity) EncryptWithBestModeContext(ctx context.Context, data, key, aad []byte) ([]byte, EncryptionMode, error) {
 	// Analyze data characteristics to choose optimal mode
 	dataSize := int64(len(data))
 
@@ -64,14 +83,18 @@ func (as *AdvancedSecurity) EncryptWithBestMode(data, key []byte) ([]byte, Encry
 	} else {
 		mode = ModeGCM // Default for small files
 	}
+	mode = as.fasterMode(mode, dataSize)
 
 	// Strengthen the key before encryption
 	strengthenedKey, _, err := as.StrengthenTransferCode(string(key), "encryption")
 	if err != nil {
 		return nil, mode, fmt.Errorf("key strengthening failed: %w", err)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, mode, err
+	}
 
-	encrypted, err := as.EncryptWithMode(data, strengthenedKey, mode)
+	encrypted, err := as.EncryptWithMode(data, strengthenedKey, mode, aad)
 	if err != nil {
 		return nil, mode, err
 	}
@@ -79,33 +102,85 @@ func (as *AdvancedSecurity) EncryptWithBestMode(data, key []byte) ([]byte, Encry
 	return encrypted, mode, nil
 }
 
-// EncryptWithMode encrypts data using the specified mode
-func (as *AdvancedSecurity) EncryptWithMode(data []byte, key []byte, mode EncryptionMode) ([]byte, error) {
+// DecryptWithBestMode reverses EncryptWithBestMode: it applies the same key
+// strengthening and tries each mode, returning the one that authenticated.
+// aad must match what the data was encrypted with; CBC, which cannot
+// authenticate it, is not tried when aad is set.
+func (as *AdvancedSecurity) DecryptWithBestMode(data, key, aad []byte) ([]byte, EncryptionMode, error) {
+	return as.DecryptWithBestModeContext(context.Background(), data, key, aad)
+}
+
+// DecryptWithBestModeContext is DecryptWithBestMode, stopping between the
+// modes it tries once ctx ends and returning its error
+func (as *AdvancedSecurity) DecryptWithBestModeContext(ctx context.Context, data, key, aad []byte) ([]byte, EncryptionMode, error) {
+	strengthenedKey, _, err := as.StrengthenTransferCode(string(key), "encryption")
+	if err != nil {
+		return nil, ModeGCM, fmt.Errorf("key strengthening failed: %w", err)
+	}
+
+	var lastErr error
+	for _, mode := range []EncryptionMode{ModeGCM, ModeChaCha20, ModeCBC, ModeHybrid} {
+		if mode == ModeCBC && aad != nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, ModeGCM, err
+		}
+		plaintext, err := as.DecryptWithMode(data, strengthenedKey, mode, aad)
+		if err == nil {
+			return plaintext, mode, nil
+		}
+		lastErr = err
+	}
+	return nil, ModeGCM, lastErr
+}
+
+// EncryptWithMode encrypts data using the specified mode, authenticating aad
+// with it when set. Large runs are timed, for Throughput and adaptive mode
+// selection.
+func (as *AdvancedSecurity) EncryptWithMode(data []byte, key []byte, mode EncryptionMode, aad []byte) ([]byte, error) {
+	start := time.Now()
+	encrypted, err := as.encryptWithMode(data, key, mode, aad)
+	if err == nil {
+		as.recordThroughput(mode, len(data), time.Since(start))
+	}
+	return encrypted, err
+}
+
+// encryptWithMode does the work of EncryptWithMode
+func (as *AdvancedSecurity) encryptWithMode(data []byte, key []byte, mode EncryptionMode, aad []byte) ([]byte, error) {
 	switch mode {
 	case ModeGCM:
-		return as.encryptAESGCM(data, key)
+		return as.encryptAESGCM(data, key, aad)
 	case ModeChaCha20:
-		return as.encryptChaCha20Poly1305(data, key)
+		return as.encryptChaCha20Poly1305(data, key, aad)
 	case ModeCBC:
+		if aad != nil {
+			return nil, ErrAADUnsupported
+		}
 		return as.encryptAESCBC(data, key)
 	case ModeHybrid:
-		return as.encryptHybridMode(data, key)
+		return as.encryptHybridMode(data, key, aad)
 	default:
 		return nil, fmt.Errorf("unsupported encryption mode: %d", mode)
 	}
 }
 
-// DecryptWithMode decrypts data using the specified mode
-func (as *AdvancedSecurity) DecryptWithMode(data, key []byte, mode EncryptionMode) ([]byte, error) {
+// DecryptWithMode decrypts data using the specified mode, failing unless aad
+// matches what the data was encrypted with
+func (as *AdvancedSecurity) DecryptWithMode(data, key []byte, mode EncryptionMode, aad []byte) ([]byte, error) {
 	switch mode {
 	case ModeCBC:
+		if aad != nil {
+			return nil, ErrAADUnsupported
+		}
 		return as.decryptAESCBC(data, key)
 	case ModeGCM:
-		return as.decryptAESGCM(data, key)
+		return as.decryptAESGCM(data, key, aad)
 	case ModeChaCha20:
-		return as.decryptChaCha20Poly1305(data, key)
+		return as.decryptChaCha20Poly1305(data, key, aad)
 	case ModeHybrid:
-		return as.decryptHybridMode(data, key)
+		return as.decryptHybridMode(data, key, aad)
 	default:
 		return nil, fmt.Errorf("unknown encryption mode: %v", mode)
 	}
@@ -147,7 +222,7 @@ func (as *AdvancedSecurity) StrengthenTransferCodeWithSalt(transferCode string,
 }
 
 // encryptAESGCM encrypts data using AES-256-GCM (authenticated encryption)
-func (as *AdvancedSecurity) encryptAESGCM(data []byte, key []byte) ([]byte, error) {
+func (as *AdvancedSecurity) encryptAESGCM(data []byte, key []byte, aad []byte) ([]byte, error) {
 	// Derive a proper 32-byte key from input
 	derivedKey := as.deriveKey(key, 32)
 
@@ -170,7 +245,7 @@ func (as *AdvancedSecurity) encryptAESGCM(data []byte, key []byte) ([]byte, erro
 	}
 
 	// Encrypt and authenticate
-	ciphertext := gcm.Seal(nil, nonce, data, nil)
+	ciphertext := gcm.Seal(nil, nonce, data, aad)
 
 	// Prepend nonce to ciphertext
 	result := make([]byte, len(nonce)+len(ciphertext))
@@ -181,7 +256,7 @@ func (as *AdvancedSecurity) encryptAESGCM(data []byte, key []byte) ([]byte, erro
 }
 
 // decryptAESGCM decrypts data using AES-256-GCM
-func (as *AdvancedSecurity) decryptAESGCM(data []byte, key []byte) ([]byte, error) {
+func (as *AdvancedSecurity) decryptAESGCM(data []byte, key []byte, aad []byte) ([]byte, error) {
 	// Derive a proper 32-byte key from input
 	derivedKey := as.deriveKey(key, 32)
 
@@ -208,7 +283,7 @@ func (as *AdvancedSecurity) decryptAESGCM(data []byte, key []byte) ([]byte, erro
 	ciphertext := data[nonceSize:]
 
 	// Decrypt and verify
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
 	if err != nil {
 		return nil, fmt.Errorf("decryption failed: %w", err)
 	}
@@ -217,7 +292,7 @@ func (as *AdvancedSecurity) decryptAESGCM(data []byte, key []byte) ([]byte, erro
 }
 
 // encryptChaCha20Poly1305 encrypts data using ChaCha20-Poly1305 (authenticated encryption)
-func (as *AdvancedSecurity) encryptChaCha20Poly1305(data []byte, key []byte) ([]byte, error) {
+func (as *AdvancedSecurity) encryptChaCha20Poly1305(data []byte, key []byte, aad []byte) ([]byte, error) {
 	// Derive a proper 32-byte key from input
 	derivedKey := as.deriveKey(key, 32)
 
@@ -234,7 +309,7 @@ func (as *AdvancedSecurity) encryptChaCha20Poly1305(data []byte, key []byte) ([]
 	}
 
 	// Encrypt and authenticate
-	ciphertext := aead.Seal(nil, nonce, data, nil)
+	ciphertext := aead.Seal(nil, nonce, data, aad)
 
 	// Prepend nonce to ciphertext
 	result := make([]byte, len(nonce)+len(ciphertext))
@@ -245,7 +320,7 @@ func (as *AdvancedSecurity) encryptChaCha20Poly1305(data []byte, key []byte) ([]
 }
 
 // decryptChaCha20Poly1305 decrypts data using ChaCha20-Poly1305
-func (as *AdvancedSecurity) decryptChaCha20Poly1305(data []byte, key []byte) ([]byte, error) {
+func (as *AdvancedSecurity) decryptChaCha20Poly1305(data []byte, key []byte, aad []byte) ([]byte, error) {
 	// Derive a proper 32-byte key from input
 	derivedKey := as.deriveKey(key, 32)
 
@@ -266,7 +341,7 @@ func (as *AdvancedSecurity) decryptChaCha20Poly1305(data []byte, key []byte) ([]
 	ciphertext := data[nonceSize:]
 
 	// Decrypt and verify
-	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
 	if err != nil {
 		return nil, fmt.Errorf("decryption failed: %w", err)
 	}
@@ -275,19 +350,19 @@ func (as *AdvancedSecurity) decryptChaCha20Poly1305(data []byte, key []byte) ([]
 }
 
 // encryptHybridMode encrypts using hybrid approach with enhanced key derivation
-func (as *AdvancedSecurity) encryptHybridMode(data []byte, key []byte) ([]byte, error) {
+func (as *AdvancedSecurity) encryptHybridMode(data []byte, key []byte, aad []byte) ([]byte, error) {
 	// Use multiple rounds of key derivation for enhanced security
 	derivedKey1 := as.deriveKey(key, 32)
 	derivedKey2 := as.deriveKey(derivedKey1, 32)
 
 	// First encryption layer with ChaCha20
-	firstLayer, err := as.encryptChaCha20Poly1305(data, derivedKey1)
+	firstLayer, err := as.encryptChaCha20Poly1305(data, derivedKey1, aad)
 	if err != nil {
 		return nil, fmt.Errorf("first encryption layer failed: %w", err)
 	}
 
 	// Second encryption layer with AES-GCM
-	secondLayer, err := as.encryptAESGCM(firstLayer, derivedKey2)
+	secondLayer, err := as.encryptAESGCM(firstLayer, derivedKey2, aad)
 	if err != nil {
 		return nil, fmt.Errorf("second encryption layer failed: %w", err)
 	}
@@ -296,19 +371,19 @@ func (as *AdvancedSecurity) encryptHybridMode(data []byte, key []byte) ([]byte,
 }
 
 // decryptHybridMode decrypts using hybrid approach
-func (as *AdvancedSecurity) decryptHybridMode(data []byte, key []byte) ([]byte, error) {
+func (as *AdvancedSecurity) decryptHybridMode(data []byte, key []byte, aad []byte) ([]byte, error) {
 	// Use same key derivation as encryption
 	derivedKey1 := as.deriveKey(key, 32)
 	derivedKey2 := as.deriveKey(derivedKey1, 32)
 
 	// First decryption layer (AES-GCM)
-	firstLayer, err := as.decryptAESGCM(data, derivedKey2)
+	firstLayer, err := as.decryptAESGCM(data, derivedKey2, aad)
 	if err != nil {
 		return nil, fmt.Errorf("first decryption layer failed: %w", err)
 	}
 
 	// Second decryption layer (ChaCha20)
-	plaintext, err := as.decryptChaCha20Poly1305(firstLayer, derivedKey1)
+	plaintext, err := as.decryptChaCha20Poly1305(firstLayer, derivedKey1, aad)
 	if err != nil {
 		return nil, fmt.Errorf("second decryption layer failed: %w", err)
 	}
@@ -431,8 +506,19 @@ func (as *AdvancedSecurity) GenerateSecureKey(size int) ([]byte, error) {
 	return key, nil
 }
 
-// GetRecommendedMode returns the recommended encryption mode based on threat level and data characteristics
+// GetRecommendedMode returns the recommended encryption mode based on threat level and data characteristics.
+// Outside institutional networks, large payloads get whichever of GCM and ChaCha20 runs faster on this device.
 func (as *AdvancedSecurity) GetRecommendedMode(dataSize int64, networkType string) EncryptionMode {
+	switch networkType {
+	case "corporate", "university", "institutional":
+		return recommendedModeForNetwork(dataSize, networkType)
+	default:
+		return as.fasterMode(recommendedModeForNetwork(dataSize, networkType), dataSize)
+	}
+}
+
+// recommendedModeForNetwork is GetRecommendedMode by size and network alone
+func recommendedModeForNetwork(dataSize int64, networkType string) EncryptionMode {
 	// Recommendations based on institutional network requirements
 	switch networkType {
 	case "corporate", "university", "institutional":
@@ -0,0 +1,109 @@
+package security
+
+import (
+	"crypto/rand"
+	"sort"
+	"time"
+)
+
+// Encryption runs smaller than minMeasuredBytes are not measured, since
+// setup costs dominate their timings
+const minMeasuredBytes = 1024 * 1024
+
+// calibrationBytes is the size of the sample each mode encrypts in Calibrate
+const calibrationBytes = 4 * 1024 * 1024
+
+// Payloads of at least adaptiveModeMinSize switch to the faster of GCM and
+// ChaCha20 on this device when it is more than fasterModeRatio times as fast
+// as the mode chosen by size. Below that the difference is milliseconds.
+const (
+	adaptiveModeMinSize = 10 * 1024 * 1024
+	fasterModeRatio     = 1.5
+)
+
+// ModeThroughput is how fast one encryption mode has run on this device
+type ModeThroughput struct {
+	Mode     EncryptionMode
+	Bytes    int64         // Plaintext bytes encrypted in measured runs
+	Duration time.Duration // Time those runs took
+}
+
+// BytesPerSecond returns the measured encryption rate
+func (t ModeThroughput) BytesPerSecond() float64 {
+	if t.Duration <= 0 {
+		return 0
+	}
+	return float64(t.Bytes) / t.Duration.Seconds()
+}
+
+// recordThroughput adds one encryption run to the mode's measurements
+func (as *AdvancedSecurity) recordThroughput(mode EncryptionMode, bytes int, duration time.Duration) {
+	if bytes < minMeasuredBytes || duration <= 0 {
+		return
+	}
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	if as.throughput == nil {
+		as.throughput = make(map[EncryptionMode]ModeThroughput)
+	}
+	measured := as.throughput[mode]
+	measured.Mode = mode
+	measured.Bytes += int64(bytes)
+	measured.Duration += duration
+	as.throughput[mode] = measured
+}
+
+// Throughput returns the encryption rate measured for each mode that has
+// encrypted at least minMeasuredBytes in one run, in mode order
+func (as *AdvancedSecurity) Throughput() []ModeThroughput {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	measured := make([]ModeThroughput, 0, len(as.throughput))
+	for _, t := range as.throughput {
+		measured = append(measured, t)
+	}
+	sort.Slice(measured, func(i, j int) bool { return measured[i].Mode < measured[j].Mode })
+	return measured
+}
+
+// Calibrate measures every encryption mode on a sample so mode selection
+// reflects this device rather than payload sizes alone. It runs at most once,
+// and automatically before the first large payload is encrypted.
+func (as *AdvancedSecurity) Calibrate() {
+	as.calibrateOnce.Do(func() {
+		sample := make([]byte, calibrationBytes)
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return
+		}
+		for _, mode := range []EncryptionMode{ModeGCM, ModeChaCha20, ModeCBC, ModeHybrid} {
+			as.EncryptWithMode(sample, key, mode, nil)
+		}
+	})
+}
+
+// fasterMode returns the authenticated mode to use for dataSize bytes in
+// place of mode: the other of GCM and ChaCha20 when it has run clearly faster
+// on this device, and mode otherwise. Whether AES is faster than ChaCha20
+// depends on the CPU having AES instructions.
+func (as *AdvancedSecurity) fasterMode(mode EncryptionMode, dataSize int64) EncryptionMode {
+	if dataSize < adaptiveModeMinSize || (mode != ModeGCM && mode != ModeChaCha20) {
+		return mode
+	}
+	as.Calibrate()
+
+	other := ModeGCM
+	if mode == ModeGCM {
+		other = ModeChaCha20
+	}
+
+	as.mutex.Lock()
+	current, alternative := as.throughput[mode].BytesPerSecond(), as.throughput[other].BytesPerSecond()
+	as.mutex.Unlock()
+	if current > 0 && alternative > current*fasterModeRatio {
+		return other
+	}
+	return mode
+}
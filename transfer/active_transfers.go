@@ -0,0 +1,150 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrTransferNotFound is returned by CancelTransfer when no transfer in
+// flight has the given ID
+var ErrTransferNotFound = errors.New("no active transfer with that ID")
+
+// TransferStatus describes a transfer in flight
+type TransferStatus struct {
+	ID        string
+	Code      string
+	Direction string // "send" or "receive"
+	StartedAt time.Time
+	Progress  TransferProgress // Latest progress update, zero until the first one
+	Status    string           // Latest status message
+	Transport string           // Transport expected to carry the transfer
+}
+
+// activeTransfer is the manager's record of one transfer in flight
+type activeTransfer struct {
+	status TransferStatus
+	cancel context.CancelFunc
+}
+
+// beginTransfer registers a transfer in flight and returns its context, which
+// ends when the transfer is cancelled or the manager is closed, and its ID.
+// The manager runs one transfer at a time, so it fails while another is active.
+func (btm *BulletproofTransferManager) beginTransfer(ctx context.Context, transferCode, direction string) (context.Context, string, error) {
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+
+	if btm.closing {
+		return nil, "", errShuttingDown
+	}
+	if len(btm.transfers) > 0 {
+		return nil, "", fmt.Errorf("transfer already in progress")
+	}
+
+	transferCtx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(btm.cancelContext, cancel)
+
+	btm.transferSeq++
+	id := fmt.Sprintf("%s-%d", direction, btm.transferSeq)
+	if btm.transfers == nil {
+		btm.transfers = make(map[string]*activeTransfer)
+	}
+	btm.transfers[id] = &activeTransfer{
+		status: TransferStatus{
+			ID:        id,
+			Code:      transferCode,
+			Direction: direction,
+			StartedAt: time.Now(),
+		},
+		cancel: func() {
+			stop()
+			cancel()
+		},
+	}
+	btm.currentTransferID = id
+	btm.running.Add(1)
+	return transferCtx, id, nil
+}
+
+// endTransfer releases the transfer started by beginTransfer
+func (btm *BulletproofTransferManager) endTransfer(id string) {
+	// The final status must reach the callback and Status channels before
+	// they close; it is delivered outside the lock so the callback may call
+	// back into the manager
+	btm.statusThrottle.flush(btm.deliverStatus)
+
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+
+	if transfer, ok := btm.transfers[id]; ok {
+		transfer.cancel()
+		delete(btm.transfers, id)
+		btm.running.Done()
+	}
+	if btm.currentTransferID == id {
+		btm.currentTransferID = ""
+	}
+
+	// Progress and Status channels last for one transfer
+	btm.subscribers.closeAll()
+}
+
+// ActiveTransfers lists the transfers in flight, oldest first
+func (btm *BulletproofTransferManager) ActiveTransfers() []TransferStatus {
+	btm.mutex.Lock()
+	statuses := make([]TransferStatus, 0, len(btm.transfers))
+	for _, transfer := range btm.transfers {
+		statuses = append(statuses, transfer.status)
+	}
+	btm.mutex.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].StartedAt.Before(statuses[j].StartedAt)
+	})
+
+	// Asking the transport manager can take its own locks, so do it unlocked
+	if len(statuses) > 0 {
+		transport := btm.getUsedTransportName()
+		for i := range statuses {
+			statuses[i].Transport = transport
+		}
+	}
+	return statuses
+}
+
+// CancelTransfer cancels the transfer in flight with the given ID. It returns
+// ErrTransferNotFound if the transfer has already finished.
+func (btm *BulletproofTransferManager) CancelTransfer(id string) error {
+	btm.mutex.Lock()
+	transfer, ok := btm.transfers[id]
+	btm.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+	}
+	transfer.cancel()
+	btm.updateStatus("Transfer cancelled by user")
+	return nil
+}
+
+// recordTransferProgress keeps the latest progress of the current transfer
+// for ActiveTransfers
+func (btm *BulletproofTransferManager) recordTransferProgress(progress TransferProgress) {
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+	if transfer, ok := btm.transfers[btm.currentTransferID]; ok {
+		transfer.status.Progress = progress
+	}
+}
+
+// recordTransferStatus keeps the latest status message of the current
+// transfer for ActiveTransfers
+func (btm *BulletproofTransferManager) recordTransferStatus(status string) {
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+	if transfer, ok := btm.transfers[btm.currentTransferID]; ok {
+		transfer.status.Status = status
+	}
+}
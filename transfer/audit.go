@@ -0,0 +1,53 @@
+package transfer
+
+import (
+	"fmt"
+
+	"trustdrop-bulletproof/blockchain"
+	"trustdrop-bulletproof/logging"
+)
+
+// initAudit opens the audit ledger the first time it is needed. If that
+// fails, transfers carry on without an audit trail: the failure is logged and
+// reported once, and LoggingAvailable reports false from then on.
+func (btm *BulletproofTransferManager) initAudit() {
+	if btm.auditDisabled {
+		return
+	}
+	btm.auditOnce.Do(func() {
+		ledger, err := blockchain.NewBlockchain(btm.targetDataDir)
+		if err != nil {
+			btm.auditErr = err
+			logging.Warnf("Audit logging disabled: %v", err)
+			btm.updateStatus(fmt.Sprintf("Warning: Audit logging unavailable, transfers will continue without an audit trail: %v", err))
+			return
+		}
+		btm.blockchain = ledger
+	})
+}
+
+// LoggingAvailable reports whether transfers are being recorded in the audit
+// ledger. The ledger is opened on the first call if no transfer has done so.
+func (btm *BulletproofTransferManager) LoggingAvailable() bool {
+	btm.initAudit()
+	return !btm.auditDisabled && btm.blockchain != nil
+}
+
+// SetAuditLogging turns the audit ledger on or off. While it is off no
+// transfer is recorded and, if it was never opened, no ledger files are
+// created in the data directory. It is on by default.
+func (btm *BulletproofTransferManager) SetAuditLogging(enabled bool) {
+	btm.auditDisabled = !enabled
+}
+
+// AuditLoggingDisabled reports whether audit logging was turned off with
+// SetAuditLogging, as opposed to being unavailable
+func (btm *BulletproofTransferManager) AuditLoggingDisabled() bool {
+	return btm.auditDisabled
+}
+
+// LoggingError returns why audit logging is unavailable, or nil if it works
+func (btm *BulletproofTransferManager) LoggingError() error {
+	btm.initAudit()
+	return btm.auditErr
+}
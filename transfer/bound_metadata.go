@@ -0,0 +1,48 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// boundMetadata is the part of the transfer metadata a receiver may show or
+// use, sent in the transit envelope and authenticated as the associated data
+// of the encrypted payload. Changing any of it in transit makes decryption
+// fail. The transfer code is left out: the envelope is not encrypted.
+type boundMetadata struct {
+	FileName string `json:"file_name"`
+	FileSize int64  `json:"file_size"`
+	Checksum string `json:"checksum"`
+}
+
+// bindMetadata encodes the metadata to authenticate with a payload
+func bindMetadata(metadata transport.TransferMetadata) ([]byte, error) {
+	data, err := json.Marshal(boundMetadata{
+		FileName: metadata.FileName,
+		FileSize: metadata.FileSize,
+		Checksum: metadata.Checksum,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transfer metadata: %w", err)
+	}
+	return data, nil
+}
+
+// applyBoundMetadata fills metadata from the bound metadata of a payload that
+// decrypted, and so authenticated, with it. Payloads from older senders carry
+// none and leave metadata as it is.
+func applyBoundMetadata(metadata *transport.TransferMetadata, bound []byte) error {
+	if bound == nil || metadata == nil {
+		return nil
+	}
+	var decoded boundMetadata
+	if err := json.Unmarshal(bound, &decoded); err != nil {
+		return fmt.Errorf("failed to read transfer metadata: %w", err)
+	}
+	metadata.FileName = decoded.FileName
+	metadata.FileSize = decoded.FileSize
+	metadata.Checksum = decoded.Checksum
+	return nil
+}
@@ -1,10 +1,13 @@
 package transfer
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand/v2"
@@ -21,46 +24,135 @@ import (
 	"trustdrop-bulletproof/transport"
 )
 
+// DefaultMaxInMemorySize is the default limit for loading a file into memory (100MB)
+const DefaultMaxInMemorySize = 100 * 1024 * 1024
+
+// payloadOverhead is the room transports allow beyond an encoded file at the
+// in-memory limit for the payload's name and header fields, checksums and
+// encryption
+const payloadOverhead = 4 * 1024 * 1024
+
+// maxPayloadFor returns the transport payload cap for files of up to
+// inMemory bytes. A single file is sent base64 encoded in JSON, which grows
+// it by a third, so the cap follows from the encoded size rather than the
+// file's.
+func maxPayloadFor(inMemory int64) int64 {
+	return (inMemory+2)/3*4 + payloadOverhead
+}
+
+// networkChangeCheckInterval is how often the network interfaces are checked for changes
+const networkChangeCheckInterval = 5 * time.Second
+
 // BulletproofTransferManager provides ultra-reliable file transfers with network-aware failover
 type BulletproofTransferManager struct {
 	// Core components
 	transportManager *transport.MultiTransportManager
 	advancedSecurity *security.AdvancedSecurity
 	blockchain       *blockchain.Blockchain
+	auditOnce        sync.Once
+	auditErr         error // why the ledger could not be opened, if it couldn't
+	auditDisabled    bool  // turned off by the operator; no ledger is created
 	logger           *logging.Logger
 
 	// Transfer state
-	targetDataDir    string
-	transferID       string
-	totalFiles       int
-	totalSize        int64
-	progressCallback func(int64, int64, string)
-	statusCallback   func(string)
-	lastTransferMeta *transport.TransferMetadata
+	targetDataDir      string
+	transferID         string
+	totalFiles         int
+	totalSize          int64
+	progressCallback   func(TransferProgress)
+	fileStatusCallback func(FileStatus)
+	statusCallback     func(string)
+	peerCallback       func(sending bool)
+	statusThrottle     statusThrottle // coalesces status messages to a few a second
+	lastTransferMeta   *transport.TransferMetadata
+	networkPath        string     // path the current transfer's data took, transport.PathDirect or PathRelay
+	progressMutex      sync.Mutex // guards the progress counters below, which transport callbacks also touch
+	completedBytes     int64      // bytes of earlier files in the current send
+	completedFiles     int        // files finished in the current transfer
+	currentFileSize    int64      // size of the file currently being sent
+	currentPhase       string     // phase of the file currently being sent
+	progressStart      time.Time  // when the current transfer started, for speed
+	subscribers        subscribers
 
 	// Enhanced reliability features
-	maxRetries      int
-	retryDelay      time.Duration
+	retryBaseline   RetryStrategy // configured retries, scaled per network by adaptSettingsToNetwork
 	chunkSize       int64
+	maxInMemorySize int64 // largest file loaded fully into memory for sending
+	maxEmbedSize    int64 // largest folder file whose contents are sent
+	maxFolderFiles  int   // files the folders in one send may hold unconfirmed, 0 for no limit
+	maxFolderBytes  int64 // bytes the folders in one send may hold unconfirmed, 0 for no limit
+	maxReceiveSize  int64 // most file data one receive accepts, 0 for no limit
 	resumeSupport   bool
 	integrityChecks bool
 
+	// archive chooses when folders are sent as a tar archive
+	archive ArchiveConfig
+
+	// binaryFiles sends single files in a binary frame instead of JSON
+	binaryFiles bool
+
+	// keepPartialReceives keeps files written before a receive failed instead
+	// of discarding them with the staging directory
+	keepPartialReceives bool
+
+	// receiveLayout is one of the ReceiveLayout* folder organizations
+	receiveLayout string
+
+	// tempDir is where temp and staging files are written; empty uses the
+	// OS temp dir for transports and the destination folder for staging
+	tempDir string
+
+	// summaryPath is where finished transfers write their JSON summary, if anywhere
+	summaryPath string
+
+	// iceServers replaces the default STUN and TURN servers used by ICE
+	iceServers transport.ICEServers
+
+	// minCodeBits is the least estimated code entropy a send accepts; 0 accepts any code
+	minCodeBits float64
+
+	// fileTypePolicy limits the kinds of files receives write
+	fileTypePolicy FileTypePolicy
+
+	// quarantine, when set, holds receives until they pass a check
+	quarantine *QuarantineConfig
+
+	// tracing is set while this manager has the protocol trace running
+	tracing bool
+
+	// hooks are commands run after transfers succeed
+	hooks TransferHooks
+
+	// Sender identity attached to sent transfers when shareIdentity is set
+	identityKey   ed25519.PrivateKey
+	shareIdentity bool
+	displayName   string
+
 	// Concurrency control
 	mutex          sync.Mutex
-	transferActive bool
-	cancelContext  context.Context
+	cancelContext  context.Context // cancelled when the manager is closed
 	cancelFunction context.CancelFunc
 
+	// Transfers in flight, by ID, and the one progress and status belong to
+	transfers         map[string]*activeTransfer
+	transferSeq       int
+	currentTransferID string
+	running           sync.WaitGroup // counts transfers in flight, for Shutdown
+	closing           bool           // set by Shutdown and Close; no new transfers start
+
 	// Network adaptation
 	networkProfile      transport.NetworkProfile
 	networkRestrictions []transport.NetworkRestriction
 	adaptiveSettings    AdaptiveSettings
 	lastNetworkCheck    time.Time
+	networkAnalyzed     bool // the profile above came from a finished analysis
 
 	// International transfer optimizations
 	connectionPool     *ConnectionPool
+	connectivityCache  *transport.ConnectivityCache // recently reachable endpoints, so retries skip re-probing
 	regionalPreference string
-	lastSpeedTest      time.Time
+	lastSpeedTest      time.Time // when lastSendRate was measured
+	lastSendRate       float64   // bytes per second of the last completed send
 }
 
 // ConnectionPool manages persistent connections for international transfers
@@ -69,6 +161,15 @@ type ConnectionPool struct {
 	mutex         sync.RWMutex
 	maxAge        time.Duration
 	checkInterval time.Duration
+
+	maxConnections int           // cap on open connections, idle or in use
+	maxWait        time.Duration // how long GetConnection waits for a free slot
+	released       chan struct{} // closed whenever a slot may have freed up
+	hits           int64
+	misses         int64
+	rejected       int64
+	dialer         transport.HappyEyeballsDialer
+	keepalive      time.Duration // probe interval for pooled connections; zero turns probes off
 }
 
 type PooledConnection struct {
@@ -81,9 +182,13 @@ type PooledConnection struct {
 // NewConnectionPool creates a connection pool for international reliability
 func NewConnectionPool() *ConnectionPool {
 	pool := &ConnectionPool{
-		connections:   make(map[string]*PooledConnection),
-		maxAge:        5 * time.Minute,
-		checkInterval: 30 * time.Second,
+		connections:    make(map[string]*PooledConnection),
+		maxAge:         5 * time.Minute,
+		checkInterval:  30 * time.Second,
+		maxConnections: DefaultMaxPoolConnections,
+		maxWait:        DefaultPoolWait,
+		released:       make(chan struct{}),
+		keepalive:      transport.DefaultKeepaliveInterval,
 	}
 
 	// Start cleanup routine
@@ -91,7 +196,8 @@ func NewConnectionPool() *ConnectionPool {
 	return pool
 }
 
-// cleanup removes stale connections
+// cleanup removes stale connections and idle ones that missed their
+// keepalive probes
 func (cp *ConnectionPool) cleanup() {
 	ticker := time.NewTicker(cp.checkInterval)
 	defer ticker.Stop()
@@ -99,43 +205,61 @@ func (cp *ConnectionPool) cleanup() {
 	for range ticker.C {
 		cp.mutex.Lock()
 		now := time.Now()
+		removed := false
 		for key, conn := range cp.connections {
-			if !conn.inUse && now.Sub(conn.lastUsed) > cp.maxAge {
+			if !conn.inUse && (now.Sub(conn.lastUsed) > cp.maxAge || !transport.ConnAlive(conn.conn)) {
 				conn.conn.Close()
 				delete(cp.connections, key)
+				removed = true
 			}
 		}
+		if removed {
+			cp.signalReleased()
+		}
 		cp.mutex.Unlock()
 	}
 }
 
-// GetConnection retrieves or creates a connection from the pool
+// GetConnection retrieves or creates a connection from the pool. When the
+// pool is at its cap it evicts an idle connection, or waits up to the
+// configured time for one to be returned before failing with
+// ErrConnectionPoolFull.
 func (cp *ConnectionPool) GetConnection(address string) (net.Conn, error) {
 	cp.mutex.Lock()
 	defer cp.mutex.Unlock()
 
 	// Check if we have a valid connection
 	if pooled, exists := cp.connections[address]; exists {
-		if !pooled.inUse && time.Since(pooled.created) < cp.maxAge {
+		if !pooled.inUse && time.Since(pooled.created) < cp.maxAge && transport.ConnAlive(pooled.conn) {
 			pooled.inUse = true
 			pooled.lastUsed = time.Now()
+			cp.hits++
 			return pooled.conn, nil
 		} else {
-			// Connection is stale or in use, remove it
+			// Connection is stale, dead or in use, remove it
 			if pooled.conn != nil {
 				pooled.conn.Close()
 			}
 			delete(cp.connections, address)
 		}
 	}
+	cp.misses++
+
+	if err := cp.reserveSlot(); err != nil {
+		return nil, err
+	}
 
 	// Create new connection
-	conn, err := net.DialTimeout("tcp", address, 15*time.Second)
+	conn, err := cp.dialer.DialTimeout(context.Background(), address, 15*time.Second)
 	if err != nil {
 		return nil, err
 	}
+	transport.EnableKeepalive(conn, cp.keepalive)
 
-	// Add to pool
+	// Add to pool, replacing any entry made while waiting for a slot
+	if pooled, exists := cp.connections[address]; exists && pooled.conn != nil {
+		pooled.conn.Close()
+	}
 	now := time.Now()
 	cp.connections[address] = &PooledConnection{
 		conn:     conn,
@@ -155,6 +279,7 @@ func (cp *ConnectionPool) ReturnConnection(address string) {
 	if pooled, exists := cp.connections[address]; exists {
 		pooled.inUse = false
 		pooled.lastUsed = time.Now()
+		cp.signalReleased()
 	}
 }
 
@@ -169,6 +294,7 @@ func (cp *ConnectionPool) Close() {
 		}
 	}
 	cp.connections = make(map[string]*PooledConnection)
+	cp.signalReleased()
 }
 
 // AdaptiveSettings contains settings that adapt based on network conditions
@@ -187,21 +313,45 @@ type RetryStrategy struct {
 	BackoffFactor float64
 	MaxDelay      time.Duration
 	JitterEnabled bool
+
+	// Hard resets rebuild every transport after the whole failover chain fails
+	MaxHardResets  int
+	HardResetDelay time.Duration // Doubles with each reset, capped at MaxDelay
+
+	// Reconnects resume a transfer whose connection dropped mid-way; they do
+	// not use up attempts
+	MaxReconnects int
 }
 
 // TransferResult contains the result of a transfer operation
 type TransferResult struct {
+	TransferCode        string
+	Direction           string // "send" or "receive"
 	Success             bool
 	TransferredFiles    []string
-	TotalBytes          int64
+	Files               []TransferredFile
+	TotalBytes          int64   // Bytes of file data
+	WireBytes           int64   // Bytes the transport carried, including encryption and payload overhead
 	TransferredMB       float64 // Added for modern reliability
 	Duration            time.Duration
 	TransportUsed       string
+	Path                string // transport.PathDirect or transport.PathRelay, when the transport reports it
 	Method              string // Added for modern reliability
 	EncryptionMode      security.EncryptionMode
 	IntegrityVerified   bool
+	NamesPreserved      bool        // False when a received file had to be given a generated name
+	Degraded            bool        // Received data had no manifest and was saved as one raw file; see RawRecoveryNote
+	DestinationDir      string      // Folder the files were received into
+	Sender              *SenderInfo // Who sent a received transfer, if they shared an identity
 	NetworkRestrictions []transport.NetworkRestriction
 	NetworkType         string
+	MissingFiles        []MissingFile // Files listed by the sender that were not received
+	BlockedFiles        []MissingFile // Received files the file type policy kept from being written
+	UnsentFiles         []MissingFile // Files in a sent folder that were left out, unreadable or over the embed limit
+	QuarantineReport    string        // Report explaining why received files were held in quarantine
+	HookError           error         // Why the after-transfer hook failed, if it did; the transfer still succeeded
+	SenderClockAhead    time.Duration // How far at least the sender's clock runs ahead of the receiver's, 0 if not shown
+	TracePath           string        // Protocol trace covering the transfer, when tracing is on
 	Error               error
 }
 
@@ -235,6 +385,28 @@ func NewBulletproofTransferManager(targetDataDir string) (*BulletproofTransferMa
 		fmt.Printf("Will continue with available transports (some may work)\n")
 	}
 
+	if err := transport.ValidateTempDir(""); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	btm := newTransferManager(targetDataDir, transportManager)
+
+	// Initialize network monitoring for corporate environments
+	btm.initializeNetworkMonitoring()
+
+	if logging.TraceRequested() {
+		if err := btm.SetTrace(true); err != nil {
+			fmt.Printf("Warning: protocol trace unavailable: %v\n", err)
+		}
+	}
+
+	fmt.Printf("Corporate-network-ready transfer manager initialized\n")
+	return btm, nil
+}
+
+// newTransferManager builds a manager around transportManager with the
+// default settings, without starting network monitoring
+func newTransferManager(targetDataDir string, transportManager *transport.MultiTransportManager) *BulletproofTransferManager {
 	fmt.Printf("Initializing advanced security system...\n")
 	advancedSecurity := security.NewAdvancedSecurity()
 
@@ -247,9 +419,14 @@ func NewBulletproofTransferManager(targetDataDir string) (*BulletproofTransferMa
 		blockchain:       nil, // Will be initialized if needed
 		logger:           nil, // Will be initialized if needed
 		targetDataDir:    targetDataDir,
-		maxRetries:       15,              // Increased for corporate networks with potential delays
-		retryDelay:       8 * time.Second, // Longer delays for corporate networks
+		retryBaseline:    DefaultRetryStrategy(),
 		chunkSize:        4 * 1024 * 1024, // 4MB chunks for stability over reliability
+		maxInMemorySize:  DefaultMaxInMemorySize,
+		maxEmbedSize:     DefaultMaxEmbedSize,
+		maxFolderFiles:   DefaultMaxFolderFiles,
+		maxFolderBytes:   DefaultMaxFolderBytes,
+		minCodeBits:      DefaultMinCodeBits,
+		receiveLayout:    ReceiveLayoutFlat,
 		resumeSupport:    true,
 		integrityChecks:  true,
 		cancelContext:    ctx,
@@ -259,26 +436,24 @@ func NewBulletproofTransferManager(targetDataDir string) (*BulletproofTransferMa
 			ChunkSizeBytes:     4 * 1024 * 1024,
 			MaxConcurrentFiles: 1,             // Ultra-conservative for corporate stability
 			PreferredTransport: "simple-croc", // Use simple CROC as primary
-			RetryStrategy: RetryStrategy{
-				MaxAttempts:   15, // Increased for corporate network reliability
-				InitialDelay:  8 * time.Second,
-				BackoffFactor: 1.3,
-				MaxDelay:      90 * time.Second,
-				JitterEnabled: true,
-			},
+			RetryStrategy:      DefaultRetryStrategy(),
 		},
 
 		// International transfer optimizations
 		connectionPool:     NewConnectionPool(),
+		connectivityCache:  transport.NewConnectivityCache(transport.DefaultConnectivityCacheTTL),
 		regionalPreference: "auto",
 		lastSpeedTest:      time.Time{},
 	}
 
-	// Initialize network monitoring for corporate environments
-	btm.initializeNetworkMonitoring()
-
-	fmt.Printf("Corporate-network-ready transfer manager initialized\n")
-	return btm, nil
+	if transportManager != nil {
+		transportManager.SetPeerConnectedHandler(btm.onPeerConnected)
+		transportManager.SetPathHandler(btm.onPathChosen)
+		transportManager.SetReceiveProgressHandler(btm.onReceiveProgress)
+		transportManager.SetTimeoutMultiplier(btm.adaptiveSettings.TimeoutMultiplier)
+		transportManager.SetMaxPayloadSize(maxPayloadFor(btm.maxInMemorySize))
+	}
+	return btm
 }
 
 // initializeNetworkMonitoring sets up comprehensive network monitoring
@@ -295,29 +470,67 @@ func (btm *BulletproofTransferManager) initializeNetworkMonitoring() {
 	go func() {
 		time.Sleep(1 * time.Second) // Brief delay for initialization
 		btm.updateNetworkProfile()
+		lastFingerprint, _ := transport.NetworkFingerprint()
 
 		// Periodic network monitoring
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 
+		// Interface changes are cheap to check, so roaming is noticed quickly
+		changeTicker := time.NewTicker(networkChangeCheckInterval)
+		defer changeTicker.Stop()
+
+		// Pick up the first analysis as soon as it finishes, so restrictions
+		// are reported before the user starts a transfer
+		analysisTicker := time.NewTicker(time.Second)
+		defer analysisTicker.Stop()
+		analysisPending := analysisTicker.C
+
 		for {
 			select {
 			case <-btm.cancelContext.Done():
 				return
+			case <-analysisPending:
+				if btm.transportManager == nil || btm.transportManager.AnalysisComplete() {
+					analysisPending = nil
+					btm.updateNetworkProfile()
+				}
 			case <-ticker.C:
 				if time.Since(btm.lastNetworkCheck) > 2*time.Minute {
 					btm.updateNetworkProfile()
 				}
+			case <-changeTicker.C:
+				fingerprint, err := transport.NetworkFingerprint()
+				if err != nil || fingerprint == lastFingerprint {
+					continue
+				}
+				lastFingerprint = fingerprint
+				btm.handleNetworkChange()
 			}
 		}
 	}()
 }
 
+// handleNetworkChange discards everything learned about the previous network
+// and re-analyzes the current one
+func (btm *BulletproofTransferManager) handleNetworkChange() {
+	btm.updateStatus("Network changed — re-evaluating transports")
+
+	btm.connectivityCache.InvalidateAll()
+	btm.forgetSendRate()
+	if btm.transportManager != nil {
+		btm.transportManager.Reanalyze()
+	}
+	btm.updateNetworkProfile()
+}
+
 // updateNetworkProfile gets current network status from transport manager
 func (btm *BulletproofTransferManager) updateNetworkProfile() {
 	btm.lastNetworkCheck = time.Now()
 
 	if btm.transportManager != nil {
+		// Checked first, so a finished analysis is what gets copied below
+		btm.networkAnalyzed = btm.transportManager.AnalysisComplete()
 		btm.networkProfile = btm.transportManager.GetNetworkProfile()
 		btm.networkRestrictions = btm.transportManager.GetNetworkRestrictions()
 		btm.adaptSettingsToNetwork()
@@ -358,10 +571,102 @@ func (btm *BulletproofTransferManager) getNetworkRestrictionsDescription() strin
 }
 
 // SetProgressCallback sets the progress callback function
-func (btm *BulletproofTransferManager) SetProgressCallback(callback func(int64, int64, string)) {
+func (btm *BulletproofTransferManager) SetProgressCallback(callback func(TransferProgress)) {
 	btm.progressCallback = callback
 }
 
+// SetSimpleProgressCallback sets a progress callback that only receives the
+// overall bytes processed, the overall total and the current file name. It
+// replaces any callback set with SetProgressCallback.
+func (btm *BulletproofTransferManager) SetSimpleProgressCallback(callback func(current, total int64, fileName string)) {
+	if callback == nil {
+		btm.progressCallback = nil
+		return
+	}
+	btm.progressCallback = func(progress TransferProgress) {
+		callback(progress.OverallBytes, progress.OverallSize, progress.FileName)
+	}
+}
+
+// SetMaxInMemorySize sets the largest file size that is loaded fully into
+// memory for sending. Files are checked by their size on disk and transports
+// are allowed the larger encoded payload, so a file under the limit is never
+// refused on the way out. There is no streaming send yet, so a single file
+// over the limit is refused with a message naming the limit rather than sent
+// another way. Non-positive values restore the default.
+func (btm *BulletproofTransferManager) SetMaxInMemorySize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxInMemorySize
+	}
+	btm.maxInMemorySize = size
+	if btm.transportManager != nil {
+		btm.transportManager.SetMaxPayloadSize(maxPayloadFor(size))
+	}
+}
+
+// errOverInMemoryLimit is wrapped by the error refusing a file over the
+// in-memory limit
+var errOverInMemoryLimit = errors.New("file too large")
+
+// inMemoryLimitError refuses a single file of size bytes, over the in-memory limit
+func (btm *BulletproofTransferManager) inMemoryLimitError(size int64) error {
+	return fmt.Errorf("%w (%s): single files are loaded into memory to send and the limit is %s; raise the in-memory limit to send it",
+		errOverInMemoryLimit, btm.formatBytes(size), btm.formatBytes(btm.maxInMemorySize))
+}
+
+// SetKeepPartialReceives controls whether files from a failed receive are kept.
+// The default is to discard them so a failed transfer never looks complete.
+func (btm *BulletproofTransferManager) SetKeepPartialReceives(keep bool) {
+	btm.keepPartialReceives = keep
+}
+
+// SetTempDir sets where temp files and receive staging are written, for
+// example on the same volume as the destination or away from a small tmpfs.
+// The directory is created if needed and must be writable. An empty dir
+// restores the defaults.
+func (btm *BulletproofTransferManager) SetTempDir(dir string) error {
+	if dir != "" {
+		if err := transport.ValidateTempDir(dir); err != nil {
+			return err
+		}
+	}
+
+	btm.tempDir = dir
+	if btm.transportManager != nil {
+		btm.transportManager.SetTempDir(dir)
+	}
+	return nil
+}
+
+// SetRelayPassword sets the password for croc relays, needed for a private
+// relay started with its own password. An empty password restores the public
+// relays' default.
+func (btm *BulletproofTransferManager) SetRelayPassword(password string) {
+	if btm.transportManager != nil {
+		btm.transportManager.SetRelayPassword(password)
+	}
+}
+
+// SetICEServers sets the STUN and TURN servers used by the ICE transport,
+// for networks that block the public ones or run their own TURN relay. Empty
+// lists restore the defaults.
+func (btm *BulletproofTransferManager) SetICEServers(servers transport.ICEServers) error {
+	if err := servers.Validate(); err != nil {
+		return err
+	}
+	btm.iceServers = servers
+	return nil
+}
+
+// RelayConfigProblems returns the configured relays that were skipped at
+// startup because their address is invalid
+func (btm *BulletproofTransferManager) RelayConfigProblems() []error {
+	if btm.transportManager == nil {
+		return nil
+	}
+	return btm.transportManager.RelayConfigProblems()
+}
+
 // SetStatusCallback sets the status callback function
 func (btm *BulletproofTransferManager) SetStatusCallback(callback func(string)) {
 	btm.statusCallback = callback
@@ -369,87 +674,146 @@ func (btm *BulletproofTransferManager) SetStatusCallback(callback func(string))
 
 // SendFiles sends files with maximum reliability and institutional network compatibility
 func (btm *BulletproofTransferManager) SendFiles(filePaths []string, transferCode string) (*TransferResult, error) {
-	btm.mutex.Lock()
-	if btm.transferActive {
-		btm.mutex.Unlock()
-		return nil, fmt.Errorf("transfer already in progress")
+	return btm.SendFilesContext(context.Background(), filePaths, transferCode)
+}
+
+// SendFilesContext is SendFiles with cancellation and deadline taken from ctx
+// in addition to Cancel and Close
+func (btm *BulletproofTransferManager) SendFilesContext(ctx context.Context, filePaths []string, transferCode string) (*TransferResult, error) {
+	result, err := btm.traceTransfer("send", func() (*TransferResult, error) {
+		return btm.sendFiles(ctx, filePaths, transferCode)
+	})
+	btm.writeSummary("send", transferCode, result, err)
+	btm.runHook("send", result, err)
+	return result, err
+}
+
+// sendFiles does the work of SendFilesContext
+func (btm *BulletproofTransferManager) sendFiles(ctx context.Context, filePaths []string, transferCode string) (*TransferResult, error) {
+	if err := btm.checkCodeStrength(transferCode); err != nil {
+		return nil, err
+	}
+	if err := btm.checkTransportOverride(ctx); err != nil {
+		return nil, err
 	}
-	btm.transferActive = true
-	btm.mutex.Unlock()
 
-	defer func() {
-		btm.mutex.Lock()
-		btm.transferActive = false
-		btm.mutex.Unlock()
-	}()
+	// Leave out what cannot be read, so one bad item does not stop the rest
+	filePaths, unreadable, err := btm.checkSelection(filePaths)
+	if err != nil {
+		btm.updateStatus(fmt.Sprintf("Nothing to send: %v", err))
+		return nil, err
+	}
+
+	ctx, transferID, err := btm.beginTransfer(ctx, transferCode, "send")
+	if err != nil {
+		return nil, err
+	}
+	defer btm.endTransfer(transferID)
 
 	startTime := time.Now()
+	trafficBefore := btm.transportTraffic()
 	result := &TransferResult{
+		TransferCode:        transferCode,
+		Direction:           "send",
 		TransferredFiles:    []string{},
 		NetworkRestrictions: btm.networkRestrictions,
 		NetworkType:         btm.networkProfile.NetworkType,
+		UnsentFiles:         unreadable,
 	}
 
 	btm.transferID = transferCode
+	btm.networkPath = ""
 	btm.updateStatus("Initializing secure transfer...")
 
 	// Provide network-specific guidance
 	btm.provideNetworkGuidance()
 
 	// Calculate total size with progress updates
-	totalSize, err := btm.calculateTotalSizeWithProgress(filePaths)
+	sizes, err := btm.calculateTotalSizeWithProgress(filePaths)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze files: %w", err)
 	}
+	if err := btm.checkFolderLimits(ctx, sizes); err != nil {
+		return nil, err
+	}
+	totalSize, pathSizes := sizes.total, sizes.perPath
 	btm.totalSize = totalSize
 	btm.totalFiles = len(filePaths)
+	btm.startProgress(startTime)
 
+	btm.reportQueued(filePaths, unreadable)
 	btm.updateStatus(fmt.Sprintf("Preparing %d files (%s) for secure transfer...",
 		len(filePaths), btm.formatBytes(totalSize)))
 
 	// Process files with enhanced error handling and network awareness
 	var transferredBytes int64
 	for i, filePath := range filePaths {
-		select {
-		case <-btm.cancelContext.Done():
-			return result, fmt.Errorf("transfer cancelled by user")
-		default:
+		if ctx.Err() != nil {
+			return result, contextError(ctx)
 		}
 
 		fileName := filepath.Base(filePath)
+		if info, err := os.Lstat(filePath); err == nil {
+			if kind := specialFileKind(filePath, info); kind != "" {
+				btm.updateStatus(fmt.Sprintf("Warning: Skipping %s (%s cannot be transferred)", fileName, kind))
+				continue
+			}
+		}
 		btm.updateStatus(fmt.Sprintf("Processing file %d/%d: %s", i+1, len(filePaths), fileName))
+		btm.progressMutex.Lock()
+		btm.currentFileSize = pathSizes[i]
+		btm.progressMutex.Unlock()
 
 		// Process file with institutional network-aware retries
-		fileResult, err := btm.processFileWithNetworkAwareRetries(filePath, transferCode)
+		fileResult, err := btm.processFileWithNetworkAwareRetries(ctx, filePath, transferCode)
 		if err != nil {
-			detailedError := btm.enhanceErrorMessage(err, filePath)
+			result.Duration = time.Since(startTime)
+			btm.recordIncompleteTransfer(result, transferCode, err)
+			detailedError := btm.enhanceErrorMessage(err, filePath, "send")
 			btm.updateStatus(fmt.Sprintf("Failed to process file %s", fileName))
 			result.Error = detailedError
 			return result, result.Error
 		}
 
 		result.TransferredFiles = append(result.TransferredFiles, filePath)
+		result.UnsentFiles = append(result.UnsentFiles, fileResult.Unsent...)
+		result.Files = append(result.Files, fileResult.Files...)
+		result.EncryptionMode = fileResult.Mode
 		transferredBytes += fileResult.Size
-		btm.updateProgress(transferredBytes, totalSize, fileName)
-	}
-
-	// Record blockchain entry if available
-	if err := btm.recordTransferInBlockchain(result, transferCode); err != nil {
-		btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", err))
+		btm.completeFile(fileResult.Size)
+		btm.updateProgress(TransferProgress{
+			FileName:     fileName,
+			FileBytes:    fileResult.Size,
+			FileSize:     fileResult.Size,
+			OverallBytes: transferredBytes,
+			OverallSize:  totalSize,
+		})
 	}
 
 	result.Success = true
 	result.TotalBytes = transferredBytes
+	result.WireBytes = btm.transportTraffic().Since(trafficBefore).Sent
+	result.NamesPreserved = true // Names always travel in the payload or manifest
 	result.Duration = time.Since(startTime)
-	result.IntegrityVerified = btm.integrityChecks
+	btm.recordSendRate(result.TotalBytes, result.Duration)
+	result.IntegrityVerified = false // Checksums are sent along and verified by the receiver
 	result.TransportUsed = btm.getUsedTransportName()
+	result.Path = btm.networkPath
+
+	// Record blockchain entry if available
+	if err := btm.recordTransferInBlockchain(result, transferCode, blockchain.VerificationSkipped); err != nil {
+		btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", err))
+	}
 
 	successMsg := fmt.Sprintf("Transfer completed successfully! %d files (%s) in %v",
-		len(result.TransferredFiles), btm.formatBytes(result.TotalBytes), result.Duration)
+		len(result.TransferredFiles), result.DescribeSize(), result.Duration)
 
 	if btm.networkProfile.IsRestrictive {
 		successMsg += " via institutional-compatible transport"
 	}
+	if len(result.UnsentFiles) > 0 {
+		successMsg += fmt.Sprintf(" (%d files were left out)", len(result.UnsentFiles))
+	}
 
 	btm.updateStatus(successMsg)
 	return result, nil
@@ -457,39 +821,95 @@ func (btm *BulletproofTransferManager) SendFiles(filePaths []string, transferCod
 
 // ReceiveFiles receives files with enhanced reliability and institutional network support
 func (btm *BulletproofTransferManager) ReceiveFiles(transferCode string) (*TransferResult, error) {
-	btm.mutex.Lock()
-	if btm.transferActive {
-		btm.mutex.Unlock()
-		return nil, fmt.Errorf("transfer already in progress")
+	return btm.ReceiveFilesContext(context.Background(), transferCode)
+}
+
+// ReceiveFilesContext is ReceiveFiles with cancellation and deadline taken from
+// ctx in addition to Cancel and Close
+func (btm *BulletproofTransferManager) ReceiveFilesContext(ctx context.Context, transferCode string) (*TransferResult, error) {
+	return btm.receiveFiles(ctx, transferCode, "")
+}
+
+// ReceiveFilesTo receives files directly into destDir instead of the data
+// directory's "received" folder. The receive layout does not apply; folder
+// structure from the sender is rebuilt under destDir, which is created if needed.
+func (btm *BulletproofTransferManager) ReceiveFilesTo(transferCode, destDir string) (*TransferResult, error) {
+	return btm.ReceiveFilesToContext(context.Background(), transferCode, destDir)
+}
+
+// ReceiveFilesToContext is ReceiveFilesTo with cancellation and deadline taken from ctx
+func (btm *BulletproofTransferManager) ReceiveFilesToContext(ctx context.Context, transferCode, destDir string) (*TransferResult, error) {
+	if destDir == "" {
+		return nil, fmt.Errorf("destination directory is required")
 	}
-	btm.transferActive = true
-	btm.mutex.Unlock()
 
-	defer func() {
-		btm.mutex.Lock()
-		btm.transferActive = false
-		btm.mutex.Unlock()
-	}()
+	dir, err := prepareDestinationDir(destDir)
+	if err != nil {
+		return nil, err
+	}
+	return btm.receiveFiles(ctx, transferCode, dir)
+}
+
+// receiveFiles receives a transfer into destDir, or into the configured
+// layout under the data directory when destDir is empty
+func (btm *BulletproofTransferManager) receiveFiles(ctx context.Context, transferCode, destDir string) (*TransferResult, error) {
+	result, err := btm.traceTransfer("receive", func() (*TransferResult, error) {
+		return btm.receiveInto(ctx, transferCode, destDir)
+	})
+	btm.writeSummary("receive", transferCode, result, err)
+	btm.runHook("receive", result, err)
+	return result, err
+}
+
+// receiveInto does the work of receiveFiles
+func (btm *BulletproofTransferManager) receiveInto(ctx context.Context, transferCode, destDir string) (*TransferResult, error) {
+	if err := btm.checkTransportOverride(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, transferID, err := btm.beginTransfer(ctx, transferCode, "receive")
+	if err != nil {
+		return nil, err
+	}
+	defer btm.endTransfer(transferID)
 
 	startTime := time.Now()
+	trafficBefore := btm.transportTraffic()
 	result := &TransferResult{
+		TransferCode:        transferCode,
+		Direction:           "receive",
 		TransferredFiles:    []string{},
 		NetworkRestrictions: btm.networkRestrictions,
 		NetworkType:         btm.networkProfile.NetworkType,
 	}
 
 	btm.transferID = transferCode
+	btm.networkPath = ""
+	btm.totalFiles = 0
+	btm.totalSize = 0
+	btm.startProgress(startTime)
 	btm.updateStatus("Connecting with enhanced reliability...")
 
 	// Provide network-specific connection guidance
 	btm.provideConnectionGuidance()
 
-	// Create received files directory
-	receivedDir := filepath.Join(btm.targetDataDir, "received")
+	// Create the received files directory for the configured layout
+	receivedDir := destDir
+	if receivedDir == "" {
+		receivedDir = btm.receiveDestination(transferCode, startTime)
+	}
 	if err := os.MkdirAll(receivedDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create received directory: %w", err)
 	}
 
+	// With quarantine on, files land in a holding folder until they pass the check
+	finalDir := receivedDir
+	if btm.quarantine != nil {
+		if receivedDir, err = btm.quarantineHoldDir(startTime); err != nil {
+			return nil, err
+		}
+	}
+
 	// Receive files using transport manager with institutional network optimization
 	metadata := transport.TransferMetadata{
 		TransferID: transferCode,
@@ -499,9 +919,13 @@ func (btm *BulletproofTransferManager) ReceiveFiles(transferCode string) (*Trans
 	btm.updateStatus("Establishing secure connection through available transports...")
 
 	// Receive with enhanced retries optimized for institutional networks
-	data, err := btm.receiveWithInstitutionalNetworkSupport(metadata)
+	payload, err := btm.receiveWithInstitutionalNetworkSupport(ctx, metadata)
+	peerVersion := payload.version
+	btm.enterPhase("") // Writing files reports against the manifest instead
 	if err != nil {
-		detailedError := btm.enhanceErrorMessage(err, "")
+		result.Duration = time.Since(startTime)
+		btm.recordIncompleteTransfer(result, transferCode, err)
+		detailedError := btm.enhanceErrorMessage(err, "", "receive")
 		return nil, detailedError
 	}
 
@@ -511,25 +935,74 @@ func (btm *BulletproofTransferManager) ReceiveFiles(transferCode string) (*Trans
 		FileName:   btm.lastTransferMeta.FileName,
 	}
 
-	receivedFiles, totalBytes, err := btm.processReceivedDataWithMetadata(data, transferCode, enhancedMetadata)
+	traced := logging.TraceSpan("verify", "decrypt, verify and write %d bytes", len(payload.ciphertext))
+	received, err := btm.processReceivedDataWithMetadata(ctx, payload.ciphertext, payload.metadata, transferCode, receivedDir, enhancedMetadata)
+	traced(err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process received data: %w", err)
+		err = explainVersionMismatch(err, peerVersion)
+		if errors.Is(err, ErrIntegrityFailed) {
+			// Log the rejected transfer so the audit trail shows it arrived corrupted
+			result.Duration = time.Since(startTime)
+			result.TransportUsed = btm.getUsedTransportName()
+			if logErr := btm.recordTransferInBlockchain(result, transferCode, blockchain.VerificationFailed); logErr != nil {
+				btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", logErr))
+			}
+		}
+		return nil, btm.enhanceErrorMessage(fmt.Errorf("failed to process received data: %w", err), "", "receive")
+	}
+	if peerVersion != 0 && peerVersion != ProtocolVersion {
+		btm.updateStatus(fmt.Sprintf("Note: The sender uses protocol version %d and this app uses %d; updating both keeps transfers working",
+			peerVersion, ProtocolVersion))
 	}
 
 	result.Success = true
-	result.TransferredFiles = receivedFiles
-	result.TotalBytes = totalBytes
+	result.TransferredFiles = received.Files
+	result.Files = received.transferredFiles()
+	result.EncryptionMode = received.Mode
+	result.TotalBytes = received.TotalBytes
+	result.WireBytes = btm.transportTraffic().Since(trafficBefore).Received
+	result.NamesPreserved = received.NamesPreserved
+	result.Degraded = received.Degraded
+	result.BlockedFiles = received.Blocked
+	result.DestinationDir = receivedDir
+	result.Sender = received.Sender
+	received.SenderClockAhead = btm.checkClockSkew(received, time.Now())
+	result.SenderClockAhead = received.SenderClockAhead
 	result.Duration = time.Since(startTime)
-	result.IntegrityVerified = btm.integrityChecks
+	result.IntegrityVerified = received.Verified
 	result.TransportUsed = btm.getUsedTransportName()
+	result.Path = btm.networkPath
+
+	if btm.quarantine != nil {
+		// Incomplete transfers stay in quarantine and are reported as incomplete below
+		if err := btm.releaseFromQuarantine(ctx, result, received, receivedDir, finalDir); err != nil && len(received.Missing) == 0 {
+			return result, btm.failQuarantinedReceive(result, transferCode, err)
+		}
+	}
+
+	// Keep the checksums so the files can be re-verified later
+	if err := btm.saveReceipt(transferCode, received, startTime); err != nil {
+		btm.updateStatus(fmt.Sprintf("Note: Could not save receipt for later verification: %v", err))
+	}
+
+	if len(received.Missing) > 0 {
+		return result, btm.failIncompleteReceive(result, received, transferCode)
+	}
+
+	verification := blockchain.VerificationSkipped
+	if received.Verified {
+		verification = blockchain.VerificationPassed
+	} else if btm.integrityChecks {
+		btm.updateStatus("Note: Some received files had no checksum to verify against")
+	}
 
 	// Record in blockchain if available
-	if err := btm.recordTransferInBlockchain(result, transferCode); err != nil {
+	if err := btm.recordTransferInBlockchain(result, transferCode, verification); err != nil {
 		btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", err))
 	}
 
 	successMsg := fmt.Sprintf("Transfer completed successfully! %d files (%s) in %v",
-		len(result.TransferredFiles), btm.formatBytes(result.TotalBytes), result.Duration)
+		len(result.TransferredFiles), result.DescribeSize(), result.Duration)
 
 	if btm.networkProfile.IsRestrictive {
 		successMsg += " via institutional-compatible transport"
@@ -555,6 +1028,10 @@ func (btm *BulletproofTransferManager) provideNetworkGuidance() {
 	} else {
 		btm.updateStatus("Open network detected - using optimized CROC P2P protocol")
 	}
+
+	if btm.networkProfile.VPNDetected {
+		btm.updateStatus("VPN detected - your VPN may be routing this transfer")
+	}
 }
 
 // provideConnectionGuidance provides connection-specific guidance
@@ -567,10 +1044,15 @@ func (btm *BulletproofTransferManager) provideConnectionGuidance() {
 	} else {
 		btm.updateStatus("Connecting via secure CROC P2P relay servers...")
 	}
+
+	if btm.networkProfile.VPNDetected {
+		btm.updateStatus("VPN detected - your VPN may be routing this transfer")
+	}
 }
 
-// receiveWithInstitutionalNetworkSupport performs receive with institutional network optimization
-func (btm *BulletproofTransferManager) receiveWithInstitutionalNetworkSupport(metadata transport.TransferMetadata) ([]byte, error) {
+// receiveWithInstitutionalNetworkSupport performs receive with institutional network
+// optimization, returning the payload out of its transit envelope
+func (btm *BulletproofTransferManager) receiveWithInstitutionalNetworkSupport(ctx context.Context, metadata transport.TransferMetadata) (transitPayload, error) {
 	strategy := btm.adaptiveSettings.RetryStrategy
 
 	// Extended retry logic for institutional networks
@@ -579,7 +1061,13 @@ func (btm *BulletproofTransferManager) receiveWithInstitutionalNetworkSupport(me
 		maxAttempts = int(float64(maxAttempts) * 1.5) // 50% more attempts for institutional networks
 	}
 
+	hardResets := 0
+	var corrupted error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			return transitPayload{}, contextError(ctx)
+		}
+
 		// Update status with institutional network context
 		if attempt > 1 {
 			if btm.networkProfile.IsRestrictive {
@@ -590,29 +1078,82 @@ func (btm *BulletproofTransferManager) receiveWithInstitutionalNetworkSupport(me
 			}
 		}
 
-		data, err := btm.transportManager.ReceiveWithFailover(metadata)
+		traced := logging.TraceSpan("receive", "attempt %d/%d", attempt, maxAttempts)
+		staged, err := btm.receiveWithReconnect(ctx, metadata)
+		var payload transitPayload
 		if err == nil {
-			return data, nil
+			payload, err = openStagedTransit(staged, btm.maxReceivePayload())
+		}
+		traced(err)
+		if err == nil {
+			return payload, nil
+		}
+
+		if transport.IsRelayRejected(err) || errors.Is(err, ErrTransferTooLarge) {
+			// The relay answers every attempt with the same password the same
+			// way, and the sender sends the same payload
+			return transitPayload{}, err
+		}
+
+		// The connection worked, so only the payload needs fetching again
+		corrupted = nil
+		if errors.Is(err, ErrCorruptedInTransit) {
+			corrupted = err
+			if attempt < maxAttempts {
+				btm.updateStatus("Received data was corrupted in transit, receiving it again...")
+				if err := sleepContext(ctx, btm.calculateInstitutionalNetworkDelay(attempt+1, strategy)); err != nil {
+					return transitPayload{}, err
+				}
+			}
+			continue
+		}
+
+		if isRateLimitError(err) {
+			if attempt < maxAttempts {
+				delay := rateLimitDelay(err, attempt)
+				btm.updateStatus(fmt.Sprintf("Relay is rate limiting connections, waiting %v before retrying...", delay.Round(time.Second)))
+				if err := sleepContext(ctx, delay); err != nil {
+					return transitPayload{}, err
+				}
+			}
+			continue
+		}
+
+		if attempt < maxAttempts {
+			reset, resetErr := btm.recoverFromChainFailure(ctx, err, &hardResets, strategy)
+			if resetErr != nil {
+				return transitPayload{}, resetErr
+			}
+			if reset {
+				continue
+			}
 		}
 
 		// Enhanced error analysis for institutional networks
 		if btm.isInstitutionalNetworkError(err) && attempt <= 3 {
 			btm.updateStatus("Institutional network restrictions detected - adjusting connection method...")
-			time.Sleep(5 * time.Second) // Extended delay for network adaptation
+			if err := sleepContext(ctx, 5*time.Second); err != nil { // Extended delay for network adaptation
+				return transitPayload{}, err
+			}
 		}
 
 		if attempt < maxAttempts {
 			delay := btm.calculateInstitutionalNetworkDelay(attempt, strategy)
 			btm.updateStatus(fmt.Sprintf("Attempt %d failed, retrying in %v: %v",
-				attempt, delay, btm.simplifyErrorMessage(err)))
-			time.Sleep(delay)
+				attempt, delay, simplifyErrorMessage(err)))
+			if err := sleepContext(ctx, delay); err != nil {
+				return transitPayload{}, err
+			}
 		}
 	}
 
-	return nil, fmt.Errorf("receive failed after %d attempts optimized for institutional networks", maxAttempts)
+	if corrupted != nil {
+		return transitPayload{}, fmt.Errorf("receive failed after %d attempts: %w", maxAttempts, corrupted)
+	}
+	return transitPayload{}, fmt.Errorf("receive failed after %d attempts optimized for institutional networks", maxAttempts)
 }
 
-func (btm *BulletproofTransferManager) processFileWithNetworkAwareRetries(filePath, transferCode string) (*FileProcessResult, error) {
+func (btm *BulletproofTransferManager) processFileWithNetworkAwareRetries(ctx context.Context, filePath, transferCode string) (*FileProcessResult, error) {
 	strategy := btm.adaptiveSettings.RetryStrategy
 
 	// INTERNATIONAL TRANSFER OPTIMIZATION: Adjust attempts based on network type and latency
@@ -626,37 +1167,77 @@ func (btm *BulletproofTransferManager) processFileWithNetworkAwareRetries(filePa
 		maxAttempts = int(float64(maxAttempts) * 1.5) // 50% more attempts for high latency
 	}
 
+	hardResets := 0
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+
 		// Pre-transfer connectivity check for international reliability
 		if attempt == 1 {
 			btm.updateStatus("Verifying international connectivity...")
-			if !btm.preflightConnectivityCheck() {
+			if !btm.preflightConnectivityCheck(ctx) {
 				btm.updateStatus("International connectivity issues detected - optimizing retry strategy...")
 				maxAttempts = int(float64(maxAttempts) * 1.3) // Increase attempts if connectivity is poor
 			}
 		}
 
-		result, err := btm.processFile(filePath, transferCode)
+		result, err := btm.processFile(ctx, filePath, transferCode)
 		if err == nil {
 			return result, nil
 		}
+		if transport.IsRelayRejected(err) || errors.Is(err, errOverInMemoryLimit) {
+			// The relay answers every attempt with the same password the same
+			// way, and the file is no smaller the next time
+			return nil, err
+		}
+
+		// A failed attempt means cached reachability can no longer be trusted
+		btm.connectivityCache.InvalidateAll()
 
 		// Enhanced error analysis for international networks
 		errorSeverity := btm.categorizeInternationalError(err)
 
+		if errorSeverity == "rate_limited" {
+			if attempt < maxAttempts {
+				delay := rateLimitDelay(err, attempt)
+				btm.updateStatus(fmt.Sprintf("Relay is rate limiting connections, waiting %v before retrying...", delay.Round(time.Second)))
+				if err := sleepContext(ctx, delay); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if attempt < maxAttempts {
+			reset, resetErr := btm.recoverFromChainFailure(ctx, err, &hardResets, strategy)
+			if resetErr != nil {
+				return nil, resetErr
+			}
+			if reset {
+				continue
+			}
+		}
+
 		if btm.isInstitutionalNetworkError(err) && attempt <= 5 {
 			btm.updateStatus("International network restrictions detected - adjusting transport method...")
-			time.Sleep(time.Duration(5+attempt*2) * time.Second) // Progressive backoff
+			if err := sleepContext(ctx, time.Duration(5+attempt*2)*time.Second); err != nil { // Progressive backoff
+				return nil, err
+			}
 		} else if errorSeverity == "timeout" && attempt <= 3 {
 			btm.updateStatus("International timeout detected - extending timeout for next attempt...")
-			time.Sleep(time.Duration(10+attempt*5) * time.Second) // Longer delays for timeouts
+			if err := sleepContext(ctx, time.Duration(10+attempt*5)*time.Second); err != nil { // Longer delays for timeouts
+				return nil, err
+			}
 		}
 
 		if attempt < maxAttempts {
 			delay := btm.calculateInternationalNetworkDelay(attempt, strategy, errorSeverity)
 			btm.updateStatus(fmt.Sprintf("International transfer attempt %d failed, retrying in %v: %v",
-				attempt, delay, btm.simplifyErrorMessage(err)))
-			time.Sleep(delay)
+				attempt, delay, simplifyErrorMessage(err)))
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -710,7 +1291,7 @@ func (btm *BulletproofTransferManager) calculateInstitutionalNetworkDelay(attemp
 }
 
 // preflightConnectivityCheck verifies international connectivity before transfer
-func (btm *BulletproofTransferManager) preflightConnectivityCheck() bool {
+func (btm *BulletproofTransferManager) preflightConnectivityCheck(ctx context.Context) bool {
 	endpoints := []string{
 		"croc.schollz.com:443",
 		"8.8.8.8:53",
@@ -719,9 +1300,7 @@ func (btm *BulletproofTransferManager) preflightConnectivityCheck() bool {
 
 	successCount := 0
 	for _, endpoint := range endpoints {
-		conn, err := net.DialTimeout("tcp", endpoint, 8*time.Second)
-		if err == nil {
-			conn.Close()
+		if btm.connectivityCache.Reachable(ctx, endpoint, 8*time.Second) {
 			successCount++
 		}
 	}
@@ -737,6 +1316,11 @@ func (btm *BulletproofTransferManager) categorizeInternationalError(err error) s
 
 	errorStr := strings.ToLower(err.Error())
 
+	// Rate limiting needs its own, much gentler backoff
+	if isRateLimitError(err) {
+		return "rate_limited"
+	}
+
 	// International-specific error patterns
 	if strings.Contains(errorStr, "timeout") || strings.Contains(errorStr, "deadline exceeded") {
 		return "timeout"
@@ -796,77 +1380,37 @@ func (btm *BulletproofTransferManager) calculateInternationalNetworkDelay(attemp
 	return time.Duration(baseDelay)
 }
 
-// enhanceErrorMessage provides detailed, network-aware error messages
-func (btm *BulletproofTransferManager) enhanceErrorMessage(err error, filePath string) error {
+// enhanceErrorMessage wraps err in a TransferFailure carrying its category and
+// the network context; FormatErrorMessage turns it into user-facing guidance.
+// direction is "send" or "receive".
+func (btm *BulletproofTransferManager) enhanceErrorMessage(err error, filePath, direction string) error {
 	if err == nil {
 		return nil
 	}
 
-	var enhancedMsg strings.Builder
-
-	// Check if this is an institutional network-related error
-	if btm.isInstitutionalNetworkError(err) {
-		if btm.networkProfile.IsRestrictive {
-			enhancedMsg.WriteString("Transfer failed due to institutional network restrictions.\n\n")
-
-			switch btm.networkProfile.NetworkType {
-			case "corporate":
-				enhancedMsg.WriteString("Your corporate network has strict security policies that block ")
-				enhancedMsg.WriteString("peer-to-peer file transfer protocols.\n\n")
-			case "university":
-				enhancedMsg.WriteString("Your university network has academic security policies that restrict ")
-				enhancedMsg.WriteString("direct file transfer protocols.\n\n")
-			default:
-				enhancedMsg.WriteString("Your managed network has IT policies that block ")
-				enhancedMsg.WriteString("direct file transfer protocols.\n\n")
-			}
-
-			if len(btm.networkRestrictions) > 0 {
-				enhancedMsg.WriteString("Detected network restrictions:\n")
-				for _, restriction := range btm.networkRestrictions {
-					enhancedMsg.WriteString(fmt.Sprintf("• %s: %s\n",
-						strings.Title(restriction.Type), restriction.Description))
-				}
-				enhancedMsg.WriteString("\n")
-			}
-
-			enhancedMsg.WriteString("Recommended solutions:\n")
-			enhancedMsg.WriteString("• Try from a different network (mobile hotspot, home WiFi)\n")
-			enhancedMsg.WriteString("• Contact your IT department about approved file transfer methods\n")
-			enhancedMsg.WriteString("• Use a personal device with mobile data if permitted by policy\n")
-			enhancedMsg.WriteString("• Consider using your organization's approved file sharing platform\n")
-			enhancedMsg.WriteString("• Temporarily connect via mobile hotspot if policies allow\n")
-		} else {
-			enhancedMsg.WriteString("Network connectivity issue detected.\n\n")
-			enhancedMsg.WriteString("Troubleshooting steps:\n")
-			enhancedMsg.WriteString("• Verify your internet connection is stable\n")
-			enhancedMsg.WriteString("• Check if your firewall or antivirus is blocking the connection\n")
-			enhancedMsg.WriteString("• Try again in a few minutes in case of temporary network issues\n")
-			enhancedMsg.WriteString("• Restart your network adapter or router if problems persist\n")
-		}
-	} else {
-		// Non-network error
-		enhancedMsg.WriteString("Transfer failed: ")
-		enhancedMsg.WriteString(btm.simplifyErrorMessage(err))
-		enhancedMsg.WriteString("\n\nGeneral troubleshooting steps:\n")
-		enhancedMsg.WriteString("• Verify the transfer code is correct and hasn't expired\n")
-		enhancedMsg.WriteString("• Ensure both devices are connected to the internet\n")
-		enhancedMsg.WriteString("• Try restarting the application\n")
-		enhancedMsg.WriteString("• Check available disk space on the receiving device\n")
+	kind := classifyFailureKind(err, btm.isInstitutionalNetworkError(err))
+	if btm.networkProfile.EndpointSecurity && isResetFailure(kind, err) {
+		// Resets are only pinned on security software once analysis has seen it interfere
+		kind = ErrEndpointSecurity
 	}
-
-	if filePath != "" {
-		enhancedMsg.WriteString(fmt.Sprintf("\nFile: %s", filePath))
+	if btm.networkProfile.Directional.Impaired(direction) && isConnectivityFailure(kind) {
+		kind = ErrOneWayNetwork
 	}
 
-	enhancedMsg.WriteString(fmt.Sprintf("\nNetwork Type: %s", btm.networkProfile.NetworkType))
-	enhancedMsg.WriteString(fmt.Sprintf("\nTechnical Details: %v", err))
-
-	return fmt.Errorf("%s", enhancedMsg.String())
+	return &TransferFailure{
+		Kind:         kind,
+		Cause:        err,
+		FilePath:     filePath,
+		Direction:    direction,
+		NetworkType:  btm.networkProfile.NetworkType,
+		Restrictive:  btm.networkProfile.IsRestrictive,
+		VPNActive:    btm.networkProfile.VPNDetected,
+		Restrictions: btm.networkRestrictions,
+	}
 }
 
 // simplifyErrorMessage creates user-friendly versions of technical errors
-func (btm *BulletproofTransferManager) simplifyErrorMessage(err error) string {
+func simplifyErrorMessage(err error) string {
 	if err == nil {
 		return ""
 	}
@@ -931,124 +1475,276 @@ func (btm *BulletproofTransferManager) adaptSettingsToNetwork() {
 		btm.adaptiveSettings.ChunkSizeBytes = 8 * 1024 * 1024 // 8MB chunks for stability
 		btm.adaptiveSettings.MaxConcurrentFiles = 1
 		btm.adaptiveSettings.PreferredTransport = "simple-croc" // CROC is primary
-		btm.adaptiveSettings.RetryStrategy.MaxAttempts = 20     // More retries for labs
-		btm.adaptiveSettings.RetryStrategy.InitialDelay = 10 * time.Second
-		btm.adaptiveSettings.RetryStrategy.MaxDelay = 120 * time.Second
 	} else {
 		// Open network - more aggressive but still reliable
 		btm.adaptiveSettings.TimeoutMultiplier = 2.0
 		btm.adaptiveSettings.ChunkSizeBytes = 16 * 1024 * 1024 // 16MB chunks
 		btm.adaptiveSettings.MaxConcurrentFiles = 2
 		btm.adaptiveSettings.PreferredTransport = "simple-croc" // CROC is primary
-		btm.adaptiveSettings.RetryStrategy.MaxAttempts = 15
-		btm.adaptiveSettings.RetryStrategy.InitialDelay = 5 * time.Second
-		btm.adaptiveSettings.RetryStrategy.MaxDelay = 60 * time.Second
+	}
+	btm.adaptiveSettings.RetryStrategy = adaptRetryStrategy(btm.retryBaseline, profile.IsRestrictive)
+	if btm.transportManager != nil {
+		btm.transportManager.SetTimeoutMultiplier(btm.adaptiveSettings.TimeoutMultiplier)
 	}
 
 	fmt.Printf("Adapted settings for %s network (restrictive: %t, preferred: %s)\n",
 		profile.NetworkType, profile.IsRestrictive, btm.adaptiveSettings.PreferredTransport)
 }
 
+// sendSizes is what calculateTotalSizeWithProgress found in the paths to send
+type sendSizes struct {
+	total   int64
+	perPath []int64 // bytes sent for each path, folder contents included
+	files   int     // files that will be sent
+	skipped int     // special files that will be skipped
+
+	folderFiles int   // files inside selected folders
+	folderBytes int64 // bytes inside selected folders
+}
+
 // calculateTotalSizeWithProgress calculates the total size of files with progress updates
-func (btm *BulletproofTransferManager) calculateTotalSizeWithProgress(filePaths []string) (int64, error) {
-	var totalSize int64
+func (btm *BulletproofTransferManager) calculateTotalSizeWithProgress(filePaths []string) (sendSizes, error) {
+	sizes := sendSizes{perPath: make([]int64, len(filePaths))}
 	for i, filePath := range filePaths {
 		btm.updateStatus(fmt.Sprintf("Analyzing file %d/%d: %s", i+1, len(filePaths), filepath.Base(filePath)))
 
 		info, err := os.Stat(filePath)
 		if err != nil {
-			return 0, fmt.Errorf("failed to analyze file %s: %w", filePath, err)
+			return sendSizes{}, fmt.Errorf("failed to analyze file %s: %w", filePath, err)
+		}
+		if !info.IsDir() {
+			if info.Mode().IsRegular() {
+				sizes.perPath[i] = info.Size()
+				sizes.files++
+			} else {
+				sizes.skipped++
+			}
+		} else {
+			// Count folder contents so progress reflects the bytes actually sent
+			filepath.Walk(filePath, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return nil
+				}
+				if specialFileKind(path, info) != "" {
+					sizes.skipped++
+					return nil
+				}
+				sizes.perPath[i] += info.Size()
+				sizes.files++
+				sizes.folderFiles++
+				sizes.folderBytes += info.Size()
+				if sizes.folderFiles%folderCountReportInterval == 0 {
+					btm.updateStatus(fmt.Sprintf("Counting files in %s: %d so far (%s)",
+						filepath.Base(filePath), sizes.folderFiles, btm.formatBytes(sizes.folderBytes)))
+				}
+				return nil
+			})
 		}
-		totalSize += info.Size()
+		sizes.total += sizes.perPath[i]
 	}
-	return totalSize, nil
+	return sizes, nil
 }
 
 type FileProcessResult struct {
-	Size int64
-	Hash string
+	Size   int64
+	Hash   string
+	Unsent []MissingFile // Folder files that could not be read, even after retrying
+	Files  []TransferredFile
+	Mode   security.EncryptionMode
 }
 
-// processReceivedDataWithMetadata handles processing of received data with enhanced metadata
-func (btm *BulletproofTransferManager) processReceivedDataWithMetadata(encryptedData []byte, transferCode string, metadata *transport.TransferMetadata) ([]string, int64, error) {
-	// Decrypt data with all available modes
-	var decryptedData []byte
+// receivedPayload describes the files written for a received transfer
+type receivedPayload struct {
+	Files          []string
+	TotalBytes     int64
+	NamesPreserved bool // Whether every file kept the name the sender used
+	Verified       bool // Whether every file's checksum was checked and matched
+	Degraded       bool // Saved as raw data because no manifest or named payload was found
+	Sender         *SenderInfo
+	Missing        []MissingFile     // Manifest entries that were not written
+	Blocked        []MissingFile     // Files the file type policy kept from being written
+	ManifestFiles  int               // Files listed in the manifest, for folder transfers
+	Checksums      map[string]string // SHA-256 of each written file, by path
+	Mode           security.EncryptionMode
+
+	SentAt           time.Time     // When the sender stamped the payload, on its clock
+	SenderClockAhead time.Duration // How far at least the sender's clock runs ahead of ours
+}
 
-	// Try different encryption modes for maximum compatibility
-	modes := []security.EncryptionMode{
-		security.ModeCBC,      // Most compatible
-		security.ModeGCM,      // Modern authenticated
-		security.ModeChaCha20, // High security
-		security.ModeHybrid,   // Future-proof
-	}
+// senderKeyContexts are the contexts senders pass to StrengthenTransferCode
+// for folder manifests, single-file payloads and progressive sends
+var senderKeyContexts = []string{"manifest", "payload", "file"}
 
+// processReceivedDataWithMetadata handles processing of received data with enhanced metadata.
+// bound is the metadata the sender authenticated with the data; once the data
+// decrypts it replaces the matching fields of metadata.
+func (btm *BulletproofTransferManager) processReceivedDataWithMetadata(ctx context.Context, encryptedData, bound []byte, transferCode, receivedDir string, metadata *transport.TransferMetadata) (*receivedPayload, error) {
+	// Senders strengthen the code with a context naming what they encrypted,
+	// so try each one
+	var decryptedData []byte
+	var mode security.EncryptionMode
 	var lastErr error
 	decryptionSucceeded := false
 
-	for _, mode := range modes {
-		// Strengthen the transfer code before using it for decryption
-		strengthenedKey, _, err := btm.advancedSecurity.StrengthenTransferCode(transferCode, "decryption")
+	for _, keyContext := range senderKeyContexts {
+		strengthenedKey, _, err := btm.advancedSecurity.StrengthenTransferCode(transferCode, keyContext)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to strengthen transfer code: %w", err)
+			return nil, fmt.Errorf("failed to strengthen transfer code: %w", err)
 		}
 
-		decryptedData, lastErr = btm.advancedSecurity.DecryptWithMode(encryptedData, strengthenedKey, mode)
+		decryptedData, mode, lastErr = btm.decryptContext(ctx, encryptedData, strengthenedKey, bound)
 		if lastErr == nil {
 			decryptionSucceeded = true
 			break
 		}
+		if ctx.Err() != nil {
+			return nil, lastErr
+		}
 	}
 
 	if !decryptionSucceeded {
-		return nil, 0, fmt.Errorf("failed to decrypt data with any supported encryption mode: %w", lastErr)
+		return nil, fmt.Errorf("%w with any supported encryption mode: %w", errUndecryptable, lastErr)
+	}
+	if err := applyBoundMetadata(metadata, bound); err != nil {
+		return nil, err
 	}
 
 	// Create received directory
-	receivedDir := filepath.Join(btm.targetDataDir, "received")
 	if err := os.MkdirAll(receivedDir, 0755); err != nil {
-		return nil, 0, fmt.Errorf("failed to create received directory: %w", err)
+		return nil, fmt.Errorf("failed to create received directory: %w", err)
+	}
+
+	if bytes.HasPrefix(decryptedData, archiveMagic) {
+		received, err := btm.processArchivePayload(decryptedData, receivedDir, transferCode)
+		if err != nil {
+			return nil, err
+		}
+		received.Mode = mode
+		return received, nil
+	}
+
+	if bytes.HasPrefix(decryptedData, fileFrameMagic) {
+		received, err := btm.processFileFrameReceived(decryptedData, receivedDir, transferCode)
+		if err != nil {
+			return nil, err
+		}
+		received.Mode = mode
+		return received, nil
 	}
 
 	// Try to parse as file manifest (multiple files or folder)
-	var manifest FileManifest
-	if err := json.Unmarshal(decryptedData, &manifest); err == nil && len(manifest.Files) > 0 {
-		return btm.processFileManifestWithProgress(manifest, receivedDir, transferCode)
+	manifest, isManifest, err := decodeManifest(decryptedData)
+	if err != nil {
+		return nil, err
+	}
+	if isManifest {
+		if err := btm.checkReceiveSize(manifest.dataSize()); err != nil {
+			return nil, err
+		}
+		content := manifest.signedContent()
+		received, err := btm.processFileManifestWithProgress(*manifest, receivedDir, transferCode)
+		if err != nil {
+			return nil, err
+		}
+		received.Sender = btm.recognizeSender(manifest.Sender, transferCode, content)
+		received.SentAt = manifest.SentAt
+		received.Mode = mode
+		return received, nil
 	}
 
 	// Try to parse as single file payload with embedded filename
 	var filePayload struct {
-		OriginalName string `json:"original_name"`
-		Data         []byte `json:"data"`
+		OriginalName string          `json:"original_name"`
+		Data         []byte          `json:"data"`
+		Hash         string          `json:"hash,omitempty"`
+		Sender       *SenderIdentity `json:"sender,omitempty"`
+		SentAt       time.Time       `json:"sent_at,omitempty"`
 	}
 
 	if err := json.Unmarshal(decryptedData, &filePayload); err == nil && filePayload.OriginalName != "" {
 		// Single file with embedded filename
-		filename := btm.sanitizeFilename(filePayload.OriginalName)
-		filePath := filepath.Join(receivedDir, filename)
-
-		if err := os.WriteFile(filePath, filePayload.Data, 0644); err != nil {
-			return nil, 0, fmt.Errorf("failed to write received file: %w", err)
+		received, err := btm.saveSingleFile(filePayload.OriginalName, filePayload.Data, filePayload.Hash, 0644, receivedDir)
+		if err != nil {
+			return nil, err
+		}
+		if filePayload.Sender != nil {
+			received.Sender = btm.recognizeSender(filePayload.Sender, transferCode, signedContent{
+				Name:     filePayload.OriginalName,
+				Size:     int64(len(filePayload.Data)),
+				Checksum: checksumOf(filePayload.Data),
+				SentAt:   filePayload.SentAt,
+			})
 		}
+		received.SentAt = filePayload.SentAt
+		received.Mode = mode
+		return received, nil
+	}
 
-		btm.updateStatus(fmt.Sprintf("Received file: %s", filename))
-		return []string{filePath}, int64(len(filePayload.Data)), nil
+	if err := btm.checkReceiveSize(int64(len(decryptedData))); err != nil {
+		return nil, err
 	}
 
-	// Raw file data (legacy format)
-	filename := fmt.Sprintf("received_file_%d", time.Now().Unix())
-	if metadata != nil && metadata.FileName != "" {
-		filename = btm.sanitizeFilename(metadata.FileName)
-	} else if btm.transferID != "" {
-		filename = fmt.Sprintf("file_%s", btm.transferID)
+	// Raw file data (legacy format) carries no name of its own, so only its
+	// content can be judged
+	blocked, err := btm.checkFileType("", decryptedData)
+	if err != nil {
+		return nil, err
+	}
+	if blocked != nil {
+		blocked.Path = metadata.FileName
+		return &receivedPayload{Degraded: true, Blocked: []MissingFile{*blocked}, Mode: mode}, nil
 	}
+	received, err := btm.saveRawPayload(decryptedData, receivedDir, metadata)
+	if err != nil {
+		return nil, err
+	}
+	received.Mode = mode
+	return received, nil
+}
 
+// saveSingleFile checks a single received file called name against its
+// checksum, if the sender sent one, and the file type policy, and writes it
+// into receivedDir
+func (btm *BulletproofTransferManager) saveSingleFile(name string, data []byte, hash string, perm os.FileMode, receivedDir string) (*receivedPayload, error) {
+	if err := btm.checkReceiveSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+	filename := btm.sanitizeFilename(name)
 	filePath := filepath.Join(receivedDir, filename)
-	if err := os.WriteFile(filePath, decryptedData, 0644); err != nil {
-		return nil, 0, fmt.Errorf("failed to write received file: %w", err)
+
+	// Older senders don't include a checksum, leaving nothing to verify against
+	verified := false
+	if btm.integrityChecks && hash != "" {
+		if err := verifyChecksum(data, hash); err != nil {
+			return nil, fmt.Errorf("file %s: %w", filename, err)
+		}
+		verified = true
+	}
+
+	blocked, err := btm.checkFileType(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	if blocked != nil {
+		return &receivedPayload{
+			NamesPreserved: true,
+			Verified:       verified,
+			Blocked:        []MissingFile{*blocked},
+		}, nil
+	}
+
+	if err := writeReceivedFile(filePath, data, perm); err != nil {
+		return nil, fmt.Errorf("failed to write received file: %w", err)
 	}
 
 	btm.updateStatus(fmt.Sprintf("Received file: %s", filename))
-	return []string{filePath}, int64(len(decryptedData)), nil
+	return &receivedPayload{
+		Files:          []string{filePath},
+		TotalBytes:     int64(len(data)),
+		NamesPreserved: true,
+		Verified:       verified,
+		Checksums:      map[string]string{filePath: checksumOf(data)},
+	}, nil
 }
 
 // sanitizeFilename ensures filenames are safe for the filesystem
@@ -1072,10 +1768,14 @@ func (btm *BulletproofTransferManager) sanitizeFilename(filename string) string
 
 // FileManifest represents multiple files or folder structure
 type FileManifest struct {
+	Version    int                 `json:"version,omitempty"` // Layout of the manifest, see ManifestVersion
 	Files      map[string]FileInfo `json:"files"`
 	FolderName string              `json:"folder_name,omitempty"`
 	TotalFiles int                 `json:"total_files"`
 	TotalSize  int64               `json:"total_size"`
+	Sender     *SenderIdentity     `json:"sender,omitempty"`
+	EmbedLimit int64               `json:"embed_limit,omitempty"` // Largest file the sender sent contents for
+	SentAt     time.Time           `json:"sent_at,omitempty"`     // When the sender built the manifest, on its clock
 }
 
 type FileInfo struct {
@@ -1085,95 +1785,223 @@ type FileInfo struct {
 	Size         int64  `json:"size"`
 	Hash         string `json:"hash"`
 	Data         []byte `json:"data,omitempty"`
+	SendError    string `json:"send_error,omitempty"` // Why the sender could not include the file
+	Omitted      bool   `json:"omitted,omitempty"`    // Contents left out for being larger than the embed limit
+}
+
+// processFileManifestWithProgress handles multiple files/folder reconstruction with progress.
+// Files are rebuilt in a staging directory and only moved into receivedDir once
+// the whole manifest has been written.
+func (btm *BulletproofTransferManager) processFileManifestWithProgress(manifest FileManifest, receivedDir, _ string) (*receivedPayload, error) {
+	// A rejecting policy refuses the transfer before anything is written
+	if btm.fileTypePolicy.Action == FileTypeActionReject {
+		for _, fileInfo := range manifest.Files {
+			if fileInfo.IsDirectory || fileInfo.Data == nil {
+				continue
+			}
+			if _, err := btm.checkFileType(fileInfo.RelativePath, fileInfo.Data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return btm.stageReceivedFiles(receivedDir, func(stagingDir string) (*receivedPayload, error) {
+		return btm.reconstructManifest(manifest, stagingDir)
+	})
+}
+
+// stageReceivedFiles has write rebuild a transfer in a staging directory and
+// moves what it wrote into receivedDir once it has all been written. write
+// returns the paths it wrote even when it fails part way through.
+func (btm *BulletproofTransferManager) stageReceivedFiles(receivedDir string, write func(stagingDir string) (*receivedPayload, error)) (*receivedPayload, error) {
+	// Staging beside the destination keeps the final renames atomic
+	stagingParent := receivedDir
+	if btm.tempDir != "" {
+		stagingParent = btm.tempDir
+	}
+	stagingDir, err := os.MkdirTemp(stagingParent, ".trustdrop-staging-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	staged, err := write(stagingDir)
+	if err != nil {
+		// Files of a transfer refused by policy are never kept
+		refused := errors.Is(err, ErrFileTypeBlocked) || errors.Is(err, ErrTransferTooLarge)
+		if btm.keepPartialReceives && len(staged.Files) > 0 && !refused {
+			if kept, commitErr := commitStagedFiles(stagingDir, receivedDir, staged.Files); commitErr == nil {
+				btm.updateStatus(fmt.Sprintf("Kept %d partially received files", len(kept)))
+			}
+		}
+		return nil, err
+	}
+
+	processedFiles, err := commitStagedFiles(stagingDir, receivedDir, staged.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	// Committed paths come back in staging order
+	checksums := make(map[string]string, len(staged.Checksums))
+	for i, finalPath := range processedFiles {
+		if checksum, ok := staged.Checksums[staged.Files[i]]; ok {
+			checksums[finalPath] = checksum
+		}
+	}
+
+	btm.updateStatus(fmt.Sprintf("Successfully reconstructed %d files", len(processedFiles)))
+	staged.Files = processedFiles
+	staged.Checksums = checksums
+	return staged, nil
 }
 
-// processFileManifestWithProgress handles multiple files/folder reconstruction with progress
-func (btm *BulletproofTransferManager) processFileManifestWithProgress(manifest FileManifest, receivedDir, _ string) ([]string, int64, error) {
-	var processedFiles []string
-	var totalBytes int64
+// reconstructManifest writes the manifest contents under receivedDir. The
+// returned payload lists every path written so far even when it fails part way
+// through; files are only written once their checksum has been verified.
+func (btm *BulletproofTransferManager) reconstructManifest(manifest FileManifest, receivedDir string) (*receivedPayload, error) {
+	payload := &receivedPayload{
+		NamesPreserved: true,
+		Verified:       btm.integrityChecks,
+		ManifestFiles:  manifest.TotalFiles,
+		Checksums:      make(map[string]string),
+	}
 
 	// Create base folder if specified
 	baseDir := receivedDir
 	if manifest.FolderName != "" {
 		baseDir = filepath.Join(receivedDir, btm.sanitizeFilename(manifest.FolderName))
 		if err := os.MkdirAll(baseDir, 0755); err != nil {
-			return nil, 0, fmt.Errorf("failed to create folder %s: %w", manifest.FolderName, err)
+			return payload, fmt.Errorf("failed to create folder %s: %w", manifest.FolderName, err)
 		}
 	}
 
 	btm.updateStatus(fmt.Sprintf("Reconstructing %d files from transfer...", manifest.TotalFiles))
+	for _, fileInfo := range manifest.Files {
+		if !fileInfo.IsDirectory {
+			btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStatePending, "")
+		}
+	}
 
 	// Process each file with progress updates
-	processedCount := 0
 	for _, fileInfo := range manifest.Files {
-		processedCount++
-
-		// Sanitize the relative path
-		relativePath := btm.sanitizeFilename(fileInfo.RelativePath)
-		fullPath := filepath.Join(baseDir, relativePath)
+		// Resolve the relative path without letting it escape baseDir
+		fullPath, err := safeJoin(baseDir, fileInfo.RelativePath)
+		if err != nil {
+			return payload, fmt.Errorf("rejected unsafe path in transfer: %w", err)
+		}
 
 		if fileInfo.IsDirectory {
 			// Create directory
 			if err := os.MkdirAll(fullPath, 0755); err != nil {
-				return nil, 0, fmt.Errorf("failed to create directory %s: %w", fullPath, err)
+				return payload, fmt.Errorf("failed to create directory %s: %w", fullPath, err)
 			}
-			processedFiles = append(processedFiles, fullPath)
+			payload.Files = append(payload.Files, fullPath)
 		} else {
 			// Create parent directories if needed
 			parentDir := filepath.Dir(fullPath)
 			if err := os.MkdirAll(parentDir, 0755); err != nil {
-				return nil, 0, fmt.Errorf("failed to create parent directory %s: %w", parentDir, err)
+				return payload, fmt.Errorf("failed to create parent directory %s: %w", parentDir, err)
 			}
 
+			// Empty files carry no data but are still recreated
 			var fileData []byte
-			if len(fileInfo.Data) > 0 {
+			placeholder := false
+			if fileInfo.SendError == "" && (len(fileInfo.Data) > 0 || fileInfo.Size == 0) {
+				if btm.integrityChecks && fileInfo.Hash != "" {
+					if err := verifyChecksum(fileInfo.Data, fileInfo.Hash); err != nil {
+						btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, err.Error())
+						return payload, fmt.Errorf("file %s: %w", fileInfo.RelativePath, err)
+					}
+				} else {
+					payload.Verified = false
+				}
 				fileData = fileInfo.Data
+
+				blocked, err := btm.checkFileType(fileInfo.RelativePath, fileData)
+				if err != nil {
+					return payload, err
+				}
+				if blocked != nil {
+					payload.Blocked = append(payload.Blocked, *blocked)
+					btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, blocked.Reason)
+					continue
+				}
 			} else {
+				// Nothing was sent for this file, so there is nothing to verify
+				payload.Verified = false
+
+				if fileInfo.SendError != "" {
+					btm.updateStatus(fmt.Sprintf("Sender could not read file: %s", fileInfo.RelativePath))
+					payload.Missing = append(payload.Missing, MissingFile{Path: fileInfo.RelativePath, Size: fileInfo.Size, Reason: MissingReasonUnreadable})
+					btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, MissingReasonUnreadable)
+					continue
+				}
+
 				// Large file placeholder
-				if fileInfo.Size > 100*1024*1024 {
+				if manifest.omittedForSize(fileInfo) {
+					payload.Missing = append(payload.Missing, MissingFile{Path: fileInfo.RelativePath, Size: fileInfo.Size, Reason: MissingReasonTooLarge})
+					btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, MissingReasonTooLarge)
 					btm.updateStatus(fmt.Sprintf("Large file %s requires separate transfer", fileInfo.RelativePath))
 					placeholderContent := fmt.Sprintf("LARGE FILE PLACEHOLDER\n\nOriginal: %s\nSize: %s\nHash: %s\n\nThis file was too large for the current transfer method.\nPlease transfer large files individually.",
 						fileInfo.OriginalPath, btm.formatBytes(fileInfo.Size), fileInfo.Hash)
 					fileData = []byte(placeholderContent)
 					fullPath = fullPath + ".placeholder.txt"
+					placeholder = true
 				} else {
-					btm.updateStatus(fmt.Sprintf("Skipping incomplete file: %s", fileInfo.RelativePath))
+					btm.updateStatus(fmt.Sprintf("Missing data for file: %s", fileInfo.RelativePath))
+					payload.Missing = append(payload.Missing, MissingFile{Path: fileInfo.RelativePath, Size: fileInfo.Size, Reason: MissingReasonNoData})
+					btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, MissingReasonNoData)
 					continue
 				}
 			}
 
-			if err := os.WriteFile(fullPath, fileData, 0644); err != nil {
-				return nil, 0, fmt.Errorf("failed to write file %s: %w", fullPath, err)
+			if err := writeReceivedFile(fullPath, fileData, 0644); err != nil {
+				btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, err.Error())
+				return payload, fmt.Errorf("failed to write file %s: %w", fullPath, err)
+			}
+			if !placeholder {
+				btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateDone, "")
 			}
 
-			processedFiles = append(processedFiles, fullPath)
-			totalBytes += int64(len(fileData))
+			payload.Files = append(payload.Files, fullPath)
+			payload.TotalBytes += int64(len(fileData))
+			if !placeholder {
+				payload.Checksums[fullPath] = checksumOf(fileData)
+			}
 
 			// Update progress
-			btm.updateProgress(int64(processedCount), int64(manifest.TotalFiles), fileInfo.RelativePath)
+			btm.completeFile(int64(len(fileData)))
+			btm.updateProgress(TransferProgress{
+				FileName:     fileInfo.RelativePath,
+				FileBytes:    int64(len(fileData)),
+				FileSize:     fileInfo.Size,
+				OverallBytes: payload.TotalBytes,
+				OverallSize:  manifest.TotalSize,
+				FilesTotal:   manifest.TotalFiles,
+			})
 		}
 	}
 
-	btm.updateStatus(fmt.Sprintf("Successfully reconstructed %d files", len(processedFiles)))
-	return processedFiles, totalBytes, nil
+	return payload, nil
 }
 
 // processFile handles sending individual files or folders
-func (btm *BulletproofTransferManager) processFile(filePath, transferCode string) (*FileProcessResult, error) {
+func (btm *BulletproofTransferManager) processFile(ctx context.Context, filePath, transferCode string) (*FileProcessResult, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to access file: %w", err)
 	}
 
 	if fileInfo.IsDir() {
-		return btm.processFolder(filePath, transferCode)
+		return btm.processFolder(ctx, filePath, transferCode)
 	} else {
-		return btm.processSingleFile(filePath, transferCode)
+		return btm.processSingleFile(ctx, filePath, transferCode)
 	}
 }
 
 // processFolder handles sending entire folders
-func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode string) (*FileProcessResult, error) {
+func (btm *BulletproofTransferManager) processFolder(ctx context.Context, folderPath, transferCode string) (*FileProcessResult, error) {
 	btm.updateStatus(fmt.Sprintf("Analyzing folder: %s", filepath.Base(folderPath)))
 
 	manifest := FileManifest{
@@ -1181,13 +2009,19 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 		FolderName: filepath.Base(folderPath),
 		TotalFiles: 0,
 		TotalSize:  0,
+		EmbedLimit: btm.maxEmbedSize,
 	}
 
 	// Count files for progress tracking
 	fileCount := 0
+	var folderSize int64
 	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() {
+		if err == nil && !info.IsDir() && specialFileKind(path, info) == "" {
 			fileCount++
+			folderSize += info.Size()
+			if relPath, err := filepath.Rel(folderPath, path); err == nil {
+				btm.updateFileStatus(relPath, info.Size(), FileStatePending, "")
+			}
 		}
 		return nil
 	})
@@ -1196,11 +2030,22 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 		return nil, fmt.Errorf("failed to analyze folder: %w", err)
 	}
 
+	if btm.archivesFolder(fileCount, folderSize) {
+		return btm.processFolderArchive(ctx, folderPath, transferCode)
+	}
+
 	btm.updateStatus(fmt.Sprintf("Processing %d files in folder...", fileCount))
+	btm.enterPhase("")
 	processedFiles := 0
+	var bytesRead int64
+	var unreadable []FileInfo
+	var tooLarge []MissingFile
 
 	// Walk through folder and collect files
 	err = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
 		if err != nil {
 			btm.updateStatus(fmt.Sprintf("Warning: Error accessing %s, skipping", path))
 			return nil
@@ -1215,6 +2060,11 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 			return nil
 		}
 
+		if kind := specialFileKind(path, info); kind != "" {
+			btm.updateStatus(fmt.Sprintf("Warning: Skipping %s (%s cannot be transferred)", relPath, kind))
+			return nil
+		}
+
 		fileInfo := FileInfo{
 			OriginalPath: path,
 			RelativePath: relPath,
@@ -1224,13 +2074,14 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 
 		if !info.IsDir() {
 			processedFiles++
-			btm.updateProgress(int64(processedFiles), int64(fileCount), relPath)
 
-			// Handle files up to 25MB for embedding
-			if info.Size() < 25*1024*1024 {
+			// Contents are sent for files up to the embed limit
+			if btm.embeds(info.Size()) {
 				data, err := os.ReadFile(path)
 				if err != nil {
-					btm.updateStatus(fmt.Sprintf("Warning: Could not read %s, skipping", relPath))
+					// Often a lock held by another program, so try again once the walk is done
+					btm.updateStatus(fmt.Sprintf("Warning: Could not read %s, will retry", relPath))
+					unreadable = append(unreadable, fileInfo)
 					return nil
 				}
 
@@ -1238,6 +2089,9 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 				fileInfo.Hash = hex.EncodeToString(hash[:])
 				fileInfo.Data = data
 				manifest.TotalSize += int64(len(data))
+				bytesRead += int64(len(data))
+				btm.updateFileStatus(relPath, info.Size(), FileStateInProgress, "")
+				btm.updateIncrementalProgress(PhaseReading, bytesRead, relPath)
 			} else {
 				// For larger files, store metadata only
 				btm.updateStatus(fmt.Sprintf("Large file detected: %s (%s) - adding metadata only",
@@ -1253,7 +2107,12 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 				}
 
 				fileInfo.Data = nil
+				fileInfo.Omitted = true
+				tooLarge = append(tooLarge, MissingFile{Path: relPath, Size: info.Size(), Reason: MissingReasonTooLarge})
+				btm.updateFileStatus(relPath, info.Size(), FileStateFailed, "too large to send in a folder")
 				manifest.TotalSize += info.Size()
+				bytesRead += info.Size()
+				btm.updateIncrementalProgress(PhaseReading, bytesRead, relPath)
 			}
 		}
 
@@ -1266,8 +2125,16 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 		return nil, fmt.Errorf("failed to process folder: %w", err)
 	}
 
-	// Serialize and encrypt manifest
-	manifestData, err := json.Marshal(manifest)
+	unsent, err := btm.retryUnreadableFiles(ctx, unreadable, &manifest, &bytesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize and encrypt manifest; the identity signs it as it is encoded
+	manifest.SentAt = time.Now()
+	manifest.Version = ManifestVersion
+	manifest.Sender = btm.senderIdentity(transferCode, manifest.signedContent())
+	manifestData, err := encodeManifest(&manifest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create folder manifest: %w", err)
 	}
@@ -1281,41 +2148,54 @@ func (btm *BulletproofTransferManager) processFolder(folderPath, transferCode st
 		return nil, fmt.Errorf("failed to strengthen transfer code: %w", err)
 	}
 
-	encryptedData, _, err := btm.advancedSecurity.EncryptWithBestMode(manifestData, strengthenedKey)
-	if err != nil {
-		return nil, fmt.Errorf("encryption failed: %w", err)
-	}
-
-	// Send via transport manager
 	metadata := transport.TransferMetadata{
 		TransferID: transferCode,
 		FileName:   manifest.FolderName,
 		FileSize:   int64(len(manifestData)),
 		Checksum:   hashString,
 	}
+	bound, err := bindMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	btm.updateIncrementalProgress(PhaseEncrypting, bytesRead, manifest.FolderName)
+	encryptedData, mode, err := btm.encryptContext(ctx, manifestData, strengthenedKey, bound)
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %w", err)
+	}
+	btm.updateIncrementalProgress(PhaseTransferring, bytesRead, manifest.FolderName)
 
-	err = btm.transportManager.SendWithFailover(encryptedData, metadata)
+	// Send via transport manager
+	err = btm.sendWithReconnect(ctx, sealTransit(encryptedData, bound), metadata)
 	if err != nil {
 		return nil, fmt.Errorf("transport failed: %w", err)
 	}
+	btm.reportFilesSent(manifest.filesWithData())
 
 	return &FileProcessResult{
-		Size: manifest.TotalSize,
-		Hash: hashString,
+		Size:   manifest.TotalSize,
+		Hash:   hashString,
+		Unsent: append(tooLarge, unsent...),
+		Files:  manifest.sentFiles(),
+		Mode:   mode,
 	}, nil
 }
 
 // processSingleFile handles sending individual files
-func (btm *BulletproofTransferManager) processSingleFile(filePath, transferCode string) (*FileProcessResult, error) {
+func (btm *BulletproofTransferManager) processSingleFile(ctx context.Context, filePath, transferCode string) (*FileProcessResult, error) {
 	// Check file size first to prevent memory issues with large files
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	const maxMemorySize = 100 * 1024 * 1024 // 100MB limit for memory loading
-	if fileInfo.Size() > maxMemorySize {
-		return nil, fmt.Errorf("file too large (%s). Files over 100MB require chunked transfer (not yet implemented)", btm.formatBytes(fileInfo.Size()))
+	if !fileInfo.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file and cannot be transferred", filepath.Base(filePath))
+	}
+
+	if fileInfo.Size() > btm.maxInMemorySize {
+		return nil, btm.inMemoryLimitError(fileInfo.Size())
 	}
 
 	// Read file with proper resource management
@@ -1329,18 +2209,43 @@ func (btm *BulletproofTransferManager) processSingleFile(filePath, transferCode
 		}
 	}()
 
-	data, err := io.ReadAll(file)
+	if btm.binaryFiles {
+		return btm.processFileFrame(ctx, file, fileInfo, transferCode)
+	}
+
+	fileName := filepath.Base(filePath)
+	btm.enterPhase("")
+	btm.updateIncrementalProgress(PhaseReading, 0, fileName)
+
+	data, err := readAllContext(ctx, file, func(read int64) {
+		btm.updateIncrementalProgress(PhaseReading, read, fileName)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Create file payload with preserved filename
+	hash := sha256.Sum256(data)
+	hashString := hex.EncodeToString(hash[:])
+	sentAt := time.Now()
+
+	// Create file payload with preserved filename and checksum
 	filePayload := struct {
-		OriginalName string `json:"original_name"`
-		Data         []byte `json:"data"`
+		OriginalName string          `json:"original_name"`
+		Data         []byte          `json:"data"`
+		Hash         string          `json:"hash,omitempty"`
+		Sender       *SenderIdentity `json:"sender,omitempty"`
+		SentAt       time.Time       `json:"sent_at,omitempty"`
 	}{
 		OriginalName: filepath.Base(filePath),
 		Data:         data,
+		Hash:         hashString,
+		Sender: btm.senderIdentity(transferCode, signedContent{
+			Name:     fileName,
+			Size:     int64(len(data)),
+			Checksum: hashString,
+			SentAt:   sentAt,
+		}),
+		SentAt: sentAt,
 	}
 
 	payloadData, err := json.Marshal(filePayload)
@@ -1348,47 +2253,49 @@ func (btm *BulletproofTransferManager) processSingleFile(filePath, transferCode
 		return nil, fmt.Errorf("failed to create file payload: %w", err)
 	}
 
-	hash := sha256.Sum256(data)
-	hashString := hex.EncodeToString(hash[:])
-
 	// Strengthen the transfer code before using it as an encryption key
 	strengthenedKey, _, err := btm.advancedSecurity.StrengthenTransferCode(transferCode, "payload")
 	if err != nil {
 		return nil, fmt.Errorf("failed to strengthen transfer code: %w", err)
 	}
 
-	encryptedData, _, err := btm.advancedSecurity.EncryptWithBestMode(payloadData, strengthenedKey)
-	if err != nil {
-		return nil, fmt.Errorf("encryption failed: %w", err)
-	}
-
 	metadata := transport.TransferMetadata{
 		TransferID: transferCode,
 		FileName:   filepath.Base(filePath),
 		FileSize:   int64(len(payloadData)),
 		Checksum:   hashString,
 	}
+	bound, err := bindMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
 
-	err = btm.transportManager.SendWithFailover(encryptedData, metadata)
+	btm.updateIncrementalProgress(PhaseEncrypting, int64(len(data)), fileName)
+	encryptedData, mode, err := btm.encryptContext(ctx, payloadData, strengthenedKey, bound)
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %w", err)
+	}
+	btm.updateIncrementalProgress(PhaseTransferring, int64(len(data)), fileName)
+
+	err = btm.sendWithReconnect(ctx, sealTransit(encryptedData, bound), metadata)
 	if err != nil {
 		return nil, fmt.Errorf("transport failed: %w", err)
 	}
 
 	return &FileProcessResult{
-		Size: int64(len(data)),
-		Hash: hashString,
+		Size:  int64(len(data)),
+		Hash:  hashString,
+		Files: []TransferredFile{{Path: filePath, Size: int64(len(data)), Checksum: hashString}},
+		Mode:  mode,
 	}, nil
 }
 
-// recordTransferInBlockchain records transfer in blockchain if enabled
-func (btm *BulletproofTransferManager) recordTransferInBlockchain(result *TransferResult, transferCode string) error {
-	// Initialize blockchain if not already done
-	if btm.blockchain == nil {
-		blockchain, err := blockchain.NewBlockchain(btm.targetDataDir)
-		if err != nil {
-			return fmt.Errorf("failed to initialize blockchain: %w", err)
-		}
-		btm.blockchain = blockchain
+// recordTransferInBlockchain records transfer in blockchain if enabled, along
+// with the outcome of integrity verification. It does nothing when audit
+// logging is unavailable; initAudit has already reported why.
+func (btm *BulletproofTransferManager) recordTransferInBlockchain(result *TransferResult, transferCode, verification string) error {
+	if !btm.LoggingAvailable() {
+		return nil
 	}
 
 	entry := blockchain.TransferEntry{
@@ -1398,30 +2305,139 @@ func (btm *BulletproofTransferManager) recordTransferInBlockchain(result *Transf
 		TotalSize:    result.TotalBytes,
 		Success:      result.Success,
 		Transport:    result.TransportUsed,
+		Verification: verification,
+	}
+	var missing *MissingFilesError
+	if reason, ok := transport.IncompleteReason(result.Error); ok {
+		entry.Error = "transfer did not complete: " + reason
+	} else if errors.As(result.Error, &missing) {
+		entry.Error = missing.Error()
 	}
 
 	return btm.blockchain.AddTransferEntry(entry)
 }
 
-// updateProgress calls the progress callback if set
-func (btm *BulletproofTransferManager) updateProgress(current, total int64, fileName string) {
+// recordIncompleteTransfer records a failed transfer in the blockchain when
+// croc itself reported that it did not complete, so the audit trail carries
+// the reason instead of silently missing the attempt
+func (btm *BulletproofTransferManager) recordIncompleteTransfer(result *TransferResult, transferCode string, err error) {
+	if _, ok := transport.IncompleteReason(err); !ok {
+		return
+	}
+
+	result.Error = err
+	result.TransportUsed = btm.getUsedTransportName()
+	if logErr := btm.recordTransferInBlockchain(result, transferCode, blockchain.VerificationSkipped); logErr != nil {
+		btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", logErr))
+	}
+}
+
+// startProgress resets the progress counters for a new transfer
+func (btm *BulletproofTransferManager) startProgress(start time.Time) {
+	btm.progressMutex.Lock()
+	defer btm.progressMutex.Unlock()
+	btm.progressStart = start
+	btm.completedBytes = 0
+	btm.completedFiles = 0
+	btm.currentFileSize = 0
+	btm.currentPhase = ""
+}
+
+// enterPhase makes phase the current one, reporting whether it changed
+func (btm *BulletproofTransferManager) enterPhase(phase string) bool {
+	btm.progressMutex.Lock()
+	defer btm.progressMutex.Unlock()
+	changed := phase != btm.currentPhase
+	btm.currentPhase = phase
+	return changed
+}
+
+// completeFile counts a finished file of size bytes towards the transfer
+func (btm *BulletproofTransferManager) completeFile(size int64) {
+	btm.progressMutex.Lock()
+	defer btm.progressMutex.Unlock()
+	btm.completedBytes += size
+	btm.completedFiles++
+}
+
+// updateProgress fills in the transfer-wide counters and delivers the update to
+// the progress callback and any Progress channels
+func (btm *BulletproofTransferManager) updateProgress(progress TransferProgress) {
+	btm.progressMutex.Lock()
+	progress.FilesCompleted = btm.completedFiles
+	if progress.Phase == "" {
+		progress.Phase = btm.currentPhase
+	}
+	start := btm.progressStart
+	btm.progressMutex.Unlock()
+
+	if progress.FilesTotal == 0 {
+		progress.FilesTotal = btm.totalFiles
+	}
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 && !start.IsZero() {
+		progress.BytesPerSecond = float64(progress.OverallBytes) / elapsed
+	}
+	btm.recordTransferProgress(progress)
+
 	if btm.progressCallback != nil {
-		btm.progressCallback(current, total, fileName)
+		btm.progressCallback(progress)
+	}
+	btm.subscribers.publishProgress(progress)
+}
+
+// updateIncrementalProgress reports progress within the file currently being
+// sent, announcing each change of phase through the status callback
+func (btm *BulletproofTransferManager) updateIncrementalProgress(phase string, fileBytes int64, fileName string) {
+	if btm.enterPhase(phase) {
+		switch phase {
+		case PhaseReading:
+			btm.updateStatus(fmt.Sprintf("Reading %s...", fileName))
+		case PhaseEncrypting:
+			btm.updateStatus(fmt.Sprintf("Encrypting %s...", fileName))
+		case PhaseTransferring:
+			btm.updateStatus(fmt.Sprintf("Transferring %s...", fileName))
+		}
 	}
+
+	btm.progressMutex.Lock()
+	fileSize, completed := btm.currentFileSize, btm.completedBytes
+	btm.progressMutex.Unlock()
+
+	btm.updateProgress(TransferProgress{
+		FileName:     fileName,
+		FileBytes:    fileBytes,
+		FileSize:     fileSize,
+		OverallBytes: completed + fileBytes,
+		OverallSize:  btm.totalSize,
+		Phase:        phase,
+	})
 }
 
-// updateStatus calls the status callback if set
+// updateStatus delivers a status message to the status callback and any Status channels
 func (btm *BulletproofTransferManager) updateStatus(status string) {
 	if btm.logger != nil {
 		btm.logger.LogInfo(status)
 	}
+	btm.recordTransferStatus(status)
+	btm.statusThrottle.submit(status, btm.deliverStatus)
+}
+
+// deliverStatus passes a throttled status message to the callback and any
+// Status channels
+func (btm *BulletproofTransferManager) deliverStatus(status string) {
 	if btm.statusCallback != nil {
 		btm.statusCallback(status)
 	}
+	btm.subscribers.publishStatus(status)
 }
 
 // formatBytes formats bytes in human-readable format
 func (btm *BulletproofTransferManager) formatBytes(bytes int64) string {
+	return FormatBytes(bytes)
+}
+
+// FormatBytes formats a byte count for display, e.g. "1.5 MB"
+func FormatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -1437,14 +2453,17 @@ func (btm *BulletproofTransferManager) formatBytes(bytes int64) string {
 // GetNetworkStatus returns comprehensive network status and transport availability
 func (btm *BulletproofTransferManager) GetNetworkStatus() map[string]interface{} {
 	status := map[string]interface{}{
-		"network_profile":      btm.networkProfile,
-		"network_restrictions": btm.networkRestrictions,
-		"adaptive_settings":    btm.adaptiveSettings,
-		"last_check":           btm.lastNetworkCheck,
+		"network_profile":       btm.networkProfile,
+		"network_restrictions":  btm.networkRestrictions,
+		"adaptive_settings":     btm.adaptiveSettings,
+		"last_check":            btm.lastNetworkCheck,
+		"encryption_throughput": btm.EncryptionThroughput(),
+		"analysis_complete":     btm.networkAnalyzed,
 	}
 
 	if btm.transportManager != nil {
 		status["transport_status"] = btm.transportManager.GetTransportStatus()
+		status["expected_transport"] = btm.transportManager.ExpectedTransport()
 	}
 
 	return status
@@ -1452,39 +2471,21 @@ func (btm *BulletproofTransferManager) GetNetworkStatus() map[string]interface{}
 
 // Cancel cancels the current transfer
 func (btm *BulletproofTransferManager) Cancel() {
-	if btm.cancelFunction != nil {
-		btm.cancelFunction()
+	btm.mutex.Lock()
+	for _, transfer := range btm.transfers {
+		transfer.cancel()
 	}
+	btm.mutex.Unlock()
+
 	btm.updateStatus("Transfer cancelled by user")
 }
 
-// Close cleans up resources
+// Close cancels transfers in flight without waiting for them and releases
+// resources. Use Shutdown to wait for transfers to unwind first.
 func (btm *BulletproofTransferManager) Close() error {
 	btm.Cancel()
-
-	var errors []error
-
-	if btm.transportManager != nil {
-		if err := btm.transportManager.Close(); err != nil {
-			errors = append(errors, err)
-		}
-	}
-
-	if btm.connectionPool != nil {
-		btm.connectionPool.Close()
-	}
-
-	if btm.logger != nil {
-		if err := btm.logger.Close(); err != nil {
-			errors = append(errors, err)
-		}
-	}
-
-	if len(errors) > 0 {
-		return fmt.Errorf("cleanup errors: %v", errors)
-	}
-
-	return nil
+	btm.stopTransfers()
+	return shutdownError(btm.releaseResources())
 }
 
 // DetectNetworkType determines the current network environment
@@ -1503,9 +2504,12 @@ func (btm *BulletproofTransferManager) prepareFileData(filePath, transferCode st
 		return nil, transport.TransferMetadata{}, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	const maxMemorySize = 100 * 1024 * 1024 // 100MB limit
-	if fileInfo.Size() > maxMemorySize {
-		return nil, transport.TransferMetadata{}, fmt.Errorf("file too large (%s). Files over 100MB require chunked transfer", btm.formatBytes(fileInfo.Size()))
+	if !fileInfo.Mode().IsRegular() {
+		return nil, transport.TransferMetadata{}, fmt.Errorf("%s is not a regular file and cannot be transferred", filepath.Base(filePath))
+	}
+
+	if fileInfo.Size() > btm.maxInMemorySize {
+		return nil, transport.TransferMetadata{}, btm.inMemoryLimitError(fileInfo.Size())
 	}
 
 	// Read file with proper resource management
@@ -1531,24 +2535,28 @@ func (btm *BulletproofTransferManager) prepareFileData(filePath, transferCode st
 		return nil, transport.TransferMetadata{}, fmt.Errorf("failed to strengthen transfer code: %w", err)
 	}
 
-	encryptedData, _, err := btm.advancedSecurity.EncryptWithBestMode(data, strengthenedKey)
-	if err != nil {
-		return nil, transport.TransferMetadata{}, err
-	}
-
 	// Calculate checksum
 	hash := sha256.Sum256(data)
 	checksum := hex.EncodeToString(hash[:])
 
-	// Create metadata
+	// Create metadata, authenticated along with the data
 	metadata := transport.TransferMetadata{
 		TransferID: transferCode,
 		FileName:   filepath.Base(filePath),
 		FileSize:   fileInfo.Size(),
 		Checksum:   checksum,
 	}
+	bound, err := bindMetadata(metadata)
+	if err != nil {
+		return nil, transport.TransferMetadata{}, err
+	}
 
-	return encryptedData, metadata, nil
+	encryptedData, _, err := btm.advancedSecurity.EncryptWithBestMode(data, strengthenedKey, bound)
+	if err != nil {
+		return nil, transport.TransferMetadata{}, err
+	}
+
+	return sealTransit(encryptedData, bound), metadata, nil
 }
 
 // SendWithModernReliability uses 2024 best practices for maximum reliability
@@ -1557,6 +2565,10 @@ func (btm *BulletproofTransferManager) SendWithModernReliability(filePaths []str
 
 	// Initialize modern systems
 	progressiveManager := transport.NewProgressiveTransportManager()
+	if err := progressiveManager.SetICEServers(btm.iceServers); err != nil {
+		return nil, fmt.Errorf("invalid ICE servers: %w", err)
+	}
+	progressiveManager.SetNetworkType(btm.transportManager.GetNetworkProfile().NetworkType)
 	errorClassifier := NewNetworkErrorClassifier()
 	defer progressiveManager.Close()
 
@@ -1640,6 +2652,10 @@ func (btm *BulletproofTransferManager) ReceiveWithModernReliability(transferCode
 
 	// Initialize modern systems
 	progressiveManager := transport.NewProgressiveTransportManager()
+	if err := progressiveManager.SetICEServers(btm.iceServers); err != nil {
+		return nil, fmt.Errorf("invalid ICE servers: %w", err)
+	}
+	progressiveManager.SetNetworkType(btm.transportManager.GetNetworkProfile().NetworkType)
 	errorClassifier := NewNetworkErrorClassifier()
 	defer progressiveManager.Close()
 
@@ -0,0 +1,86 @@
+package transfer
+
+import "sync"
+
+// subscriberBufferSize is how many undelivered updates a subscriber channel
+// holds before new ones are dropped
+const subscriberBufferSize = 64
+
+// subscribers fans progress and status updates out to channels returned by
+// Progress and Status. Channels are closed when the transfer in flight (or
+// the next one, if none is running) finishes.
+type subscribers struct {
+	mutex    sync.Mutex
+	progress []chan TransferProgress
+	status   []chan string
+}
+
+// Progress returns a channel of progress updates for the transfer in flight,
+// or the next transfer if none is running. The channel is closed when that
+// transfer finishes. Updates are dropped rather than blocking the transfer
+// when the channel is full.
+func (btm *BulletproofTransferManager) Progress() <-chan TransferProgress {
+	ch := make(chan TransferProgress, subscriberBufferSize)
+
+	btm.subscribers.mutex.Lock()
+	btm.subscribers.progress = append(btm.subscribers.progress, ch)
+	btm.subscribers.mutex.Unlock()
+	return ch
+}
+
+// Status returns a channel of status messages until the transfer in flight,
+// or the next transfer if none is running, finishes. Messages are dropped
+// rather than blocking the transfer when the channel is full.
+func (btm *BulletproofTransferManager) Status() <-chan string {
+	ch := make(chan string, subscriberBufferSize)
+
+	btm.subscribers.mutex.Lock()
+	btm.subscribers.status = append(btm.subscribers.status, ch)
+	btm.subscribers.mutex.Unlock()
+	return ch
+}
+
+// hasProgressSubscribers reports whether any Progress channel is open
+func (s *subscribers) hasProgressSubscribers() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.progress) > 0
+}
+
+// publishProgress delivers a progress update to every channel without blocking
+func (s *subscribers) publishProgress(progress TransferProgress) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, ch := range s.progress {
+		select {
+		case ch <- progress:
+		default:
+		}
+	}
+}
+
+// publishStatus delivers a status message to every channel without blocking
+func (s *subscribers) publishStatus(status string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, ch := range s.status {
+		select {
+		case ch <- status:
+		default:
+		}
+	}
+}
+
+// closeAll closes and forgets every subscriber channel
+func (s *subscribers) closeAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, ch := range s.progress {
+		close(ch)
+	}
+	for _, ch := range s.status {
+		close(ch)
+	}
+	s.progress = nil
+	s.status = nil
+}
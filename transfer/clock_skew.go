@@ -0,0 +1,44 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+)
+
+// clockSkewWarnThreshold is how far a sender's clock may run ahead of this
+// device's before the receiver warns about it
+const clockSkewWarnThreshold = 2 * time.Minute
+
+// senderClockAhead estimates how far the sender's clock runs ahead of this
+// device's from when the sender stamped a payload (sentAt, on its clock) and
+// when the payload arrived (receivedAt, on ours). Payloads are stamped before
+// the sender waits for its receiver, so one arriving late says nothing about
+// the clocks, but one arriving before it was sent shows the sender's clock is
+// ahead by at least the difference. It returns 0 when no skew is shown.
+func senderClockAhead(sentAt, receivedAt time.Time) time.Duration {
+	if sentAt.IsZero() {
+		return 0
+	}
+	return max(sentAt.Sub(receivedAt), 0)
+}
+
+// checkClockSkew works out how far ahead the sender's clock runs for a
+// payload that arrived at receivedAt, warning when it is far enough for
+// timestamps from the two devices to disagree
+func (btm *BulletproofTransferManager) checkClockSkew(received *receivedPayload, receivedAt time.Time) time.Duration {
+	ahead := senderClockAhead(received.SentAt, receivedAt)
+	if ahead >= clockSkewWarnThreshold {
+		btm.updateStatus(fmt.Sprintf("Warning: The sender's clock is at least %v ahead of this device's; check the date and time settings on both, as times recorded on each will disagree",
+			ahead.Round(time.Second)))
+	}
+	return ahead
+}
+
+// senderSentAt returns when the sender sent a payload on this device's clock,
+// or the zero time if the sender did not say
+func (p *receivedPayload) senderSentAt() time.Time {
+	if p.SentAt.IsZero() {
+		return time.Time{}
+	}
+	return p.SentAt.Add(-p.SenderClockAhead)
+}
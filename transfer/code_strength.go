@@ -0,0 +1,158 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/schollz/croc/v10/src/mnemonicode"
+
+	"trustdrop-bulletproof/internal"
+)
+
+// DefaultMinCodeBits is the least estimated code entropy a send accepts
+// unless another minimum is set. Generated codes carry about 45 bits.
+const DefaultMinCodeBits = 40
+
+// strongCodeBits is the estimated entropy from which a code counts as strong
+const strongCodeBits = 45
+
+// maxMinCodeBits caps the configurable minimum
+const maxMinCodeBits = 256
+
+// Code strength ratings
+const (
+	CodeStrengthWeak   = "weak"   // Below the minimum; sends are refused
+	CodeStrengthFair   = "fair"   // Allowed, but guessable with effort
+	CodeStrengthStrong = "strong" // As strong as a generated code or better
+)
+
+// ErrWeakCode is matched by errors.Is when a send is refused because its
+// code is too easy to guess
+var ErrWeakCode = errors.New("transfer code is too weak")
+
+// mnemonicWords indexes the word list generated codes are built from
+var mnemonicWords = func() map[string]bool {
+	words := make(map[string]bool, len(mnemonicode.WordList))
+	for _, word := range mnemonicode.WordList {
+		words[word] = true
+	}
+	return words
+}()
+
+// EstimateCodeEntropy estimates how many bits of guessing a transfer code
+// takes. The code is split into words at separators; a word from the
+// generated-code word list counts for that list's size, and any other word
+// for the character classes it uses and its distinct characters. A repeated
+// word counts once. Codes this process generated get the entropy they were
+// generated with, so a generated code with a number such as 0000 is not
+// mistaken for a weak one; a typed code of the same form is estimated like
+// any other.
+func EstimateCodeEntropy(code string) float64 {
+	if internal.WasGenerated(code) {
+		words := strings.Count(code, "-")
+		return float64(words)*math.Log2(float64(len(mnemonicode.WordList))) + math.Log2(10000)
+	}
+
+	isSeparator := func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || unicode.IsSpace(r)
+	}
+
+	bits := 0.0
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(code, isSeparator) {
+		key := strings.ToLower(word)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if mnemonicWords[key] {
+			bits += math.Log2(float64(len(mnemonicode.WordList)))
+			continue
+		}
+		bits += characterEntropy(word)
+	}
+	return bits
+}
+
+// characterEntropy estimates the entropy of word from the character classes
+// it uses and how many distinct characters it contains
+func characterEntropy(word string) float64 {
+	var lower, upper, digit, other bool
+	distinct := make(map[rune]bool)
+	for _, r := range word {
+		distinct[r] = true
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	// Repeated characters add little, so count at most twice the distinct ones
+	length := min(len([]rune(word)), 2*len(distinct))
+	return float64(length) * math.Log2(float64(pool))
+}
+
+// CodeStrength rates code against minBits, returning one of the
+// CodeStrength* ratings and the estimated entropy
+func CodeStrength(code string, minBits float64) (string, float64) {
+	bits := EstimateCodeEntropy(code)
+	switch {
+	case bits < minBits:
+		return CodeStrengthWeak, bits
+	case bits < strongCodeBits:
+		return CodeStrengthFair, bits
+	default:
+		return CodeStrengthStrong, bits
+	}
+}
+
+// SetMinCodeBits sets the least estimated code entropy a send accepts.
+// Weak codes directly weaken the key derived from them. Zero turns the check
+// off.
+func (btm *BulletproofTransferManager) SetMinCodeBits(bits float64) error {
+	if bits < 0 || bits > maxMinCodeBits {
+		return fmt.Errorf("minimum code entropy must be between 0 and %d bits, got %g", maxMinCodeBits, bits)
+	}
+	btm.minCodeBits = bits
+	return nil
+}
+
+// MinCodeBits returns the least estimated code entropy a send accepts
+func (btm *BulletproofTransferManager) MinCodeBits() float64 {
+	return btm.minCodeBits
+}
+
+// checkCodeStrength refuses a send whose code falls below the minimum
+func (btm *BulletproofTransferManager) checkCodeStrength(transferCode string) error {
+	if strength, bits := CodeStrength(transferCode, btm.minCodeBits); strength == CodeStrengthWeak {
+		return fmt.Errorf("%w: it has about %.0f bits of entropy and at least %.0f are required; use a generated code or a longer one mixing words, digits and symbols",
+			ErrWeakCode, bits, btm.minCodeBits)
+	}
+	return nil
+}
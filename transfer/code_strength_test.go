@@ -0,0 +1,50 @@
+package transfer
+
+import (
+	"testing"
+
+	"trustdrop-bulletproof/internal"
+)
+
+func TestGeneratedCodesPassMinimum(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code := internal.GetRandomName()
+		if strength, bits := CodeStrength(code, DefaultMinCodeBits); strength != CodeStrengthStrong {
+			t.Errorf("generated code %q rated %s at %.1f bits", code, strength, bits)
+		}
+	}
+}
+
+func TestWeakCodesRefused(t *testing.T) {
+	for _, code := range []string{"", "1234", "password", "aaaaaaaaaaaaaaaa"} {
+		if strength, bits := CodeStrength(code, DefaultMinCodeBits); strength != CodeStrengthWeak {
+			t.Errorf("code %q rated %s at %.1f bits", code, strength, bits)
+		}
+	}
+}
+
+func TestTypedCodesInGeneratedFormAreEstimated(t *testing.T) {
+	for _, code := range []string{"acid-acid-acid-0000", "acid-jet-quick-0000", "acid-jet-quick-1111"} {
+		if strength, bits := CodeStrength(code, DefaultMinCodeBits); strength != CodeStrengthWeak {
+			t.Errorf("typed code %q rated %s at %.1f bits", code, strength, bits)
+		}
+	}
+}
+
+func TestRepeatedWordsCountOnce(t *testing.T) {
+	tests := []struct {
+		repeated, single string
+	}{
+		{"apple-apple-apple-apple", "apple"},
+		{"Apple apple APPLE", "apple"},
+		{"xq7#-xq7#-xq7#", "xq7#"},
+	}
+	for _, tt := range tests {
+		if got, want := EstimateCodeEntropy(tt.repeated), EstimateCodeEntropy(tt.single); got != want {
+			t.Errorf("EstimateCodeEntropy(%q) = %.1f, want %.1f as for %q", tt.repeated, got, want, tt.single)
+		}
+	}
+	if strength, bits := CodeStrength("apple-apple-apple-apple", DefaultMinCodeBits); strength != CodeStrengthWeak {
+		t.Errorf("apple-apple-apple-apple rated %s at %.1f bits", strength, bits)
+	}
+}
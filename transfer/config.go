@@ -0,0 +1,224 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// ConfigFileName is the optional settings file read from the data directory
+const ConfigFileName = "trustdrop.json"
+
+// Config is the optional settings file. Sections and fields that are left out
+// keep their defaults.
+type Config struct {
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// AuditLogging set to false keeps no record of transfers in the ledger
+	AuditLogging *bool `json:"audit_logging,omitempty"`
+
+	// SummaryPath receives a JSON summary of each finished transfer; "-"
+	// writes it to standard output
+	SummaryPath string `json:"summary_path,omitempty"`
+
+	// ICEServers replaces the default STUN and TURN servers
+	ICEServers *transport.ICEServers `json:"ice_servers,omitempty"`
+
+	// MinCodeBits is the least estimated code entropy a send accepts; 0 accepts any code
+	MinCodeBits *float64 `json:"min_code_bits,omitempty"`
+
+	// MaxEmbedSize is the largest folder file whose contents are sent, in bytes
+	MaxEmbedSize int64 `json:"max_embed_size,omitempty"`
+
+	// MaxFolderFiles and MaxFolderBytes bound the folders in one send before
+	// it needs confirming; negative turns a limit off
+	MaxFolderFiles int   `json:"max_folder_files,omitempty"`
+	MaxFolderBytes int64 `json:"max_folder_bytes,omitempty"`
+
+	// MaxReceiveSize is the most file data one receive accepts, in bytes; 0 accepts any size
+	MaxReceiveSize int64 `json:"max_receive_size,omitempty"`
+
+	// Archive sends folders as a single tar archive
+	Archive *ArchiveConfig `json:"archive,omitempty"`
+
+	// BinaryFiles sends single files in a binary frame instead of JSON
+	BinaryFiles bool `json:"binary_files,omitempty"`
+
+	// Keepalive is how often idle relay connections are probed, such as
+	// "20s", or "off"; empty keeps the 30 second default
+	Keepalive string `json:"keepalive,omitempty"`
+
+	// Path is "auto" to connect directly to peers on the local network, or
+	// "relay" to always go through the relay; empty only looks for a direct
+	// path when receiving
+	Path string `json:"path,omitempty"`
+
+	// FileTypePolicy limits the kinds of files receives write
+	FileTypePolicy *FileTypePolicy `json:"file_type_policy,omitempty"`
+
+	// Quarantine holds receives until they pass a check
+	Quarantine *QuarantineConfig `json:"quarantine,omitempty"`
+
+	// Hooks are commands run after transfers succeed
+	Hooks *TransferHooks `json:"hooks,omitempty"`
+
+	// Trace writes a protocol trace of every transfer to the state
+	// directory's traces folder, for debugging stuck transfers
+	Trace bool `json:"trace,omitempty"`
+}
+
+// RetryConfig is the "retry" section of the config file. Durations use Go
+// syntax such as "8s" or "2m".
+type RetryConfig struct {
+	MaxAttempts    int     `json:"max_attempts,omitempty"`
+	InitialDelay   string  `json:"initial_delay,omitempty"`
+	BackoffFactor  float64 `json:"backoff_factor,omitempty"`
+	MaxDelay       string  `json:"max_delay,omitempty"`
+	Jitter         *bool   `json:"jitter,omitempty"`
+	MaxHardResets  *int    `json:"max_hard_resets,omitempty"`
+	HardResetDelay string  `json:"hard_reset_delay,omitempty"`
+	MaxReconnects  *int    `json:"max_reconnects,omitempty"`
+}
+
+// LoadConfig reads a config file. A missing file is not an error and gives
+// the zero Config, which changes nothing.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ApplyConfig applies every section present in config to the manager
+func (btm *BulletproofTransferManager) ApplyConfig(config Config) error {
+	if config.Retry != nil {
+		strategy, err := config.Retry.Strategy(btm.retryBaseline)
+		if err != nil {
+			return fmt.Errorf("invalid retry config: %w", err)
+		}
+		if err := btm.SetRetryStrategy(strategy); err != nil {
+			return err
+		}
+	}
+	if config.AuditLogging != nil {
+		btm.SetAuditLogging(*config.AuditLogging)
+	}
+	if config.SummaryPath != "" {
+		btm.SetSummaryPath(config.SummaryPath)
+	}
+	if config.ICEServers != nil {
+		if err := btm.SetICEServers(*config.ICEServers); err != nil {
+			return fmt.Errorf("invalid ice_servers config: %w", err)
+		}
+	}
+	if config.MinCodeBits != nil {
+		if err := btm.SetMinCodeBits(*config.MinCodeBits); err != nil {
+			return err
+		}
+	}
+	if config.MaxEmbedSize != 0 {
+		btm.SetMaxEmbedSize(config.MaxEmbedSize)
+	}
+	if config.MaxFolderFiles != 0 || config.MaxFolderBytes != 0 {
+		btm.SetFolderLimits(config.MaxFolderFiles, config.MaxFolderBytes)
+	}
+	if config.MaxReceiveSize != 0 {
+		btm.SetMaxReceiveSize(config.MaxReceiveSize)
+	}
+	if config.Archive != nil {
+		if err := btm.SetArchiveConfig(*config.Archive); err != nil {
+			return fmt.Errorf("invalid archive config: %w", err)
+		}
+	}
+	if config.BinaryFiles {
+		btm.SetBinaryFiles(true)
+	}
+	if config.Keepalive != "" {
+		interval, err := parseKeepalive(config.Keepalive)
+		if err != nil {
+			return fmt.Errorf("invalid keepalive config: %w", err)
+		}
+		btm.SetKeepaliveInterval(interval)
+	}
+	if config.Path != "" {
+		if err := btm.SetPathMode(config.Path); err != nil {
+			return fmt.Errorf("invalid path config: %w", err)
+		}
+	}
+	if config.FileTypePolicy != nil {
+		if err := btm.SetFileTypePolicy(*config.FileTypePolicy); err != nil {
+			return fmt.Errorf("invalid file_type_policy config: %w", err)
+		}
+	}
+	if config.Quarantine != nil {
+		if err := btm.SetQuarantine(config.Quarantine); err != nil {
+			return fmt.Errorf("invalid quarantine config: %w", err)
+		}
+	}
+	if config.Hooks != nil {
+		if err := btm.SetHooks(*config.Hooks); err != nil {
+			return fmt.Errorf("invalid hooks config: %w", err)
+		}
+	}
+	if config.Trace {
+		if err := btm.SetTrace(true); err != nil {
+			return fmt.Errorf("failed to start protocol trace: %w", err)
+		}
+	}
+	return nil
+}
+
+// Strategy overlays the fields set in rc on base
+func (rc RetryConfig) Strategy(base RetryStrategy) (RetryStrategy, error) {
+	strategy := base
+
+	if rc.MaxAttempts != 0 {
+		strategy.MaxAttempts = rc.MaxAttempts
+	}
+	if rc.BackoffFactor != 0 {
+		strategy.BackoffFactor = rc.BackoffFactor
+	}
+	if rc.Jitter != nil {
+		strategy.JitterEnabled = *rc.Jitter
+	}
+	if rc.MaxHardResets != nil {
+		strategy.MaxHardResets = *rc.MaxHardResets
+	}
+	if rc.MaxReconnects != nil {
+		strategy.MaxReconnects = *rc.MaxReconnects
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"initial_delay", rc.InitialDelay, &strategy.InitialDelay},
+		{"max_delay", rc.MaxDelay, &strategy.MaxDelay},
+		{"hard_reset_delay", rc.HardResetDelay, &strategy.HardResetDelay},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			return base, fmt.Errorf("%s: %w", field.name, err)
+		}
+		*field.dest = duration
+	}
+
+	return strategy, strategy.Validate()
+}
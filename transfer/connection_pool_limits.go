@@ -0,0 +1,130 @@
+package transfer
+
+import (
+	"errors"
+	"time"
+)
+
+// Defaults for the connection pool cap
+const (
+	DefaultMaxPoolConnections = 16
+	DefaultPoolWait           = 10 * time.Second
+)
+
+// ErrConnectionPoolFull is returned by GetConnection when every pooled
+// connection stayed in use for the whole wait
+var ErrConnectionPoolFull = errors.New("connection pool is full")
+
+// PoolStats is a snapshot of a ConnectionPool
+type PoolStats struct {
+	Active         int   // Connections checked out
+	Idle           int   // Connections open and ready for reuse
+	MaxConnections int   // Cap on Active plus Idle
+	Hits           int64 // GetConnection calls served by an idle connection
+	Misses         int64 // GetConnection calls that had to dial
+	Rejected       int64 // GetConnection calls refused because the pool was full
+}
+
+// HitRatio is the share of GetConnection calls served from the pool
+func (s PoolStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// SetMaxConnections caps how many connections the pool keeps open. When the
+// cap is reached GetConnection waits up to wait for a connection to be
+// returned; a wait of zero fails at once. A max below one removes the cap.
+func (cp *ConnectionPool) SetMaxConnections(max int, wait time.Duration) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	cp.maxConnections = max
+	cp.maxWait = wait
+	cp.signalReleased()
+}
+
+// Stats returns current connection counts and reuse figures
+func (cp *ConnectionPool) Stats() PoolStats {
+	cp.mutex.RLock()
+	defer cp.mutex.RUnlock()
+
+	stats := PoolStats{
+		MaxConnections: cp.maxConnections,
+		Hits:           cp.hits,
+		Misses:         cp.misses,
+		Rejected:       cp.rejected,
+	}
+	for _, pooled := range cp.connections {
+		if pooled.inUse {
+			stats.Active++
+		} else {
+			stats.Idle++
+		}
+	}
+	return stats
+}
+
+// reserveSlot makes room for one more connection, evicting the least recently
+// used idle one or waiting for one to be returned. It is called with the
+// mutex held and may release it while waiting.
+func (cp *ConnectionPool) reserveSlot() error {
+	deadline := time.Now().Add(cp.maxWait)
+	for cp.maxConnections > 0 && len(cp.connections) >= cp.maxConnections {
+		if cp.evictIdle() {
+			continue
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			cp.rejected++
+			return ErrConnectionPoolFull
+		}
+
+		released := cp.released
+		cp.mutex.Unlock()
+		timer := time.NewTimer(remaining)
+		select {
+		case <-released:
+		case <-timer.C:
+		}
+		timer.Stop()
+		cp.mutex.Lock()
+	}
+	return nil
+}
+
+// evictIdle closes the least recently used idle connection, reporting whether
+// there was one
+func (cp *ConnectionPool) evictIdle() bool {
+	oldestKey := ""
+	var oldest *PooledConnection
+	for key, pooled := range cp.connections {
+		if !pooled.inUse && (oldest == nil || pooled.lastUsed.Before(oldest.lastUsed)) {
+			oldestKey, oldest = key, pooled
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	if oldest.conn != nil {
+		oldest.conn.Close()
+	}
+	delete(cp.connections, oldestKey)
+	return true
+}
+
+// signalReleased wakes callers waiting for a free slot. The mutex must be held.
+func (cp *ConnectionPool) signalReleased() {
+	close(cp.released)
+	cp.released = make(chan struct{})
+}
+
+// ConnectionPoolStats reports the transfer manager's connection pool usage
+func (btm *BulletproofTransferManager) ConnectionPoolStats() PoolStats {
+	if btm.connectionPool == nil {
+		return PoolStats{}
+	}
+	return btm.connectionPool.Stats()
+}
@@ -0,0 +1,65 @@
+package transfer
+
+import (
+	"context"
+	"io"
+
+	"trustdrop-bulletproof/logging"
+	"trustdrop-bulletproof/security"
+)
+
+// contextReader stops a read with a categorized error once ctx ends
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// Read implements io.Reader
+func (cr contextReader) Read(p []byte) (int, error) {
+	if cr.ctx.Err() != nil {
+		return 0, contextError(cr.ctx)
+	}
+	return cr.reader.Read(p)
+}
+
+// readAllContext reads r to the end, reporting progress through onProgress
+// and stopping between reads once ctx ends
+func readAllContext(ctx context.Context, r io.Reader, onProgress func(read int64)) ([]byte, error) {
+	return io.ReadAll(newProgressReader(contextReader{ctx: ctx, reader: r}, onProgress))
+}
+
+// runCryptoContext runs fn, which stops between steps once ctx ends, and
+// reports a cancel as a categorized error. It returns only once fn has, so a
+// cancelled transfer leaves no encryption or decryption running.
+func runCryptoContext(ctx context.Context, fn func(context.Context) ([]byte, security.EncryptionMode, error)) ([]byte, security.EncryptionMode, error) {
+	if ctx.Err() != nil {
+		return nil, 0, contextError(ctx)
+	}
+	data, mode, err := fn(ctx)
+	if ctx.Err() != nil {
+		return nil, 0, contextError(ctx)
+	}
+	return data, mode, err
+}
+
+// encryptContext encrypts data with the best available mode, authenticating
+// aad with it, giving up once ctx ends
+func (btm *BulletproofTransferManager) encryptContext(ctx context.Context, data, key, aad []byte) ([]byte, security.EncryptionMode, error) {
+	traced := logging.TraceSpan("encrypt", "%d bytes", len(data))
+	sealed, mode, err := runCryptoContext(ctx, func(ctx context.Context) ([]byte, security.EncryptionMode, error) {
+		return btm.advancedSecurity.EncryptWithBestModeContext(ctx, data, key, aad)
+	})
+	traced(err)
+	return sealed, mode, err
+}
+
+// decryptContext decrypts data with whichever mode it was sealed with,
+// checking aad against what it was sealed with, giving up once ctx ends
+func (btm *BulletproofTransferManager) decryptContext(ctx context.Context, data, key, aad []byte) ([]byte, security.EncryptionMode, error) {
+	traced := logging.TraceSpan("decrypt", "%d bytes", len(data))
+	opened, mode, err := runCryptoContext(ctx, func(ctx context.Context) ([]byte, security.EncryptionMode, error) {
+		return btm.advancedSecurity.DecryptWithBestModeContext(ctx, data, key, aad)
+	})
+	traced(err)
+	return opened, mode, err
+}
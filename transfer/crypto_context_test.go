@@ -0,0 +1,51 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"trustdrop-bulletproof/security"
+)
+
+func TestRunCryptoContextWaitsForWork(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var finished atomic.Bool
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, _, err := runCryptoContext(ctx, func(ctx context.Context) ([]byte, security.EncryptionMode, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+		return nil, 0, ctx.Err()
+	})
+	if err == nil {
+		t.Fatal("cancelled run returned no error")
+	}
+	if !finished.Load() {
+		t.Fatal("runCryptoContext returned before the work stopped")
+	}
+}
+
+func TestDecryptStopsBetweenModes(t *testing.T) {
+	as := security.NewAdvancedSecurity()
+	key := []byte("correct-horse-battery")
+	sealed, _, err := as.EncryptWithBestMode([]byte("payload"), key, []byte("aad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := as.DecryptWithBestModeContext(ctx, sealed, key, []byte("aad")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("decrypt after cancel returned %v, want context.Canceled", err)
+	}
+	if opened, _, err := as.DecryptWithBestModeContext(context.Background(), sealed, key, []byte("aad")); err != nil || string(opened) != "payload" {
+		t.Fatalf("decrypt returned %q, %v", opened, err)
+	}
+}
@@ -0,0 +1,34 @@
+package transfer
+
+// DefaultMaxEmbedSize is the default largest folder file whose contents are
+// sent (25MB). Larger files are listed by name and size only, and the
+// receiver saves a placeholder in their place.
+const DefaultMaxEmbedSize = 25 * 1024 * 1024
+
+// legacyEmbedLimit is the cutoff senders used before manifests recorded theirs
+const legacyEmbedLimit = 25 * 1024 * 1024
+
+// SetMaxEmbedSize sets the largest folder file whose contents are sent. A
+// folder is sent as one payload held in memory, so this bounds how much each
+// file adds to it. Non-positive values restore the default.
+func (btm *BulletproofTransferManager) SetMaxEmbedSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxEmbedSize
+	}
+	btm.maxEmbedSize = size
+}
+
+// embeds reports whether a folder file of size is sent with its contents
+func (btm *BulletproofTransferManager) embeds(size int64) bool {
+	return size <= btm.maxEmbedSize
+}
+
+// omittedForSize reports whether the sender left the contents of file out of
+// the manifest because of its size. Older senders did not mark such files, so
+// for them the cutoff they used decides.
+func (m *FileManifest) omittedForSize(file FileInfo) bool {
+	if file.Omitted {
+		return true
+	}
+	return m.EmbedLimit == 0 && len(file.Data) == 0 && file.Size >= legacyEmbedLimit
+}
@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbedLimitBoundary(t *testing.T) {
+	const limit = 1000
+	folder := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[string]int{"under.bin": limit - 1, "at.bin": limit, "over.bin": limit + 1}
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(folder, name), bytes.Repeat([]byte{'x'}, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sender, receiver := loopbackPair(t)
+	sender.SetMaxEmbedSize(limit)
+	// The receiver's own limit must not matter; it follows the sender's marks
+	receiver.SetMaxEmbedSize(1)
+
+	const code = "embed-limit-test-code"
+	sent, err := sender.SendFilesContext(context.Background(), []string{folder}, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent.UnsentFiles) != 1 || sent.UnsentFiles[0].Path != "over.bin" || sent.UnsentFiles[0].Reason != MissingReasonTooLarge {
+		t.Fatalf("sender left out %+v, want only over.bin as too large", sent.UnsentFiles)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "inbox")
+	// A file left out for size makes the receive incomplete, but the rest is kept
+	_, err = receiver.ReceiveFilesToContext(context.Background(), code, destDir)
+	var missing *MissingFilesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("receive returned %v, want a MissingFilesError", err)
+	}
+	if len(missing.Files) != 1 || missing.Files[0].Path != "over.bin" || missing.Files[0].Reason != MissingReasonTooLarge {
+		t.Errorf("receiver reported missing %+v, want only over.bin as too large", missing.Files)
+	}
+
+	for _, name := range []string{"under.bin", "at.bin"} {
+		data, err := os.ReadFile(filepath.Join(destDir, "data", name))
+		if err != nil || len(data) != sizes[name] {
+			t.Errorf("%s received with %d bytes, %v; want %d", name, len(data), err, sizes[name])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "data", "over.bin.placeholder.txt")); err != nil {
+		t.Errorf("no placeholder for the file over the limit: %v", err)
+	}
+}
+
+func TestOmittedForSizeFromOlderSenders(t *testing.T) {
+	// Manifests without an embed limit come from senders with the 25MB cutoff
+	legacy := &FileManifest{}
+	if legacy.omittedForSize(FileInfo{Size: legacyEmbedLimit - 1}) {
+		t.Error("file under the legacy cutoff treated as left out for size")
+	}
+	if !legacy.omittedForSize(FileInfo{Size: legacyEmbedLimit}) {
+		t.Error("file at the legacy cutoff not treated as left out for size")
+	}
+
+	// Newer senders mark what they left out, whatever its size
+	current := &FileManifest{EmbedLimit: DefaultMaxEmbedSize}
+	if current.omittedForSize(FileInfo{Size: legacyEmbedLimit}) {
+		t.Error("unmarked file treated as left out when the sender records its limit")
+	}
+	if !current.omittedForSize(FileInfo{Size: 10, Omitted: true}) {
+		t.Error("file marked as left out not treated as such")
+	}
+}
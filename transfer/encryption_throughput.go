@@ -0,0 +1,11 @@
+package transfer
+
+import "trustdrop-bulletproof/security"
+
+// EncryptionThroughput returns how fast each encryption mode has run on this
+// device, measured while encrypting transfers and by a one-off calibration
+// before the first large one. Large payloads are encrypted with the faster of
+// the authenticated modes according to these measurements.
+func (btm *BulletproofTransferManager) EncryptionThroughput() []security.ModeThroughput {
+	return btm.advancedSecurity.Throughput()
+}
@@ -0,0 +1,243 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/i18n"
+	"trustdrop-bulletproof/transport"
+)
+
+// Error categories returned by SendFiles and ReceiveFiles. Callers can branch
+// on them with errors.Is; the underlying cause stays reachable via errors.Unwrap.
+var (
+	ErrNetworkRestricted  = errors.New("network restrictions blocked the transfer")
+	ErrWrongCode          = errors.New("transfer code is incorrect or expired")
+	ErrDiskFull           = errors.New("not enough disk space")
+	ErrTimeout            = errors.New("transfer timed out")
+	ErrFileAccess         = errors.New("cannot access file")
+	ErrTransportFailed    = errors.New("all transfer methods failed")
+	ErrCancelled          = errors.New("transfer cancelled by user")
+	ErrIntegrityFailed    = errors.New("received data failed integrity verification")
+	ErrCorruptedInTransit = errors.New("data was corrupted in transit")
+	ErrEndpointSecurity   = errors.New("antivirus or endpoint security software interfered with the transfer")
+	ErrOneWayNetwork      = errors.New("this network only allows transfers in one direction")
+	ErrIncompleteTransfer = errors.New("some files in the transfer were not received")
+	ErrVersionMismatch    = errors.New("the other side is running an incompatible version")
+	ErrFileTypeBlocked    = errors.New("the transfer contains a file type this device does not accept")
+	ErrQuarantined        = errors.New("received files were held in quarantine")
+	ErrTransferTooLarge   = errors.New("the transfer is larger than this device accepts")
+	ErrNothingToSend      = errors.New("nothing to send")
+	ErrRelayRejected      = errors.New("the relay rejected the connection")
+)
+
+// TransferFailure is a categorized transfer error with the context needed to
+// explain it to a user. Use FormatErrorMessage for the human-readable version.
+type TransferFailure struct {
+	Kind         error  // One of the Err* categories
+	Cause        error  // The underlying error
+	FilePath     string // File being processed, if any
+	Direction    string // "send" or "receive"
+	NetworkType  string
+	Restrictive  bool
+	VPNActive    bool
+	Restrictions []transport.NetworkRestriction
+}
+
+// Error implements the error interface
+func (tf *TransferFailure) Error() string {
+	if tf.Cause == nil {
+		return tf.Kind.Error()
+	}
+	return fmt.Sprintf("%v: %v", tf.Kind, tf.Cause)
+}
+
+// Unwrap exposes both the category and the cause to errors.Is and errors.As
+func (tf *TransferFailure) Unwrap() []error {
+	return []error{tf.Kind, tf.Cause}
+}
+
+// classifyFailureKind maps an error onto one of the Err* categories
+func classifyFailureKind(err error, institutionalNetworkError bool) error {
+	for _, kind := range []error{ErrCancelled, ErrVersionMismatch, ErrFileTypeBlocked, ErrTransferTooLarge, ErrQuarantined, ErrIntegrityFailed, ErrCorruptedInTransit, ErrIncompleteTransfer, ErrEndpointSecurity, ErrRelayRejected, ErrOneWayNetwork, ErrNetworkRestricted, ErrWrongCode, ErrDiskFull, ErrTimeout, ErrFileAccess, ErrTransportFailed} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	if transport.IsEndpointSecurityError(err) {
+		return ErrEndpointSecurity
+	}
+
+	if transport.IsRelayRejected(err) {
+		return ErrRelayRejected
+	}
+
+	if institutionalNetworkError {
+		return ErrNetworkRestricted
+	}
+
+	switch HandleTransferError(err, "transfer").Code {
+	case ErrorNetworkBlocked:
+		return ErrNetworkRestricted
+	case ErrorInvalidCode, ErrorEncryption:
+		// Decryption only fails this way when the code doesn't match the sender's
+		return ErrWrongCode
+	case ErrorDiskSpace:
+		return ErrDiskFull
+	case ErrorTimeout:
+		return ErrTimeout
+	case ErrorFileAccess:
+		return ErrFileAccess
+	default:
+		return ErrTransportFailed
+	}
+}
+
+// isResetFailure reports whether a network-level failure was a connection reset
+func isResetFailure(kind, err error) bool {
+	if kind != ErrNetworkRestricted && kind != ErrTransportFailed {
+		return false
+	}
+	errorStr := strings.ToLower(err.Error())
+	return strings.Contains(errorStr, "connection reset") || strings.Contains(errorStr, "forcibly closed")
+}
+
+// isConnectivityFailure reports whether a failure category could be explained
+// by the network blocking one direction of a transfer
+func isConnectivityFailure(kind error) bool {
+	return kind == ErrNetworkRestricted || kind == ErrTransportFailed || kind == ErrTimeout
+}
+
+// FormatErrorMessage turns a transfer error into the detailed, network-aware
+// explanation shown in the GUI. Errors that are not a TransferFailure are
+// returned as-is.
+func FormatErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var failure *TransferFailure
+	if !errors.As(err, &failure) {
+		return err.Error()
+	}
+
+	var enhancedMsg strings.Builder
+	var missing *MissingFilesError
+	var mismatch *VersionMismatchError
+	var manifestVersion *ManifestVersionError
+
+	switch {
+	case errors.Is(failure.Kind, ErrNetworkRestricted) && failure.Restrictive:
+		enhancedMsg.WriteString(i18n.T("error.restricted.title"))
+
+		switch failure.NetworkType {
+		case "corporate":
+			enhancedMsg.WriteString(i18n.T("error.restricted.corporate"))
+		case "university":
+			enhancedMsg.WriteString(i18n.T("error.restricted.university"))
+		default:
+			enhancedMsg.WriteString(i18n.T("error.restricted.managed"))
+		}
+
+		if len(failure.Restrictions) > 0 {
+			enhancedMsg.WriteString(i18n.T("error.restricted.detected"))
+			for _, restriction := range failure.Restrictions {
+				enhancedMsg.WriteString(fmt.Sprintf("• %s: %s\n",
+					strings.Title(restriction.Type), restriction.Description))
+			}
+			enhancedMsg.WriteString("\n")
+		}
+
+		enhancedMsg.WriteString(i18n.T("error.restricted.solutions"))
+
+	case errors.Is(failure.Kind, ErrNetworkRestricted):
+		enhancedMsg.WriteString(i18n.T("error.network"))
+
+	case errors.Is(failure.Kind, ErrOneWayNetwork):
+		if failure.Direction == "send" {
+			enhancedMsg.WriteString(i18n.T("error.one_way.send"))
+		} else {
+			enhancedMsg.WriteString(i18n.T("error.one_way.receive"))
+		}
+
+	case errors.Is(failure.Kind, ErrEndpointSecurity):
+		enhancedMsg.WriteString(i18n.T("error.endpoint_security"))
+
+	case errors.Is(failure.Kind, ErrRelayRejected):
+		enhancedMsg.WriteString(i18n.T("error.relay_rejected"))
+
+	case errors.Is(failure.Kind, ErrIntegrityFailed):
+		enhancedMsg.WriteString(i18n.T("error.integrity"))
+
+	case errors.Is(failure.Kind, ErrCorruptedInTransit):
+		enhancedMsg.WriteString(i18n.T("error.corrupted"))
+
+	case errors.Is(failure.Kind, ErrFileTypeBlocked):
+		enhancedMsg.WriteString(i18n.T("error.file_type_blocked"))
+
+	case errors.Is(failure.Kind, ErrTransferTooLarge):
+		enhancedMsg.WriteString(i18n.T("error.too_large"))
+
+	case errors.Is(failure.Kind, ErrQuarantined):
+		enhancedMsg.WriteString(i18n.T("error.quarantined"))
+
+	case errors.As(failure.Cause, &mismatch):
+		if mismatch.PeerNewer() {
+			enhancedMsg.WriteString(i18n.T("error.version.newer", mismatch.PeerVersion, mismatch.LocalVersion))
+		} else {
+			enhancedMsg.WriteString(i18n.T("error.version.older", mismatch.PeerVersion, mismatch.LocalVersion))
+		}
+
+	case errors.As(failure.Cause, &manifestVersion):
+		enhancedMsg.WriteString(i18n.T("error.version.manifest", manifestVersion.Version, manifestVersion.Supported))
+
+	case errors.As(failure.Cause, &missing):
+		enhancedMsg.WriteString(i18n.T("error.missing_files", len(missing.Files), missing.Total, missingFileList(missing.Files)))
+
+	default:
+		if reason, ok := transport.IncompleteReason(failure.Cause); ok {
+			enhancedMsg.WriteString(i18n.T("error.incomplete", reason))
+		} else {
+			enhancedMsg.WriteString(i18n.T("error.failed", simplifyErrorMessage(failure.Cause)))
+		}
+		enhancedMsg.WriteString(i18n.T("error.general_steps"))
+	}
+
+	if failure.VPNActive {
+		enhancedMsg.WriteString(i18n.T("error.vpn"))
+	}
+
+	if failure.FilePath != "" {
+		enhancedMsg.WriteString(i18n.T("error.file", failure.FilePath))
+	}
+
+	enhancedMsg.WriteString(i18n.T("error.network_type", failure.NetworkType))
+	enhancedMsg.WriteString(i18n.T("error.technical", failure.Cause))
+
+	return enhancedMsg.String()
+}
+
+// contextError converts a finished transfer context into a categorized error
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+	return fmt.Errorf("%w: %w", ErrCancelled, ctx.Err())
+}
+
+// sleepContext waits for d, returning early with a categorized error if ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
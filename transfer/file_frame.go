@@ -0,0 +1,242 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// fileFrameMagic starts a decrypted single-file frame. It is followed by the
+// length of the binary fileFrameHeader as a big-endian uint32, the header, the
+// file's contents and their SHA-256 checksum. Unlike the JSON single-file
+// payload the contents are not encoded, so a file costs its own size.
+var fileFrameMagic = []byte("TDFILE1\x00")
+
+// maxFileFrameHeaderSize bounds the header a receiver will parse
+const maxFileFrameHeaderSize = 64 * 1024
+
+// fileFrameHeader describes the file in a single-file frame. Every string and
+// byte field is written as a big-endian uint16 length and the bytes; numbers
+// are big-endian.
+type fileFrameHeader struct {
+	Name   string
+	Size   uint64
+	Mode   uint32 // Permission bits of the sender's file
+	SentAt int64  // When the sender built the frame, Unix nanoseconds on its clock
+	Sender *SenderIdentity
+}
+
+// SetBinaryFiles sends single files in a binary frame rather than a JSON
+// payload, which avoids encoding the contents and the memory that takes.
+// Receivers need a release that understands the frame, so it is off by
+// default.
+func (btm *BulletproofTransferManager) SetBinaryFiles(enabled bool) {
+	btm.binaryFiles = enabled
+}
+
+// marshal encodes the header
+func (h *fileFrameHeader) marshal() ([]byte, error) {
+	var fields [][]byte
+	fields = append(fields, []byte(h.Name))
+	if h.Sender != nil {
+		fields = append(fields, h.Sender.PublicKey, []byte(h.Sender.DisplayName), h.Sender.Signature)
+	} else {
+		fields = append(fields, nil, nil, nil)
+	}
+
+	header := binary.BigEndian.AppendUint64(nil, h.Size)
+	header = binary.BigEndian.AppendUint32(header, h.Mode)
+	header = binary.BigEndian.AppendUint64(header, uint64(h.SentAt))
+	for _, field := range fields {
+		if len(field) > 0xFFFF {
+			return nil, fmt.Errorf("file frame field is too long (%d bytes)", len(field))
+		}
+		header = binary.BigEndian.AppendUint16(header, uint16(len(field)))
+		header = append(header, field...)
+	}
+	return header, nil
+}
+
+// unmarshalFileFrameHeader decodes a header written by marshal
+func unmarshalFileFrameHeader(data []byte) (*fileFrameHeader, error) {
+	if len(data) < 20 {
+		return nil, errors.New("file frame header is cut off")
+	}
+	h := &fileFrameHeader{
+		Size:   binary.BigEndian.Uint64(data),
+		Mode:   binary.BigEndian.Uint32(data[8:]),
+		SentAt: int64(binary.BigEndian.Uint64(data[12:])),
+	}
+	rest := data[20:]
+
+	fields := make([][]byte, 4)
+	for i := range fields {
+		if len(rest) < 2 {
+			return nil, errors.New("file frame header is cut off")
+		}
+		length := int(binary.BigEndian.Uint16(rest))
+		rest = rest[2:]
+		if length > len(rest) {
+			return nil, errors.New("file frame header is damaged")
+		}
+		fields[i], rest = rest[:length], rest[length:]
+	}
+
+	h.Name = string(fields[0])
+	if len(fields[1]) > 0 {
+		h.Sender = &SenderIdentity{PublicKey: fields[1], DisplayName: string(fields[2]), Signature: fields[3]}
+	}
+	return h, nil
+}
+
+// sentAt returns when the sender built the frame, or the zero time if unknown
+func (h *fileFrameHeader) sentAt() time.Time {
+	if h.SentAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, h.SentAt)
+}
+
+// processFileFrame sends one regular file as a binary frame. The file is read
+// straight into the frame, so the payload is built without a second copy.
+func (btm *BulletproofTransferManager) processFileFrame(ctx context.Context, file *os.File, info os.FileInfo, transferCode string) (*FileProcessResult, error) {
+	fileName := filepath.Base(file.Name())
+	frame := &fileFrameHeader{
+		Name:   fileName,
+		Size:   uint64(info.Size()),
+		Mode:   uint32(info.Mode().Perm()),
+		SentAt: time.Now().UnixNano(),
+	}
+	// The contents aren't read yet, so this signature only reserves its space
+	frame.Sender = btm.senderIdentity(transferCode, signedContent{})
+	header, err := frame.marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file frame: %w", err)
+	}
+
+	prefix := len(fileFrameMagic) + 4 + len(header)
+	payload := make([]byte, prefix+int(info.Size()), prefix+int(info.Size())+sha256.Size)
+	copy(payload, fileFrameMagic)
+	binary.BigEndian.PutUint32(payload[len(fileFrameMagic):], uint32(len(header)))
+	copy(payload[len(fileFrameMagic)+4:], header)
+
+	btm.enterPhase("")
+	btm.updateIncrementalProgress(PhaseReading, 0, fileName)
+	reader := newProgressReader(contextReader{ctx: ctx, reader: file}, func(read int64) {
+		btm.updateIncrementalProgress(PhaseReading, read, fileName)
+	})
+	data := payload[prefix:]
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	payload = append(payload, hash[:]...)
+	hashString := hex.EncodeToString(hash[:])
+
+	// The signature is the header's last field and has a fixed length, so the
+	// real one is written over the placeholder
+	if frame.Sender != nil {
+		signature := btm.senderIdentity(transferCode, signedContent{
+			Name:     fileName,
+			Size:     int64(len(data)),
+			Checksum: hashString,
+			SentAt:   frame.sentAt(),
+		}).Signature
+		copy(payload[prefix-len(signature):prefix], signature)
+	}
+
+	strengthenedKey, _, err := btm.advancedSecurity.StrengthenTransferCode(transferCode, "payload")
+	if err != nil {
+		return nil, fmt.Errorf("failed to strengthen transfer code: %w", err)
+	}
+	metadata := transport.TransferMetadata{
+		TransferID: transferCode,
+		FileName:   fileName,
+		FileSize:   int64(len(payload)),
+		Checksum:   hashString,
+	}
+	bound, err := bindMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	btm.updateIncrementalProgress(PhaseEncrypting, int64(len(data)), fileName)
+	encryptedData, mode, err := btm.encryptContext(ctx, payload, strengthenedKey, bound)
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %w", err)
+	}
+	btm.updateIncrementalProgress(PhaseTransferring, int64(len(data)), fileName)
+
+	if err := btm.sendWithReconnect(ctx, sealTransit(encryptedData, bound), metadata); err != nil {
+		return nil, fmt.Errorf("transport failed: %w", err)
+	}
+
+	return &FileProcessResult{
+		Size:  int64(len(data)),
+		Hash:  hashString,
+		Files: []TransferredFile{{Path: file.Name(), Size: int64(len(data)), Checksum: hashString}},
+		Mode:  mode,
+	}, nil
+}
+
+// parseFileFrame splits a decrypted single-file frame into its header,
+// contents and the contents' hex SHA-256 checksum
+func parseFileFrame(payload []byte) (*fileFrameHeader, []byte, string, error) {
+	rest := payload[len(fileFrameMagic):]
+	if len(rest) < 4 {
+		return nil, nil, "", errors.New("file frame is cut off")
+	}
+	size := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if size > maxFileFrameHeaderSize || int(size) > len(rest) {
+		return nil, nil, "", errors.New("file frame header is damaged")
+	}
+	header, err := unmarshalFileFrameHeader(rest[:size])
+	if err != nil {
+		return nil, nil, "", err
+	}
+	rest = rest[size:]
+
+	if uint64(len(rest)) != header.Size+sha256.Size {
+		return nil, nil, "", fmt.Errorf("file frame holds %d bytes, expected %d", len(rest), header.Size+sha256.Size)
+	}
+	data, hash := rest[:header.Size], rest[header.Size:]
+	return header, data, hex.EncodeToString(hash), nil
+}
+
+// processFileFrameReceived saves the file in a single-file frame
+func (btm *BulletproofTransferManager) processFileFrameReceived(payload []byte, receivedDir, transferCode string) (*receivedPayload, error) {
+	header, data, hash, err := parseFileFrame(payload)
+	if err != nil {
+		return nil, err
+	}
+	if header.Name == "" || strings.ContainsRune(header.Name, 0) {
+		return nil, errors.New("file frame has no usable file name")
+	}
+
+	perm := os.FileMode(header.Mode).Perm() | 0600 // The receiver must be able to read and replace its copy
+	received, err := btm.saveSingleFile(header.Name, data, hash, perm, receivedDir)
+	if err != nil {
+		return nil, err
+	}
+	if header.Sender != nil {
+		received.Sender = btm.recognizeSender(header.Sender, transferCode, signedContent{
+			Name:     header.Name,
+			Size:     int64(len(data)),
+			Checksum: checksumOf(data),
+			SentAt:   header.sentAt(),
+		})
+	}
+	received.SentAt = header.sentAt()
+	return received, nil
+}
@@ -0,0 +1,40 @@
+package transfer
+
+// States of a file in a folder transfer, reported through the file status
+// callback
+const (
+	FileStatePending    = "pending"     // Listed but not yet read or written
+	FileStateInProgress = "in_progress" // Read for sending, or being written
+	FileStateDone       = "done"        // Sent, or written on the receiver
+	FileStateFailed     = "failed"      // Left out; Reason says why
+)
+
+// FileStatus is the state of one file in a folder transfer
+type FileStatus struct {
+	Path   string // Relative to the folder
+	Size   int64  // Size in bytes, or 0 if not known
+	State  string // One of the FileState* values
+	Reason string // Why the file failed, for FileStateFailed
+}
+
+// SetFileStatusCallback sets a callback for the state of each file in folder
+// sends and receives. Every file is reported pending first, then as it moves
+// on; a sent file is done once the folder has reached the relay. Single-file
+// transfers report nothing, since the progress callback already covers them.
+func (btm *BulletproofTransferManager) SetFileStatusCallback(callback func(FileStatus)) {
+	btm.fileStatusCallback = callback
+}
+
+// updateFileStatus delivers the state of one file to the file status callback
+func (btm *BulletproofTransferManager) updateFileStatus(path string, size int64, state, reason string) {
+	if btm.fileStatusCallback != nil {
+		btm.fileStatusCallback(FileStatus{Path: path, Size: size, State: state, Reason: reason})
+	}
+}
+
+// reportFilesSent marks the files that went out with a folder as done
+func (btm *BulletproofTransferManager) reportFilesSent(files []FileInfo) {
+	for _, fileInfo := range files {
+		btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateDone, "")
+	}
+}
@@ -0,0 +1,194 @@
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// What a receive does with files its FileTypePolicy blocks
+const (
+	FileTypeActionSkip   = "skip"   // Leave blocked files out and report them (the default)
+	FileTypeActionReject = "reject" // Refuse the whole transfer if any file is blocked
+)
+
+// MissingReasonBlocked starts the reason given for a file the file type
+// policy kept from being written
+const MissingReasonBlocked = "blocked by the file type policy"
+
+// FileTypePolicy limits the kinds of files a receive writes. Entries are
+// extensions such as ".exe" or MIME types such as "application/pdf" or
+// "image/*". Files are judged by their name and by a sniff of their content,
+// so renaming an executable does not get it past the policy.
+type FileTypePolicy struct {
+	Allow  []string `json:"allow,omitempty"`  // When set, only these types are written
+	Deny   []string `json:"deny,omitempty"`   // These types are never written
+	Action string   `json:"action,omitempty"` // One of the FileTypeAction* values
+}
+
+// contentSignature recognizes executable content whatever the file is called
+type contentSignature struct {
+	magic       []byte
+	mimeType    string
+	extensions  []string // Extensions such content usually has
+	description string
+}
+
+var executableSignatures = []contentSignature{
+	{[]byte("MZ"), "application/vnd.microsoft.portable-executable", []string{".exe", ".dll", ".scr", ".com", ".sys", ".cpl"}, "a Windows executable"},
+	{[]byte("\x7fELF"), "application/x-elf", []string{".elf", ".so", ".bin", ".run"}, "a Linux executable"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary", []string{".dylib", ".bundle"}, "a macOS executable"},
+	{[]byte("\xce\xfa\xed\xfe"), "application/x-mach-binary", []string{".dylib", ".bundle"}, "a macOS executable"},
+	{[]byte("#!"), "text/x-shellscript", []string{".sh", ".bash", ".py", ".pl", ".rb"}, "a script"},
+}
+
+// Validate checks that the action is known and every entry is an extension
+// or a MIME type
+func (p FileTypePolicy) Validate() error {
+	switch p.Action {
+	case "", FileTypeActionSkip, FileTypeActionReject:
+	default:
+		return fmt.Errorf("unknown file type action %q (use %q or %q)", p.Action, FileTypeActionSkip, FileTypeActionReject)
+	}
+	for _, entry := range slices.Concat(p.Allow, p.Deny) {
+		if !strings.HasPrefix(entry, ".") && !strings.Contains(entry, "/") {
+			return fmt.Errorf("file type %q must be an extension like \".exe\" or a MIME type like \"image/*\"", entry)
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether the policy accepts every file
+func (p FileTypePolicy) IsZero() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// blockReason returns why a file called name holding data is blocked, or ""
+// if the policy accepts it
+func (p FileTypePolicy) blockReason(name string, data []byte) string {
+	if p.IsZero() {
+		return ""
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	extMIME := mimeTypeOf(mime.TypeByExtension(ext))
+	signature := sniffExecutable(data)
+	sniffed := mimeTypeOf(http.DetectContentType(data))
+
+	if matchesFileType(p.Deny, ext) || matchesFileType(p.Deny, extMIME) {
+		return fmt.Sprintf("%s files are not accepted", describeFileType(ext, extMIME))
+	}
+	if signature != nil && signatureListed(p.Deny, signature) {
+		return fmt.Sprintf("its content looks like %s", signature.description)
+	}
+	if matchesFileType(p.Deny, sniffed) {
+		return fmt.Sprintf("its content is %s, which is not accepted", sniffed)
+	}
+
+	if len(p.Allow) == 0 {
+		return ""
+	}
+	if !matchesFileType(p.Allow, ext) && !matchesFileType(p.Allow, extMIME) {
+		return fmt.Sprintf("%s files are not on the accepted list", describeFileType(ext, extMIME))
+	}
+	if signature != nil && !signatureListed(p.Allow, signature) {
+		return fmt.Sprintf("its content looks like %s, which is not on the accepted list", signature.description)
+	}
+	return ""
+}
+
+// sniffExecutable returns the executable signature data starts with, if any
+func sniffExecutable(data []byte) *contentSignature {
+	for i := range executableSignatures {
+		if bytes.HasPrefix(data, executableSignatures[i].magic) {
+			return &executableSignatures[i]
+		}
+	}
+	return nil
+}
+
+// signatureListed reports whether list names the signature's MIME type or
+// any extension such content usually has
+func signatureListed(list []string, signature *contentSignature) bool {
+	if matchesFileType(list, signature.mimeType) {
+		return true
+	}
+	return slices.ContainsFunc(signature.extensions, func(ext string) bool {
+		return matchesFileType(list, ext)
+	})
+}
+
+// matchesFileType reports whether value, an extension or MIME type, is in
+// list. A "type/*" entry matches every subtype.
+func matchesFileType(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, entry := range list {
+		entry = strings.ToLower(entry)
+		if entry == value {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(entry, "/*"); ok && strings.HasPrefix(value, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mimeTypeOf strips parameters such as "; charset=utf-8" from a MIME type
+func mimeTypeOf(value string) string {
+	mediaType, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// describeFileType names a file type for a block reason
+func describeFileType(ext, mimeType string) string {
+	switch {
+	case ext != "":
+		return ext
+	case mimeType != "":
+		return mimeType
+	default:
+		return "extensionless"
+	}
+}
+
+// SetFileTypePolicy limits the kinds of files receives write. The zero
+// policy accepts every file.
+func (btm *BulletproofTransferManager) SetFileTypePolicy(policy FileTypePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	btm.fileTypePolicy = policy
+	return nil
+}
+
+// FileTypePolicy returns the policy receives apply
+func (btm *BulletproofTransferManager) FileTypePolicy() FileTypePolicy {
+	return btm.fileTypePolicy
+}
+
+// checkFileType applies the file type policy to one received file. It
+// returns the file as blocked when it should be skipped, or an error matching
+// ErrFileTypeBlocked when the policy rejects the whole transfer.
+func (btm *BulletproofTransferManager) checkFileType(path string, data []byte) (*MissingFile, error) {
+	reason := btm.fileTypePolicy.blockReason(path, data)
+	if reason == "" {
+		return nil, nil
+	}
+	if btm.fileTypePolicy.Action == FileTypeActionReject {
+		return nil, fmt.Errorf("%w: %s: %s", ErrFileTypeBlocked, path, reason)
+	}
+
+	btm.updateStatus(fmt.Sprintf("Blocked %s: %s", path, reason))
+	return &MissingFile{
+		Path:   path,
+		Size:   int64(len(data)),
+		Reason: fmt.Sprintf("%s: %s", MissingReasonBlocked, reason),
+	}, nil
+}
@@ -0,0 +1,536 @@
+package transfer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// When folders are sent as a tar archive instead of a manifest
+const (
+	ArchiveModeOff    = "off"    // Always send a manifest (the default)
+	ArchiveModeAuto   = "auto"   // Archive folders with at least MinFiles files
+	ArchiveModeAlways = "always" // Archive every folder that fits in memory
+)
+
+// DefaultArchiveMinFiles is the folder size at which auto mode archives
+const DefaultArchiveMinFiles = 500
+
+// Archive formats named in an archive header
+const (
+	archiveFormatTar     = "tar"
+	archiveFormatTarGzip = "tar+gzip"
+)
+
+// archiveMagic starts a decrypted folder archive payload. It is followed by
+// the length of the JSON archiveHeader as a big-endian uint32, the header and
+// the archive itself.
+var archiveMagic = []byte("TDTAR1\x00\x00")
+
+// maxArchiveHeaderSize bounds the header a receiver will parse
+const maxArchiveHeaderSize = 64 * 1024 * 1024
+
+// ArchiveConfig chooses when folders travel as a single tar archive. An
+// archive avoids the per-file encoding of the manifest, keeps timestamps and
+// permissions, and compresses well when it holds many small files. The
+// whole archive is built in memory, so folders larger than the in-memory
+// limit are still sent as a manifest.
+type ArchiveConfig struct {
+	Mode     string `json:"mode,omitempty"`      // One of the ArchiveMode* values; empty is off
+	MinFiles int    `json:"min_files,omitempty"` // Files at which auto mode archives; 0 uses DefaultArchiveMinFiles
+	Compress bool   `json:"compress,omitempty"`  // Gzip the archive
+}
+
+// Validate checks the mode and threshold
+func (a ArchiveConfig) Validate() error {
+	switch a.Mode {
+	case "", ArchiveModeOff, ArchiveModeAuto, ArchiveModeAlways:
+	default:
+		return fmt.Errorf("unknown archive mode %q (use %q, %q or %q)", a.Mode, ArchiveModeOff, ArchiveModeAuto, ArchiveModeAlways)
+	}
+	if a.MinFiles < 0 {
+		return fmt.Errorf("archive min_files must not be negative")
+	}
+	return nil
+}
+
+// SetArchiveConfig chooses when folders are sent as a tar archive. Receivers
+// need a release that understands archives, so it is off by default.
+func (btm *BulletproofTransferManager) SetArchiveConfig(config ArchiveConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.MinFiles == 0 {
+		config.MinFiles = DefaultArchiveMinFiles
+	}
+	btm.archive = config
+	return nil
+}
+
+// archivesFolder reports whether a folder of files files totalling size
+// bytes is sent as an archive
+func (btm *BulletproofTransferManager) archivesFolder(files int, size int64) bool {
+	switch btm.archive.Mode {
+	case ArchiveModeAlways:
+	case ArchiveModeAuto:
+		if files < btm.archive.MinFiles {
+			return false
+		}
+	default:
+		return false
+	}
+	if size > btm.maxInMemorySize {
+		btm.updateStatus(fmt.Sprintf("Folder holds %s, more than can be archived in memory (%s) - sending file by file",
+			btm.formatBytes(size), btm.formatBytes(btm.maxInMemorySize)))
+		return false
+	}
+	return true
+}
+
+// archiveHeader describes a folder archive. It travels encrypted with the
+// archive, ahead of it.
+type archiveHeader struct {
+	FolderName string            `json:"folder_name"`
+	Format     string            `json:"format"`    // One of the archiveFormat* values
+	Checksum   string            `json:"checksum"`  // SHA-256 of the archive as sent
+	Checksums  map[string]string `json:"checksums"` // SHA-256 of each file, by archive name
+	TotalFiles int               `json:"total_files"`
+	TotalSize  int64             `json:"total_size"`
+	Missing    []MissingFile     `json:"missing,omitempty"` // Files the sender could not read
+	Sender     *SenderIdentity   `json:"sender,omitempty"`
+	SentAt     time.Time         `json:"sent_at,omitempty"` // When the sender built the archive, on its clock
+}
+
+// folderArchive builds a folder archive in memory
+type folderArchive struct {
+	buffer bytes.Buffer
+	gzip   *gzip.Writer
+	tar    *tar.Writer
+	header archiveHeader
+	files  []TransferredFile
+}
+
+// newFolderArchive starts an empty archive of the folder called name
+func newFolderArchive(name string, compress bool) *folderArchive {
+	a := &folderArchive{header: archiveHeader{
+		FolderName: name,
+		Format:     archiveFormatTar,
+		Checksums:  make(map[string]string),
+	}}
+	var w io.Writer = &a.buffer
+	if compress {
+		a.gzip = gzip.NewWriter(w)
+		a.header.Format = archiveFormatTarGzip
+		w = a.gzip
+	}
+	a.tar = tar.NewWriter(w)
+	return a
+}
+
+// addDir adds the folder at relPath
+func (a *folderArchive) addDir(relPath string, info os.FileInfo) error {
+	return a.tar.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     filepath.ToSlash(relPath) + "/",
+		Mode:     int64(info.Mode().Perm()),
+		ModTime:  info.ModTime(),
+		Format:   tar.FormatPAX,
+	})
+}
+
+// addFile adds the folder file described by fileInfo and info, holding
+// data. Owners are left out; the receiver owns what it writes.
+func (a *folderArchive) addFile(fileInfo FileInfo, info os.FileInfo, data []byte) error {
+	name := filepath.ToSlash(fileInfo.RelativePath)
+	if err := a.tar.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     int64(info.Mode().Perm()),
+		ModTime:  info.ModTime(),
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return err
+	}
+	if _, err := a.tar.Write(data); err != nil {
+		return err
+	}
+
+	checksum := checksumOf(data)
+	a.header.Checksums[name] = checksum
+	a.header.TotalFiles++
+	a.header.TotalSize += int64(len(data))
+	a.files = append(a.files, TransferredFile{Path: fileInfo.OriginalPath, Size: int64(len(data)), Checksum: checksum})
+	return nil
+}
+
+// finish closes the archive and returns the payload to encrypt. sign gives
+// the sender identity for the finished header, or nil to send none.
+func (a *folderArchive) finish(sign func(signedContent) *SenderIdentity) ([]byte, error) {
+	if err := a.tar.Close(); err != nil {
+		return nil, err
+	}
+	if a.gzip != nil {
+		if err := a.gzip.Close(); err != nil {
+			return nil, err
+		}
+	}
+	a.header.Checksum = checksumOf(a.buffer.Bytes())
+	a.header.SentAt = time.Now()
+	a.header.Sender = sign(a.header.signedContent(a.header.Checksum))
+
+	header, err := json.Marshal(a.header)
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, 0, len(archiveMagic)+4+len(header)+a.buffer.Len())
+	payload = append(payload, archiveMagic...)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(len(header)))
+	payload = append(payload, header...)
+	return append(payload, a.buffer.Bytes()...), nil
+}
+
+// processFolderArchive sends a folder as a single tar archive
+func (btm *BulletproofTransferManager) processFolderArchive(ctx context.Context, folderPath, transferCode string) (*FileProcessResult, error) {
+	archive := newFolderArchive(filepath.Base(folderPath), btm.archive.Compress)
+	btm.updateStatus(fmt.Sprintf("Archiving folder %s...", archive.header.FolderName))
+
+	var bytesRead int64
+	var unreadable, archived []FileInfo
+	addFile := func(fileInfo FileInfo, data []byte) error {
+		info, err := os.Stat(fileInfo.OriginalPath)
+		if err != nil {
+			return fmt.Errorf("failed to access %s: %w", fileInfo.RelativePath, err)
+		}
+		if err := archive.addFile(fileInfo, info, data); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", fileInfo.RelativePath, err)
+		}
+		bytesRead += int64(len(data))
+		archived = append(archived, fileInfo)
+		btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateInProgress, "")
+		btm.updateIncrementalProgress(PhaseReading, bytesRead, fileInfo.RelativePath)
+		return nil
+	}
+
+	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		if err != nil {
+			btm.updateStatus(fmt.Sprintf("Warning: Error accessing %s, skipping", path))
+			return nil
+		}
+
+		relPath, err := filepath.Rel(folderPath, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if kind := specialFileKind(path, info); kind != "" {
+			btm.updateStatus(fmt.Sprintf("Warning: Skipping %s (%s cannot be transferred)", relPath, kind))
+			return nil
+		}
+
+		if info.IsDir() {
+			return archive.addDir(relPath, info)
+		}
+
+		fileInfo := FileInfo{OriginalPath: path, RelativePath: relPath, Size: info.Size()}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// Often a lock held by another program, so try again once the walk is done
+			btm.updateStatus(fmt.Sprintf("Warning: Could not read %s, will retry", relPath))
+			unreadable = append(unreadable, fileInfo)
+			return nil
+		}
+		return addFile(fileInfo, data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive folder: %w", err)
+	}
+
+	failed, err := btm.retryFolderReads(ctx, unreadable, addFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileInfo := range failed {
+		archive.header.Missing = append(archive.header.Missing,
+			MissingFile{Path: filepath.ToSlash(fileInfo.RelativePath), Size: fileInfo.Size, Reason: MissingReasonUnreadable})
+	}
+
+	payload, err := archive.finish(func(content signedContent) *SenderIdentity {
+		return btm.senderIdentity(transferCode, content)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder archive: %w", err)
+	}
+	btm.updateStatus(fmt.Sprintf("Archived %d files (%s) into %s",
+		archive.header.TotalFiles, btm.formatBytes(archive.header.TotalSize), btm.formatBytes(int64(archive.buffer.Len()))))
+
+	strengthenedKey, _, err := btm.advancedSecurity.StrengthenTransferCode(transferCode, "manifest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to strengthen transfer code: %w", err)
+	}
+
+	metadata := transport.TransferMetadata{
+		TransferID: transferCode,
+		FileName:   archive.header.FolderName,
+		FileSize:   int64(len(payload)),
+		Checksum:   archive.header.Checksum,
+	}
+	bound, err := bindMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	btm.updateIncrementalProgress(PhaseEncrypting, bytesRead, archive.header.FolderName)
+	encryptedData, mode, err := btm.encryptContext(ctx, payload, strengthenedKey, bound)
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %w", err)
+	}
+	btm.updateIncrementalProgress(PhaseTransferring, bytesRead, archive.header.FolderName)
+
+	if err := btm.sendWithReconnect(ctx, sealTransit(encryptedData, bound), metadata); err != nil {
+		return nil, fmt.Errorf("transport failed: %w", err)
+	}
+	btm.reportFilesSent(archived)
+
+	return &FileProcessResult{
+		Size:   archive.header.TotalSize,
+		Hash:   archive.header.Checksum,
+		Unsent: archive.header.Missing,
+		Files:  archive.files,
+		Mode:   mode,
+	}, nil
+}
+
+// archivedAttrs are the timestamp and permissions of an archive entry
+type archivedAttrs struct {
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// processArchivePayload unpacks a folder archive into receivedDir, checking
+// the archive and every file in it against the sender's checksums
+func (btm *BulletproofTransferManager) processArchivePayload(payload []byte, receivedDir, transferCode string) (*receivedPayload, error) {
+	header, archive, err := parseArchivePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := btm.checkReceiveSize(header.TotalSize); err != nil {
+		return nil, err
+	}
+	if btm.integrityChecks {
+		if err := verifyChecksum(archive, header.Checksum); err != nil {
+			return nil, fmt.Errorf("folder archive: %w", err)
+		}
+	}
+
+	// Renaming into place resets folder times and copying across volumes
+	// resets file times, so both are applied once everything is committed
+	attrs := make(map[string]archivedAttrs)
+	received, err := btm.stageReceivedFiles(receivedDir, func(stagingDir string) (*receivedPayload, error) {
+		return btm.unpackArchive(header, archive, stagingDir, attrs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for relPath, attr := range attrs {
+		path, err := safeJoin(receivedDir, relPath)
+		if err != nil {
+			continue
+		}
+		if err := os.Chmod(path, attr.mode); err != nil {
+			btm.updateStatus(fmt.Sprintf("Note: Could not restore permissions of %s: %v", relPath, err))
+		}
+		if err := os.Chtimes(path, attr.modTime, attr.modTime); err != nil {
+			btm.updateStatus(fmt.Sprintf("Note: Could not restore timestamp of %s: %v", relPath, err))
+		}
+	}
+
+	if header.Sender != nil {
+		received.Sender = btm.recognizeSender(header.Sender, transferCode, header.signedContent(checksumOf(archive)))
+	}
+	received.SentAt = header.SentAt
+	return received, nil
+}
+
+// parseArchivePayload splits a decrypted archive payload into its header and
+// archive
+func parseArchivePayload(payload []byte) (*archiveHeader, []byte, error) {
+	if len(payload) < len(archiveMagic)+4 {
+		return nil, nil, fmt.Errorf("folder archive is cut off")
+	}
+	rest := payload[len(archiveMagic):]
+	size := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if size > maxArchiveHeaderSize || int(size) > len(rest) {
+		return nil, nil, fmt.Errorf("folder archive header is damaged")
+	}
+
+	var header archiveHeader
+	if err := json.Unmarshal(rest[:size], &header); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse folder archive header: %w", err)
+	}
+	switch header.Format {
+	case archiveFormatTar, archiveFormatTarGzip:
+	default:
+		return nil, nil, fmt.Errorf("unsupported folder archive format %q", header.Format)
+	}
+	return &header, rest[size:], nil
+}
+
+// unpackArchive writes the archive's files under stagingDir and records the
+// attributes of each entry in attrs, by path relative to stagingDir. The
+// returned payload lists every path written so far even when it fails.
+func (btm *BulletproofTransferManager) unpackArchive(header *archiveHeader, archive []byte, stagingDir string, attrs map[string]archivedAttrs) (*receivedPayload, error) {
+	payload := &receivedPayload{
+		NamesPreserved: true,
+		Verified:       btm.integrityChecks && len(header.Missing) == 0,
+		ManifestFiles:  header.TotalFiles + len(header.Missing),
+		Checksums:      make(map[string]string),
+		Missing:        header.Missing,
+	}
+
+	baseDir := filepath.Join(stagingDir, btm.sanitizeFilename(header.FolderName))
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return payload, fmt.Errorf("failed to create folder %s: %w", header.FolderName, err)
+	}
+
+	var r io.Reader = bytes.NewReader(archive)
+	if header.Format == archiveFormatTarGzip {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return payload, fmt.Errorf("failed to read folder archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	btm.updateStatus(fmt.Sprintf("Unpacking %d files from archive...", header.TotalFiles))
+	for name := range header.Checksums {
+		btm.updateFileStatus(name, 0, FileStatePending, "")
+	}
+	for _, missing := range header.Missing {
+		btm.updateFileStatus(missing.Path, missing.Size, FileStateFailed, missing.Reason)
+	}
+	unpacked := make(map[string]bool, len(header.Checksums))
+	written := make(map[string]bool, len(header.Checksums)) // by path, however the entry spelled it
+	tr := tar.NewReader(r)
+	for {
+		entry, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return payload, fmt.Errorf("failed to read folder archive: %w", err)
+		}
+
+		name := strings.TrimSuffix(entry.Name, "/")
+		fullPath, err := safeJoin(baseDir, name)
+		if err != nil {
+			return payload, fmt.Errorf("rejected unsafe path in transfer: %w", err)
+		}
+		relPath, err := filepath.Rel(stagingDir, fullPath)
+		if err != nil {
+			return payload, err
+		}
+
+		switch entry.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fullPath, 0755); err != nil {
+				return payload, fmt.Errorf("failed to create directory %s: %w", fullPath, err)
+			}
+			payload.Files = append(payload.Files, fullPath)
+			// Owners keep full access to what they received
+			attrs[relPath] = archivedAttrs{mode: os.FileMode(entry.Mode).Perm() | 0700, modTime: entry.ModTime}
+			continue
+		case tar.TypeReg:
+		default:
+			btm.updateStatus(fmt.Sprintf("Warning: Skipping %s (unsupported archive entry)", name))
+			continue
+		}
+
+		// A second entry for a path would replace the first after it was checked
+		if written[relPath] {
+			return payload, fmt.Errorf("folder archive holds %s more than once", name)
+		}
+		written[relPath] = true
+
+		// The header's total is the sender's word, so hold the archive itself to the limit
+		if err := btm.checkReceiveSize(payload.TotalBytes + entry.Size); err != nil {
+			return payload, err
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return payload, fmt.Errorf("failed to create parent directory for %s: %w", fullPath, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return payload, fmt.Errorf("failed to read %s from folder archive: %w", name, err)
+		}
+
+		checksum, listed := header.Checksums[name]
+		if btm.integrityChecks && listed {
+			if err := verifyChecksum(data, checksum); err != nil {
+				btm.updateFileStatus(name, entry.Size, FileStateFailed, err.Error())
+				return payload, fmt.Errorf("file %s: %w", name, err)
+			}
+		} else {
+			payload.Verified = false
+		}
+		unpacked[name] = true
+
+		blocked, err := btm.checkFileType(name, data)
+		if err != nil {
+			return payload, err
+		}
+		if blocked != nil {
+			payload.Blocked = append(payload.Blocked, *blocked)
+			btm.updateFileStatus(name, entry.Size, FileStateFailed, blocked.Reason)
+			continue
+		}
+
+		if err := writeReceivedFile(fullPath, data, 0600); err != nil {
+			btm.updateFileStatus(name, entry.Size, FileStateFailed, err.Error())
+			return payload, fmt.Errorf("failed to write file %s: %w", fullPath, err)
+		}
+		btm.updateFileStatus(name, entry.Size, FileStateDone, "")
+		payload.Files = append(payload.Files, fullPath)
+		payload.TotalBytes += int64(len(data))
+		payload.Checksums[fullPath] = checksumOf(data)
+		attrs[relPath] = archivedAttrs{mode: os.FileMode(entry.Mode).Perm() | 0600, modTime: entry.ModTime}
+
+		btm.completeFile(int64(len(data)))
+		btm.updateProgress(TransferProgress{
+			FileName:     name,
+			FileBytes:    int64(len(data)),
+			FileSize:     entry.Size,
+			OverallBytes: payload.TotalBytes,
+			OverallSize:  header.TotalSize,
+			FilesTotal:   header.TotalFiles,
+		})
+	}
+
+	// Every file the sender archived must have come out of the archive
+	for name := range header.Checksums {
+		if !unpacked[name] {
+			payload.Verified = false
+			payload.Missing = append(payload.Missing, MissingFile{Path: name, Reason: MissingReasonNoData})
+			btm.updateFileStatus(name, 0, FileStateFailed, MissingReasonNoData)
+		}
+	}
+	return payload, nil
+}
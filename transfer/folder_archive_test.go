@@ -0,0 +1,230 @@
+package transfer
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveEntry adds a file called name holding data to a, listed in the
+// header like any file the sender reads
+func archiveEntry(t *testing.T, a *folderArchive, name, data string) {
+	t.Helper()
+	info, err := os.Stat(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.addFile(FileInfo{RelativePath: name, OriginalPath: name}, info, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// unlistedEntry adds a file to a's archive without listing it in the header
+func unlistedEntry(t *testing.T, a *folderArchive, name, data string) {
+	t.Helper()
+	if err := a.tar.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(data)), Mode: 0644}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.tar.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func finishArchive(t *testing.T, a *folderArchive) []byte {
+	t.Helper()
+	payload, err := a.finish(func(signedContent) *SenderIdentity { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestParseArchivePayload(t *testing.T) {
+	framed := func(size uint32, header string) []byte {
+		payload := append([]byte{}, archiveMagic...)
+		payload = binary.BigEndian.AppendUint32(payload, size)
+		return append(payload, header...)
+	}
+	valid := finishArchive(t, newFolderArchive("photos", false))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr string
+	}{
+		{name: "valid", payload: valid},
+		{name: "magic only", payload: append([]byte{}, archiveMagic...), wantErr: "cut off"},
+		{name: "size cut off", payload: append(append([]byte{}, archiveMagic...), 0, 0), wantErr: "cut off"},
+		{name: "header cut off", payload: valid[:len(archiveMagic)+8], wantErr: "damaged"},
+		{name: "header size past payload", payload: framed(100, `{"format":"tar"}`), wantErr: "damaged"},
+		{name: "header over the limit", payload: framed(maxArchiveHeaderSize+1, `{"format":"tar"}`), wantErr: "damaged"},
+		{name: "header not json", payload: framed(4, "tar!"), wantErr: "failed to parse"},
+		{name: "unknown format", payload: framed(16, `{"format":"zip"}`), wantErr: "unsupported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, archive, err := parseArchivePayload(tt.payload)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.FolderName != "photos" || header.Format != archiveFormatTar {
+				t.Errorf("header = %+v, want the photos tar archive", header)
+			}
+			if checksumOf(archive) != header.Checksum {
+				t.Error("archive returned does not match the header's checksum")
+			}
+		})
+	}
+}
+
+func TestUnpackArchive(t *testing.T) {
+	tests := []struct {
+		name       string
+		compress   bool
+		maxReceive int64
+		build      func(t *testing.T, a *folderArchive)
+		wantErr    string
+		wantFiles  map[string]string // contents by path under the folder
+		unverified bool
+		missing    []string
+	}{
+		{
+			name:     "gzip round trip",
+			compress: true,
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "a.txt", "first")
+				archiveEntry(t, a, "sub/b.txt", strings.Repeat("second ", 1000))
+			},
+			wantFiles: map[string]string{"a.txt": "first", "sub/b.txt": strings.Repeat("second ", 1000)},
+		},
+		{
+			name: "parent reference",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "../escape.txt", "outside")
+			},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "nested parent reference",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "sub/../../escape.txt", "outside")
+			},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "absolute path",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "/tmp/escape.txt", "outside")
+			},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "duplicate entry",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "a.txt", "first")
+				unlistedEntry(t, a, "a.txt", "replaced")
+			},
+			wantErr: "more than once",
+		},
+		{
+			name: "duplicate entry spelled differently",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "a.txt", "first")
+				unlistedEntry(t, a, "./a.txt", "replaced")
+			},
+			wantErr: "more than once",
+		},
+		{
+			name: "unlisted entry",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "a.txt", "first")
+				unlistedEntry(t, a, "extra.txt", "unchecked")
+			},
+			wantFiles:  map[string]string{"a.txt": "first", "extra.txt": "unchecked"},
+			unverified: true,
+		},
+		{
+			name: "listed file absent",
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "a.txt", "first")
+				a.header.Checksums["gone.txt"] = checksumOf([]byte("gone"))
+			},
+			wantFiles:  map[string]string{"a.txt": "first"},
+			unverified: true,
+			missing:    []string{"gone.txt"},
+		},
+		{
+			name:       "entry over the receive limit",
+			maxReceive: 1024,
+			build: func(t *testing.T, a *folderArchive) {
+				archiveEntry(t, a, "small.txt", "fits")
+				archiveEntry(t, a, "big.bin", strings.Repeat("x", 2048))
+				a.header.TotalSize = 4 // The header understating it must not matter
+			},
+			wantErr: ErrTransferTooLarge.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newFolderArchive("shared", tt.compress)
+			tt.build(t, a)
+			header, archive, err := parseArchivePayload(finishArchive(t, a))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, receiver := loopbackPair(t)
+			receiver.maxReceiveSize = tt.maxReceive
+			stagingDir := t.TempDir()
+			received, err := receiver.unpackArchive(header, archive, stagingDir, make(map[string]archivedAttrs))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				if tt.maxReceive != 0 && !errors.Is(err, ErrTransferTooLarge) {
+					t.Errorf("err = %v, want ErrTransferTooLarge", err)
+				}
+				for _, dir := range []string{stagingDir, filepath.Dir(stagingDir)} {
+					if _, err := os.Stat(filepath.Join(dir, "escape.txt")); err == nil {
+						t.Errorf("entry was written outside the folder, in %s", dir)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, want := range tt.wantFiles {
+				got, err := os.ReadFile(filepath.Join(stagingDir, "shared", filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != want {
+					t.Errorf("%s holds %q, want %q", name, got, want)
+				}
+			}
+			if received.Verified == tt.unverified {
+				t.Errorf("verified = %v, want %v", received.Verified, !tt.unverified)
+			}
+			var missing []string
+			for _, file := range received.Missing {
+				missing = append(missing, file.Path)
+			}
+			if strings.Join(missing, ",") != strings.Join(tt.missing, ",") {
+				t.Errorf("missing = %v, want %v", missing, tt.missing)
+			}
+		})
+	}
+}
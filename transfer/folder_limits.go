@@ -0,0 +1,101 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Default folder limits. A folder is sent as one manifest built in memory,
+// so tens of thousands of files or gigabytes of contents strain both ends
+// and the relay.
+const (
+	DefaultMaxFolderFiles = 10000
+	DefaultMaxFolderBytes = 1024 * 1024 * 1024 // 1GB
+)
+
+// folderCountReportInterval is how many files are counted between status
+// updates while a folder is measured
+const folderCountReportInterval = 1000
+
+// ErrFolderTooLarge is matched by errors.Is when a send is refused because
+// its folders exceed the folder limits
+var ErrFolderTooLarge = errors.New("folder is too large to send as is")
+
+// FolderLimitError describes a send over the folder limits
+type FolderLimitError struct {
+	Files    int   // Files in the selected folders
+	Bytes    int64 // Bytes in the selected folders
+	MaxFiles int   // Limit on Files, 0 if off
+	MaxBytes int64 // Limit on Bytes, 0 if off
+}
+
+// Error says which limit was passed and what to do instead
+func (e *FolderLimitError) Error() string {
+	var over string
+	switch {
+	case e.MaxFiles > 0 && e.Files > e.MaxFiles:
+		over = fmt.Sprintf("%d files, more than the limit of %d", e.Files, e.MaxFiles)
+	default:
+		over = fmt.Sprintf("%s, more than the limit of %s", FormatBytes(e.Bytes), FormatBytes(e.MaxBytes))
+	}
+	return fmt.Sprintf("the selected folders hold %s; archive them into a single .zip or .tar file first, which is faster and lighter on memory", over)
+}
+
+// Unwrap exposes ErrFolderTooLarge
+func (e *FolderLimitError) Unwrap() error {
+	return ErrFolderTooLarge
+}
+
+// folderLimitsConfirmedKey marks a context whose sends may pass the folder limits
+type folderLimitsConfirmedKey struct{}
+
+// ConfirmFolderLimits returns a context whose sends go ahead past the folder
+// limits, for use once the user has confirmed a send PreflightSend warned about
+func ConfirmFolderLimits(ctx context.Context) context.Context {
+	return context.WithValue(ctx, folderLimitsConfirmedKey{}, true)
+}
+
+// SetFolderLimits sets how many files and bytes the folders in one send may
+// hold before the send needs confirming. Zero restores a default and a
+// negative value turns that limit off.
+func (btm *BulletproofTransferManager) SetFolderLimits(maxFiles int, maxBytes int64) {
+	if maxFiles == 0 {
+		maxFiles = DefaultMaxFolderFiles
+	}
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxFolderBytes
+	}
+	btm.maxFolderFiles = max(maxFiles, 0)
+	btm.maxFolderBytes = max(maxBytes, 0)
+}
+
+// folderLimitError returns a *FolderLimitError if the folders in sizes pass
+// the limits, and nil otherwise
+func (btm *BulletproofTransferManager) folderLimitError(sizes sendSizes) error {
+	overFiles := btm.maxFolderFiles > 0 && sizes.folderFiles > btm.maxFolderFiles
+	overBytes := btm.maxFolderBytes > 0 && sizes.folderBytes > btm.maxFolderBytes
+	if !overFiles && !overBytes {
+		return nil
+	}
+	return &FolderLimitError{
+		Files:    sizes.folderFiles,
+		Bytes:    sizes.folderBytes,
+		MaxFiles: btm.maxFolderFiles,
+		MaxBytes: btm.maxFolderBytes,
+	}
+}
+
+// checkFolderLimits refuses a send over the folder limits unless ctx carries
+// the user's confirmation
+func (btm *BulletproofTransferManager) checkFolderLimits(ctx context.Context, sizes sendSizes) error {
+	err := btm.folderLimitError(sizes)
+	if err == nil {
+		return nil
+	}
+	if confirmed, _ := ctx.Value(folderLimitsConfirmedKey{}).(bool); confirmed {
+		btm.updateStatus(fmt.Sprintf("Warning: %v", err))
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// folderFileRetries is how many more times a folder file that could not be
+// read is tried, with the retry strategy's backoff between rounds
+const folderFileRetries = 2
+
+// retryUnreadableFiles tries again to read folder files that failed during the
+// walk and adds the ones that now succeed to manifest. Files that still cannot
+// be read are listed in the manifest with the error, so the receiver reports
+// them as missing, and are returned so the sender can report them too.
+func (btm *BulletproofTransferManager) retryUnreadableFiles(ctx context.Context, pending []FileInfo, manifest *FileManifest, bytesRead *int64) ([]MissingFile, error) {
+	failed, err := btm.retryFolderReads(ctx, pending, func(fileInfo FileInfo, data []byte) error {
+		hash := sha256.Sum256(data)
+		fileInfo.Hash = hex.EncodeToString(hash[:])
+		fileInfo.Data = data
+		manifest.Files[fileInfo.RelativePath] = fileInfo
+		manifest.TotalFiles++
+		manifest.TotalSize += int64(len(data))
+		*bytesRead += int64(len(data))
+		btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateInProgress, "")
+		btm.updateIncrementalProgress(PhaseReading, *bytesRead, fileInfo.RelativePath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unsent []MissingFile
+	for _, fileInfo := range failed {
+		manifest.Files[fileInfo.RelativePath] = fileInfo
+		manifest.TotalFiles++
+		unsent = append(unsent, MissingFile{Path: fileInfo.RelativePath, Size: fileInfo.Size, Reason: MissingReasonUnreadable})
+	}
+	return unsent, nil
+}
+
+// retryFolderReads tries again to read folder files that failed during the
+// walk and passes each one that now succeeds to add. It returns the files
+// that still cannot be read, with SendError saying why.
+func (btm *BulletproofTransferManager) retryFolderReads(ctx context.Context, pending []FileInfo, add func(fileInfo FileInfo, data []byte) error) ([]FileInfo, error) {
+	strategy := btm.adaptiveSettings.RetryStrategy
+	errs := make(map[string]error, len(pending))
+
+	for round := 1; round <= folderFileRetries && len(pending) > 0; round++ {
+		delay := btm.calculateInstitutionalNetworkDelay(round+1, strategy)
+		btm.updateStatus(fmt.Sprintf("Retrying %d unreadable files in %v...", len(pending), delay.Round(time.Second)))
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		var stillPending []FileInfo
+		for _, fileInfo := range pending {
+			data, err := os.ReadFile(fileInfo.OriginalPath)
+			if err != nil {
+				errs[fileInfo.RelativePath] = err
+				stillPending = append(stillPending, fileInfo)
+				continue
+			}
+			if err := add(fileInfo, data); err != nil {
+				return nil, err
+			}
+		}
+		pending = stillPending
+	}
+
+	for i, fileInfo := range pending {
+		err := errs[fileInfo.RelativePath]
+		if err == nil {
+			err = fmt.Errorf("could not be read")
+		}
+		btm.updateStatus(fmt.Sprintf("Warning: Could not read %s, it will not be sent: %v", fileInfo.RelativePath, err))
+		btm.updateFileStatus(fileInfo.RelativePath, fileInfo.Size, FileStateFailed, err.Error())
+		pending[i].SendError = err.Error()
+	}
+	return pending, nil
+}
@@ -0,0 +1,47 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// recoverFromChainFailure performs a hard reset when err shows that every
+// transport failed: rather than retrying the same possibly-wedged relay
+// sessions, the transports are rebuilt from scratch after the hard reset
+// backoff. It reports whether a reset was performed; hardResets counts the
+// resets made so far in the current retry loop.
+func (btm *BulletproofTransferManager) recoverFromChainFailure(ctx context.Context, err error, hardResets *int, strategy RetryStrategy) (bool, error) {
+	if !errors.Is(err, transport.ErrAllTransportsFailed) || *hardResets >= strategy.MaxHardResets || btm.transportManager == nil {
+		return false, nil
+	}
+
+	*hardResets++
+	delay := hardResetDelay(*hardResets, strategy)
+	btm.updateStatus(fmt.Sprintf("All transfer methods failed - starting fresh connections in %v (reset %d/%d)...",
+		delay.Round(time.Second), *hardResets, strategy.MaxHardResets))
+	if err := sleepContext(ctx, delay); err != nil {
+		return false, err
+	}
+
+	if resetErr := btm.transportManager.HardReset(); resetErr != nil {
+		btm.updateStatus(fmt.Sprintf("Warning: %v", resetErr))
+	}
+	btm.connectivityCache.InvalidateAll()
+	return true, nil
+}
+
+// hardResetDelay doubles the hard reset delay for each reset, capped at MaxDelay
+func hardResetDelay(resets int, strategy RetryStrategy) time.Duration {
+	delay := strategy.HardResetDelay
+	for i := 1; i < resets && delay < strategy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if strategy.MaxDelay > 0 && delay > strategy.MaxDelay {
+		delay = strategy.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,149 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// defaultHookTimeout bounds a hook command
+const defaultHookTimeout = 5 * time.Minute
+
+// maxHookOutput caps how much hook output is logged
+const maxHookOutput = 64 * 1024
+
+// TransferHooks are commands run after a transfer succeeds, for example to
+// start processing a dataset as soon as it arrives. Each is a program and its
+// arguments, run directly rather than through a shell, so nothing the sender
+// chose can become part of a command. The hook gets the transfer summary (see
+// TransferResult.ToJSON) on standard input and TRUSTDROP_* environment
+// variables describing the transfer.
+type TransferHooks struct {
+	AfterSend    []string `json:"after_send,omitempty"`
+	AfterReceive []string `json:"after_receive,omitempty"`
+
+	// Timeout bounds each hook, in Go syntax such as "30s"; the default is 5m
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate checks the commands and timeout
+func (h TransferHooks) Validate() error {
+	for name, command := range map[string][]string{"after_send": h.AfterSend, "after_receive": h.AfterReceive} {
+		if len(command) > 0 && command[0] == "" {
+			return fmt.Errorf("%s hook has no program", name)
+		}
+	}
+	_, err := h.timeout()
+	return err
+}
+
+// timeout returns how long a hook may run
+func (h TransferHooks) timeout() (time.Duration, error) {
+	if h.Timeout == "" {
+		return defaultHookTimeout, nil
+	}
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("hook timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("hook timeout must be positive")
+	}
+	return timeout, nil
+}
+
+// SetHooks sets the commands run after transfers succeed. The zero value runs
+// none.
+func (btm *BulletproofTransferManager) SetHooks(hooks TransferHooks) error {
+	if err := hooks.Validate(); err != nil {
+		return err
+	}
+	btm.hooks = hooks
+	return nil
+}
+
+// runHook runs the hook for direction after a successful transfer. A failing
+// hook is logged and reported as a warning and in result.HookError; the
+// transfer itself still counts as successful.
+func (btm *BulletproofTransferManager) runHook(direction string, result *TransferResult, err error) {
+	command := btm.hooks.AfterSend
+	if direction == "receive" {
+		command = btm.hooks.AfterReceive
+	}
+	if len(command) == 0 || err != nil || result == nil || !result.Success {
+		return
+	}
+
+	output, hookErr := btm.runHookCommand(command, result)
+	if len(output) > 0 {
+		if len(output) > maxHookOutput {
+			output = append(output[:maxHookOutput:maxHookOutput], "\n[output truncated]"...)
+		}
+		logging.Infof("after-%s hook output:\n%s", direction, bytes.TrimRight(output, "\n"))
+	}
+	if hookErr != nil {
+		result.HookError = hookErr
+		logging.Warnf("after-%s hook %s failed: %v", direction, command[0], hookErr)
+		btm.deliverStatus(fmt.Sprintf("Warning: The after-%s hook failed: %v", direction, hookErr))
+	}
+}
+
+// runHookCommand runs command with the summary of result on standard input
+// and returns its combined output
+func (btm *BulletproofTransferManager) runHookCommand(command []string, result *TransferResult) ([]byte, error) {
+	summary, err := result.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := btm.hooks.timeout()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Resolve the program before running it in the destination, where a
+	// relative path would otherwise pick a file the sender just wrote
+	program, err := resolveProgram(command[0])
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, program, command[1:]...)
+	cmd.Stdin = bytes.NewReader(summary)
+	cmd.Env = append(os.Environ(), hookEnv(result)...)
+	if result.Direction == "receive" && result.DestinationDir != "" {
+		cmd.Dir = result.DestinationDir
+	}
+
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %v", timeout)
+	}
+	return output, err
+}
+
+// hookEnv describes a transfer to a hook. The transfer code, the secret the
+// encryption key comes from, is only in the summary on standard input, since
+// other processes can often read a program's environment.
+func hookEnv(result *TransferResult) []string {
+	paths := make([]string, len(result.Files))
+	for i, file := range result.Files {
+		paths[i] = file.Path
+	}
+	return []string{
+		"TRUSTDROP_DIRECTION=" + result.Direction,
+		"TRUSTDROP_DESTINATION_DIR=" + result.DestinationDir,
+		"TRUSTDROP_FILE_COUNT=" + strconv.Itoa(len(result.Files)),
+		"TRUSTDROP_TOTAL_BYTES=" + strconv.FormatInt(result.TotalBytes, 10),
+		"TRUSTDROP_INTEGRITY_VERIFIED=" + strconv.FormatBool(result.IntegrityVerified),
+		"TRUSTDROP_FILES=" + strings.Join(paths, string(os.PathListSeparator)),
+	}
+}
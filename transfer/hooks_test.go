@@ -0,0 +1,48 @@
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReceiveHookIgnoresReceivedProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	destination := t.TempDir()
+	marker := filepath.Join(destination, "ran")
+	script := "#!/bin/sh\ntouch " + marker + "\n"
+	if err := os.WriteFile(filepath.Join(destination, "notify.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	btm := &BulletproofTransferManager{hooks: TransferHooks{AfterReceive: []string{"./notify.sh"}}}
+	result := &TransferResult{Success: true, Direction: "receive", DestinationDir: destination}
+	btm.runHook("receive", result, nil)
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("received file was run as the hook")
+	}
+	if result.HookError == nil {
+		t.Fatal("hook with a program only present in the destination did not fail")
+	}
+}
+
+func TestReceiveHookRunsInDestination(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell command")
+	}
+	destination := t.TempDir()
+	btm := &BulletproofTransferManager{hooks: TransferHooks{AfterReceive: []string{"sh", "-c", "touch ran"}}}
+	result := &TransferResult{Success: true, Direction: "receive", DestinationDir: destination}
+	btm.runHook("receive", result, nil)
+
+	if result.HookError != nil {
+		t.Fatalf("hook failed: %v", result.HookError)
+	}
+	if _, err := os.Stat(filepath.Join(destination, "ran")); err != nil {
+		t.Fatalf("hook did not run in the destination: %v", err)
+	}
+}
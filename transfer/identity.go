@@ -0,0 +1,210 @@
+package transfer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	identityKeyFile   = "sender_identity.key"
+	knownSendersFile  = "known_senders.json"
+	identitySignLabel = "trustdrop-sender-v2"
+)
+
+// SenderIdentity is the optional identity a sender attaches to a transfer. The
+// signature covers the transfer code, the display name and the signedContent
+// of the payload, so an identity cannot be copied onto other content, even
+// content sent later with the same code.
+type SenderIdentity struct {
+	PublicKey   []byte `json:"public_key"`
+	DisplayName string `json:"display_name,omitempty"`
+	Signature   []byte `json:"signature"`
+}
+
+// SenderInfo describes who sent a received transfer
+type SenderInfo struct {
+	Fingerprint string // Stable fingerprint of the sender's public key
+	DisplayName string // Name the sender chose, if any
+	Known       bool   // Whether this sender has been seen before
+}
+
+// signedContent is what a sender identity vouches for: the name, size and
+// checksum of the payload's contents and when they were sent. Both sides
+// derive it from the contents themselves, leaving out the identity.
+type signedContent struct {
+	Name     string
+	Size     int64
+	Checksum string
+	SentAt   time.Time
+}
+
+// knownSender is a previously seen sender as persisted in known_senders.json
+type knownSender struct {
+	DisplayName string    `json:"display_name,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// SetSenderIdentity controls whether sent transfers carry this device's
+// identity, a key fingerprint plus an optional display name. The signing key
+// is created in the data directory the first time it is needed.
+func (btm *BulletproofTransferManager) SetSenderIdentity(share bool, displayName string) error {
+	if share && btm.identityKey == nil {
+		key, err := loadOrCreateIdentityKey(btm.targetDataDir)
+		if err != nil {
+			return err
+		}
+		btm.identityKey = key
+	}
+
+	btm.shareIdentity = share
+	btm.displayName = strings.TrimSpace(displayName)
+	return nil
+}
+
+// IdentityFingerprint returns this device's sender fingerprint, or "" if no
+// identity has been set up
+func (btm *BulletproofTransferManager) IdentityFingerprint() string {
+	if btm.identityKey == nil {
+		return ""
+	}
+	return fingerprint(btm.identityKey.Public().(ed25519.PublicKey))
+}
+
+// senderIdentity returns the identity to attach to a transfer of content, or
+// nil when identity sharing is off
+func (btm *BulletproofTransferManager) senderIdentity(transferCode string, content signedContent) *SenderIdentity {
+	if !btm.shareIdentity || btm.identityKey == nil {
+		return nil
+	}
+
+	return &SenderIdentity{
+		PublicKey:   btm.identityKey.Public().(ed25519.PublicKey),
+		DisplayName: btm.displayName,
+		Signature:   ed25519.Sign(btm.identityKey, identityMessage(transferCode, btm.displayName, content)),
+	}
+}
+
+// recognizeSender verifies a received identity against the content that was
+// actually decrypted and records it as known, reporting whether it had been
+// seen before. It returns nil if the transfer carried no identity or the
+// signature does not match.
+func (btm *BulletproofTransferManager) recognizeSender(identity *SenderIdentity, transferCode string, content signedContent) *SenderInfo {
+	if identity == nil {
+		return nil
+	}
+
+	if len(identity.PublicKey) != ed25519.PublicKeySize ||
+		!ed25519.Verify(identity.PublicKey, identityMessage(transferCode, identity.DisplayName, content), identity.Signature) {
+		btm.updateStatus("Warning: Sender identity could not be verified and was ignored")
+		return nil
+	}
+
+	info := &SenderInfo{
+		Fingerprint: fingerprint(identity.PublicKey),
+		DisplayName: identity.DisplayName,
+	}
+
+	path := filepath.Join(btm.targetDataDir, knownSendersFile)
+	known := make(map[string]knownSender)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &known); err != nil {
+			btm.updateStatus(fmt.Sprintf("Warning: Could not read known senders: %v", err))
+		}
+	}
+
+	now := time.Now()
+	entry, seen := known[info.Fingerprint]
+	info.Known = seen
+	if !seen {
+		entry.FirstSeen = now
+	}
+	entry.LastSeen = now
+	entry.DisplayName = identity.DisplayName
+	known[info.Fingerprint] = entry
+
+	if data, err := json.MarshalIndent(known, "", "  "); err == nil {
+		if err := writeFileAtomic(path, data, 0600); err != nil {
+			btm.updateStatus(fmt.Sprintf("Warning: Could not save known senders: %v", err))
+		}
+	}
+
+	return info
+}
+
+// identityMessage is what a sender signs for a transfer of content
+func identityMessage(transferCode, displayName string, content signedContent) []byte {
+	fields := []string{
+		identitySignLabel,
+		transferCode,
+		displayName,
+		content.Name,
+		strconv.FormatInt(content.Size, 10),
+		content.Checksum,
+		content.SentAt.UTC().Format(time.RFC3339Nano),
+	}
+	return []byte(strings.Join(fields, "\x00"))
+}
+
+// signedContent describes the manifest for its sender identity. The checksum
+// covers the whole manifest but the identity, file contents included.
+func (m FileManifest) signedContent() signedContent {
+	m.Sender = nil
+	data, _ := json.Marshal(m) // encodeManifest has already encoded the same fields
+	return signedContent{Name: m.FolderName, Size: m.TotalSize, Checksum: checksumOf(data), SentAt: m.SentAt}
+}
+
+// signedContent is what the sender identity signs for a folder archive: its
+// header, holding archiveChecksum as the checksum of the archive itself
+func (h archiveHeader) signedContent(archiveChecksum string) signedContent {
+	h.Sender = nil
+	h.Checksum = archiveChecksum
+	data, _ := json.Marshal(h)
+	return signedContent{Name: h.FolderName, Size: h.TotalSize, Checksum: checksumOf(data), SentAt: h.SentAt}
+}
+
+// fingerprint formats the first 16 bytes of the key's SHA-256 as colon-separated groups
+func fingerprint(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	encoded := hex.EncodeToString(sum[:16])
+
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, ":")
+}
+
+// loadOrCreateIdentityKey reads the identity key from dataDir, generating and
+// saving a new one if none exists
+func loadOrCreateIdentityKey(dataDir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(dataDir, identityKeyFile)
+
+	if data, err := os.ReadFile(path); err == nil {
+		seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("sender identity key %s is corrupt", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read sender identity key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate sender identity key: %w", err)
+	}
+	if err := writeFileAtomic(path, []byte(hex.EncodeToString(key.Seed())), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save sender identity key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,91 @@
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSenderIdentityRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder bool
+		setup  func(t *testing.T, sender *BulletproofTransferManager)
+	}{
+		{name: "json file"},
+		{name: "file frame", setup: func(t *testing.T, sender *BulletproofTransferManager) {
+			sender.SetBinaryFiles(true)
+		}},
+		{name: "folder manifest", folder: true},
+		{name: "folder archive", folder: true, setup: func(t *testing.T, sender *BulletproofTransferManager) {
+			if err := sender.SetArchiveConfig(ArchiveConfig{Mode: ArchiveModeAlways, Compress: true}); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := filepath.Join(t.TempDir(), "notes.txt")
+			if tt.folder {
+				source = filepath.Join(t.TempDir(), "notes")
+				if err := os.Mkdir(source, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(source, "a.txt"), []byte("first"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := os.WriteFile(source, []byte("signed contents"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sender, receiver := loopbackPair(t)
+			if err := sender.SetSenderIdentity(true, "Alice"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(t, sender)
+			}
+
+			result, _ := loopbackTransfer(t, sender, receiver, source)
+			if result.Sender == nil {
+				t.Fatal("signed transfer arrived without a verified sender")
+			}
+			if result.Sender.DisplayName != "Alice" || result.Sender.Fingerprint != sender.IdentityFingerprint() {
+				t.Errorf("sender = %+v, want Alice with fingerprint %s", result.Sender, sender.IdentityFingerprint())
+			}
+		})
+	}
+}
+
+func TestSenderIdentityBindsContent(t *testing.T) {
+	const code = "identity-test-code"
+	sender, receiver := loopbackPair(t)
+	if err := sender.SetSenderIdentity(true, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	sentAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	content := signedContent{Name: "report.pdf", Size: 42, Checksum: checksumOf([]byte("original")), SentAt: sentAt}
+	identity := sender.senderIdentity(code, content)
+
+	if receiver.recognizeSender(identity, code, content) == nil {
+		t.Fatal("identity did not verify against the content it signed")
+	}
+
+	tampered := map[string]signedContent{
+		"name":     {Name: "invoice.pdf", Size: 42, Checksum: content.Checksum, SentAt: sentAt},
+		"size":     {Name: "report.pdf", Size: 43, Checksum: content.Checksum, SentAt: sentAt},
+		"checksum": {Name: "report.pdf", Size: 42, Checksum: checksumOf([]byte("replaced")), SentAt: sentAt},
+		"sent at":  {Name: "report.pdf", Size: 42, Checksum: content.Checksum, SentAt: sentAt.Add(time.Second)},
+	}
+	for field, other := range tampered {
+		if receiver.recognizeSender(identity, code, other) != nil {
+			t.Errorf("identity verified against content with a different %s", field)
+		}
+	}
+	if receiver.recognizeSender(identity, "other-code", content) != nil {
+		t.Error("identity verified under a different transfer code")
+	}
+}
@@ -0,0 +1,52 @@
+package transfer
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileAtInMemoryLimitFitsPayloadCap(t *testing.T) {
+	const limit = 256 * 1024
+	data := make([]byte, limit)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(t.TempDir(), "limit.bin")
+	if err := os.WriteFile(source, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sender, receiver := loopbackPair(t)
+	sender.SetMaxInMemorySize(limit)
+	result, err := sender.SendFilesContext(context.Background(), []string{source}, "loopback-test-code")
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	// The payload is the encoded file plus headers and encryption, which
+	// payloadOverhead leaves plenty of room for
+	encoded := maxPayloadFor(limit) - payloadOverhead
+	if result.WireBytes < encoded || result.WireBytes-encoded > 64*1024 {
+		t.Errorf("payload of %d bytes is not the %d byte encoded file plus headers", result.WireBytes, encoded)
+	}
+	if _, err := receiver.ReceiveFilesToContext(context.Background(), "loopback-test-code", t.TempDir()); err != nil {
+		t.Fatalf("receive failed: %v", err)
+	}
+}
+
+func TestFileOverInMemoryLimitRefused(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "over.bin")
+	if err := os.WriteFile(source, make([]byte, 1025), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sender, _ := loopbackPair(t)
+	sender.SetMaxInMemorySize(1024)
+	_, err := sender.SendFilesContext(context.Background(), []string{source}, "loopback-test-code")
+	if !errors.Is(err, errOverInMemoryLimit) {
+		t.Fatalf("send over the limit returned %v, want the in-memory limit refusal", err)
+	}
+}
@@ -0,0 +1,40 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// checksumOf returns the hex SHA-256 checksum of data
+func checksumOf(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// fileChecksum returns the hex SHA-256 checksum of the file at path
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyChecksum checks data against the hex SHA-256 checksum sent with it
+func verifyChecksum(data []byte, expected string) error {
+	actual := checksumOf(data)
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrIntegrityFailed, expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// SetKeepalive sets how often the pool probes its connections so firewalls
+// don't drop them while idle. Connections that miss their probes are closed
+// and dialed again on next use. An interval of zero turns probes off for
+// connections dialed after the call.
+func (cp *ConnectionPool) SetKeepalive(interval time.Duration) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	cp.keepalive = interval
+}
+
+// SetKeepaliveInterval sets how often idle relay connections, pooled and in
+// use by a transfer, are probed to keep firewalls from dropping them. Zero
+// restores transport.DefaultKeepaliveInterval and a negative interval turns
+// probes off.
+func (btm *BulletproofTransferManager) SetKeepaliveInterval(interval time.Duration) {
+	if btm.connectionPool != nil {
+		btm.connectionPool.SetKeepalive(transport.KeepaliveInterval(interval))
+	}
+	if btm.transportManager != nil {
+		btm.transportManager.SetKeepaliveInterval(interval)
+	}
+}
+
+// parseKeepalive reads the keepalive config value: a Go duration such as
+// "20s", or "off" to turn probes off
+func parseKeepalive(value string) (time.Duration, error) {
+	if value == "off" {
+		return -1, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("keepalive interval must be positive, got %s", value)
+	}
+	return interval, nil
+}
@@ -0,0 +1,70 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ManifestVersion is the folder manifest layout this build writes. Bump it
+// whenever a change to FileManifest means an older receiver would misread a
+// manifest, and teach decodeManifest to read the old layouts. Manifests from
+// before versioning carry no version and read as version 1.
+const ManifestVersion = 1
+
+// ManifestVersionError reports a folder manifest written in a layout newer
+// than this build reads
+type ManifestVersionError struct {
+	Version   int // Layout the sender wrote
+	Supported int // Newest layout this build reads
+}
+
+// Error says the receiver needs updating
+func (e *ManifestVersionError) Error() string {
+	return fmt.Sprintf("the folder was sent with manifest version %d, newer than the %d this app reads - update to receive it",
+		e.Version, e.Supported)
+}
+
+// Unwrap exposes ErrVersionMismatch
+func (e *ManifestVersionError) Unwrap() error {
+	return ErrVersionMismatch
+}
+
+// manifestEnvelope holds the fields of a manifest that every layout keeps, so
+// the version can be read before the rest is decoded
+type manifestEnvelope struct {
+	Version int             `json:"version"`
+	Files   json.RawMessage `json:"files"`
+}
+
+// encodeManifest serializes manifest in the current layout
+func encodeManifest(manifest *FileManifest) ([]byte, error) {
+	manifest.Version = ManifestVersion
+	return json.Marshal(manifest)
+}
+
+// decodeManifest reads a folder manifest of any layout this build knows. It
+// reports false when data is not a manifest at all, such as a single-file
+// payload, and a ManifestVersionError for a manifest from a newer layout,
+// without trying to decode fields whose meaning may have changed.
+func decodeManifest(data []byte) (*FileManifest, bool, error) {
+	var envelope manifestEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil || len(envelope.Files) == 0 || string(envelope.Files) == "null" {
+		return nil, false, nil
+	}
+	if envelope.Version > ManifestVersion {
+		return nil, true, &ManifestVersionError{Version: envelope.Version, Supported: ManifestVersion}
+	}
+	if envelope.Version < 0 {
+		return nil, true, fmt.Errorf("folder manifest has an invalid version %d", envelope.Version)
+	}
+
+	// Version 0, from senders before versioning, has the version 1 layout
+	var manifest FileManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, true, fmt.Errorf("failed to read folder manifest: %w", err)
+	}
+	if len(manifest.Files) == 0 {
+		return nil, false, nil
+	}
+	return &manifest, true, nil
+}
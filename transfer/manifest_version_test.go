@@ -0,0 +1,96 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testManifest() *FileManifest {
+	return &FileManifest{
+		Files: map[string]FileInfo{
+			"notes.txt": {RelativePath: "notes.txt", Size: 5, Hash: "abc", Data: []byte("notes")},
+			"sub":       {RelativePath: "sub", IsDirectory: true},
+		},
+		FolderName: "project",
+		TotalFiles: 2,
+		TotalSize:  5,
+		EmbedLimit: DefaultMaxEmbedSize,
+		SentAt:     time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	sent := testManifest()
+	data, err := encodeManifest(sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Version != ManifestVersion {
+		t.Fatalf("encoded manifest has version %d, want %d", sent.Version, ManifestVersion)
+	}
+
+	received, isManifest, err := decodeManifest(data)
+	if err != nil || !isManifest {
+		t.Fatalf("decodeManifest: %v, manifest %v", err, isManifest)
+	}
+	if !reflect.DeepEqual(received, sent) {
+		t.Fatalf("manifest changed in the round trip:\n got %+v\nwant %+v", received, sent)
+	}
+}
+
+func TestManifestFromBeforeVersioning(t *testing.T) {
+	data, err := encodeManifest(testManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Older senders wrote the same layout without the version field
+	legacy := bytes.Replace(data, []byte(fmt.Sprintf(`"version":%d,`, ManifestVersion)), nil, 1)
+	if bytes.Equal(legacy, data) {
+		t.Fatal("test manifest has no version field to remove")
+	}
+
+	received, isManifest, err := decodeManifest(legacy)
+	if err != nil || !isManifest {
+		t.Fatalf("decodeManifest: %v, manifest %v", err, isManifest)
+	}
+	if received.Version != 0 || len(received.Files) != 2 || received.FolderName != "project" {
+		t.Fatalf("unversioned manifest read as %+v", received)
+	}
+}
+
+func TestManifestFromNewerVersionIsRejected(t *testing.T) {
+	newer := fmt.Sprintf(`{"version":%d,"files":{"a":{"relative_path":"a","layout":"changed"}}}`, ManifestVersion+1)
+
+	_, isManifest, err := decodeManifest([]byte(newer))
+	if !isManifest {
+		t.Fatal("newer manifest not recognized as a manifest")
+	}
+	var versionErr *ManifestVersionError
+	if !errors.As(err, &versionErr) || versionErr.Version != ManifestVersion+1 || versionErr.Supported != ManifestVersion {
+		t.Fatalf("got %v, want a ManifestVersionError for version %d", err, ManifestVersion+1)
+	}
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatal("newer manifest error does not match ErrVersionMismatch")
+	}
+}
+
+func TestDecodeManifestIgnoresOtherPayloads(t *testing.T) {
+	for _, data := range []string{
+		`{"original_name":"file.txt","data":"aGVsbG8="}`,
+		`{"version":1,"files":null}`,
+		`{"files":{}}`,
+		`not json`,
+	} {
+		if manifest, isManifest, err := decodeManifest([]byte(data)); isManifest || err != nil || manifest != nil {
+			t.Errorf("decodeManifest(%s) = %v, %v, %v; want no manifest", data, manifest, isManifest, err)
+		}
+	}
+
+	if _, isManifest, err := decodeManifest([]byte(`{"version":-1,"files":{"a":{}}}`)); !isManifest || err == nil {
+		t.Errorf("negative version accepted: %v", err)
+	}
+}
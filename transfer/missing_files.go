@@ -0,0 +1,87 @@
+package transfer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"trustdrop-bulletproof/blockchain"
+)
+
+// Reasons a manifest entry was not written to disk
+const (
+	MissingReasonNoData     = "no data was received for this file"
+	MissingReasonTooLarge   = "too large to send in a folder; a placeholder was saved instead"
+	MissingReasonUnreadable = "the sender could not read this file"
+)
+
+// maxListedMissingFiles caps how many missing files an error message names
+const maxListedMissingFiles = 10
+
+// MissingFile is a manifest entry that was not materialized on the receiver
+type MissingFile struct {
+	Path   string `json:"path"`   // Path relative to the received folder
+	Size   int64  `json:"size"`   // Size the sender reported
+	Reason string `json:"reason"` // One of the MissingReason* values
+}
+
+// MissingFilesError reports that a folder arrived without some of its files.
+// The files that did arrive are kept.
+type MissingFilesError struct {
+	Files []MissingFile
+	Total int // Number of files in the manifest
+}
+
+// Error lists the first few missing files and why they are missing
+func (e *MissingFilesError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d of %d files were not received", len(e.Files), e.Total)
+	for i, file := range e.Files {
+		if i == maxListedMissingFiles {
+			fmt.Fprintf(&msg, "; and %d more", len(e.Files)-i)
+			break
+		}
+		fmt.Fprintf(&msg, "; %s: %s", file.Path, file.Reason)
+	}
+	return msg.String()
+}
+
+// Is lets errors.Is match ErrIncompleteTransfer
+func (e *MissingFilesError) Is(target error) bool {
+	return target == ErrIncompleteTransfer
+}
+
+// missingFileList formats missing files one per line for the GUI
+func missingFileList(files []MissingFile) string {
+	var list strings.Builder
+	for i, file := range files {
+		if i == maxListedMissingFiles {
+			fmt.Fprintf(&list, "• … %d more\n", len(files)-i)
+			break
+		}
+		fmt.Fprintf(&list, "• %s (%s)\n", file.Path, file.Reason)
+	}
+	return list.String()
+}
+
+// failIncompleteReceive finishes a receive whose folder arrived without some
+// of its files. The files that did arrive stay on disk and are listed in
+// result next to the missing ones; the transfer itself is reported as failed.
+func (btm *BulletproofTransferManager) failIncompleteReceive(result *TransferResult, received *receivedPayload, transferCode string) error {
+	sort.Slice(received.Missing, func(i, j int) bool {
+		return received.Missing[i].Path < received.Missing[j].Path
+	})
+	missing := &MissingFilesError{Files: received.Missing, Total: received.ManifestFiles}
+
+	result.Success = false
+	result.MissingFiles = received.Missing
+	result.Error = btm.enhanceErrorMessage(missing, "", "receive")
+
+	if err := btm.recordTransferInBlockchain(result, transferCode, blockchain.VerificationSkipped); err != nil {
+		btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", err))
+	}
+
+	btm.updateStatus(fmt.Sprintf("Transfer incomplete: received %d files, %d missing",
+		len(result.TransferredFiles), len(received.Missing)))
+	return result.Error
+}
@@ -1,6 +1,8 @@
 package transfer
 
 import (
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
@@ -12,11 +14,12 @@ type NetworkErrorClassifier struct {
 
 // ErrorPattern represents a recognized error pattern with guidance
 type ErrorPattern struct {
-	Keywords   []string // Keywords to match in error messages
-	Category   string   // Category of the error
-	Severity   string   // Severity level
-	Suggested  []string // Suggested transports to try
-	UserAction string   // User-friendly action guidance
+	Keywords   []string       // Keywords to match in error messages
+	Pattern    *regexp.Regexp // Also matched against the lowercased message, if set
+	Category   string         // Category of the error
+	Severity   string         // Severity level
+	Suggested  []string       // Suggested transports to try
+	UserAction string         // User-friendly action guidance
 }
 
 // ErrorClassification provides detailed error analysis
@@ -113,6 +116,23 @@ func NewNetworkErrorClassifier() *NetworkErrorClassifier {
 		UserAction: "Application firewall blocking file transfers. Using web-based protocols.",
 	}
 
+	nec.patterns["relay_rejected"] = ErrorPattern{
+		Keywords:   []string{"bad password", "relay rejected the connection"},
+		Category:   "relay_rejected",
+		Severity:   "high",
+		Suggested:  []string{"ice-webrtc", "https-443"},
+		UserAction: "The relay rejected the connection. Check the relay password and relay settings.",
+	}
+
+	nec.patterns["rate_limited"] = ErrorPattern{
+		Keywords:   rateLimitKeywords,
+		Pattern:    rateLimitStatusPattern,
+		Category:   "rate_limited",
+		Severity:   "medium",
+		Suggested:  []string{"croc-relay"},
+		UserAction: "The relay server is limiting connections. Waiting before retrying to avoid overloading it.",
+	}
+
 	return nec
 }
 
@@ -126,17 +146,18 @@ func (nec *NetworkErrorClassifier) ClassifyError(err error, attemptedTransport s
 
 	// Check against known patterns
 	for _, pattern := range nec.patterns {
-		for _, keyword := range pattern.Keywords {
-			if strings.Contains(errorText, keyword) {
-				return ErrorClassification{
-					Category:            pattern.Category,
-					Severity:            pattern.Severity,
-					SuggestedTransports: pattern.Suggested,
-					UserAction:          pattern.UserAction,
-					TechnicalDetails:    err.Error(),
-					OriginalTransport:   attemptedTransport,
-					NetworkGuidance:     nec.getNetworkSpecificGuidance(pattern.Category),
-				}
+		matches := slices.ContainsFunc(pattern.Keywords, func(keyword string) bool {
+			return strings.Contains(errorText, keyword)
+		})
+		if matches || (pattern.Pattern != nil && pattern.Pattern.MatchString(errorText)) {
+			return ErrorClassification{
+				Category:            pattern.Category,
+				Severity:            pattern.Severity,
+				SuggestedTransports: pattern.Suggested,
+				UserAction:          pattern.UserAction,
+				TechnicalDetails:    err.Error(),
+				OriginalTransport:   attemptedTransport,
+				NetworkGuidance:     nec.getNetworkSpecificGuidance(pattern.Category),
 			}
 		}
 	}
@@ -203,6 +224,18 @@ func (nec *NetworkErrorClassifier) getNetworkSpecificGuidance(category string) s
 2. Try during different times of day
 3. Contact IT about business justification`
 
+	case "rate_limited":
+		return `The relay server is temporarily limiting connections. Solutions:
+1. TrustDrop will wait and retry automatically
+2. Avoid starting several transfers at once
+3. Try again in a few minutes`
+
+	case "relay_rejected":
+		return `The relay was reached but refused the connection. Solutions:
+1. Check the relay password matches the one the relay was started with
+2. Check the relay address points at the relay you expect
+3. Remove the custom relay settings to use the public relays`
+
 	default:
 		return "Try alternative connection methods or contact your network administrator."
 	}
@@ -302,6 +335,9 @@ func (nec *NetworkErrorClassifier) GetRetryStrategy(err error) (maxRetries int,
 	case "dns_filtering":
 		return 3, 5 * time.Second // Quick retries
 
+	case "rate_limited":
+		return 3, rateLimitBaseDelay // Few, widely spaced retries to respect the relay
+
 	case "deep_packet_inspection", "firewall_port_block":
 		return 1, 1 * time.Second // Don't retry much, switch transports
 
@@ -0,0 +1,31 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"trustdrop-bulletproof/transport"
+)
+
+func TestRelayAuthFailureClassification(t *testing.T) {
+	// croc's answer from a relay started with another password
+	crocErr := errors.New("bad response: bad password")
+
+	for _, err := range []error{
+		crocErr,
+		fmt.Errorf("all international CROC relay strategies failed, last error: %w", crocErr),
+		fmt.Errorf("%w by relay.example:9009 (check the relay password): %w", transport.ErrRelayRejected, crocErr),
+	} {
+		if kind := classifyFailureKind(err, true); kind != ErrRelayRejected {
+			t.Errorf("classifyFailureKind(%v) = %v, want ErrRelayRejected", err, kind)
+		}
+		if category := NewNetworkErrorClassifier().ClassifyError(err, "simple-croc").Category; category != "relay_rejected" {
+			t.Errorf("ClassifyError(%v) category = %q, want relay_rejected", err, category)
+		}
+	}
+
+	if kind := classifyFailureKind(errors.New("dial tcp: connection refused"), false); kind == ErrRelayRejected {
+		t.Error("connection refused classified as a relay rejection")
+	}
+}
@@ -0,0 +1,33 @@
+package transfer
+
+import "trustdrop-bulletproof/transport"
+
+// Status messages naming the path a transfer's data took
+const (
+	StatusDirectPath = "Transferred directly over the local network"
+	StatusRelayPath  = "Transferred through the relay"
+)
+
+// SetPathMode chooses whether transfers connect directly to a peer on the
+// same local network when one answers quickly (transport.PathModeAuto) or
+// always go through the relay (transport.PathModeRelay). A peer that answers
+// is used without measuring whether it is faster. The default, an empty
+// mode, looks for a direct path only when receiving, so it is only taken
+// when the sender chose auto. The path each transfer took is reported in its
+// status and TransferResult.Path.
+func (btm *BulletproofTransferManager) SetPathMode(mode string) error {
+	if btm.transportManager == nil {
+		return transport.ValidatePathMode(mode)
+	}
+	return btm.transportManager.SetPathMode(mode)
+}
+
+// onPathChosen is the transport manager's path handler
+func (btm *BulletproofTransferManager) onPathChosen(path string) {
+	btm.networkPath = path
+	if path == transport.PathDirect {
+		btm.updateStatus(StatusDirectPath)
+	} else {
+		btm.updateStatus(StatusRelayPath)
+	}
+}
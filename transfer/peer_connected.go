@@ -0,0 +1,28 @@
+package transfer
+
+// Status messages shown once the other side joins
+const (
+	StatusReceiverConnected = "Receiver connected — transferring..."
+	StatusSenderConnected   = "Connected to sender — receiving..."
+)
+
+// SetPeerConnectedCallback sets a function called when the other side of a
+// transfer joins, before any data moves. sending is true on the sender, where
+// it means the receiver connected. It may be called from a transfer
+// goroutine, and once per file for multi-file sends.
+func (btm *BulletproofTransferManager) SetPeerConnectedCallback(callback func(sending bool)) {
+	btm.peerCallback = callback
+}
+
+// onPeerConnected is the transport manager's peer-connected handler
+func (btm *BulletproofTransferManager) onPeerConnected(sending bool) {
+	if btm.peerCallback != nil {
+		btm.peerCallback(sending)
+	}
+
+	if sending {
+		btm.updateStatus(StatusReceiverConnected)
+	} else {
+		btm.updateStatus(StatusSenderConnected)
+	}
+}
@@ -0,0 +1,125 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Throughputs assumed by PreflightSend until a send has been measured on the
+// current network. Relayed transfers rarely reach the link speed, and
+// restrictive networks fall back to slower transports.
+const (
+	assumedSendRate            = 2 * 1024 * 1024 // bytes per second
+	assumedRestrictiveSendRate = 512 * 1024
+)
+
+// Measured rates are only trusted for sends of at least this size and are
+// forgotten after sendRateMaxAge or when the network changes
+const (
+	minMeasuredSendBytes = 1024 * 1024
+	sendRateMaxAge       = time.Hour
+)
+
+// SendEstimate is what PreflightSend expects a send to involve
+type SendEstimate struct {
+	Files          int           // Files that will be sent
+	Skipped        int           // Special files that will be skipped
+	Unreadable     int           // Selected items that cannot be read and will be left out
+	TotalBytes     int64         // Bytes that will be sent
+	BytesPerSecond float64       // Throughput the estimate assumes
+	Measured       bool          // Whether BytesPerSecond came from a recent send on this network
+	NetworkType    string        // Network the estimate applies to
+	Duration       time.Duration // Expected time once the receiver has connected
+
+	// FolderLimit is set when the selected folders pass the folder limits;
+	// the send is refused unless confirmed with ConfirmFolderLimits
+	FolderLimit error
+}
+
+// Summary describes the estimate in one line for a confirmation prompt
+func (e *SendEstimate) Summary() string {
+	summary := fmt.Sprintf("About to send %s across %d files (~%s on this network)",
+		FormatBytes(e.TotalBytes), e.Files, formatEstimate(e.Duration))
+	if e.Skipped > 0 {
+		summary += fmt.Sprintf("; %d special files will be skipped", e.Skipped)
+	}
+	if e.Unreadable > 0 {
+		summary += fmt.Sprintf("; %d unreadable items will be left out", e.Unreadable)
+	}
+	return summary
+}
+
+// PreflightSend reports how much SendFiles would send for filePaths and how
+// long it is likely to take, without sending anything or contacting a relay.
+// Files are counted with the same rules as a send. The time uses the speed of
+// the last send on this network, or a conservative figure for the network
+// type when there has not been one. It fails with ErrNothingToSend when
+// nothing is selected or readable.
+func (btm *BulletproofTransferManager) PreflightSend(filePaths []string) (*SendEstimate, error) {
+	filePaths, unreadable, err := btm.checkSelection(filePaths)
+	if err != nil {
+		return nil, err
+	}
+	sizes, err := btm.calculateTotalSizeWithProgress(filePaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze files: %w", err)
+	}
+
+	estimate := &SendEstimate{
+		Files:       sizes.files,
+		Skipped:     sizes.skipped,
+		Unreadable:  len(unreadable),
+		TotalBytes:  sizes.total,
+		NetworkType: btm.networkProfile.NetworkType,
+	}
+	estimate.FolderLimit = btm.folderLimitError(sizes)
+	estimate.BytesPerSecond, estimate.Measured = btm.sendRate()
+	estimate.Duration = time.Duration(float64(sizes.total) / estimate.BytesPerSecond * float64(time.Second))
+	return estimate, nil
+}
+
+// recordSendRate remembers the throughput of a completed send
+func (btm *BulletproofTransferManager) recordSendRate(bytes int64, duration time.Duration) {
+	if bytes < minMeasuredSendBytes || duration <= 0 {
+		return
+	}
+
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+	btm.lastSendRate = float64(bytes) / duration.Seconds()
+	btm.lastSpeedTest = time.Now()
+}
+
+// forgetSendRate drops a measured rate that no longer applies
+func (btm *BulletproofTransferManager) forgetSendRate() {
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+	btm.lastSendRate = 0
+	btm.lastSpeedTest = time.Time{}
+}
+
+// sendRate returns the throughput to estimate with and whether it was measured
+func (btm *BulletproofTransferManager) sendRate() (float64, bool) {
+	btm.mutex.Lock()
+	defer btm.mutex.Unlock()
+
+	if btm.lastSendRate > 0 && time.Since(btm.lastSpeedTest) < sendRateMaxAge {
+		return btm.lastSendRate, true
+	}
+	if btm.networkProfile.IsRestrictive {
+		return assumedRestrictiveSendRate, false
+	}
+	return assumedSendRate, false
+}
+
+// formatEstimate rounds an estimated duration to something readable
+func formatEstimate(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "under a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("%d min", int(d.Round(time.Minute).Minutes()))
+	default:
+		return fmt.Sprintf("%.1f h", d.Hours())
+	}
+}
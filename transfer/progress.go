@@ -0,0 +1,88 @@
+package transfer
+
+import (
+	"io"
+)
+
+// Transfer phases reported alongside progress updates
+const (
+	PhaseReading      = "reading"    // File contents being read into the payload
+	PhaseEncrypting   = "encrypting" // Payload being encrypted, once everything is read
+	PhaseTransferring = "transferring"
+	PhaseReceiving    = "receiving" // Payload arriving, before files are written
+)
+
+// TransferProgress is a progress update covering both the file currently being
+// processed and the transfer as a whole
+type TransferProgress struct {
+	FileName  string
+	FileBytes int64 // Bytes of the current file processed so far
+	FileSize  int64 // Size of the current file, or 0 if not yet known
+
+	OverallBytes int64 // Bytes processed across the whole transfer
+	OverallSize  int64 // Total bytes in the transfer, or 0 if not yet known
+
+	FilesCompleted int
+	FilesTotal     int
+
+	BytesPerSecond float64 // Average speed since the transfer started
+	Phase          string  // One of the Phase* constants, if known
+}
+
+// FilesRemaining returns how many files are still to be processed
+func (tp TransferProgress) FilesRemaining() int {
+	if remaining := tp.FilesTotal - tp.FilesCompleted; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// FileFraction returns progress through the current file from 0 to 1
+func (tp TransferProgress) FileFraction() float64 {
+	return fraction(tp.FileBytes, tp.FileSize)
+}
+
+// OverallFraction returns progress through the whole transfer from 0 to 1
+func (tp TransferProgress) OverallFraction() float64 {
+	return fraction(tp.OverallBytes, tp.OverallSize)
+}
+
+// fraction returns done/total clamped to [0, 1], treating an unknown total as no progress
+func fraction(done, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	if done >= total {
+		return 1
+	}
+	return float64(done) / float64(total)
+}
+
+// progressReportInterval is how many bytes are read between progress reports
+const progressReportInterval = 1024 * 1024
+
+// progressReader reports the running byte count through onProgress as data is read
+type progressReader struct {
+	reader       io.Reader
+	read         int64
+	lastReported int64
+	onProgress   func(read int64)
+}
+
+// newProgressReader wraps reader so that onProgress is called roughly every megabyte
+func newProgressReader(reader io.Reader, onProgress func(read int64)) *progressReader {
+	return &progressReader{reader: reader, onProgress: onProgress}
+}
+
+// Read implements io.Reader
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.reader.Read(p)
+	pr.read += int64(n)
+
+	if pr.onProgress != nil && (pr.read-pr.lastReported >= progressReportInterval || (err == io.EOF && pr.read != pr.lastReported)) {
+		pr.lastReported = pr.read
+		pr.onProgress(pr.read)
+	}
+
+	return n, err
+}
@@ -0,0 +1,47 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSendPhasesInOrder(t *testing.T) {
+	for _, binary := range []bool{false, true} {
+		name := "json file"
+		if binary {
+			name = "file frame"
+		}
+		t.Run(name, func(t *testing.T) {
+			source := filepath.Join(t.TempDir(), "notes.txt")
+			if err := os.WriteFile(source, []byte("phase order"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sender, _ := loopbackPair(t)
+			sender.SetBinaryFiles(binary)
+			var mutex sync.Mutex
+			var phases []string
+			sender.SetProgressCallback(func(progress TransferProgress) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				if n := len(phases); n == 0 || phases[n-1] != progress.Phase {
+					phases = append(phases, progress.Phase)
+				}
+			})
+
+			if _, err := sender.SendFilesContext(context.Background(), []string{source}, "loopback-test-code"); err != nil {
+				t.Fatal(err)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			want := []string{PhaseReading, PhaseEncrypting, PhaseTransferring}
+			if len(phases) < len(want) || !slices.Equal(phases[:len(want)], want) {
+				t.Errorf("phases = %v, want %v first", phases, want)
+			}
+		})
+	}
+}
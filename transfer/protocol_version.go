@@ -0,0 +1,55 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProtocolVersion identifies the payload format this build sends. Bump it
+// whenever a change means an older receiver can no longer read what a newer
+// sender produces. Senders from before versioning report no version.
+const ProtocolVersion = 3
+
+// errUndecryptable marks a payload that no supported mode could decrypt
+var errUndecryptable = errors.New("failed to decrypt data")
+
+// VersionMismatchError reports a payload from a sender whose protocol version
+// this build cannot read
+type VersionMismatchError struct {
+	PeerVersion  int // Protocol version the sender reported
+	LocalVersion int // ProtocolVersion of this build
+	Cause        error
+}
+
+// PeerNewer reports whether the sender runs the newer version
+func (e *VersionMismatchError) PeerNewer() bool {
+	return e.PeerVersion > e.LocalVersion
+}
+
+// Error explains which side needs updating
+func (e *VersionMismatchError) Error() string {
+	if e.PeerNewer() {
+		return fmt.Sprintf("the sender is running a newer version (protocol %d, this app speaks %d) - update to transfer",
+			e.PeerVersion, e.LocalVersion)
+	}
+	return fmt.Sprintf("the sender is running an older version (protocol %d, this app speaks %d) - ask them to update to transfer",
+		e.PeerVersion, e.LocalVersion)
+}
+
+// Unwrap exposes ErrVersionMismatch and the underlying failure
+func (e *VersionMismatchError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{ErrVersionMismatch}
+	}
+	return []error{ErrVersionMismatch, e.Cause}
+}
+
+// explainVersionMismatch replaces a decryption failure with a
+// VersionMismatchError when the sender reported a different protocol version.
+// Senders that reported no version keep the original error.
+func explainVersionMismatch(err error, peerVersion int) error {
+	if peerVersion == 0 || peerVersion == ProtocolVersion || !errors.Is(err, errUndecryptable) {
+		return err
+	}
+	return &VersionMismatchError{PeerVersion: peerVersion, LocalVersion: ProtocolVersion, Cause: err}
+}
@@ -0,0 +1,261 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/blockchain"
+)
+
+// QuarantineReportName is the report left beside files held in quarantine
+const QuarantineReportName = "quarantine-report.txt"
+
+// defaultQuarantineTimeout bounds the quarantine check command
+const defaultQuarantineTimeout = 10 * time.Minute
+
+// maxQuarantineOutput caps how much check command output goes in a report
+const maxQuarantineOutput = 64 * 1024
+
+// QuarantineConfig holds received files in a quarantine folder until they
+// pass a check, then releases them to the receive destination. Files that
+// fail stay in quarantine with a report saying why.
+type QuarantineConfig struct {
+	// Dir holds files until release; empty uses "quarantine" in the data directory
+	Dir string `json:"dir,omitempty"`
+
+	// Command, if set, is run on each quarantined transfer, for example a
+	// virus scanner. "{dir}" in it is replaced by the transfer's quarantine
+	// folder; without "{dir}" the folder is passed as the last argument.
+	// Exit status 0 releases.
+	Command []string `json:"command,omitempty"`
+
+	// Timeout bounds Command, in Go syntax such as "5m"; the default is 10m
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate checks the timeout
+func (q QuarantineConfig) Validate() error {
+	if _, err := q.timeout(); err != nil {
+		return err
+	}
+	if len(q.Command) > 0 && q.Command[0] == "" {
+		return fmt.Errorf("quarantine command has no program")
+	}
+	return nil
+}
+
+// timeout returns how long Command may run
+func (q QuarantineConfig) timeout() (time.Duration, error) {
+	if q.Timeout == "" {
+		return defaultQuarantineTimeout, nil
+	}
+	timeout, err := time.ParseDuration(q.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("quarantine timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("quarantine timeout must be positive")
+	}
+	return timeout, nil
+}
+
+// SetQuarantine makes receives land in quarantine and only reach their
+// destination once they pass the check. Nil turns quarantine off.
+func (btm *BulletproofTransferManager) SetQuarantine(config *QuarantineConfig) error {
+	if config == nil {
+		btm.quarantine = nil
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	quarantine := *config
+	if quarantine.Dir == "" {
+		quarantine.Dir = filepath.Join(btm.targetDataDir, "quarantine")
+	}
+	btm.quarantine = &quarantine
+	return nil
+}
+
+// quarantineHoldDir creates the folder a transfer is received into while
+// quarantine is on. It is named for the time and a random suffix, never the
+// transfer code: the path is passed to the check command and shown in
+// reports, and the code is the transfer's secret.
+func (btm *BulletproofTransferManager) quarantineHoldDir(now time.Time) (string, error) {
+	if err := os.MkdirAll(btm.quarantine.Dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	holdDir, err := os.MkdirTemp(btm.quarantine.Dir, now.Format("20060102-150405")+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	return holdDir, nil
+}
+
+// releaseFromQuarantine checks a transfer received into holdDir and moves it
+// to finalDir if it passes. Otherwise the files stay in holdDir with a report
+// and the returned error matches ErrQuarantined.
+func (btm *BulletproofTransferManager) releaseFromQuarantine(ctx context.Context, result *TransferResult, received *receivedPayload, holdDir, finalDir string) error {
+	var reasons []string
+	if len(received.Missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d files listed by the sender were not received", len(received.Missing)))
+	}
+	if !received.Verified {
+		reasons = append(reasons, "the files could not be verified against the sender's checksums")
+	}
+
+	var output []byte
+	if len(reasons) == 0 && len(btm.quarantine.Command) > 0 {
+		btm.updateStatus("Checking received files in quarantine...")
+		var err error
+		output, err = btm.runQuarantineCheck(ctx, holdDir)
+		if err != nil {
+			if ctx.Err() != nil {
+				return contextError(ctx)
+			}
+			reasons = append(reasons, fmt.Sprintf("the quarantine check failed: %v", err))
+		}
+	}
+
+	if len(reasons) > 0 {
+		reportPath := filepath.Join(holdDir, QuarantineReportName)
+		if err := writeQuarantineReport(reportPath, received, btm.quarantine.Command, reasons, output); err != nil {
+			btm.updateStatus(fmt.Sprintf("Note: Could not write quarantine report: %v", err))
+		} else {
+			result.QuarantineReport = reportPath
+		}
+		return fmt.Errorf("%w: %s; files kept in %s", ErrQuarantined, strings.Join(reasons, "; "), holdDir)
+	}
+
+	released, err := commitStagedFiles(holdDir, finalDir, received.Files)
+	if err != nil {
+		return fmt.Errorf("failed to release files from quarantine: %w", err)
+	}
+	checksums := make(map[string]string, len(received.Checksums))
+	for i := range released {
+		if checksum, ok := received.Checksums[received.Files[i]]; ok {
+			checksums[released[i]] = checksum
+		}
+	}
+	received.Files = released
+	received.Checksums = checksums
+
+	if err := os.RemoveAll(holdDir); err != nil {
+		btm.updateStatus(fmt.Sprintf("Note: Could not remove quarantine folder %s: %v", holdDir, err))
+	}
+
+	result.TransferredFiles = received.Files
+	result.Files = received.transferredFiles()
+	result.DestinationDir = finalDir
+	btm.updateStatus("Received files passed the quarantine check and were released")
+	return nil
+}
+
+// runQuarantineCheck runs the quarantine command on holdDir and returns its
+// combined output
+func (btm *BulletproofTransferManager) runQuarantineCheck(ctx context.Context, holdDir string) ([]byte, error) {
+	timeout, err := btm.quarantine.timeout()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	program, err := resolveProgram(btm.quarantine.Command[0])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, len(btm.quarantine.Command))
+	placed := false
+	for _, arg := range btm.quarantine.Command[1:] {
+		placed = placed || strings.Contains(arg, "{dir}")
+		args = append(args, strings.ReplaceAll(arg, "{dir}", holdDir))
+	}
+	if !placed {
+		args = append(args, holdDir)
+	}
+
+	// The check runs from the current directory, not holdDir, so nothing the
+	// sender wrote can stand in for the program or files it opens
+	cmd := exec.CommandContext(ctx, program, args...)
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %v", timeout)
+	}
+	return output, err
+}
+
+// resolveProgram returns the absolute path of the program a hook or check
+// runs, looked up now rather than relative to the folder it runs on
+func resolveProgram(name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// writeQuarantineReport explains why a transfer was held in quarantine
+func writeQuarantineReport(path string, received *receivedPayload, command, reasons []string, output []byte) error {
+	var report strings.Builder
+	fmt.Fprintf(&report, "TrustDrop quarantine report\n")
+	fmt.Fprintf(&report, "Held: %s\n\n", time.Now().Format(time.RFC3339))
+
+	report.WriteString("Why these files were not released:\n")
+	for _, reason := range reasons {
+		fmt.Fprintf(&report, "- %s\n", reason)
+	}
+
+	report.WriteString("\nFiles:\n")
+	files := make([]string, 0, len(received.Checksums))
+	for file := range received.Checksums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		name, err := filepath.Rel(filepath.Dir(path), file)
+		if err != nil {
+			name = file
+		}
+		fmt.Fprintf(&report, "- %s  sha256:%s\n", filepath.ToSlash(name), received.Checksums[file])
+	}
+	for _, missing := range received.Missing {
+		fmt.Fprintf(&report, "- %s  not received: %s\n", missing.Path, missing.Reason)
+	}
+
+	if len(command) > 0 {
+		fmt.Fprintf(&report, "\nCheck command: %s\n", strings.Join(command, " "))
+	}
+	if len(output) > 0 {
+		if len(output) > maxQuarantineOutput {
+			output = append(output[:maxQuarantineOutput:maxQuarantineOutput], "\n[output truncated]"...)
+		}
+		report.WriteString("\nCheck output:\n")
+		report.Write(bytes.TrimRight(output, "\n"))
+		report.WriteString("\n")
+	}
+
+	return os.WriteFile(path, []byte(report.String()), 0600)
+}
+
+// failQuarantinedReceive finishes a receive whose files were held in
+// quarantine, logging it in the audit trail as failed verification
+func (btm *BulletproofTransferManager) failQuarantinedReceive(result *TransferResult, transferCode string, err error) error {
+	result.Success = false
+	result.Error = btm.enhanceErrorMessage(err, "", "receive")
+
+	if logErr := btm.recordTransferInBlockchain(result, transferCode, blockchain.VerificationFailed); logErr != nil {
+		btm.updateStatus(fmt.Sprintf("Note: Transfer audit logging unavailable: %v", logErr))
+	}
+
+	btm.updateStatus(fmt.Sprintf("Received files held in quarantine: %s", result.DestinationDir))
+	return result.Error
+}
@@ -0,0 +1,84 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestQuarantineCheckIgnoresReceivedProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	holdDir := t.TempDir()
+	marker := filepath.Join(holdDir, "ran")
+	script := "#!/bin/sh\ntouch " + marker + "\n"
+	if err := os.WriteFile(filepath.Join(holdDir, "scan.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	btm := &BulletproofTransferManager{quarantine: &QuarantineConfig{Command: []string{"./scan.sh"}}}
+	if _, err := btm.runQuarantineCheck(context.Background(), holdDir); err == nil {
+		t.Fatal("check with a program only present in the hold folder succeeded")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("received file was run as the quarantine check")
+	}
+}
+
+func TestQuarantineCheckPassesFolder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	holdDir := t.TempDir()
+	btm := &BulletproofTransferManager{quarantine: &QuarantineConfig{Command: []string{"sh", "-c", `test -d "$0"`}}}
+	if _, err := btm.runQuarantineCheck(context.Background(), holdDir); err != nil {
+		t.Fatalf("folder was not passed as the last argument: %v", err)
+	}
+}
+
+func TestQuarantineHoldKeepsCodeSecret(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	const code = "loopback-test-code"
+	source := filepath.Join(t.TempDir(), "held.txt")
+	if err := os.WriteFile(source, []byte("held"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sender, receiver := loopbackPair(t)
+	quarantineDir := t.TempDir()
+	if err := receiver.SetQuarantine(&QuarantineConfig{Dir: quarantineDir, Command: []string{"sh", "-c", `echo "$0"; exit 1`}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sender.SendFilesContext(context.Background(), []string{source}, code); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	result, err := receiver.ReceiveFilesToContext(context.Background(), code, t.TempDir())
+	if !errors.Is(err, ErrQuarantined) {
+		t.Fatalf("receive returned %v, want the files held in quarantine", err)
+	}
+
+	if strings.Contains(err.Error(), code) {
+		t.Errorf("quarantine error names the transfer code: %v", err)
+	}
+	holds, err := os.ReadDir(quarantineDir)
+	if err != nil || len(holds) != 1 {
+		t.Fatalf("quarantine holds %v (%v), want one folder", holds, err)
+	}
+	if strings.Contains(holds[0].Name(), code) {
+		t.Errorf("hold folder %s is named for the transfer code", holds[0].Name())
+	}
+	report, err := os.ReadFile(result.QuarantineReport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(report), code) {
+		t.Errorf("quarantine report names the transfer code:\n%s", report)
+	}
+}
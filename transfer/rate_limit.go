@@ -0,0 +1,85 @@
+package transfer
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate-limit backoff is deliberately much longer than the network backoff so
+// retries don't hammer shared relay infrastructure
+const (
+	rateLimitBaseDelay  = 30 * time.Second
+	rateLimitMaxDelay   = 5 * time.Minute
+	maxRetryAfterHonour = 15 * time.Minute
+)
+
+// rateLimitKeywords identify rate-limit responses from croc relays and HTTP services
+var rateLimitKeywords = []string{
+	"too many requests", "rate limit", "rate-limit", "ratelimit", "rate limited",
+	"slow down", "retry-after", "retry after",
+}
+
+// rateLimitStatusPattern matches an HTTP 429 status given without its reason
+// phrase. A bare 429 is too often a byte count or part of an address.
+var rateLimitStatusPattern = regexp.MustCompile(`\b(?:status(?:[ _]?code)?|http(?:/[\d.]+)?)[\s:=]*429\b`)
+
+// retryAfterPattern extracts a Retry-After value in seconds from an error message
+var retryAfterPattern = regexp.MustCompile(`(?i)retry[- ]after[:=\s]+(\d+)`)
+
+// isRateLimitError reports whether err signals that a relay is rate limiting us
+func isRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	for _, keyword := range rateLimitKeywords {
+		if strings.Contains(errorStr, keyword) {
+			return true
+		}
+	}
+	return rateLimitStatusPattern.MatchString(errorStr)
+}
+
+// parseRetryAfter returns the Retry-After delay embedded in err, if any
+func parseRetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	match := retryAfterPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+
+	seconds, convErr := strconv.Atoi(match[1])
+	if convErr != nil || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// rateLimitDelay returns how long to wait before retrying after a rate-limit
+// error. An explicit Retry-After wins; otherwise the delay doubles per attempt.
+func rateLimitDelay(err error, attempt int) time.Duration {
+	if retryAfter, ok := parseRetryAfter(err); ok {
+		if retryAfter > maxRetryAfterHonour {
+			return maxRetryAfterHonour
+		}
+		return retryAfter
+	}
+
+	delay := rateLimitBaseDelay
+	for i := 1; i < attempt && delay < rateLimitMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > rateLimitMaxDelay {
+		delay = rateLimitMaxDelay
+	}
+
+	// Only add jitter upwards so we never retry sooner than intended
+	return delay + time.Duration(float64(delay)*0.2*rand.Float64())
+}
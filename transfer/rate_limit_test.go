@@ -0,0 +1,43 @@
+package transfer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRateLimitErrors(t *testing.T) {
+	limited := []string{
+		"relay answered 429 Too Many Requests",
+		"unexpected status 429",
+		"status code: 429",
+		"HTTP/1.1 429",
+		"server returned http 429, retry later",
+		"rate limited by relay, retry-after: 30",
+	}
+	for _, message := range limited {
+		err := errors.New(message)
+		if !isRateLimitError(err) {
+			t.Errorf("isRateLimitError(%q) = false, want true", message)
+		}
+		if category := NewNetworkErrorClassifier().ClassifyError(err, "simple-croc").Category; category != "rate_limited" {
+			t.Errorf("ClassifyError(%q) category = %q, want rate_limited", message, category)
+		}
+	}
+
+	notLimited := []string{
+		"read 429 bytes before the connection closed",
+		"dial tcp 10.0.0.1:4290: i/o timeout",
+		"dial tcp 10.0.42.9:429: connection refused",
+		"http: wrote 429 bytes of 1024",
+		"relay sent 8429 bytes",
+	}
+	for _, message := range notLimited {
+		err := errors.New(message)
+		if isRateLimitError(err) {
+			t.Errorf("isRateLimitError(%q) = true, want false", message)
+		}
+		if category := NewNetworkErrorClassifier().ClassifyError(err, "simple-croc").Category; category == "rate_limited" {
+			t.Errorf("ClassifyError(%q) classified as rate limited", message)
+		}
+	}
+}
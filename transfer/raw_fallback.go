@@ -0,0 +1,63 @@
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// RawRecoveryNote explains a receive whose TransferResult is Degraded
+const RawRecoveryNote = "The transfer did not include a file list, so it was saved as a single file. " +
+	"File names and folder structure may have been lost, and the contents could not be checked for missing or damaged files."
+
+// saveRawPayload saves decrypted data that is neither a manifest nor a named
+// single-file payload as one file. This is a degraded recovery: the name comes
+// from the transport metadata when it has one and is generated otherwise, and
+// there is no checksum to verify the contents against.
+func (btm *BulletproofTransferManager) saveRawPayload(data []byte, receivedDir string, metadata *transport.TransferMetadata) (*receivedPayload, error) {
+	filename := fmt.Sprintf("received_file_%d", time.Now().Unix())
+	namesPreserved := false
+	if metadata != nil && metadata.FileName != "" {
+		filename = btm.sanitizeFilename(metadata.FileName)
+		namesPreserved = true
+	} else if btm.transferID != "" {
+		filename = fmt.Sprintf("file_%s", btm.transferID)
+	}
+
+	btm.updateStatus("Warning: no file list was found in the transfer; saving it as a single file")
+	if !namesPreserved {
+		btm.updateStatus(fmt.Sprintf("Original file name was not included in the transfer; saved as %s", filename))
+	}
+
+	size := int64(len(data))
+	report := func(written int64) {
+		btm.updateProgress(TransferProgress{
+			FileName:     filename,
+			FileBytes:    written,
+			FileSize:     size,
+			OverallBytes: written,
+			OverallSize:  size,
+			FilesTotal:   1,
+		})
+	}
+	report(0)
+
+	filePath := filepath.Join(receivedDir, filename)
+	checksum := checksumOf(data)
+	check := checkWrittenFile(size, checksum)
+	if err := writeStreamChecked(filePath, newProgressReader(bytes.NewReader(data), report), 0644, check); err != nil {
+		return nil, fmt.Errorf("failed to write received file: %w", err)
+	}
+
+	btm.updateStatus(fmt.Sprintf("Received file: %s", filename))
+	return &receivedPayload{
+		Files:          []string{filePath},
+		TotalBytes:     size,
+		NamesPreserved: namesPreserved,
+		Degraded:       true,
+		Checksums:      map[string]string{filePath: checksum},
+	}, nil
+}
@@ -0,0 +1,92 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// sendRaw sends data from sender as a payload with no manifest, bound to metadata
+func sendRaw(t *testing.T, sender *BulletproofTransferManager, data []byte, metadata transport.TransferMetadata) {
+	t.Helper()
+	key, _, err := sender.advancedSecurity.StrengthenTransferCode(metadata.TransferID, "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, err := bindMetadata(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, _, err := sender.advancedSecurity.EncryptWithBestMode(data, key, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.transportManager.SendWithFailover(sealTransit(encrypted, bound), metadata); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReceiveWithoutManifestIsDegraded(t *testing.T) {
+	data := bytes.Repeat([]byte("raw payload "), 10000)
+	tests := []struct {
+		name           string
+		fileName       string
+		namesPreserved bool
+	}{
+		{"named by metadata", "report.pdf", true},
+		{"no name", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sender, receiver := loopbackPair(t)
+			const code = "raw-payload-test-code"
+			sendRaw(t, sender, data, transport.TransferMetadata{TransferID: code, FileName: test.fileName, FileSize: int64(len(data))})
+
+			var mutex sync.Mutex
+			var last TransferProgress
+			receiver.SetProgressCallback(func(progress TransferProgress) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				last = progress
+			})
+
+			destDir := filepath.Join(t.TempDir(), "inbox")
+			result, err := receiver.ReceiveFilesToContext(context.Background(), code, destDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Degraded {
+				t.Error("receive without a manifest was not marked degraded")
+			}
+			if result.IntegrityVerified {
+				t.Error("receive without a manifest claimed to be verified")
+			}
+			if result.NamesPreserved != test.namesPreserved {
+				t.Errorf("NamesPreserved = %v, want %v", result.NamesPreserved, test.namesPreserved)
+			}
+			if len(result.TransferredFiles) != 1 {
+				t.Fatalf("received %d files, want 1", len(result.TransferredFiles))
+			}
+			path := result.TransferredFiles[0]
+			if test.fileName != "" && filepath.Base(path) != test.fileName {
+				t.Errorf("saved as %s, want %s", filepath.Base(path), test.fileName)
+			}
+			if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("saved contents differ from what was sent: %v", err)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if last.OverallSize != int64(len(data)) || last.OverallBytes != int64(len(data)) {
+				t.Errorf("progress ended at %d of %d bytes, want %d of %d", last.OverallBytes, last.OverallSize, len(data), len(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package transfer
+
+// Ready reports whether at least one transport initialized, without which
+// every transfer fails, and lists the transports that did not as
+// "name: error". The manager still starts when some or all of them fail.
+func (btm *BulletproofTransferManager) Ready() (bool, []string) {
+	if btm.transportManager == nil {
+		return false, []string{"transport manager: not initialized"}
+	}
+	ready, failures := btm.transportManager.Readiness()
+	return ready > 0, failures
+}
@@ -0,0 +1,71 @@
+package transfer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Receive layouts control how received transfers are organized under the
+// "received" folder
+const (
+	ReceiveLayoutFlat   = "flat"    // Everything directly in received/
+	ReceiveLayoutByDate = "by-date" // received/2024-06-01/
+	ReceiveLayoutByCode = "by-code" // received/<transfer code>/
+)
+
+// SetReceiveLayout sets how received files are organized. The default is
+// ReceiveLayoutFlat.
+func (btm *BulletproofTransferManager) SetReceiveLayout(layout string) error {
+	switch layout {
+	case ReceiveLayoutFlat, ReceiveLayoutByDate, ReceiveLayoutByCode:
+		btm.receiveLayout = layout
+		return nil
+	case "":
+		btm.receiveLayout = ReceiveLayoutFlat
+		return nil
+	default:
+		return fmt.Errorf("unknown receive layout %q", layout)
+	}
+}
+
+// receiveDestination returns the folder a transfer is received into under the
+// current layout
+func (btm *BulletproofTransferManager) receiveDestination(transferCode string, now time.Time) string {
+	receivedRoot := filepath.Join(btm.targetDataDir, "received")
+
+	switch btm.receiveLayout {
+	case ReceiveLayoutByDate:
+		return filepath.Join(receivedRoot, now.Format("2006-01-02"))
+	case ReceiveLayoutByCode:
+		return filepath.Join(receivedRoot, btm.sanitizeFilename(transferCode))
+	default:
+		return receivedRoot
+	}
+}
+
+// prepareDestinationDir resolves a caller-chosen receive directory, creating
+// it if needed and checking that files can be written there
+func prepareDestinationDir(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination directory %s: %w", dir, err)
+	}
+
+	if info, err := os.Stat(absDir); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("destination %s is not a directory", absDir)
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory %s: %w", absDir, err)
+	}
+
+	probe, err := os.CreateTemp(absDir, ".trustdrop-write-test-*")
+	if err != nil {
+		return "", fmt.Errorf("destination directory %s is not writable: %w", absDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return absDir, nil
+}
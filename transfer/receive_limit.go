@@ -0,0 +1,43 @@
+package transfer
+
+import "fmt"
+
+// SetMaxReceiveSize sets the most file data a single receive accepts, in
+// bytes. Larger transfers are refused before anything is written, and a
+// received payload too large to hold that much is refused before it is
+// loaded into memory. Zero or less accepts any size, the default.
+func (btm *BulletproofTransferManager) SetMaxReceiveSize(size int64) {
+	btm.maxReceiveSize = max(size, 0)
+}
+
+// checkReceiveSize refuses a transfer that would write size bytes of file
+// data when that is over the receive limit
+func (btm *BulletproofTransferManager) checkReceiveSize(size int64) error {
+	if btm.maxReceiveSize == 0 || size <= btm.maxReceiveSize {
+		return nil
+	}
+	btm.updateStatus(fmt.Sprintf("Refused a transfer of %s, more than the %s this device accepts",
+		btm.formatBytes(size), btm.formatBytes(btm.maxReceiveSize)))
+	return fmt.Errorf("%w: the transfer holds %s and this device accepts at most %s",
+		ErrTransferTooLarge, btm.formatBytes(size), btm.formatBytes(btm.maxReceiveSize))
+}
+
+// maxReceivePayload is the largest received payload worth loading to decrypt
+// under the receive limit, or 0 if there is no limit. File data can be sent
+// base64 encoded, so the payload is allowed the encoded size, plus as much
+// again as an archive header may take for a listing of many small files.
+func (btm *BulletproofTransferManager) maxReceivePayload() int64 {
+	if btm.maxReceiveSize == 0 {
+		return 0
+	}
+	return maxPayloadFor(btm.maxReceiveSize) + maxArchiveHeaderSize
+}
+
+// dataSize is the file data the manifest carries
+func (m *FileManifest) dataSize() int64 {
+	var size int64
+	for _, file := range m.Files {
+		size += int64(len(file.Data))
+	}
+	return size
+}
@@ -0,0 +1,21 @@
+package transfer
+
+import "fmt"
+
+// onReceiveProgress is the transport manager's receive progress handler. It
+// reports an incoming payload against the size the sender announced, so the
+// receiver sees a real percentage while the data arrives rather than only
+// once the manifest is read and files are written.
+func (btm *BulletproofTransferManager) onReceiveProgress(received, total int64) {
+	if btm.enterPhase(PhaseReceiving) {
+		btm.updateStatus(fmt.Sprintf("Receiving %s...", btm.formatBytes(total)))
+	}
+
+	btm.updateProgress(TransferProgress{
+		FileBytes:    received,
+		FileSize:     total,
+		OverallBytes: received,
+		OverallSize:  total,
+		Phase:        PhaseReceiving,
+	})
+}
@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"trustdrop-bulletproof/logging"
+	"trustdrop-bulletproof/transport"
+)
+
+// reconnectDelay is the pause before reconnecting after a connection drops,
+// giving the other side time to notice the drop too
+const reconnectDelay = 2 * time.Second
+
+// sendWithReconnect sends payload, reconnecting when the connection drops
+// after the receiver joined. The same payload is sent again each time, so the
+// receiver can resume from the data it already has.
+func (btm *BulletproofTransferManager) sendWithReconnect(ctx context.Context, payload []byte, metadata transport.TransferMetadata) error {
+	for reconnects := 0; ; reconnects++ {
+		err := btm.transportManager.SendWithFailoverContext(ctx, payload, metadata)
+		if err == nil {
+			btm.reportResumed(reconnects)
+			return nil
+		}
+		again, waitErr := btm.reconnectAfterDrop(ctx, err, reconnects)
+		if waitErr != nil {
+			return waitErr
+		}
+		if !again {
+			return err
+		}
+	}
+}
+
+// receiveWithReconnect receives the payload for metadata into a staging file,
+// writing it as it arrives rather than holding it in memory, and returns the
+// file, which the caller removes. Drops after the sender joined reconnect,
+// and reconnected receives resume from the data received before the drop.
+func (btm *BulletproofTransferManager) receiveWithReconnect(ctx context.Context, metadata transport.TransferMetadata) (string, error) {
+	for reconnects := 0; ; reconnects++ {
+		stream, err := btm.transportManager.ReceiveStreamWithFailoverContext(ctx, metadata)
+		if err == nil {
+			var path string
+			path, err = btm.stageReceivedPayload(ctx, stream)
+			if err == nil {
+				btm.reportResumed(reconnects)
+				return path, nil
+			}
+		}
+		again, waitErr := btm.reconnectAfterDrop(ctx, err, reconnects)
+		if waitErr != nil {
+			return "", waitErr
+		}
+		if !again {
+			return "", err
+		}
+		metadata.Resume = true
+	}
+}
+
+// stageReceivedPayload copies a received stream to a staging file chunk by
+// chunk, stopping once ctx ends, and closes the stream
+func (btm *BulletproofTransferManager) stageReceivedPayload(ctx context.Context, stream io.ReadCloser) (string, error) {
+	defer stream.Close()
+
+	file, err := os.CreateTemp(btm.tempDir, ".trustdrop-receive-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create receive staging file: %w", err)
+	}
+	_, err = io.Copy(file, contextReader{ctx: ctx, reader: stream})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		if ctx.Err() != nil {
+			return "", contextError(ctx)
+		}
+		return "", fmt.Errorf("failed to stage received payload: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// reconnectAfterDrop reports a dropped connection and waits to reconnect. It
+// returns false when err is not a dropped connection or the reconnect cap has
+// been reached, and an error if ctx ends while waiting.
+func (btm *BulletproofTransferManager) reconnectAfterDrop(ctx context.Context, err error, reconnects int) (bool, error) {
+	maxReconnects := btm.adaptiveSettings.RetryStrategy.MaxReconnects
+	if !errors.Is(err, transport.ErrConnectionDropped) || reconnects >= maxReconnects || ctx.Err() != nil {
+		return false, nil
+	}
+
+	btm.updateStatus(fmt.Sprintf("Connection dropped mid-transfer - reconnecting to resume (%d/%d)...",
+		reconnects+1, maxReconnects))
+	logging.Tracef("reconnect", "connection dropped (%v), reconnect %d/%d", err, reconnects+1, maxReconnects)
+	if err := sleepContext(ctx, reconnectDelay); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reportResumed notes a transfer that finished after reconnecting
+func (btm *BulletproofTransferManager) reportResumed(reconnects int) {
+	if reconnects > 0 {
+		btm.updateStatus(fmt.Sprintf("Transfer resumed after %d reconnect(s)", reconnects))
+	}
+}
@@ -0,0 +1,93 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits enforced by RetryStrategy.Validate
+const (
+	maxRetryAttempts     = 100
+	minRetryDelay        = 100 * time.Millisecond
+	maxRetryDelay        = 30 * time.Minute
+	maxRetryBackoff      = 10.0
+	maxRetryHardResets   = 10
+	maxRetryHardResetGap = 10 * time.Minute
+	maxRetryReconnects   = 10
+)
+
+// DefaultRetryStrategy returns the retry baseline used unless one is
+// configured. It is tuned for corporate networks with slow proxies.
+func DefaultRetryStrategy() RetryStrategy {
+	return RetryStrategy{
+		MaxAttempts:    15,
+		InitialDelay:   8 * time.Second,
+		BackoffFactor:  1.3,
+		MaxDelay:       90 * time.Second,
+		JitterEnabled:  true,
+		MaxHardResets:  3,
+		HardResetDelay: 15 * time.Second,
+		MaxReconnects:  3,
+	}
+}
+
+// Validate checks that every field is in a usable range
+func (rs RetryStrategy) Validate() error {
+	if rs.MaxAttempts < 1 || rs.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("max attempts must be between 1 and %d, got %d", maxRetryAttempts, rs.MaxAttempts)
+	}
+	if rs.InitialDelay < minRetryDelay || rs.InitialDelay > maxRetryDelay {
+		return fmt.Errorf("initial delay must be between %v and %v, got %v", minRetryDelay, maxRetryDelay, rs.InitialDelay)
+	}
+	if rs.MaxDelay < rs.InitialDelay || rs.MaxDelay > maxRetryDelay {
+		return fmt.Errorf("max delay must be between the initial delay (%v) and %v, got %v", rs.InitialDelay, maxRetryDelay, rs.MaxDelay)
+	}
+	if rs.BackoffFactor < 1 || rs.BackoffFactor > maxRetryBackoff {
+		return fmt.Errorf("backoff factor must be between 1 and %g, got %g", maxRetryBackoff, rs.BackoffFactor)
+	}
+	if rs.MaxHardResets < 0 || rs.MaxHardResets > maxRetryHardResets {
+		return fmt.Errorf("max hard resets must be between 0 and %d, got %d", maxRetryHardResets, rs.MaxHardResets)
+	}
+	if rs.HardResetDelay < 0 || rs.HardResetDelay > maxRetryHardResetGap {
+		return fmt.Errorf("hard reset delay must be between 0 and %v, got %v", maxRetryHardResetGap, rs.HardResetDelay)
+	}
+	if rs.MaxReconnects < 0 || rs.MaxReconnects > maxRetryReconnects {
+		return fmt.Errorf("max reconnects must be between 0 and %d, got %d", maxRetryReconnects, rs.MaxReconnects)
+	}
+	return nil
+}
+
+// SetRetryStrategy sets the retry baseline. Network adaptation scales it up on
+// restrictive networks and down on open ones, so a fast-LAN deployment can cut
+// attempts and delays without losing that adaptation.
+func (btm *BulletproofTransferManager) SetRetryStrategy(strategy RetryStrategy) error {
+	if err := strategy.Validate(); err != nil {
+		return fmt.Errorf("invalid retry strategy: %w", err)
+	}
+
+	btm.retryBaseline = strategy
+	btm.adaptiveSettings.RetryStrategy = adaptRetryStrategy(strategy, btm.networkProfile.IsRestrictive)
+	return nil
+}
+
+// RetryStrategy returns the retry baseline
+func (btm *BulletproofTransferManager) RetryStrategy() RetryStrategy {
+	return btm.retryBaseline
+}
+
+// adaptRetryStrategy scales the baseline for the network: restrictive networks
+// get a third more attempts and longer delays, open networks retry sooner.
+// With the default baseline this gives 20 attempts from 10s up to 120s on
+// restrictive networks and 15 attempts from 5s up to 60s on open ones.
+func adaptRetryStrategy(base RetryStrategy, restrictive bool) RetryStrategy {
+	adapted := base
+	if restrictive {
+		adapted.MaxAttempts = min(base.MaxAttempts*4/3, maxRetryAttempts)
+		adapted.InitialDelay = base.InitialDelay * 5 / 4
+		adapted.MaxDelay = base.MaxDelay * 4 / 3
+	} else {
+		adapted.InitialDelay = max(base.InitialDelay*5/8, minRetryDelay)
+		adapted.MaxDelay = max(base.MaxDelay*2/3, adapted.InitialDelay)
+	}
+	return adapted
+}
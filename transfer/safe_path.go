@@ -0,0 +1,65 @@
+package transfer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// safeJoin joins an untrusted relative path from a transfer manifest under
+// baseDir. Absolute paths, ".." components and paths that would pass through
+// an existing symlink are rejected so a sender cannot write outside baseDir.
+func safeJoin(baseDir, relativePath string) (string, error) {
+	// Manifests may come from any platform, so treat both separators alike
+	normalized := strings.ReplaceAll(relativePath, "\\", "/")
+	if normalized == "" {
+		return "", fmt.Errorf("empty path in transfer")
+	}
+	if strings.HasPrefix(normalized, "/") || filepath.IsAbs(relativePath) || filepath.VolumeName(relativePath) != "" {
+		return "", fmt.Errorf("absolute path not allowed in transfer: %q", relativePath)
+	}
+
+	var components []string
+	for _, part := range strings.Split(normalized, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("parent directory reference not allowed in transfer: %q", relativePath)
+		}
+		if strings.Contains(part, ":") {
+			return "", fmt.Errorf("drive or stream reference not allowed in transfer: %q", relativePath)
+		}
+		components = append(components, part)
+	}
+	if len(components) == 0 {
+		return "", fmt.Errorf("empty path in transfer: %q", relativePath)
+	}
+
+	cleanBase := filepath.Clean(baseDir)
+	fullPath := filepath.Join(append([]string{cleanBase}, components...)...)
+
+	rel, err := filepath.Rel(cleanBase, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("path escapes destination directory: %q", relativePath)
+	}
+
+	// Refuse to follow symlinks that already exist below baseDir
+	current := cleanBase
+	for _, part := range components {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to inspect %s: %w", current, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("path passes through symlink %s: %q", current, relativePath)
+		}
+	}
+
+	return fullPath, nil
+}
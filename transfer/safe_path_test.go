@@ -0,0 +1,60 @@
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	base := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "real"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(base, "link")); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string // empty when the path must be rejected
+	}{
+		{"plain file", "file.txt", "file.txt"},
+		{"nested file", "dir/sub/file.txt", "dir/sub/file.txt"},
+		{"existing directory", "real/file.txt", "real/file.txt"},
+		{"dot components", "./dir/./file.txt", "dir/file.txt"},
+		{"windows separators", `dir\sub\file.txt`, "dir/sub/file.txt"},
+		{"empty", "", ""},
+		{"only dots", "./.", ""},
+		{"parent", "../file.txt", ""},
+		{"nested parent", "dir/../../file.txt", ""},
+		{"parent that stays inside", "dir/../file.txt", ""},
+		{"windows parent", `dir\..\..\file.txt`, ""},
+		{"absolute", "/etc/passwd", ""},
+		{"windows root", `\Windows\system.ini`, ""},
+		{"windows drive", `C:\Windows\system.ini`, ""},
+		{"drive relative", "C:file.txt", ""},
+		{"alternate stream", "file.txt:stream", ""},
+		{"symlinked parent", "link/file.txt", ""},
+		{"symlink itself", "link", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := safeJoin(base, test.path)
+			if test.want == "" {
+				if err == nil {
+					t.Fatalf("safeJoin(%q) = %q, want an error", test.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("safeJoin(%q): %v", test.path, err)
+			}
+			if want := filepath.Join(base, filepath.FromSlash(test.want)); got != want {
+				t.Fatalf("safeJoin(%q) = %q, want %q", test.path, got, want)
+			}
+		})
+	}
+}
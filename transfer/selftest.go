@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"trustdrop-bulletproof/security"
+	"trustdrop-bulletproof/transport"
+)
+
+// selfTestFiles are the files written into the self-test folder. Sending a
+// folder exercises the manifest path as well as encryption.
+var selfTestFiles = map[string]int{
+	"payload.bin":         256 * 1024,
+	"nested/notes.txt":    4 * 1024,
+	"nested/deep/empty.d": 0,
+}
+
+// SelfTestResult reports how a loopback self-test went
+type SelfTestResult struct {
+	Passed          bool
+	Bytes           int64
+	Files           int
+	SendDuration    time.Duration
+	ReceiveDuration time.Duration
+	Duration        time.Duration
+	Error           error
+
+	// EncryptionThroughput is how fast each encryption mode ran on this
+	// device, measured after a passing test
+	EncryptionThroughput []security.ModeThroughput
+}
+
+// SelfTest checks an install end to end without the network. A sender and a
+// receiver manager are created in the same process, sharing an in-memory
+// transport, and a known folder is sent with a random code. The test passes
+// when every file comes back byte for byte after encryption, the manifest
+// round trip and the receiver's integrity checks. Everything is written under
+// a temporary directory that is removed afterwards.
+func SelfTest(ctx context.Context) *SelfTestResult {
+	start := time.Now()
+	result := &SelfTestResult{}
+	result.Error = runSelfTest(ctx, result)
+	result.Passed = result.Error == nil
+	result.Duration = time.Since(start)
+	return result
+}
+
+// runSelfTest does the work of SelfTest, filling in result as it goes
+func runSelfTest(ctx context.Context, result *SelfTestResult) error {
+	workDir, err := os.MkdirTemp("", "trustdrop-selftest-")
+	if err != nil {
+		return fmt.Errorf("failed to create self-test directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	sourceDir := filepath.Join(workDir, "source", "selftest")
+	expected := make(map[string][]byte, len(selfTestFiles))
+	for name, size := range selfTestFiles {
+		data := make([]byte, size)
+		if _, err := rand.Read(data); err != nil {
+			return fmt.Errorf("failed to generate self-test data: %w", err)
+		}
+		path := filepath.Join(sourceDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create self-test data: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to create self-test data: %w", err)
+		}
+		expected[name] = data
+		result.Bytes += int64(size)
+	}
+	result.Files = len(expected)
+
+	exchange := transport.NewMemoryExchange()
+	defer exchange.Close()
+
+	sender, err := newLoopbackManager(filepath.Join(workDir, "sender"), exchange)
+	if err != nil {
+		return err
+	}
+	defer sender.Close()
+
+	receiver, err := newLoopbackManager(filepath.Join(workDir, "receiver"), exchange)
+	if err != nil {
+		return err
+	}
+	defer receiver.Close()
+
+	codeBytes := make([]byte, 12)
+	if _, err := rand.Read(codeBytes); err != nil {
+		return fmt.Errorf("failed to generate self-test code: %w", err)
+	}
+	code := "selftest-" + hex.EncodeToString(codeBytes)
+
+	sendStart := time.Now()
+	if _, err := sender.SendFilesContext(ctx, []string{sourceDir}, code); err != nil {
+		return fmt.Errorf("send failed: %w", err)
+	}
+	result.SendDuration = time.Since(sendStart)
+
+	receiveStart := time.Now()
+	destDir := filepath.Join(workDir, "inbox")
+	received, err := receiver.ReceiveFilesToContext(ctx, code, destDir)
+	if err != nil {
+		return fmt.Errorf("receive failed: %w", err)
+	}
+	result.ReceiveDuration = time.Since(receiveStart)
+
+	if !received.IntegrityVerified {
+		return fmt.Errorf("receiver did not verify integrity")
+	}
+
+	for name, want := range expected {
+		path := filepath.Join(destDir, "selftest", filepath.FromSlash(name))
+		got, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("received file %s is missing: %w", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			return fmt.Errorf("received file %s does not match what was sent", name)
+		}
+	}
+
+	sender.advancedSecurity.Calibrate()
+	result.EncryptionThroughput = sender.EncryptionThroughput()
+	return nil
+}
+
+// newLoopbackManager creates a manager in dataDir that transfers over
+// exchange instead of the network
+func newLoopbackManager(dataDir string, exchange *transport.MemoryExchange) (*BulletproofTransferManager, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create self-test data directory: %w", err)
+	}
+
+	btm := newTransferManager(dataDir, transport.NewLoopbackTransportManager(exchange))
+	btm.networkProfile = btm.transportManager.GetNetworkProfile()
+	btm.adaptSettingsToNetwork()
+	return btm, nil
+}
@@ -0,0 +1,92 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxQueuedNames bounds the names listed in the queued-for-transfer status
+const maxQueuedNames = 10
+
+// checkSelection splits filePaths into the items that can be read and the
+// ones that cannot, posting a warning for each one left out. It fails with
+// ErrNothingToSend when nothing was selected or nothing is readable. Special
+// files pass, since the send skips them with its own warning.
+func (btm *BulletproofTransferManager) checkSelection(filePaths []string) ([]string, []MissingFile, error) {
+	if len(filePaths) == 0 {
+		return nil, nil, fmt.Errorf("%w: no files or folders were selected", ErrNothingToSend)
+	}
+
+	readable := make([]string, 0, len(filePaths))
+	var unreadable []MissingFile
+	for _, filePath := range filePaths {
+		if err := checkReadable(filePath); err != nil {
+			btm.updateStatus(fmt.Sprintf("Warning: Skipping %s (cannot be read: %v)", filepath.Base(filePath), err))
+			unreadable = append(unreadable, MissingFile{Path: filePath, Reason: MissingReasonUnreadable})
+			continue
+		}
+		readable = append(readable, filePath)
+	}
+
+	if len(readable) == 0 {
+		return nil, unreadable, fmt.Errorf("%w: none of the %d selected items could be read", ErrNothingToSend, len(filePaths))
+	}
+	return readable, unreadable, nil
+}
+
+// checkReadable reports why filePath cannot be read, or nil when it can.
+// Regular files are opened and folders listed; other files are not opened,
+// since opening a pipe or device can block.
+func checkReadable(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if info.IsDir() {
+		if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportQueued posts the items a send will actually transfer and, since the
+// per-item warnings can be coalesced with other status messages, the ones
+// left out
+func (btm *BulletproofTransferManager) reportQueued(queued []string, unreadable []MissingFile) {
+	status := fmt.Sprintf("Queued for transfer: %s", listNames(queued))
+	if len(unreadable) > 0 {
+		left := make([]string, len(unreadable))
+		for i, missing := range unreadable {
+			left[i] = missing.Path
+		}
+		status = fmt.Sprintf("Queued for transfer (%d of %d selected items): %s; left out as unreadable: %s",
+			len(queued), len(queued)+len(unreadable), listNames(queued), listNames(left))
+	}
+	btm.updateStatus(status)
+}
+
+// listNames joins the base names of paths, up to maxQueuedNames of them
+func listNames(paths []string) string {
+	names := make([]string, 0, maxQueuedNames+1)
+	for i, path := range paths {
+		if i == maxQueuedNames {
+			names = append(names, fmt.Sprintf("and %d more", len(paths)-maxQueuedNames))
+			break
+		}
+		names = append(names, filepath.Base(path))
+	}
+	return strings.Join(names, ", ")
+}
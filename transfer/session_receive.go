@@ -0,0 +1,53 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// maxSessionFailures ends a receive session after this many receives in a row
+// fail. Each one has already been through the full retry strategy.
+const maxSessionFailures = 3
+
+// ReceiveSession receives successive transfers sent with the same code, for a
+// sender who reuses one code for several separate sends. See
+// ReceiveSessionContext.
+func (btm *BulletproofTransferManager) ReceiveSession(transferCode string, onReceive func(*TransferResult, error)) (int, error) {
+	return btm.ReceiveSessionContext(context.Background(), transferCode, onReceive)
+}
+
+// ReceiveSessionContext keeps transferCode active and receives one transfer
+// after another into the received folder until ctx ends or the current
+// receive is cancelled. onReceive, if set, is called after every receive with
+// its result and error; the result is nil when nothing arrived. It returns how
+// many transfers were received. The session ends with an error only when
+// maxSessionFailures receives fail in a row.
+func (btm *BulletproofTransferManager) ReceiveSessionContext(ctx context.Context, transferCode string, onReceive func(*TransferResult, error)) (int, error) {
+	received := 0
+	failures := 0
+
+	for {
+		btm.updateStatus(fmt.Sprintf("Session %s: waiting for transfer %d...", transferCode, received+1))
+		result, err := btm.ReceiveFilesContext(ctx, transferCode)
+		if ctx.Err() != nil || errors.Is(err, ErrCancelled) {
+			btm.updateStatus(fmt.Sprintf("Session %s ended after %d transfers", transferCode, received))
+			return received, nil
+		}
+
+		if onReceive != nil {
+			onReceive(result, err)
+		}
+		if result != nil {
+			// Incomplete folders still count; what arrived was kept
+			received++
+			failures = 0
+			continue
+		}
+
+		failures++
+		if failures == maxSessionFailures {
+			return received, fmt.Errorf("receive session %s stopped after %d failed receives in a row: %w", transferCode, failures, err)
+		}
+	}
+}
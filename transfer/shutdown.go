@@ -0,0 +1,121 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ShutdownFailure is one resource Shutdown or Close could not release
+type ShutdownFailure struct {
+	Resource string // What failed, such as "transports" or "transfer receive-3"
+	Err      error
+}
+
+// ShutdownError lists every resource Shutdown or Close could not release
+type ShutdownError struct {
+	Failures []ShutdownFailure
+}
+
+// Error names each failed resource with its error
+func (e *ShutdownError) Error() string {
+	parts := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		parts = append(parts, fmt.Sprintf("%s: %v", failure.Resource, failure.Err))
+	}
+	return "shutdown incomplete: " + strings.Join(parts, "; ")
+}
+
+// Unwrap exposes the underlying errors
+func (e *ShutdownError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// errShuttingDown is returned by transfers started once Shutdown or Close has
+// begun. It matches ErrCancelled, so receive sessions end quietly.
+var errShuttingDown = fmt.Errorf("%w: the transfer manager is shutting down", ErrCancelled)
+
+// Shutdown stops the manager for good: it refuses new transfers, cancels
+// every transfer in flight, waits until they have unwound or ctx ends, then
+// releases the transports, connection pool, protocol trace and logger. Audit
+// ledger entries are written before a transfer unwinds, so once its transfers
+// have stopped nothing is left to flush. Transfers still running when ctx
+// ends, and resources that fail to close, are listed in a *ShutdownError;
+// the resources are released either way. The local relays croc starts for
+// sends in transport.PathModeAuto cannot be stopped and stay open until the
+// process exits.
+func (btm *BulletproofTransferManager) Shutdown(ctx context.Context) error {
+	btm.stopTransfers()
+
+	var failures []ShutdownFailure
+	done := make(chan struct{})
+	go func() {
+		btm.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		for _, status := range btm.ActiveTransfers() {
+			failures = append(failures, ShutdownFailure{
+				Resource: "transfer " + status.ID,
+				Err:      fmt.Errorf("did not stop in time: %w", ctx.Err()),
+			})
+		}
+	}
+
+	failures = append(failures, btm.releaseResources()...)
+	return shutdownError(failures)
+}
+
+// stopTransfers refuses new transfers and cancels those in flight
+func (btm *BulletproofTransferManager) stopTransfers() {
+	btm.mutex.Lock()
+	btm.closing = true
+	for _, transfer := range btm.transfers {
+		transfer.cancel()
+	}
+	btm.mutex.Unlock()
+
+	if btm.cancelFunction != nil {
+		btm.cancelFunction()
+	}
+}
+
+// releaseResources closes everything the manager holds open, returning what
+// failed to close
+func (btm *BulletproofTransferManager) releaseResources() []ShutdownFailure {
+	var failures []ShutdownFailure
+	fail := func(resource string, err error) {
+		if err != nil {
+			failures = append(failures, ShutdownFailure{Resource: resource, Err: err})
+		}
+	}
+
+	if btm.transportManager != nil {
+		fail("transports", btm.transportManager.Close())
+	}
+	if btm.connectionPool != nil {
+		btm.connectionPool.Close()
+	}
+	fail("protocol trace", btm.SetTrace(false))
+	if btm.logger != nil {
+		fail("logger", btm.logger.Close())
+	}
+
+	btm.statusThrottle.flush(btm.deliverStatus)
+	btm.subscribers.closeAll()
+	return failures
+}
+
+// shutdownError returns failures as a *ShutdownError, or nil if there are none
+func shutdownError(failures []ShutdownFailure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	return &ShutdownError{Failures: failures}
+}
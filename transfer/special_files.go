@@ -0,0 +1,33 @@
+package transfer
+
+import (
+	"os"
+)
+
+// specialFileKind describes a path that is neither a regular file nor a
+// directory, such as a named pipe, socket or device, and returns "" otherwise.
+// Reading these can block forever or produce endless data, so they are skipped.
+// Symlinks are judged by what they point to.
+func specialFileKind(path string, info os.FileInfo) string {
+	mode := info.Mode()
+	if mode&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			return "broken symlink"
+		}
+		mode = target.Mode()
+	}
+
+	switch {
+	case mode.IsRegular(), mode.IsDir():
+		return ""
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeDevice != 0, mode&os.ModeCharDevice != 0:
+		return "device"
+	default:
+		return "special file"
+	}
+}
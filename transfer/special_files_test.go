@@ -0,0 +1,65 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// loopbackPair returns a sender and a receiver manager that transfer through
+// memory, as the self-test does
+func loopbackPair(t *testing.T) (sender, receiver *BulletproofTransferManager) {
+	t.Helper()
+	exchange := transport.NewMemoryExchange()
+	t.Cleanup(exchange.Close)
+
+	work := t.TempDir()
+	sender, err := newLoopbackManager(filepath.Join(work, "sender"), exchange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sender.Close() })
+	receiver, err = newLoopbackManager(filepath.Join(work, "receiver"), exchange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { receiver.Close() })
+	return sender, receiver
+}
+
+// loopbackTransfer sends paths from sender to receiver and returns the
+// receive result and the folder it wrote to
+func loopbackTransfer(t *testing.T, sender, receiver *BulletproofTransferManager, paths ...string) (*TransferResult, string) {
+	t.Helper()
+	const code = "loopback-test-code"
+	if _, err := sender.SendFilesContext(context.Background(), paths, code); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	destDir := filepath.Join(t.TempDir(), "inbox")
+	result, err := receiver.ReceiveFilesToContext(context.Background(), code, destDir)
+	if err != nil {
+		t.Fatalf("receive failed: %v", err)
+	}
+	return result, destDir
+}
+
+func TestEmptyFileRoundTrip(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(source, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sender, receiver := loopbackPair(t)
+	_, destDir := loopbackTransfer(t, sender, receiver, source)
+
+	info, err := os.Stat(filepath.Join(destDir, "empty.txt"))
+	if err != nil {
+		t.Fatalf("empty file was not recreated: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("empty file received with %d bytes", info.Size())
+	}
+}
@@ -0,0 +1,60 @@
+//go:build unix
+
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFolderWithFIFOSkipsIt(t *testing.T) {
+	folder := filepath.Join(t.TempDir(), "project")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "empty.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(folder, "pipe"), 0644); err != nil {
+		t.Skipf("cannot create a named pipe: %v", err)
+	}
+
+	sender, receiver := loopbackPair(t)
+
+	// Reading the pipe would block with no writer, so a hang means it was read
+	sent := make(chan error, 1)
+	go func() {
+		_, err := sender.SendFilesContext(context.Background(), []string{folder}, "fifo-test-code")
+		sent <- err
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("send blocked on the named pipe")
+	}
+
+	destDir := filepath.Join(t.TempDir(), "inbox")
+	if _, err := receiver.ReceiveFilesToContext(context.Background(), "fifo-test-code", destDir); err != nil {
+		t.Fatalf("receive failed: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(destDir, "project", "notes.txt")); err != nil || string(data) != "notes" {
+		t.Errorf("notes.txt received as %q, %v", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(destDir, "project", "empty.txt")); err != nil || info.Size() != 0 {
+		t.Errorf("empty file in folder not recreated: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(destDir, "project", "pipe")); !os.IsNotExist(err) {
+		t.Errorf("named pipe was recreated: %v", err)
+	}
+}
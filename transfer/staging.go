@@ -0,0 +1,182 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// commitStagedFiles moves staged paths from stagingDir to the same relative
+// location under finalDir and returns the final paths. Existing files at the
+// destination are replaced, matching the behaviour of direct writes.
+func commitStagedFiles(stagingDir, finalDir string, staged []string) ([]string, error) {
+	committed := make([]string, 0, len(staged))
+
+	for _, stagedPath := range staged {
+		relPath, err := filepath.Rel(stagingDir, stagedPath)
+		if err != nil {
+			return committed, fmt.Errorf("failed to resolve staged file %s: %w", stagedPath, err)
+		}
+
+		finalPath, err := safeJoin(finalDir, relPath)
+		if err != nil {
+			return committed, fmt.Errorf("rejected unsafe path in transfer: %w", err)
+		}
+
+		info, err := os.Stat(stagedPath)
+		if err != nil {
+			return committed, fmt.Errorf("failed to access staged file %s: %w", stagedPath, err)
+		}
+
+		if info.IsDir() {
+			if err := os.MkdirAll(finalPath, 0755); err != nil {
+				return committed, fmt.Errorf("failed to create directory %s: %w", finalPath, err)
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(finalPath), 0755); err != nil {
+				return committed, fmt.Errorf("failed to create parent directory for %s: %w", finalPath, err)
+			}
+			if err := moveFile(stagedPath, finalPath); err != nil {
+				return committed, fmt.Errorf("failed to move %s into place: %w", finalPath, err)
+			}
+		}
+
+		committed = append(committed, finalPath)
+	}
+
+	return committed, nil
+}
+
+// moveFile renames src to dst, copying instead when they are on different
+// volumes (for example when staging happens in a separate temp directory)
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Copy next to dst first so the final step is still a same-volume rename
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := out.Name()
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Chmod(tempPath, info.Mode().Perm()); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, dst); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// writeFileAtomic writes data to a temp file in the destination directory and
+// renames it into place, so readers never observe a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeStreamAtomic(path, bytes.NewReader(data), perm)
+}
+
+// writeReceivedFile is writeFileAtomic for received files: the temp file is
+// read back before the rename and must match data's size and checksum, so a
+// file under its final name is always complete and correct
+func writeReceivedFile(path string, data []byte, perm os.FileMode) error {
+	return writeStreamChecked(path, bytes.NewReader(data), perm, checkWrittenFile(int64(len(data)), checksumOf(data)))
+}
+
+// writeStreamAtomic is writeFileAtomic for data read from r
+func writeStreamAtomic(path string, r io.Reader, perm os.FileMode) error {
+	return writeStreamChecked(path, r, perm, nil)
+}
+
+// checkWrittenFile returns a check that the file at a path has size bytes
+// and the given SHA-256 checksum
+func checkWrittenFile(size int64, checksum string) func(path string) error {
+	return func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Size() != size {
+			return fmt.Errorf("%w: wrote %d bytes, expected %d", ErrIntegrityFailed, info.Size(), size)
+		}
+		written, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		if written != checksum {
+			return fmt.Errorf("%w: the written file does not match the data received", ErrIntegrityFailed)
+		}
+		return nil
+	}
+}
+
+// writeStreamChecked is writeStreamAtomic that runs check, if not nil, on
+// the finished temp file and only renames it into place if check passes
+func writeStreamChecked(path string, r io.Reader, perm os.FileMode, check func(tempPath string) error) error {
+	dir := filepath.Dir(path)
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	cleanup := func() {
+		tempFile.Close()
+		os.Remove(tempPath)
+	}
+
+	if _, err := io.Copy(tempFile, r); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to flush temp file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if check != nil {
+		if err := check(tempPath); err != nil {
+			os.Remove(tempPath)
+			return fmt.Errorf("failed to verify written file: %w", err)
+		}
+	}
+	if err := os.Chmod(tempPath, perm); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
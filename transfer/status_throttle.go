@@ -0,0 +1,73 @@
+package transfer
+
+import (
+	"sync"
+	"time"
+)
+
+// statusInterval is the shortest gap between status messages delivered to the
+// status callback and Status channels. Retries and sub-steps can produce many
+// messages a second, which makes GUI labels flicker.
+const statusInterval = 250 * time.Millisecond
+
+// statusThrottle coalesces status messages. A message arriving within
+// statusInterval of the last delivery is held back, replacing any message
+// already held, and the held message is delivered when the interval ends.
+type statusThrottle struct {
+	mutex      sync.Mutex
+	last       time.Time // when a message was last delivered
+	pending    string
+	hasPending bool
+	timer      *time.Timer
+
+	// deliverMutex keeps deliveries from the timer and from submit in order
+	deliverMutex sync.Mutex
+}
+
+// submit delivers message now if the interval has passed, and otherwise holds
+// it as the latest message for the end of the interval
+func (st *statusThrottle) submit(message string, deliver func(string)) {
+	st.mutex.Lock()
+	wait := statusInterval - time.Since(st.last)
+	if wait <= 0 && st.timer == nil {
+		st.last = time.Now()
+		st.mutex.Unlock()
+		st.deliver(message, deliver)
+		return
+	}
+
+	st.pending = message
+	st.hasPending = true
+	if st.timer == nil {
+		st.timer = time.AfterFunc(max(wait, 0), func() {
+			st.flush(deliver)
+		})
+	}
+	st.mutex.Unlock()
+}
+
+// flush delivers the held message, if any, straight away
+func (st *statusThrottle) flush(deliver func(string)) {
+	st.mutex.Lock()
+	if st.timer != nil {
+		st.timer.Stop()
+		st.timer = nil
+	}
+	message, ok := st.pending, st.hasPending
+	st.pending, st.hasPending = "", false
+	if ok {
+		st.last = time.Now()
+	}
+	st.mutex.Unlock()
+
+	if ok {
+		st.deliver(message, deliver)
+	}
+}
+
+// deliver hands message to deliver, one delivery at a time
+func (st *statusThrottle) deliver(message string, deliver func(string)) {
+	st.deliverMutex.Lock()
+	defer st.deliverMutex.Unlock()
+	deliver(message)
+}
@@ -0,0 +1,163 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// SummaryStdout as the summary path writes each summary to standard output
+const SummaryStdout = "-"
+
+// TransferredFile is one file whose contents a transfer moved
+type TransferredFile struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"sha256"`
+}
+
+// transferSummary is the JSON form of a TransferResult, for automation
+type transferSummary struct {
+	TransferCode      string            `json:"transfer_code"`
+	Direction         string            `json:"direction"`
+	Success           bool              `json:"success"`
+	Files             []TransferredFile `json:"files"`
+	TotalBytes        int64             `json:"total_bytes"`
+	WireBytes         int64             `json:"wire_bytes,omitempty"`
+	DurationSeconds   float64           `json:"duration_seconds"`
+	Transport         string            `json:"transport,omitempty"`
+	Path              string            `json:"path,omitempty"`
+	EncryptionMode    string            `json:"encryption_mode,omitempty"`
+	IntegrityVerified bool              `json:"integrity_verified"`
+	Degraded          bool              `json:"degraded,omitempty"`
+	DestinationDir    string            `json:"destination_dir,omitempty"`
+	MissingFiles      []MissingFile     `json:"missing_files,omitempty"`
+	UnsentFiles       []MissingFile     `json:"unsent_files,omitempty"`
+	BlockedFiles      []MissingFile     `json:"blocked_files,omitempty"`
+	QuarantineReport  string            `json:"quarantine_report,omitempty"`
+	SenderClockAhead  float64           `json:"sender_clock_ahead_seconds,omitempty"`
+	TracePath         string            `json:"trace_path,omitempty"`
+	Error             string            `json:"error,omitempty"`
+}
+
+// ToJSON describes the transfer as indented JSON: the code, each file moved
+// with its size and SHA-256, totals, transport, encryption mode and whether
+// it succeeded and was verified
+func (r *TransferResult) ToJSON() ([]byte, error) {
+	summary := transferSummary{
+		TransferCode:      r.TransferCode,
+		Direction:         r.Direction,
+		Success:           r.Success,
+		Files:             r.Files,
+		TotalBytes:        r.TotalBytes,
+		WireBytes:         r.WireBytes,
+		DurationSeconds:   r.Duration.Seconds(),
+		Transport:         r.TransportUsed,
+		Path:              r.Path,
+		IntegrityVerified: r.IntegrityVerified,
+		Degraded:          r.Degraded,
+		DestinationDir:    r.DestinationDir,
+		MissingFiles:      r.MissingFiles,
+		UnsentFiles:       r.UnsentFiles,
+		BlockedFiles:      r.BlockedFiles,
+		QuarantineReport:  r.QuarantineReport,
+		SenderClockAhead:  r.SenderClockAhead.Seconds(),
+		TracePath:         r.TracePath,
+	}
+	if summary.Files == nil {
+		summary.Files = []TransferredFile{}
+	}
+	if len(r.Files) > 0 {
+		summary.EncryptionMode = r.EncryptionMode.String()
+	}
+	if r.Error != nil {
+		summary.Error = r.Error.Error()
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transfer summary: %w", err)
+	}
+	return data, nil
+}
+
+// SetSummaryPath makes every send and receive write its JSON summary to
+// path when it finishes, replacing the previous one. SummaryStdout writes to
+// standard output instead and an empty path turns summaries off.
+func (btm *BulletproofTransferManager) SetSummaryPath(path string) {
+	btm.summaryPath = path
+}
+
+// writeSummary writes the summary of a finished transfer if a summary path is
+// set. A transfer that failed before producing a result is summarized from err.
+func (btm *BulletproofTransferManager) writeSummary(direction, transferCode string, result *TransferResult, err error) {
+	if btm.summaryPath == "" {
+		return
+	}
+	if result == nil {
+		result = &TransferResult{TransferCode: transferCode, Direction: direction, Error: err}
+	} else if result.Error == nil {
+		result.Error = err
+	}
+
+	data, err := result.ToJSON()
+	if err == nil {
+		if btm.summaryPath == SummaryStdout {
+			_, err = os.Stdout.Write(append(data, '\n'))
+		} else {
+			err = writeFileAtomic(btm.summaryPath, append(data, '\n'), 0600)
+		}
+	}
+	if err != nil {
+		logging.Warnf("Could not write transfer summary: %v", err)
+	}
+}
+
+// sentFiles lists the manifest files whose contents were sent, by path
+func (m *FileManifest) sentFiles() []TransferredFile {
+	var files []TransferredFile
+	for _, file := range m.Files {
+		if file.IsDirectory || file.SendError != "" || file.Data == nil {
+			continue
+		}
+		files = append(files, TransferredFile{Path: file.OriginalPath, Size: file.Size, Checksum: file.Hash})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// filesWithData lists the files whose contents went out with the manifest
+func (m *FileManifest) filesWithData() []FileInfo {
+	var files []FileInfo
+	for _, file := range m.Files {
+		if !file.IsDirectory && file.SendError == "" && !file.Omitted {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].RelativePath < files[j].RelativePath
+	})
+	return files
+}
+
+// transferredFiles lists the files a receive wrote, by path. Folders it
+// created have no checksum and are left out.
+func (p *receivedPayload) transferredFiles() []TransferredFile {
+	files := make([]TransferredFile, 0, len(p.Checksums))
+	for path, checksum := range p.Checksums {
+		file := TransferredFile{Path: path, Checksum: checksum}
+		if info, err := os.Stat(path); err == nil {
+			file.Size = info.Size()
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
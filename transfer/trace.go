@@ -0,0 +1,66 @@
+package transfer
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
+)
+
+// SetTrace turns the protocol trace on or off. The trace is a timeline of
+// each transfer's phases with their durations - relay dial, rendezvous,
+// handshake, chunks, verification - for finding where a stuck transfer
+// stopped. It is written to a new file in the state directory's traces
+// folder, which TracePath names. The trace is shared by the whole process.
+func (btm *BulletproofTransferManager) SetTrace(enabled bool) error {
+	if !enabled {
+		if !btm.tracing {
+			return nil
+		}
+		btm.tracing = false
+		return logging.StopTrace()
+	}
+	if logging.Tracing() {
+		return nil
+	}
+
+	dir, err := internal.EnsureStateDir(internal.StateTracesDir)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("trace-%s.log", time.Now().Format("20060102-150405")))
+	if err := logging.StartTrace(path); err != nil {
+		return err
+	}
+	btm.tracing = true
+	logging.Tracef("trace", "protocol version %d", ProtocolVersion)
+	return nil
+}
+
+// TracePath returns the protocol trace file, or "" when tracing is off.
+// Attach it when reporting a stuck or failed transfer.
+func (btm *BulletproofTransferManager) TracePath() string {
+	return logging.TracePath()
+}
+
+// traceTransfer runs one send or receive as a span of the protocol trace and
+// points a failed one at the trace. The code is a secret, so it stays out.
+func (btm *BulletproofTransferManager) traceTransfer(direction string, run func() (*TransferResult, error)) (*TransferResult, error) {
+	traced := logging.TraceSpan("transfer", "%s", direction)
+	result, err := run()
+	traced(err)
+
+	path := logging.TracePath()
+	if path == "" {
+		return result, err
+	}
+	if result != nil {
+		result.TracePath = path
+	}
+	if err != nil {
+		btm.updateStatus(fmt.Sprintf("Protocol trace of this transfer: %s", path))
+	}
+	return result, err
+}
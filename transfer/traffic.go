@@ -0,0 +1,36 @@
+package transfer
+
+import (
+	"fmt"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// transportTraffic reads the transport manager's byte counters
+func (btm *BulletproofTransferManager) transportTraffic() transport.Traffic {
+	if btm.transportManager == nil {
+		return transport.Traffic{}
+	}
+	return btm.transportManager.Traffic()
+}
+
+// OverheadBytes returns how many more bytes crossed the network than the
+// files hold, from encryption, checksums and the payload format. It is
+// negative when compression made the payload smaller than the files.
+func (r *TransferResult) OverheadBytes() int64 {
+	return r.WireBytes - r.TotalBytes
+}
+
+// DescribeSize states the file bytes and, when known, the bytes on the wire,
+// for example "10.0 MB of files, 12.1 MB on the wire (+21%)"
+func (r *TransferResult) DescribeSize() string {
+	description := FormatBytes(r.TotalBytes) + " of files"
+	if r.WireBytes == 0 {
+		return description
+	}
+	description += fmt.Sprintf(", %s on the wire", FormatBytes(r.WireBytes))
+	if r.TotalBytes > 0 {
+		description += fmt.Sprintf(" (%+.0f%%)", float64(r.OverheadBytes())*100/float64(r.TotalBytes))
+	}
+	return description
+}
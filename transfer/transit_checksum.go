@@ -0,0 +1,144 @@
+package transfer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// transitMagic starts a payload sealed by a sender from before protocol
+// versioning. Payloads from even older senders have no header at all and are
+// passed through unchecked.
+var transitMagic = []byte("TDSUM1\x00\x00")
+
+// transitVersionedMagic starts a payload sealed by sealTransit. It is followed
+// by the sender's ProtocolVersion as a big-endian uint16, which fills out the
+// same 8 bytes transitMagic takes. Later releases keep this prefix so that
+// older receivers can still read the version they fail to understand.
+var transitVersionedMagic = []byte("TDSUM2")
+
+// transitHeaderSize is the magic followed by the SHA-256 of the ciphertext
+const transitHeaderSize = 8 + sha256.Size
+
+// boundMetadataVersion is the first ProtocolVersion whose payloads carry the
+// transfer metadata, as a big-endian uint32 length and the bytes, between the
+// header and the ciphertext. The checksum covers both.
+const boundMetadataVersion = 3
+
+// transitPayload is a received payload taken out of its transit envelope
+type transitPayload struct {
+	ciphertext []byte
+	metadata   []byte // Bound transfer metadata, the ciphertext's associated data; nil from older senders
+	version    int    // Sender's ProtocolVersion, 0 if it did not report one
+}
+
+// sealTransit prefixes the encrypted payload with this build's protocol
+// version, the metadata it was encrypted with and their checksum, so the
+// receiver can tell a payload damaged on the way from one encrypted with a
+// different code, and an incompatible sender from either
+func sealTransit(ciphertext, metadata []byte) []byte {
+	body := make([]byte, 0, 4+len(metadata)+len(ciphertext))
+	body = binary.BigEndian.AppendUint32(body, uint32(len(metadata)))
+	body = append(body, metadata...)
+	body = append(body, ciphertext...)
+
+	sum := sha256.Sum256(body)
+	sealed := make([]byte, 0, transitHeaderSize+len(body))
+	sealed = append(sealed, transitVersionedMagic...)
+	sealed = binary.BigEndian.AppendUint16(sealed, ProtocolVersion)
+	sealed = append(sealed, sum[:]...)
+	return append(sealed, body...)
+}
+
+// openTransit checks a received payload of size bytes against the checksum
+// sealTransit put in front of it and splits off the bound metadata. The
+// checksum is computed by streaming through r, so a payload damaged on the
+// way is rejected without being loaded; only the metadata and ciphertext are
+// then read into memory, each once and at its exact size. Decryption needs
+// the ciphertext in one piece, so a payload must fit in memory; callers
+// bound size first, as openStagedTransit does.
+func openTransit(r io.ReaderAt, size int64) (transitPayload, error) {
+	header := make([]byte, min(size, transitHeaderSize))
+	if _, err := r.ReadAt(header, 0); err != nil && !errors.Is(err, io.EOF) {
+		return transitPayload{}, fmt.Errorf("failed to read received payload: %w", err)
+	}
+
+	version := 0
+	switch {
+	case bytes.HasPrefix(header, transitVersionedMagic) && len(header) >= len(transitMagic):
+		version = int(binary.BigEndian.Uint16(header[len(transitVersionedMagic):len(transitMagic)]))
+	case bytes.HasPrefix(header, transitMagic):
+	default:
+		ciphertext, err := readSection(r, 0, size)
+		return transitPayload{ciphertext: ciphertext}, err
+	}
+
+	if size < transitHeaderSize {
+		return transitPayload{}, fmt.Errorf("%w: payload cut off after %d bytes", ErrCorruptedInTransit, size)
+	}
+
+	bodySize := size - transitHeaderSize
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(r, transitHeaderSize, bodySize)); err != nil {
+		return transitPayload{}, fmt.Errorf("failed to read received payload: %w", err)
+	}
+	if !bytes.Equal(hash.Sum(nil), header[len(transitMagic):transitHeaderSize]) {
+		return transitPayload{}, fmt.Errorf("%w: checksum mismatch over %d received bytes", ErrCorruptedInTransit, bodySize)
+	}
+	if version < boundMetadataVersion {
+		ciphertext, err := readSection(r, transitHeaderSize, bodySize)
+		return transitPayload{ciphertext: ciphertext, version: version}, err
+	}
+
+	length, err := readSection(r, transitHeaderSize, min(bodySize, 4))
+	if err != nil {
+		return transitPayload{}, err
+	}
+	if len(length) < 4 || int64(binary.BigEndian.Uint32(length)) > bodySize-4 {
+		return transitPayload{}, fmt.Errorf("%w: transfer metadata is damaged", ErrCorruptedInTransit)
+	}
+	metadataSize := int64(binary.BigEndian.Uint32(length))
+	metadata, err := readSection(r, transitHeaderSize+4, metadataSize)
+	if err != nil {
+		return transitPayload{}, err
+	}
+	ciphertext, err := readSection(r, transitHeaderSize+4+metadataSize, bodySize-4-metadataSize)
+	if err != nil {
+		return transitPayload{}, err
+	}
+	return transitPayload{ciphertext: ciphertext, metadata: metadata, version: version}, nil
+}
+
+// openStagedTransit opens the payload staged at path and removes the file. A
+// payload larger than maxSize, when it is positive, is refused unread rather
+// than loaded into memory.
+func openStagedTransit(path string, maxSize int64) (transitPayload, error) {
+	defer os.Remove(path)
+	file, err := os.Open(path)
+	if err != nil {
+		return transitPayload{}, fmt.Errorf("failed to open received payload: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return transitPayload{}, fmt.Errorf("failed to open received payload: %w", err)
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return transitPayload{}, fmt.Errorf("%w: the received payload is %s, more than the %s a transfer this device accepts can take",
+			ErrTransferTooLarge, FormatBytes(info.Size()), FormatBytes(maxSize))
+	}
+	return openTransit(file, info.Size())
+}
+
+// readSection reads size bytes of r from offset into memory at once
+func readSection(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(io.NewSectionReader(r, offset, size), data); err != nil {
+		return nil, fmt.Errorf("failed to read received payload: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,102 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stageTransit writes payload to a file as a receive would stage it
+func stageTransit(t *testing.T, payload []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(path, payload, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenStagedTransitRoundTrip(t *testing.T) {
+	ciphertext := bytes.Repeat([]byte("ciphertext"), 1000)
+	metadata := []byte(`{"transfer_id":"code"}`)
+
+	payload, err := openStagedTransit(stageTransit(t, sealTransit(ciphertext, metadata)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload.ciphertext, ciphertext) || !bytes.Equal(payload.metadata, metadata) {
+		t.Fatal("staged payload did not round-trip")
+	}
+	if payload.version != ProtocolVersion {
+		t.Fatalf("version %d, want %d", payload.version, ProtocolVersion)
+	}
+}
+
+func TestOpenStagedTransitDetectsCorruption(t *testing.T) {
+	sealed := sealTransit(bytes.Repeat([]byte("x"), 4096), []byte("metadata"))
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := openStagedTransit(stageTransit(t, sealed), 0); !errors.Is(err, ErrCorruptedInTransit) {
+		t.Fatalf("got %v, want ErrCorruptedInTransit", err)
+	}
+
+	if _, err := openStagedTransit(stageTransit(t, sealed[:transitHeaderSize-1]), 0); !errors.Is(err, ErrCorruptedInTransit) {
+		t.Fatalf("cut off payload: got %v, want ErrCorruptedInTransit", err)
+	}
+}
+
+func TestOpenStagedTransitPassesUnsealedPayload(t *testing.T) {
+	legacy := []byte("payload from a sender without a transit header")
+	payload, err := openStagedTransit(stageTransit(t, legacy), 0)
+	if err != nil || !bytes.Equal(payload.ciphertext, legacy) {
+		t.Fatalf("got %q, %v", payload.ciphertext, err)
+	}
+}
+
+func TestOpenStagedTransitRefusesOversizedPayload(t *testing.T) {
+	sealed := sealTransit(bytes.Repeat([]byte("x"), 4096), nil)
+	path := stageTransit(t, sealed)
+	if _, err := openStagedTransit(path, int64(len(sealed))-1); !errors.Is(err, ErrTransferTooLarge) {
+		t.Fatalf("got %v, want ErrTransferTooLarge", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("refused payload left behind: %v", err)
+	}
+
+	if _, err := openStagedTransit(stageTransit(t, sealed), int64(len(sealed))); err != nil {
+		t.Fatalf("payload at the limit refused: %v", err)
+	}
+}
+
+func TestOpenStagedTransitRemovesFile(t *testing.T) {
+	path := stageTransit(t, sealTransit([]byte("ciphertext"), nil))
+	openStagedTransit(path, 0)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("staged file left behind: %v", err)
+	}
+}
+
+func TestStageReceivedPayloadStopsOnCancel(t *testing.T) {
+	btm := &BulletproofTransferManager{tempDir: t.TempDir()}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := btm.stageReceivedPayload(ctx, io.NopCloser(bytes.NewReader([]byte("data")))); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("got %v, want ErrCancelled", err)
+	}
+	entries, _ := os.ReadDir(btm.tempDir)
+	if len(entries) != 0 {
+		t.Fatalf("cancelled receive left %d staging files", len(entries))
+	}
+
+	path, err := btm.stageReceivedPayload(context.Background(), io.NopCloser(bytes.NewReader([]byte("data"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "data" {
+		t.Fatalf("staged %q", data)
+	}
+}
@@ -0,0 +1,38 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+
+	"trustdrop-bulletproof/transport"
+)
+
+// WithTransports returns a context whose transfer uses only the named
+// transports, tried in the order given, instead of automatic failover. It is
+// for networks where only one way out is known to work. Names come from
+// TransportNames; none gives full failover again.
+func WithTransports(ctx context.Context, names ...string) context.Context {
+	return transport.WithTransports(ctx, names...)
+}
+
+// TransportNames lists the transports available to WithTransports
+func (btm *BulletproofTransferManager) TransportNames() []string {
+	if btm.transportManager == nil {
+		return nil
+	}
+	return btm.transportManager.TransportNames()
+}
+
+// checkTransportOverride fails a transfer up front when ctx names a
+// transport that is not available, rather than retrying it
+func (btm *BulletproofTransferManager) checkTransportOverride(ctx context.Context) error {
+	names := transport.TransportOverride(ctx)
+	if len(names) == 0 || btm.transportManager == nil {
+		return nil
+	}
+	if err := btm.transportManager.CheckTransportOverride(ctx); err != nil {
+		return fmt.Errorf("invalid transport override: %w", err)
+	}
+	btm.updateStatus(fmt.Sprintf("Using only the chosen transports: %v", names))
+	return nil
+}
@@ -0,0 +1,165 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// receiptsDir holds one receipt per received transfer, under the data directory
+const receiptsDir = "receipts"
+
+// Outcomes for a file checked by VerifyReceived
+const (
+	VerifyStatusOK       = "ok"       // Contents match what was received
+	VerifyStatusModified = "modified" // Contents changed since they were received
+	VerifyStatusMissing  = "missing"  // The file is no longer where it was saved
+	VerifyStatusError    = "error"    // The file exists but could not be read
+)
+
+// transferReceipt records what a receive wrote so it can be checked later
+type transferReceipt struct {
+	TransferCode string          `json:"transfer_code"`
+	ReceivedAt   time.Time       `json:"received_at"`
+	SentAt       time.Time       `json:"sent_at,omitempty"` // On this device's clock, allowing for skew
+	Files        []receiptRecord `json:"files"`
+}
+
+// receiptRecord is one file written by a receive
+type receiptRecord struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// FileVerification is the result of re-checking one received file
+type FileVerification struct {
+	Path     string
+	Status   string // One of the VerifyStatus* values
+	Expected string // Checksum recorded when the file was received
+	Actual   string // Checksum now, empty when the file could not be read
+	Error    error
+}
+
+// VerifyReport is the result of VerifyReceived
+type VerifyReport struct {
+	TransferCode string
+	ReceivedAt   time.Time
+	SentAt       time.Time // When the sender sent the files, on this device's clock; zero if unknown
+	Files        []FileVerification
+	Passed       int
+	Failed       int
+}
+
+// OK reports whether every file still matches what was received
+func (r *VerifyReport) OK() bool {
+	return r.Failed == 0
+}
+
+// VerifyReceived re-checks the files a receive wrote for transferCode against
+// the checksums recorded when they arrived. It can run at any time after the
+// transfer, for example once the files have been copied to their final home,
+// and does not touch the network. Files moved since then are reported missing.
+func (btm *BulletproofTransferManager) VerifyReceived(transferCode string) (*VerifyReport, error) {
+	receipt, err := btm.loadReceipt(transferCode)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{
+		TransferCode: receipt.TransferCode,
+		ReceivedAt:   receipt.ReceivedAt,
+		SentAt:       receipt.SentAt,
+	}
+
+	for _, record := range receipt.Files {
+		check := FileVerification{Path: record.Path, Expected: record.Checksum}
+
+		actual, err := fileChecksum(record.Path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			check.Status = VerifyStatusMissing
+		case err != nil:
+			check.Status = VerifyStatusError
+			check.Error = err
+		case actual != record.Checksum:
+			check.Status = VerifyStatusModified
+			check.Actual = actual
+		default:
+			check.Status = VerifyStatusOK
+			check.Actual = actual
+		}
+
+		if check.Status == VerifyStatusOK {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Files = append(report.Files, check)
+	}
+
+	btm.updateStatus(fmt.Sprintf("Re-verified %d files from %s: %d ok, %d failed",
+		len(report.Files), transferCode, report.Passed, report.Failed))
+	return report, nil
+}
+
+// saveReceipt records the checksum of every file written by a receive. Files
+// from earlier receives with the same code, such as a receive session, are
+// kept unless this receive wrote the same path.
+func (btm *BulletproofTransferManager) saveReceipt(transferCode string, received *receivedPayload, receivedAt time.Time) error {
+	receipt := transferReceipt{TransferCode: transferCode, ReceivedAt: receivedAt, SentAt: received.senderSentAt()}
+	for path, checksum := range received.Checksums {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to access received file %s: %w", path, err)
+		}
+		receipt.Files = append(receipt.Files, receiptRecord{Path: path, Size: info.Size(), Checksum: checksum})
+	}
+	if earlier, err := btm.loadReceipt(transferCode); err == nil {
+		for _, record := range earlier.Files {
+			if _, rewritten := received.Checksums[record.Path]; !rewritten {
+				receipt.Files = append(receipt.Files, record)
+			}
+		}
+	}
+	sort.Slice(receipt.Files, func(i, j int) bool {
+		return receipt.Files[i].Path < receipt.Files[j].Path
+	})
+
+	dir := filepath.Join(btm.targetDataDir, receiptsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create receipts directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode receipt: %w", err)
+	}
+	return writeFileAtomic(btm.receiptPath(transferCode), data, 0600)
+}
+
+// loadReceipt reads the receipt saved when transferCode was received
+func (btm *BulletproofTransferManager) loadReceipt(transferCode string) (*transferReceipt, error) {
+	data, err := os.ReadFile(btm.receiptPath(transferCode))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no record of receiving %s on this device", transferCode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+
+	var receipt transferReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt: %w", err)
+	}
+	return &receipt, nil
+}
+
+// receiptPath returns where the receipt for transferCode is kept
+func (btm *BulletproofTransferManager) receiptPath(transferCode string) string {
+	return filepath.Join(btm.targetDataDir, receiptsDir, btm.sanitizeFilename(transferCode)+".json")
+}
@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultConnectivityCacheTTL is how long a successful reachability probe is trusted
+const DefaultConnectivityCacheTTL = 30 * time.Second
+
+// ConnectivityCache remembers which endpoints were recently reachable so retries
+// don't re-dial them. Only successes are cached; a failed probe or a reported
+// connection failure removes the endpoint so the next check dials it again.
+type ConnectivityCache struct {
+	ttl       time.Duration
+	mutex     sync.Mutex
+	reachable map[string]time.Time // endpoint -> time it was confirmed reachable
+	dialer    HappyEyeballsDialer
+}
+
+// NewConnectivityCache creates a cache that trusts successful probes for ttl
+func NewConnectivityCache(ttl time.Duration) *ConnectivityCache {
+	if ttl <= 0 {
+		ttl = DefaultConnectivityCacheTTL
+	}
+	return &ConnectivityCache{
+		ttl:       ttl,
+		reachable: make(map[string]time.Time),
+	}
+}
+
+// Reachable reports whether a TCP connection to endpoint can be opened, using
+// the cached result when it is still fresh and dialing with timeout otherwise
+func (c *ConnectivityCache) Reachable(ctx context.Context, endpoint string, timeout time.Duration) bool {
+	return c.Confirm(ctx, endpoint, func(ctx context.Context) error {
+		conn, err := c.dialer.DialTimeout(ctx, endpoint, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}) == nil
+}
+
+// Confirm runs probe to check endpoint unless it was confirmed within the
+// TTL. A success is cached and a failure forgets the endpoint.
+func (c *ConnectivityCache) Confirm(ctx context.Context, endpoint string, probe func(ctx context.Context) error) error {
+	c.mutex.Lock()
+	confirmedAt, ok := c.reachable[endpoint]
+	c.mutex.Unlock()
+	if ok && time.Since(confirmedAt) < c.ttl {
+		return nil
+	}
+
+	if err := probe(ctx); err != nil {
+		c.Invalidate(endpoint)
+		return err
+	}
+
+	c.mutex.Lock()
+	c.reachable[endpoint] = time.Now()
+	c.mutex.Unlock()
+	return nil
+}
+
+// Invalidate forgets the cached result for endpoint
+func (c *ConnectivityCache) Invalidate(endpoint string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.reachable, endpoint)
+}
+
+// InvalidateAll forgets every cached result
+func (c *ConnectivityCache) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reachable = make(map[string]time.Time)
+}
@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/schollz/croc/v10/src/croc"
+)
+
+// IncompleteTransferError is returned when croc finishes without confirming
+// the transfer, whether or not it also reported an error
+type IncompleteTransferError struct {
+	Reason string // Which step of the croc exchange was not reached
+	Err    error  // croc's own error, if it returned one
+}
+
+// Error implements the error interface
+func (e *IncompleteTransferError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("croc transfer did not complete: %s: %v", e.Reason, e.Err)
+	}
+	return "croc transfer did not complete: " + e.Reason
+}
+
+// Unwrap returns croc's error
+func (e *IncompleteTransferError) Unwrap() error {
+	return e.Err
+}
+
+// IncompleteReason returns why a croc transfer stopped, if err came from one
+// that did not complete
+func IncompleteReason(err error) (string, bool) {
+	var incomplete *IncompleteTransferError
+	if errors.As(err, &incomplete) {
+		return incomplete.Reason, true
+	}
+	return "", false
+}
+
+// crocOutcome turns the result of client.Send or client.Receive into an
+// error. A nil error from croc only counts as success when croc also set
+// SuccessfulTransfer; otherwise the furthest step reached explains the failure.
+// It must only be called once the croc call has returned.
+func crocOutcome(client *croc.Client, err error) error {
+	if client.SuccessfulTransfer {
+		return nil
+	}
+	return &IncompleteTransferError{Reason: crocFailureStage(client), Err: err}
+}
+
+// crocFailureStage describes the first croc step that was not completed
+func crocFailureStage(client *croc.Client) string {
+	switch {
+	case !client.Step1ChannelSecured:
+		return "secure channel was never established (wrong code or the other side never connected)"
+	case !client.Step2FileInfoTransferred:
+		return "file information was never exchanged"
+	case !client.Step3RecipientRequestFile:
+		return "receiver never requested the file"
+	case !client.Step4FileTransferred:
+		return "file data stopped before the transfer finished"
+	default:
+		return "the other side never confirmed the transfer finished"
+	}
+}
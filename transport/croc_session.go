@@ -0,0 +1,387 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/schollz/croc/v10/src/comm"
+	"github.com/schollz/croc/v10/src/croc"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// crocDrainTimeout bounds how long a cancelled croc session is waited for
+// after its connections have been cut
+const crocDrainTimeout = 10 * time.Second
+
+// crocSession is one croc Send or Receive. The croc client has no way to be
+// cancelled, so its relay connections are dialed through crocRelayProxy and
+// cut when the session is cancelled; croc then sees EOF and returns.
+type crocSession struct {
+	mutex  sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+
+	peerJoined     chan struct{} // closed once the peer has secured the channel
+	peerJoinedOnce sync.Once
+}
+
+func newCrocSession() *crocSession {
+	return &crocSession{conns: make(map[net.Conn]struct{}), peerJoined: make(chan struct{})}
+}
+
+// markPeerJoined records that the peer has secured the channel
+func (s *crocSession) markPeerJoined() {
+	s.peerJoinedOnce.Do(func() { close(s.peerJoined) })
+}
+
+// track registers conn so Cancel can cut it, reporting false once cancelled
+func (s *crocSession) track(conn net.Conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+// untrack forgets conn
+func (s *crocSession) untrack(conn net.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, conn)
+}
+
+// Cancel cuts every connection the session has open
+func (s *crocSession) Cancel() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// crocProxy is a minimal in-process SOCKS5 proxy that croc is pointed at
+// through comm.Socks5Proxy. A proxied connection belongs to every session
+// live when it was opened, which is normally just the one that opened it.
+type crocProxy struct {
+	listener net.Listener
+
+	mutex    sync.Mutex
+	sessions map[*crocSession]struct{}
+}
+
+var (
+	crocRelayProxy     *crocProxy
+	crocRelayProxyOnce sync.Once
+)
+
+// startCrocProxy starts the proxy and points croc at it, once per process.
+// Without it sessions still run but can only be abandoned, not cut.
+func startCrocProxy() *crocProxy {
+	crocRelayProxyOnce.Do(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			logging.Warnf("CROC sessions cannot be cancelled: %v", err)
+			return
+		}
+		crocRelayProxy = &crocProxy{listener: listener, sessions: make(map[*crocSession]struct{})}
+		go crocRelayProxy.serve()
+		comm.Socks5Proxy = listener.Addr().String()
+	})
+	return crocRelayProxy
+}
+
+// begin registers a new live session
+func (p *crocProxy) begin() *crocSession {
+	session := newCrocSession()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.sessions[session] = struct{}{}
+	return session
+}
+
+// end forgets a finished session
+func (p *crocProxy) end(session *crocSession) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.sessions, session)
+}
+
+// liveSessions returns the sessions a new connection belongs to
+func (p *crocProxy) liveSessions() []*crocSession {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	sessions := make([]*crocSession, 0, len(p.sessions))
+	for session := range p.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// serve accepts proxy connections for the life of the process
+func (p *crocProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(conn)
+	}
+}
+
+// handle proxies one SOCKS5 CONNECT request
+func (p *crocProxy) handle(client net.Conn) {
+	defer client.Close()
+
+	sessions := p.liveSessions()
+	if !trackAll(sessions, client) {
+		return
+	}
+	defer untrackAll(sessions, client)
+
+	client.SetDeadline(time.Now().Add(10 * time.Second))
+	target, err := readSocks5Connect(client)
+	if err != nil {
+		logging.Debugf("CROC proxy rejected a connection: %v", err)
+		return
+	}
+
+	relay, err := DialTCP(context.Background(), target, 10*time.Second)
+	if err != nil {
+		// General failure; croc reports the dial error itself
+		client.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer relay.Close()
+	// Keep firewalls from dropping the relay connection while the peers wait
+	// on each other; a relay that stops answering fails the transfer promptly
+	EnableKeepalive(relay, relayKeepaliveInterval())
+	if !trackAll(sessions, relay) {
+		return
+	}
+	defer untrackAll(sessions, relay)
+
+	if _, err := client.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	client.SetDeadline(time.Time{})
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(relay, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		watcher := &peerWatcher{joined: func() {
+			for _, session := range sessions {
+				session.markPeerJoined()
+			}
+		}}
+		io.Copy(client, io.TeeReader(relay, watcher))
+		done <- struct{}{}
+	}()
+	// Either side ending ends the pair
+	<-done
+}
+
+// trackAll adds conn to every session, reporting false if one was cancelled
+func trackAll(sessions []*crocSession, conn net.Conn) bool {
+	for i, session := range sessions {
+		if !session.track(conn) {
+			untrackAll(sessions[:i], conn)
+			return false
+		}
+	}
+	return true
+}
+
+// untrackAll removes conn from every session
+func untrackAll(sessions []*crocSession, conn net.Conn) {
+	for _, session := range sessions {
+		session.untrack(conn)
+	}
+}
+
+// readSocks5Connect performs the no-auth SOCKS5 handshake and returns the
+// address the client asked to connect to
+func readSocks5Connect(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != 5 {
+		return "", fmt.Errorf("unsupported SOCKS version %d", header[0])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return "", err
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[1] != 1 {
+		return "", errors.New("only CONNECT is supported")
+	}
+
+	var host string
+	switch request[3] {
+	case 1, 4:
+		ip := make([]byte, net.IPv4len)
+		if request[3] == 4 {
+			ip = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", fmt.Errorf("unsupported address type %d", request[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// runCrocSession runs one croc Send or Receive, given as op. When ctx ends or
+// the transport is closed the session's relay connections are cut and op is
+// waited for, so no croc goroutine or connection outlives the call and a
+// later attempt cannot collide with it. croc dials relays on the local
+// network directly rather than through the proxy, so those sessions can only
+// be abandoned.
+func (t *SimpleCrocTransport) runCrocSession(ctx context.Context, options croc.Options, op func(*croc.Client) error) error {
+	session := newCrocSession()
+	if proxy := startCrocProxy(); proxy != nil {
+		session = proxy.begin()
+		defer proxy.end(session)
+	}
+	t.trackSession(session)
+	defer t.untrackSession(session)
+
+	client, err := croc.New(options)
+	if err != nil {
+		return fmt.Errorf("failed to create CROC client for relay %s: %w", options.RelayAddress, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op(client)
+	}()
+
+	stopWatching := make(chan struct{})
+	defer close(stopWatching)
+	go t.watchForPeer(session, options.IsSender, stopWatching)
+	if !options.IsSender {
+		go t.watchReceiveProgress(client, stopWatching)
+	}
+
+	role := "receive"
+	if options.IsSender {
+		role = "send"
+	}
+	traced := logging.TraceSpan("rendezvous", "croc %s session via %s", role, options.RelayAddress)
+	if logging.Tracing() {
+		go traceCrocSession(client, options.RelayAddress, stopWatching)
+	}
+
+	select {
+	case err := <-done:
+		if client.Step1ChannelSecured {
+			// croc has returned, so this is safe to read; it covers sessions
+			// over a local relay, which bypass the proxy
+			session.markPeerJoined()
+		}
+		err = droppedAfterPeerJoined(client.Step1ChannelSecured, crocOutcome(client, err))
+		traced(err)
+		if err == nil {
+			t.reportPath(client, options.RelayAddress)
+		}
+		return err
+
+	case <-ctx.Done():
+		session.Cancel()
+		select {
+		case <-done:
+		case <-time.After(crocDrainTimeout):
+			logging.Warnf("CROC session via %s did not stop within %v of being cancelled", options.RelayAddress, crocDrainTimeout)
+		}
+		traced(ctx.Err())
+		return fmt.Errorf("CROC session via relay %s stopped: %w", options.RelayAddress, ctx.Err())
+	}
+}
+
+// watchForPeer reports through the peer-connected handler once the croc
+// handshake with the other side completes, as seen by the relay proxy, or
+// once croc returns with the channel secured.
+func (t *SimpleCrocTransport) watchForPeer(session *crocSession, sending bool, stop <-chan struct{}) {
+	t.sessionMutex.Lock()
+	handler := t.peerConnected
+	t.sessionMutex.Unlock()
+	if handler == nil {
+		return
+	}
+
+	select {
+	case <-session.peerJoined:
+		handler(sending)
+	case <-stop:
+		// runCrocSession marks the peer before stopping the watch
+		select {
+		case <-session.peerJoined:
+			handler(sending)
+		default:
+		}
+	}
+}
+
+// trackSession records a live session so Close can cut it
+func (t *SimpleCrocTransport) trackSession(session *crocSession) {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	if t.sessions == nil {
+		t.sessions = make(map[*crocSession]struct{})
+	}
+	t.sessions[session] = struct{}{}
+}
+
+// untrackSession forgets a finished session
+func (t *SimpleCrocTransport) untrackSession(session *crocSession) {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	delete(t.sessions, session)
+}
+
+// abortSessions cuts every live session
+func (t *SimpleCrocTransport) abortSessions() {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	for session := range t.sessions {
+		session.Cancel()
+	}
+}
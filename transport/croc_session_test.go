@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/schollz/croc/v10/src/croc"
+)
+
+func TestCancelledCrocSendLeavesNoSession(t *testing.T) {
+	// A relay that takes croc's connection and never answers
+	relay, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer relay.Close()
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			conn, err := relay.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	source := filepath.Join(t.TempDir(), crocPayloadName)
+	if err := os.WriteFile(source, []byte("payload"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	filesInfo, emptyFolders, totalFolders, err := croc.GetFilesInfo([]string{source}, false, false, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := NewCrocTransport(60)
+	options := transport.options
+	options.IsSender = true
+	options.SharedSecret = "1234-cancelled-send-test"
+	// A host name rather than an IP, so croc dials through the relay proxy
+	_, port, _ := net.SplitHostPort(relay.Addr().String())
+	options.RelayAddress = net.JoinHostPort("localhost", port)
+	options.RelayPorts = []string{port}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opReturned := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- transport.runCrocSession(ctx, options, func(client *croc.Client) error {
+			defer close(opReturned)
+			return client.Send(filesInfo, emptyFolders, totalFolders)
+		})
+	}()
+
+	var conn net.Conn
+	select {
+	case conn = <-accepted:
+		defer conn.Close()
+	case err := <-done:
+		t.Fatalf("send ended before reaching the relay: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("croc never connected to the relay")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("cancelled send returned %v", err)
+		}
+	case <-time.After(crocDrainTimeout + 5*time.Second):
+		t.Fatal("cancelled send did not return")
+	}
+
+	select {
+	case <-opReturned:
+	default:
+		t.Fatal("croc was abandoned rather than stopped")
+	}
+	transport.sessionMutex.Lock()
+	live := len(transport.sessions)
+	transport.sessionMutex.Unlock()
+	if live != 0 {
+		t.Fatalf("%d croc sessions still tracked after cancel", live)
+	}
+	if proxy := startCrocProxy(); proxy != nil && len(proxy.liveSessions()) != 0 {
+		t.Fatalf("relay proxy still has %d live sessions", len(proxy.liveSessions()))
+	}
+
+	// The relay connection itself was closed, not left open
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Fatalf("relay connection still open after cancel: %v", err)
+	}
+}
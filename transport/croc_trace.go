@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"time"
+
+	"github.com/schollz/croc/v10/src/croc"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// crocTraceSteps names croc's session steps for the protocol trace, in order
+var crocTraceSteps = []struct {
+	name string
+	done func(*croc.Client) bool
+}{
+	{"handshake: secure channel established", func(c *croc.Client) bool { return c.Step1ChannelSecured }},
+	{"file info exchanged", func(c *croc.Client) bool { return c.Step2FileInfoTransferred }},
+	{"recipient requested the file", func(c *croc.Client) bool { return c.Step3RecipientRequestFile }},
+	{"file transferred", func(c *croc.Client) bool { return c.Step4FileTransferred }},
+	{"closing channels", func(c *croc.Client) bool { return c.Step5CloseChannels }},
+}
+
+// traceCrocSession adds croc's progress through a session to the protocol
+// trace: each step as it completes and the chunk count as it grows. Like
+// watchForPeer it polls, as croc has no callbacks, until stop is closed.
+func traceCrocSession(client *croc.Client, relay string, stop <-chan struct{}) {
+	ticker := time.NewTicker(peerPollInterval)
+	defer ticker.Stop()
+
+	step, chunks := 0, 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		for step < len(crocTraceSteps) && crocTraceSteps[step].done(client) {
+			logging.Tracef("croc", "%s via %s", crocTraceSteps[step].name, relay)
+			step++
+		}
+		if n := client.TotalChunksTransferred; n != chunks {
+			chunks = n
+			logging.Tracef("chunk", "%d chunks acknowledged via %s", chunks, relay)
+		}
+	}
+}
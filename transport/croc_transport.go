@@ -2,13 +2,19 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/schollz/croc/v10/src/croc"
+
+	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
 )
 
 // SimpleCrocTransport implements the Transport interface using the croc library
@@ -16,30 +22,37 @@ type SimpleCrocTransport struct {
 	priority int
 	config   TransportConfig
 	options  croc.Options
+
+	sessionMutex    sync.Mutex
+	sessions        map[*crocSession]struct{}   // live croc sessions, cut by Close
+	peerConnected   func(sending bool)          // called once per session when the peer joins
+	receiveProgress func(received, total int64) // called as a received payload arrives
+	pathChosen      func(path string)           // called with the path each successful session took
+
+	relayPortMutex   sync.Mutex
+	relayPortWinners map[string]string  // relay host -> port its last session succeeded through
+	connectivity     *ConnectivityCache // relay ports that recently answered, shared across retries
 }
 
 // Setup configures the croc transport
 func (t *SimpleCrocTransport) Setup(config TransportConfig) error {
 	t.config = config
+	if t.connectivity == nil {
+		t.connectivity = NewConnectivityCache(DefaultConnectivityCacheTTL)
+	}
 
 	// Configure for international lab-to-lab transfers with corporate firewall compatibility
 	t.options = croc.Options{
 		RelayAddress:  "croc.schollz.com", // Only use the working relay server
 		RelayAddress6: "",                 // Disable IPv6 for corporate compatibility
 
-		// CORPORATE FIREWALL-COMPATIBLE port progression
-		RelayPorts: []string{
-			"443",                  // HTTPS - highest success rate in corporate networks
-			"80",                   // HTTP - second highest success rate
-			"8080",                 // Alternative HTTP - common corporate allowlist
-			"8443",                 // Alternative HTTPS - backup option
-			"9009", "9010", "9011", // CROC standard ports
-		},
+		// Replaced per attempt by the ports that answer, fastest first
+		RelayPorts: crocRelayPorts,
 
-		RelayPassword:  "pass123",
+		RelayPassword:  config.relayPassword(),
 		NoPrompt:       true,
 		NoMultiplexing: false, // Allow multiplexing for better performance
-		DisableLocal:   true,  // FORCE relay usage for international transfers
+		DisableLocal:   true,  // Sends offer a direct path per attempt, see offerDirectPath
 		Ask:            false,
 		Debug:          false,
 		Overwrite:      true,
@@ -47,24 +60,35 @@ func (t *SimpleCrocTransport) Setup(config TransportConfig) error {
 		HashAlgorithm:  "xxhash",
 	}
 
-	fmt.Printf("International CROC transport setup completed\n")
+	logging.Debugf("International CROC transport setup completed")
 	return nil
 }
 
 // Send transmits data using the croc protocol with international relay optimization
 func (t *SimpleCrocTransport) Send(data []byte, metadata TransferMetadata) error {
-	// Create temporary file for sending
-	tempFile, err := os.CreateTemp("", "croc_send_*.tmp")
+	return t.SendContext(context.Background(), data, metadata)
+}
+
+// SendContext is Send with cancellation taken from ctx. Cancelling stops the
+// croc session rather than abandoning it.
+func (t *SimpleCrocTransport) SendContext(parent context.Context, data []byte, metadata TransferMetadata) error {
+	// Create temporary file for sending, under the same name on every attempt
+	// so a receiver resuming after a dropped connection recognizes it
+	sendDir, err := os.MkdirTemp(t.config.TempDir, "croc_send_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(sendDir)
+
+	tempFile, err := os.OpenFile(filepath.Join(sendDir, crocPayloadName), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
-	defer os.Remove(tempFile.Name())
 	defer tempFile.Close()
 
-	// Check file size limit (100MB for production stability)
-	maxSize := int64(100 * 1024 * 1024) // 100MB limit
-	if int64(len(data)) > maxSize {
-		return fmt.Errorf("file too large for CROC transport (%d bytes, max %d)", len(data), maxSize)
+	// Check the payload size limit set through SetMaxPayloadSize
+	if err := t.config.checkPayloadSize(int64(len(data)), "CROC"); err != nil {
+		return err
 	}
 
 	// Write data to temp file
@@ -73,9 +97,13 @@ func (t *SimpleCrocTransport) Send(data []byte, metadata TransferMetadata) error
 	}
 	tempFile.Close()
 
+	if err := ensureTempFileIntact(tempFile.Name(), int64(len(data))); err != nil {
+		return err
+	}
+
 	// Create coordination file to signal readiness
 	if err := t.createCoordinationFile(metadata.TransferID); err != nil {
-		fmt.Printf("Warning: Could not create CROC coordination file: %v\n", err)
+		logging.Warnf("Could not create CROC coordination file: %v", err)
 	}
 
 	// Configure CROC client for sending
@@ -90,65 +118,73 @@ func (t *SimpleCrocTransport) Send(data []byte, metadata TransferMetadata) error
 	}{
 		{
 			name:    "Primary Global Relays",
-			servers: []string{"croc.schollz.com"}, // Only use working relay
-			timeout: 30 * time.Second,             // Increased timeout for international
+			servers: []string{"croc.schollz.com"},            // Only use working relay
+			timeout: t.config.scaleTimeout(30 * time.Second), // Increased timeout for international
 		},
 	}
 
 	var lastError error
 	for groupIndex, group := range relayGroups {
-		fmt.Printf("🌍 Trying %s (Group %d/%d)\n", group.name, groupIndex+1, len(relayGroups))
+		logging.Debugf("Trying %s (Group %d/%d)", group.name, groupIndex+1, len(relayGroups))
 
 		for relayIndex, relayServer := range group.servers {
-			fmt.Printf("🔄 Attempting relay %d/%d: %s\n", relayIndex+1, len(group.servers), relayServer)
+			logging.Debugf("Attempting relay %d/%d: %s", relayIndex+1, len(group.servers), relayServer)
 
-			// Update relay configuration
-			t.options.RelayAddress = relayServer
+			// Create CROC session with timeout context
+			ctx, cancel := context.WithTimeout(parent, group.timeout)
 
-			// Create CROC client with timeout context
-			ctx, cancel := context.WithTimeout(context.Background(), group.timeout)
-			defer cancel()
-
-			client, err := croc.New(t.options)
+			// Find the relay ports that answer so croc connects straight to
+			// the fastest instead of the default port
+			ports, err := t.relayPorts(ctx, relayServer)
 			if err != nil {
-				lastError = fmt.Errorf("failed to create CROC client for relay %s: %w", relayServer, err)
-				continue
-			}
-
-			// Test relay connectivity first
-			if !t.testRelayConnectivity(ctx, relayServer, t.options.RelayPorts[0]) {
-				lastError = fmt.Errorf("relay %s connectivity test failed", relayServer)
+				cancel()
+				if parent.Err() != nil {
+					return parent.Err()
+				}
+				lastError = failoverCause(lastError, fmt.Errorf("relay %s connectivity test failed: %w", relayServer, err))
 				continue
 			}
+			options := t.options
+			options.RelayAddress = net.JoinHostPort(relayServer, ports[0])
+			options.RelayPorts = ports
+			t.config.offerDirectPath(&options)
 
 			// Get file info and attempt send with timeout
 			filesInfo, emptyFolders, totalFolders, err := croc.GetFilesInfo([]string{tempFile.Name()}, false, false, []string{})
 			if err != nil {
+				cancel()
 				lastError = fmt.Errorf("failed to get file info for relay %s: %w", relayServer, err)
 				continue
 			}
 
-			fmt.Printf("🚀 Initiating international CROC send via %s (timeout: %v)...\n", relayServer, group.timeout)
-
-			// Send with context timeout
-			sendErr := make(chan error, 1)
-			go func() {
-				sendErr <- client.Send(filesInfo, emptyFolders, totalFolders)
-			}()
+			logging.Debugf("Initiating international CROC send via %s (timeout: %v)...", options.RelayAddress, group.timeout)
 
-			select {
-			case err = <-sendErr:
-				if err == nil {
-					fmt.Printf("✅ International CROC transfer successful via %s! Transfer code: %s\n", relayServer, metadata.TransferID)
-					return nil
-				}
-				lastError = err
-
-			case <-ctx.Done():
-				lastError = fmt.Errorf("timeout sending via relay %s after %v", relayServer, group.timeout)
+			err = t.runCrocSession(ctx, options, func(client *croc.Client) error {
+				return client.Send(filesInfo, emptyFolders, totalFolders)
+			})
+			timedOut := ctx.Err() != nil
+			cancel()
+			if err == nil {
+				t.rememberRelayPort(relayServer, ports[0])
+				logging.Debugf("International CROC transfer successful via %s! Transfer code: %s", options.RelayAddress, metadata.TransferID)
+				return nil
 			}
+			if parent.Err() != nil {
+				return err
+			}
+			if errors.Is(err, ErrConnectionDropped) {
+				// The receiver is waiting on this relay for the reconnect
+				return err
+			}
+			err = relayRejected(relayServer, err)
+			if timedOut {
+				err = fmt.Errorf("timeout sending via relay %s after %v: %w", relayServer, group.timeout, err)
+			}
+			lastError = failoverCause(lastError, err)
 
-			fmt.Printf("❌ Relay %s failed: %v\n", relayServer, lastError)
+			// Probe every port again next time rather than trust one that just failed
+			t.forgetRelayPort(relayServer)
+			logging.Debugf("Relay %s failed: %v", relayServer, lastError)
 		}
 	}
 
@@ -158,51 +194,52 @@ func (t *SimpleCrocTransport) Send(data []byte, metadata TransferMetadata) error
 
 // createCoordinationFile creates a file to signal CROC sender readiness
 func (t *SimpleCrocTransport) createCoordinationFile(transferID string) error {
-	homeDir, err := os.UserHomeDir()
+	coordDir, err := internal.EnsureStateDir()
 	if err != nil {
 		return err
 	}
 
-	coordDir := filepath.Join(homeDir, ".trustdrop")
-	if err := os.MkdirAll(coordDir, 0755); err != nil {
-		return err
-	}
-
 	// Create CROC coordination info
 	coordInfo := fmt.Sprintf("croc_ready\ntransfer_id:%s\nrelay:croc.schollz.com\n", transferID)
 
 	coordFile := filepath.Join(coordDir, fmt.Sprintf("croc_%s.coord", transferID))
-	return os.WriteFile(coordFile, []byte(coordInfo), 0644)
+	return os.WriteFile(coordFile, []byte(coordInfo), 0600)
 }
 
-// testRelayConnectivity tests if relay is reachable before attempting transfer
-func (t *SimpleCrocTransport) testRelayConnectivity(ctx context.Context, relayServer, port string) bool {
-	testTimeout := 5 * time.Second
-	testCtx, cancel := context.WithTimeout(ctx, testTimeout)
-	defer cancel()
-
-	dialer := &net.Dialer{}
-	conn, err := dialer.DialContext(testCtx, "tcp", net.JoinHostPort(relayServer, port))
+// Receive gets data using the croc protocol
+func (t *SimpleCrocTransport) Receive(metadata TransferMetadata) ([]byte, error) {
+	stream, err := t.ReceiveStream(metadata)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	conn.Close()
-	return true
+	return readAllAndClose(stream)
 }
 
-// Receive gets data using the croc protocol
-func (t *SimpleCrocTransport) Receive(metadata TransferMetadata) ([]byte, error) {
+// ReceiveStream gets data using the croc protocol and returns it as a stream
+// over the received temp file, which is removed when the stream is closed
+func (t *SimpleCrocTransport) ReceiveStream(metadata TransferMetadata) (io.ReadCloser, error) {
+	return t.ReceiveStreamContext(context.Background(), metadata)
+}
+
+// ReceiveStreamContext is ReceiveStream with cancellation taken from ctx.
+// Cancelling stops the croc session before its temp directory is removed.
+func (t *SimpleCrocTransport) ReceiveStreamContext(parent context.Context, metadata TransferMetadata) (io.ReadCloser, error) {
 	// Wait for sender coordination file (CROC sender ready signal)
 	if err := t.waitForSenderReady(metadata.TransferID, 45*time.Second); err != nil {
-		fmt.Printf("⏰ CROC sender not ready yet, proceeding anyway: %v\n", err)
+		logging.Debugf("CROC sender not ready yet, proceeding anyway: %v", err)
 	}
 
-	// Create temporary directory for receiving
-	tempDir, err := os.MkdirTemp("", "croc_receive_")
+	// Create the receive directory; a resumed receive keeps what it holds
+	tempDir, err := prepareCrocReceiveDir(t.config.TempDir, metadata.TransferID, metadata.Resume)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
+	keepTempDir := false
+	defer func() {
+		if !keepTempDir {
+			os.RemoveAll(tempDir)
+		}
+	}()
 
 	// Change to temp directory for receiving
 	oldDir, err := os.Getwd()
@@ -222,29 +259,30 @@ func (t *SimpleCrocTransport) Receive(metadata TransferMetadata) ([]byte, error)
 
 	var lastError error
 	for i, relayServer := range relayServers {
-		fmt.Printf("🔄 Attempting CROC receive from relay %d/%d: %s\n", i+1, len(relayServers), relayServer)
+		logging.Debugf("Attempting CROC receive from relay %d/%d: %s", i+1, len(relayServers), relayServer)
+
+		ports, err := t.relayPorts(parent, relayServer)
+		if err != nil {
+			if parent.Err() != nil {
+				return nil, parent.Err()
+			}
+			lastError = failoverCause(lastError, fmt.Errorf("relay %s connectivity test failed: %w", relayServer, err))
+			continue
+		}
 
 		options := croc.Options{
 			IsSender:     false,
 			SharedSecret: metadata.TransferID,
 
-			// LAB-OPTIMIZED RELAY CONFIGURATION with multiple fallbacks
-			RelayAddress:  relayServer,
+			// Connect on the fastest port that answered
+			RelayAddress:  net.JoinHostPort(relayServer, ports[0]),
 			RelayAddress6: "", // Disable IPv6 for corporate compatibility
+			RelayPorts:    ports,
 
-			// CORPORATE FIREWALL-COMPATIBLE port progression
-			RelayPorts: []string{
-				"443",                  // HTTPS - highest success rate in corporate networks
-				"80",                   // HTTP - second highest success rate
-				"8080",                 // Alternative HTTP - common corporate allowlist
-				"8443",                 // Alternative HTTPS - backup option
-				"9009", "9010", "9011", // CROC standard ports
-			},
-
-			RelayPassword:  "pass123",
+			RelayPassword:  t.config.relayPassword(),
 			NoPrompt:       true,
-			NoMultiplexing: false, // Allow multiplexing for better performance
-			DisableLocal:   true,  // FORCE relay usage for international transfers
+			NoMultiplexing: false,                     // Allow multiplexing for better performance
+			DisableLocal:   !t.config.directAllowed(), // Look for the sender on the local network first
 			Ask:            false,
 			Debug:          false,
 			Overwrite:      true,
@@ -252,34 +290,38 @@ func (t *SimpleCrocTransport) Receive(metadata TransferMetadata) ([]byte, error)
 			HashAlgorithm:  "xxhash",
 		}
 
-		client, err := croc.New(options)
-		if err != nil {
-			lastError = fmt.Errorf("failed to create CROC client for relay %s: %w", relayServer, err)
-			continue
+		logging.Debugf("Connecting to lab relay server: %s...", relayServer)
+
+		// Extended timeout for international; only croc's own confirmation
+		// counts, not files appearing on disk
+		timeout := t.config.scaleTimeout(60 * time.Second)
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		err = t.runCrocSession(ctx, options, func(client *croc.Client) error {
+			return client.Receive()
+		})
+		timedOut := ctx.Err() != nil
+		cancel()
+		if err == nil {
+			t.rememberRelayPort(relayServer, ports[0])
+			logging.Debugf("CROC lab receive successful from %s! Got file data", options.RelayAddress)
+			lastError = nil
+			break
 		}
-
-		fmt.Printf("📡 Connecting to lab relay server: %s...\n", relayServer)
-
-		// Add timeout for receive operation
-		receiveErr := make(chan error, 1)
-		go func() {
-			receiveErr <- client.Receive()
-		}()
-
-		// Wait for receive with timeout
-		select {
-		case err = <-receiveErr:
-			if err == nil {
-				fmt.Printf("✅ CROC lab receive successful from %s! Got file data\n", relayServer)
-				break
-			}
-			fmt.Printf("❌ Relay %s failed: %v\n", relayServer, err)
-			lastError = err
-
-		case <-time.After(60 * time.Second): // Extended timeout for international
-			lastError = fmt.Errorf("timeout receiving from relay %s after 60s", relayServer)
-			fmt.Printf("❌ Relay %s timed out after 60s\n", relayServer)
+		if parent.Err() != nil {
+			return nil, err
+		}
+		if errors.Is(err, ErrConnectionDropped) {
+			// Keep the partial data for the reconnect to resume from
+			keepTempDir = true
+			return nil, err
+		}
+		t.forgetRelayPort(relayServer)
+		err = relayRejected(relayServer, err)
+		if timedOut {
+			err = fmt.Errorf("timeout receiving from relay %s after %v: %w", relayServer, timeout, err)
 		}
+		lastError = failoverCause(lastError, err)
+		logging.Debugf("Relay %s failed: %v", relayServer, lastError)
 	}
 
 	if lastError != nil {
@@ -304,32 +346,34 @@ func (t *SimpleCrocTransport) Receive(metadata TransferMetadata) ([]byte, error)
 	}
 
 	if receivedFile == "" {
-		return nil, fmt.Errorf("no file received via CROC international transfer")
+		// croc reported success, so the file was written and then removed
+		return nil, fmt.Errorf("no file received via CROC international transfer: %w", ErrTempFileVanished)
 	}
 
-	// Read the received file
-	data, err := os.ReadFile(receivedFile)
+	info, err := os.Stat(receivedFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read received file: %w", err)
+		return nil, fmt.Errorf("failed to stat received file: %w", err)
 	}
 
-	fmt.Printf("✅ CROC international receive successful! Got %d bytes\n", len(data))
-	return data, nil
+	// Hand the file back as a stream; the temp dir goes away on Close
+	keepTempDir = true
+	stream, err := newTempFileReader(receivedFile, tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Debugf("CROC international receive successful! Got %d bytes", info.Size())
+	return stream, nil
 }
 
 // waitForSenderReady waits for the CROC coordination file to appear
 func (t *SimpleCrocTransport) waitForSenderReady(transferID string, timeout time.Duration) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-
-	coordFile := filepath.Join(homeDir, ".trustdrop", fmt.Sprintf("croc_%s.coord", transferID))
+	coordFile := filepath.Join(internal.StateDir(), fmt.Sprintf("croc_%s.coord", transferID))
 
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		if _, err := os.Stat(coordFile); err == nil {
-			fmt.Printf("📡 CROC sender ready signal detected\n")
+			logging.Debugf("CROC sender ready signal detected")
 			return nil
 		}
 		time.Sleep(2 * time.Second) // Check every 2 seconds
@@ -341,7 +385,7 @@ func (t *SimpleCrocTransport) waitForSenderReady(transferID string, timeout time
 // IsAvailable checks if the croc transport is available
 func (t *SimpleCrocTransport) IsAvailable(ctx context.Context) bool {
 	// Simple CROC transport is always available if properly configured
-	fmt.Printf("Simple CROC transport reporting as available\n")
+	logging.Debugf("Simple CROC transport reporting as available")
 	return true
 }
 
@@ -355,8 +399,37 @@ func (t *SimpleCrocTransport) GetName() string {
 	return "simple-croc"
 }
 
-// Close cleans up the transport
+// setTempDir changes where the transport writes temp files
+func (t *SimpleCrocTransport) setTempDir(dir string) {
+	t.config.TempDir = dir
+}
+
+// setTimeoutMultiplier stretches the relay probe and session timeouts
+func (t *SimpleCrocTransport) setTimeoutMultiplier(multiplier float64) {
+	t.config.TimeoutMultiplier = multiplier
+}
+
+// setMaxPayloadSize changes the largest payload the transport sends
+func (t *SimpleCrocTransport) setMaxPayloadSize(size int64) {
+	t.config.MaxPayloadSize = size
+}
+
+// setRelayPassword changes the password used to authenticate to relays
+func (t *SimpleCrocTransport) setRelayPassword(password string) {
+	t.config.RelayPassword = password
+	t.options.RelayPassword = t.config.relayPassword()
+}
+
+// setPeerConnectedHandler sets the handler told when the peer joins a session
+func (t *SimpleCrocTransport) setPeerConnectedHandler(handler func(sending bool)) {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	t.peerConnected = handler
+}
+
+// Close stops any croc sessions still running on the transport
 func (t *SimpleCrocTransport) Close() error {
+	t.abortSessions()
 	return nil
 }
 
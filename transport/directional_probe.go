@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"github.com/schollz/croc/v10/src/tcp"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// RestrictionAsymmetric is the NetworkRestriction type reported when the
+// network allows only one side of a relay rendezvous
+const RestrictionAsymmetric = "asymmetric_connectivity"
+
+// DirectionalConnectivity reports which side of a relay rendezvous works from
+// this network. A sender only has to open a room on the relay; a receiver has
+// to join that room and get data piped to it, which some networks block while
+// still allowing the outbound connection.
+type DirectionalConnectivity struct {
+	Probed     bool `json:"probed"`      // Whether the probe reached a relay at all
+	CanSend    bool `json:"can_send"`    // Opening a room as the sender worked
+	CanReceive bool `json:"can_receive"` // Joining a room and receiving data worked
+}
+
+// Asymmetric reports whether exactly one direction works
+func (d DirectionalConnectivity) Asymmetric() bool {
+	return d.Probed && d.CanSend != d.CanReceive
+}
+
+// Impaired reports whether the probe found direction ("send" or "receive")
+// blocked while the other direction works
+func (d DirectionalConnectivity) Impaired(direction string) bool {
+	if !d.Asymmetric() {
+		return false
+	}
+	switch direction {
+	case "send":
+		return !d.CanSend
+	case "receive":
+		return !d.CanReceive
+	default:
+		return false
+	}
+}
+
+// directionalProbeTimeout bounds each step of the rendezvous probe
+const directionalProbeTimeout = 10 * time.Second
+
+// detectDirectionalConnectivity probes both sides of a rendezvous on the croc
+// relay and records one-way networks
+func (mtm *MultiTransportManager) detectDirectionalConnectivity(profile *NetworkProfile) {
+	profile.Directional = ProbeDirectionalConnectivity("croc.schollz.com", []string{"9009", "443"}, mtm.config.relayPassword())
+	if profile.Directional.Asymmetric() {
+		mtm.setDetection(RestrictionAsymmetric)
+	}
+
+	logging.Debugf("Directional connectivity: probed=%t send=%t receive=%t",
+		profile.Directional.Probed, profile.Directional.CanSend, profile.Directional.CanReceive)
+}
+
+// ProbeDirectionalConnectivity opens a throwaway room on relayHost as a
+// sender, then joins it as a receiver and checks that a payload written by the
+// sender arrives. Ports are tried in order until one accepts the sender. If
+// none does, the result is not Probed: a network that can't reach the relay
+// at all isn't one-way.
+func ProbeDirectionalConnectivity(relayHost string, ports []string, password string) DirectionalConnectivity {
+	var result DirectionalConnectivity
+
+	roomBytes := make([]byte, 16)
+	if _, err := rand.Read(roomBytes); err != nil {
+		return result
+	}
+	room := "trustdrop-probe-" + hex.EncodeToString(roomBytes)
+
+	for _, port := range ports {
+		address := net.JoinHostPort(relayHost, port)
+
+		sender, _, _, err := tcp.ConnectToTCPServer(address, password, room, directionalProbeTimeout)
+		if err != nil {
+			logging.Debugf("Directional probe: sender rendezvous on %s failed: %v", address, err)
+			continue
+		}
+		defer sender.Close()
+
+		result.Probed = true
+		result.CanSend = true
+		result.CanReceive = probeReceive(address, password, room, sender.Send)
+		return result
+	}
+
+	return result
+}
+
+// probeReceive joins room as the receiver, has the sender write a payload via
+// send and reports whether it arrives intact
+func probeReceive(address, password, room string, send func([]byte) error) bool {
+	receiver, _, _, err := tcp.ConnectToTCPServer(address, password, room, directionalProbeTimeout)
+	if err != nil {
+		logging.Debugf("Directional probe: receiver rendezvous on %s failed: %v", address, err)
+		return false
+	}
+	defer receiver.Close()
+
+	payload := make([]byte, 1024)
+	if _, err := rand.Read(payload); err != nil {
+		return false
+	}
+	if err := send(payload); err != nil {
+		return false
+	}
+
+	receiver.Connection().SetReadDeadline(time.Now().Add(directionalProbeTimeout))
+	data, err := receiver.Receive()
+	if err != nil {
+		logging.Debugf("Directional probe: receiver got no data on %s: %v", address, err)
+		return false
+	}
+	return bytes.Equal(data, payload)
+}
@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// RestrictionEndpointSecurity is the NetworkRestriction type reported when
+// antivirus or endpoint protection software appears to interfere with transfers
+const RestrictionEndpointSecurity = "endpoint_security"
+
+// ErrTempFileVanished is returned when a temp file the app just wrote is gone
+// or changed before it could be used, which is how on-access scanners that
+// quarantine files usually show up
+var ErrTempFileVanished = errors.New("temporary file was removed or modified by another program")
+
+// endpointSecurityKeywords appear in errors raised when security software
+// blocks a file or program rather than the network
+var endpointSecurityKeywords = []string{
+	"contains a virus",
+	"potentially unwanted software",
+	"quarantine",
+	"blocked by group policy",
+	"blocked by your administrator",
+	"operation did not complete successfully because the file",
+}
+
+// IsEndpointSecurityError reports whether err looks like antivirus or endpoint
+// protection interference
+func IsEndpointSecurityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTempFileVanished) {
+		return true
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	for _, keyword := range endpointSecurityKeywords {
+		if strings.Contains(errorStr, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureTempFileIntact checks that a temp file written by the app is still
+// present with the expected size
+func ensureTempFileIntact(path string, size int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrTempFileVanished, path, err)
+	}
+	if info.Size() != size {
+		return fmt.Errorf("%w: %s is %d bytes, expected %d", ErrTempFileVanished, path, info.Size(), size)
+	}
+	return nil
+}
+
+// detectEndpointSecurity looks for signs of antivirus or endpoint protection
+// interfering with temp files or relay connections. It is best effort: a
+// negative result doesn't rule out interference.
+func (mtm *MultiTransportManager) detectEndpointSecurity(profile *NetworkProfile) {
+	if tempWriteVanishes(mtm.config.TempDir) || selectiveConnectionResets("croc.schollz.com") {
+		profile.EndpointSecurity = true
+		mtm.setDetection(RestrictionEndpointSecurity)
+	}
+}
+
+// tempWriteVanishes writes a probe file shaped like a transfer temp file and
+// reports whether it disappears or changes straight away
+func tempWriteVanishes(dir string) bool {
+	probe := make([]byte, 4096)
+	if _, err := rand.Read(probe); err != nil {
+		return false
+	}
+
+	file, err := os.CreateTemp(dir, "croc_send_*.tmp")
+	if err != nil {
+		return false // Can't write at all; that's reported elsewhere
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	_, writeErr := file.Write(probe)
+	closeErr := file.Close()
+	if writeErr != nil || closeErr != nil {
+		return IsEndpointSecurityError(writeErr) || IsEndpointSecurityError(closeErr)
+	}
+
+	// On-access scanners act on close; give them a moment
+	time.Sleep(500 * time.Millisecond)
+	return ensureTempFileIntact(path, int64(len(probe))) != nil
+}
+
+// selectiveConnectionResets reports whether connections to the relay ports on
+// host are reset while a connection to 443 on the same host stays open. A
+// network firewall drops or refuses; a reset right after connecting on only
+// some ports usually comes from local security software.
+func selectiveConnectionResets(host string) bool {
+	if !connectionHolds(net.JoinHostPort(host, "443")) {
+		return false
+	}
+
+	for _, port := range []string{"9009", "9010"} {
+		if connectionReset(net.JoinHostPort(host, port)) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionHolds reports whether endpoint accepts a connection that is not
+// reset straight away
+func connectionHolds(endpoint string) bool {
+	conn, err := net.DialTimeout("tcp", endpoint, 5*time.Second)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	return !readReset(conn)
+}
+
+// connectionReset reports whether a connection to endpoint is reset while
+// connecting or right after
+func connectionReset(endpoint string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return isConnectionReset(err)
+	}
+	defer conn.Close()
+	return readReset(conn)
+}
+
+// readReset waits briefly for data and reports whether the peer reset the connection
+func readReset(conn net.Conn) bool {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := conn.Read(make([]byte, 1))
+	return isConnectionReset(err)
+}
+
+// isConnectionReset matches reset errors on Unix and Windows
+func isConnectionReset(err error) bool {
+	if err == nil {
+		return false
+	}
+	errorStr := strings.ToLower(err.Error())
+	return strings.Contains(errorStr, "connection reset") ||
+		strings.Contains(errorStr, "forcibly closed")
+}
@@ -0,0 +1,231 @@
+package transport
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Frame types on a framed connection. Bulk data is split into chunks so a
+// control frame queued during a large transfer goes out before the next
+// chunk instead of after the whole payload.
+const (
+	frameData    byte = 1
+	frameControl byte = 2
+	frameEnd     byte = 3
+)
+
+const (
+	frameHeaderSize     = 5         // type byte and big-endian uint32 length
+	frameDataChunkSize  = 64 * 1024 // largest data frame
+	maxControlFrameSize = 64 * 1024 // largest control frame
+	controlQueueSize    = 32        // control messages waiting for the next chunk boundary
+)
+
+// Control message types
+const (
+	ControlMetadata = "metadata" // Sent first as the handshake; Total is the payload size
+	ControlAccept   = "accept"   // The receiver's answer to the handshake; data follows
+	ControlReject   = "reject"   // The receiver's refusal; Text says why
+	ControlProgress = "progress" // Sent as data goes out; Sent of Total bytes
+)
+
+// ControlMessage is a small message delivered ahead of queued bulk data
+type ControlMessage struct {
+	Type  string `json:"type"` // One of the Control* types
+	Sent  int64  `json:"sent,omitempty"`
+	Total int64  `json:"total,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+// errControlQueueFull is returned when control messages arrive faster than
+// chunk boundaries let them out
+var errControlQueueFull = errors.New("control message queue is full")
+
+// frameWriter writes a framed stream. Data goes out in chunks and every
+// queued control message is written before the next chunk.
+type frameWriter struct {
+	w       io.Writer
+	mutex   sync.Mutex // held while a frame is written
+	control chan []byte
+}
+
+func newFrameWriter(w io.Writer) *frameWriter {
+	return &frameWriter{w: w, control: make(chan []byte, controlQueueSize)}
+}
+
+// SendControl queues msg ahead of any data still to be written. When no data
+// is being written it goes out straight away.
+func (fw *frameWriter) SendControl(msg ControlMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode control message: %w", err)
+	}
+	if len(payload) > maxControlFrameSize {
+		return fmt.Errorf("control message too large (%d bytes)", len(payload))
+	}
+
+	select {
+	case fw.control <- payload:
+	default:
+		return errControlQueueFull
+	}
+
+	// A data write in progress flushes it at its next chunk
+	if fw.mutex.TryLock() {
+		defer fw.mutex.Unlock()
+		return fw.flushControl()
+	}
+	return nil
+}
+
+// WriteData writes data as a series of data frames, calling onChunk with the
+// bytes written so far after each one
+func (fw *frameWriter) WriteData(data []byte, onChunk func(sent int64)) error {
+	for sent := 0; sent < len(data); {
+		chunk := data[sent:min(sent+frameDataChunkSize, len(data))]
+		if err := fw.writeChunk(chunk); err != nil {
+			return err
+		}
+		sent += len(chunk)
+		if onChunk != nil {
+			onChunk(int64(sent))
+		}
+	}
+	return nil
+}
+
+// End writes any queued control messages and the end-of-stream frame
+func (fw *frameWriter) End() error {
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	if err := fw.flushControl(); err != nil {
+		return err
+	}
+	return writeFrame(fw.w, frameEnd, nil)
+}
+
+// writeChunk writes queued control messages and then one data frame
+func (fw *frameWriter) writeChunk(chunk []byte) error {
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	if err := fw.flushControl(); err != nil {
+		return err
+	}
+	return writeFrame(fw.w, frameData, chunk)
+}
+
+// flushControl writes every queued control message. The caller holds mutex.
+func (fw *frameWriter) flushControl() error {
+	for {
+		select {
+		case payload := <-fw.control:
+			if err := writeFrame(fw.w, frameControl, payload); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// writeFrame writes one frame header and its payload
+func writeFrame(w io.Writer, frameType byte, payload []byte) error {
+	header := make([]byte, frameHeaderSize)
+	header[0] = frameType
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if err := writeFull(w, header); err != nil {
+		return err
+	}
+	return writeFull(w, payload)
+}
+
+// writeControl writes msg as a single control frame, for the receiver's
+// answer on the otherwise unused return direction
+func writeControl(w io.Writer, msg ControlMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode control message: %w", err)
+	}
+	return writeFrame(w, frameControl, payload)
+}
+
+// readControl reads a single control frame
+func readControl(r io.Reader) (ControlMessage, error) {
+	var msg ControlMessage
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return msg, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[1:]))
+	if header[0] != frameControl {
+		return msg, fmt.Errorf("expected a control frame, got frame type %d", header[0])
+	}
+	if length > maxControlFrameSize {
+		return msg, fmt.Errorf("control frame of %d bytes exceeds the %d byte limit", length, maxControlFrameSize)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return msg, fmt.Errorf("failed to read control frame: %w", err)
+	}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return msg, fmt.Errorf("invalid control frame: %w", err)
+	}
+	return msg, nil
+}
+
+// readFrames reads a framed stream up to its end frame, copying data frames
+// to dst and passing control messages to onControl as they arrive; an error
+// from onControl stops the read. It returns the number of data bytes copied.
+func readFrames(r io.Reader, dst io.Writer, onControl func(ControlMessage) error) (int64, error) {
+	header := make([]byte, frameHeaderSize)
+	var received int64
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return received, fmt.Errorf("connection closed after %d bytes without an end frame: %w", received, err)
+		}
+		length := int64(binary.BigEndian.Uint32(header[1:]))
+
+		switch header[0] {
+		case frameData:
+			if length > frameDataChunkSize {
+				return received, fmt.Errorf("data frame of %d bytes exceeds the %d byte limit", length, frameDataChunkSize)
+			}
+			n, err := io.CopyN(dst, r, length)
+			received += n
+			if err != nil {
+				return received, fmt.Errorf("failed to read data frame: %w", err)
+			}
+
+		case frameControl:
+			if length > maxControlFrameSize {
+				return received, fmt.Errorf("control frame of %d bytes exceeds the %d byte limit", length, maxControlFrameSize)
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return received, fmt.Errorf("failed to read control frame: %w", err)
+			}
+			var msg ControlMessage
+			if err := json.Unmarshal(payload, &msg); err != nil {
+				return received, fmt.Errorf("invalid control frame: %w", err)
+			}
+			if onControl != nil {
+				if err := onControl(msg); err != nil {
+					return received, err
+				}
+			}
+
+		case frameEnd:
+			return received, nil
+
+		default:
+			return received, fmt.Errorf("unknown frame type %d", header[0])
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestICEHandshakeAcceptedBeforeData(t *testing.T) {
+	sender, receiver := net.Pipe()
+	defer sender.Close()
+	defer receiver.Close()
+
+	data := bytes.Repeat([]byte("trustdrop"), 3*frameDataChunkSize/9)
+	ice := NewICETransport(30)
+	sent := make(chan error, 1)
+	go func() { sent <- ice.sendFramed(sender, data) }()
+
+	var received bytes.Buffer
+	n, err := ice.receiveFramed(receiver, &received, int64(len(data)))
+	if err != nil {
+		t.Fatalf("receive failed: %v", err)
+	}
+	if err := <-sent; err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if n != int64(len(data)) || !bytes.Equal(received.Bytes(), data) {
+		t.Fatalf("received %d bytes, want %d", n, len(data))
+	}
+}
+
+func TestICEHandshakeRejectedOnSizeMismatch(t *testing.T) {
+	sender, receiver := net.Pipe()
+	defer sender.Close()
+	defer receiver.Close()
+
+	ice := NewICETransport(30)
+	sent := make(chan error, 1)
+	go func() { sent <- ice.sendFramed(sender, []byte("payload")) }()
+
+	var received bytes.Buffer
+	if _, err := ice.receiveFramed(receiver, &received, 1234); err == nil {
+		t.Fatal("receive accepted a payload of the wrong size")
+	}
+	err := <-sent
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("sender got %v, want a rejection", err)
+	}
+	if received.Len() != 0 {
+		t.Fatalf("%d bytes were sent after the handshake was rejected", received.Len())
+	}
+}
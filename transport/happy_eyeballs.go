@@ -0,0 +1,193 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// happyEyeballsDelay is how long the preferred address family gets to connect
+// before the other family is tried alongside it, as RFC 8305 recommends
+const happyEyeballsDelay = 250 * time.Millisecond
+
+// DialFunc opens a connection to one address; net.Dialer.DialContext fits
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// LookupFunc resolves a host name; net.Resolver.LookupIPAddr fits
+type LookupFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+// HappyEyeballsDialer opens TCP connections to dual-stack hosts by racing
+// IPv4 and IPv6: the family the resolver prefers starts first, the other
+// joins after FallbackDelay or as soon as the first fails, and the first
+// connection wins. A family that is black-holed then costs the delay rather
+// than a whole timeout. The zero value uses the system resolver and dialer.
+type HappyEyeballsDialer struct {
+	Dial          DialFunc      // Dials one IP address; nil uses a net.Dialer
+	Lookup        LookupFunc    // Resolves host names; nil uses net.DefaultResolver
+	FallbackDelay time.Duration // Head start of the preferred family; zero uses 250ms
+}
+
+// defaultDialer is the HappyEyeballsDialer used by DialTCP
+var defaultDialer HappyEyeballsDialer
+
+// DialTCP connects to address, a host and port, happy-eyeballs style, giving
+// up after timeout
+func DialTCP(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+	return defaultDialer.DialTimeout(ctx, address, timeout)
+}
+
+// DialTimeout is DialContext giving up after timeout
+func (d *HappyEyeballsDialer) DialTimeout(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return d.DialContext(ctx, address)
+}
+
+// familyResult is the outcome of dialing one address family
+type familyResult struct {
+	conn    net.Conn
+	err     error
+	primary bool
+}
+
+// DialContext connects to address, a host and port. IP addresses are dialed
+// directly; host names are resolved and their families raced.
+func (d *HappyEyeballsDialer) DialContext(ctx context.Context, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dial()(ctx, "tcp", address)
+	}
+
+	addrs, err := d.lookup()(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	primary, fallback := splitFamilies(addrs)
+	if len(fallback) == 0 {
+		return d.dialSerial(ctx, primary, port)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan familyResult, 2)
+	race := func(addrs []net.IPAddr, isPrimary bool) {
+		conn, err := d.dialSerial(ctx, addrs, port)
+		results <- familyResult{conn: conn, err: err, primary: isPrimary}
+	}
+	go race(primary, true)
+
+	delay := d.FallbackDelay
+	if delay <= 0 {
+		delay = happyEyeballsDelay
+	}
+	fallbackTimer := time.NewTimer(delay)
+	defer fallbackTimer.Stop()
+
+	pending, fallbackStarted := 1, false
+	var primaryErr, fallbackErr error
+	for {
+		select {
+		case <-fallbackTimer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go race(fallback, false)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				go closeLateConnections(results, pending)
+				return result.conn, nil
+			}
+			if result.primary {
+				primaryErr = result.err
+			} else {
+				fallbackErr = result.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go race(fallback, false)
+			} else if pending == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+				return nil, fallbackErr
+			}
+		}
+	}
+}
+
+// dialSerial tries addrs on port in order and returns the first connection,
+// or the first error when none connects
+func (d *HappyEyeballsDialer) dialSerial(ctx context.Context, addrs []net.IPAddr, port string) (net.Conn, error) {
+	var firstErr error
+	for _, addr := range addrs {
+		network := "tcp6"
+		if addr.IP.To4() != nil {
+			network = "tcp4"
+		}
+		conn, err := d.dial()(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// dial returns the function that dials one address
+func (d *HappyEyeballsDialer) dial() DialFunc {
+	if d.Dial != nil {
+		return d.Dial
+	}
+	return (&net.Dialer{}).DialContext
+}
+
+// lookup returns the function that resolves host names
+func (d *HappyEyeballsDialer) lookup() LookupFunc {
+	if d.Lookup != nil {
+		return d.Lookup
+	}
+	return net.DefaultResolver.LookupIPAddr
+}
+
+// splitFamilies splits addrs into the family of the first address, which the
+// resolver prefers, and the other family, keeping the resolver's order
+func splitFamilies(addrs []net.IPAddr) (primary, fallback []net.IPAddr) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	primaryIsV4 := addrs[0].IP.To4() != nil
+	for _, addr := range addrs {
+		if (addr.IP.To4() != nil) == primaryIsV4 {
+			primary = append(primary, addr)
+		} else {
+			fallback = append(fallback, addr)
+		}
+	}
+	return primary, fallback
+}
+
+// closeLateConnections closes connections from families still racing after
+// another family won
+func closeLateConnections(results <-chan familyResult, pending int) {
+	for ; pending > 0; pending-- {
+		if result := <-results; result.conn != nil {
+			result.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testIPv4 = net.IPAddr{IP: net.ParseIP("192.0.2.1")}
+	testIPv6 = net.IPAddr{IP: net.ParseIP("2001:db8::1")}
+)
+
+// recordedConn notes when it is closed
+type recordedConn struct {
+	net.Conn
+	network string
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (c *recordedConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func newRecordedConn(network string) *recordedConn {
+	return &recordedConn{network: network, closed: make(chan struct{})}
+}
+
+// testDialer resolves every host to addrs and dials each family with the
+// function given for it
+func testDialer(addrs []net.IPAddr, dial map[string]DialFunc) *HappyEyeballsDialer {
+	return &HappyEyeballsDialer{
+		Lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			return addrs, nil
+		},
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dial[network](ctx, network, address)
+		},
+		FallbackDelay: 20 * time.Millisecond,
+	}
+}
+
+// blackHole never connects, like a family whose packets are dropped
+func blackHole(ctx context.Context, network, address string) (net.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// connects succeeds at once
+func connects(ctx context.Context, network, address string) (net.Conn, error) {
+	return newRecordedConn(network), nil
+}
+
+// refuses fails at once
+func refuses(ctx context.Context, network, address string) (net.Conn, error) {
+	return nil, errors.New(network + " connection refused")
+}
+
+func TestHappyEyeballsSkipsBlackHoledFamily(t *testing.T) {
+	dialer := testDialer([]net.IPAddr{testIPv6, testIPv4}, map[string]DialFunc{"tcp6": blackHole, "tcp4": connects})
+
+	start := time.Now()
+	conn, err := dialer.DialTimeout(context.Background(), "relay.example:9009", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network := conn.(*recordedConn).network; network != "tcp4" {
+		t.Fatalf("connected over %s, want tcp4", network)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("dial took %v, stalled on the black-holed family", elapsed)
+	}
+}
+
+func TestHappyEyeballsFallsBackAtOnceOnFailure(t *testing.T) {
+	dialer := testDialer([]net.IPAddr{testIPv4, testIPv6}, map[string]DialFunc{"tcp4": refuses, "tcp6": connects})
+	dialer.FallbackDelay = time.Hour
+
+	conn, err := dialer.DialTimeout(context.Background(), "relay.example:9009", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network := conn.(*recordedConn).network; network != "tcp6" {
+		t.Fatalf("connected over %s, want tcp6", network)
+	}
+}
+
+func TestHappyEyeballsReportsPreferredFamilyError(t *testing.T) {
+	dialer := testDialer([]net.IPAddr{testIPv4, testIPv6}, map[string]DialFunc{"tcp4": refuses, "tcp6": refuses})
+
+	_, err := dialer.DialTimeout(context.Background(), "relay.example:9009", 10*time.Second)
+	if err == nil || err.Error() != "tcp4 connection refused" {
+		t.Fatalf("got %v, want the preferred family's error", err)
+	}
+}
+
+func TestHappyEyeballsClosesLosingConnection(t *testing.T) {
+	late := newRecordedConn("tcp4")
+	slow := func(ctx context.Context, network, address string) (net.Conn, error) {
+		time.Sleep(200 * time.Millisecond)
+		return late, nil
+	}
+	dialer := testDialer([]net.IPAddr{testIPv4, testIPv6}, map[string]DialFunc{"tcp4": slow, "tcp6": connects})
+
+	conn, err := dialer.DialTimeout(context.Background(), "relay.example:9009", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn == net.Conn(late) {
+		t.Fatal("the slower family won")
+	}
+
+	select {
+	case <-late.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection from the losing family was left open")
+	}
+}
+
+func TestHappyEyeballsDialsIPLiteralsDirectly(t *testing.T) {
+	var dialed string
+	dialer := &HappyEyeballsDialer{
+		Lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			t.Errorf("IP literal %s was resolved", host)
+			return nil, nil
+		},
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialed = address
+			return newRecordedConn(network), nil
+		},
+	}
+
+	if _, err := dialer.DialTimeout(context.Background(), "[2001:db8::1]:9009", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if dialed != "[2001:db8::1]:9009" {
+		t.Fatalf("dialed %q", dialed)
+	}
+}
+
+func TestSplitFamilies(t *testing.T) {
+	otherIPv4 := net.IPAddr{IP: net.ParseIP("198.51.100.1")}
+	primary, fallback := splitFamilies([]net.IPAddr{testIPv6, testIPv4, otherIPv4})
+	if len(primary) != 1 || !primary[0].IP.Equal(testIPv6.IP) {
+		t.Errorf("primary %v, want the IPv6 address the resolver listed first", primary)
+	}
+	if len(fallback) != 2 || !fallback[0].IP.Equal(testIPv4.IP) || !fallback[1].IP.Equal(otherIPv4.IP) {
+		t.Errorf("fallback %v, want the IPv4 addresses in resolver order", fallback)
+	}
+}
@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// ErrAllTransportsFailed is matched by errors.Is when every transport in the
+// failover chain failed
+var ErrAllTransportsFailed = errors.New("all transports failed")
+
+// FailoverError is returned when the whole failover chain fails. Its message is
+// the user-facing troubleshooting text; the last transport error stays
+// reachable through errors.Unwrap.
+type FailoverError struct {
+	Message string
+	Last    error
+}
+
+// Error implements the error interface
+func (fe *FailoverError) Error() string {
+	return fe.Message
+}
+
+// Unwrap exposes ErrAllTransportsFailed and the last transport error
+func (fe *FailoverError) Unwrap() []error {
+	return []error{ErrAllTransportsFailed, fe.Last}
+}
+
+// HardReset closes every transport and builds fresh instances, dropping relay
+// sessions and selections that may be wedged, along with failure cooldowns
+func (mtm *MultiTransportManager) HardReset() error {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	logging.Debugf("Hard reset: rebuilding all transports")
+	for _, transport := range mtm.transports {
+		if err := transport.Close(); err != nil {
+			logging.Warnf("failed to close %s during reset: %v", transport.GetName(), err)
+		}
+	}
+
+	mtm.transports = nil
+	mtm.currentTransport = nil
+	mtm.failedTransports = make(map[string]time.Time)
+
+	// Setup receives mtm.config, so later settings such as TempDir carry over
+	if err := mtm.initializeTransports(); err != nil {
+		return fmt.Errorf("failed to rebuild transports: %w", err)
+	}
+	return nil
+}
@@ -12,6 +12,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
 )
 
 // DirectHTTPSTransport provides direct peer-to-peer HTTPS transport
@@ -32,7 +35,7 @@ func NewHTTPSTunnelTransport(priority int) *DirectHTTPSTransport {
 
 func (t *DirectHTTPSTransport) Setup(config TransportConfig) error {
 	t.config = config
-	fmt.Printf("Direct HTTPS P2P Transport initialized for lab-to-lab transfers\n")
+	logging.Debugf("Direct HTTPS P2P Transport initialized for lab-to-lab transfers")
 	return nil
 }
 
@@ -41,12 +44,11 @@ func (t *DirectHTTPSTransport) Send(data []byte, metadata TransferMetadata) erro
 	// For true P2P, we need to establish a direct connection
 	// This implementation provides a framework for direct peer discovery
 
-	maxSize := int64(100 * 1024 * 1024) // 100MB limit
-	if int64(len(data)) > maxSize {
-		return fmt.Errorf("file too large for direct HTTPS transport (%d bytes, max %d)", len(data), maxSize)
+	if err := t.config.checkPayloadSize(int64(len(data)), "direct HTTPS"); err != nil {
+		return err
 	}
 
-	fmt.Println("🔒 Direct HTTPS: Starting peer-to-peer server for lab-to-lab transfer...")
+	logging.Debugf("Direct HTTPS: Starting peer-to-peer server for lab-to-lab transfer...")
 
 	// Store data for P2P retrieval
 	t.mutex.Lock()
@@ -55,7 +57,7 @@ func (t *DirectHTTPSTransport) Send(data []byte, metadata TransferMetadata) erro
 
 	// Create coordination file to signal readiness
 	if err := t.createCoordinationFile(metadata.TransferID); err != nil {
-		fmt.Printf("Warning: Could not create coordination file: %v\n", err)
+		logging.Warnf("Could not create coordination file: %v", err)
 	}
 
 	// Start temporary HTTPS server for direct peer connection
@@ -64,16 +66,11 @@ func (t *DirectHTTPSTransport) Send(data []byte, metadata TransferMetadata) erro
 
 // createCoordinationFile creates a file to signal server readiness
 func (t *DirectHTTPSTransport) createCoordinationFile(transferID string) error {
-	homeDir, err := os.UserHomeDir()
+	coordDir, err := internal.EnsureStateDir()
 	if err != nil {
 		return err
 	}
 
-	coordDir := filepath.Join(homeDir, ".trustdrop")
-	if err := os.MkdirAll(coordDir, 0755); err != nil {
-		return err
-	}
-
 	// Get local IP addresses
 	localIPs := t.getLocalNetworkIPs()
 
@@ -81,7 +78,7 @@ func (t *DirectHTTPSTransport) createCoordinationFile(transferID string) error {
 	coordInfo := fmt.Sprintf("ready\nport:8080\nips:%s\n", strings.Join(localIPs, ","))
 
 	coordFile := filepath.Join(coordDir, fmt.Sprintf("transfer_%s.coord", transferID))
-	return os.WriteFile(coordFile, []byte(coordInfo), 0644)
+	return os.WriteFile(coordFile, []byte(coordInfo), 0600)
 }
 
 // startP2PServer creates a temporary server for direct peer connection
@@ -92,7 +89,7 @@ func (t *DirectHTTPSTransport) startP2PServer(transferID string) error {
 	// Generate self-signed certificate for local use
 	cert, key, err := t.generateSelfSignedCert()
 	if err != nil {
-		fmt.Printf("❌ Failed to generate certificate: %v\n", err)
+		logging.Debugf("Failed to generate certificate: %v", err)
 		// Fall back to HTTP for local testing
 		return t.startHTTPServer(transferID, mux)
 	}
@@ -110,13 +107,13 @@ func (t *DirectHTTPSTransport) startP2PServer(transferID string) error {
 
 	// Start server in background
 	go func() {
-		fmt.Printf("🚀 Starting HTTPS server on port 8443 for transfer %s\n", transferID)
+		logging.Debugf("Starting HTTPS server on port 8443 for transfer %s", transferID)
 		if err := t.server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("❌ HTTPS server failed: %v\n", err)
+			logging.Debugf("HTTPS server failed: %v", err)
 		}
 	}()
 
-	fmt.Printf("✅ Direct P2P HTTPS server ready on port 8443 for transfer %s\n", transferID)
+	logging.Debugf("Direct P2P HTTPS server ready on port 8443 for transfer %s", transferID)
 	return nil
 }
 
@@ -131,13 +128,13 @@ func (t *DirectHTTPSTransport) startHTTPServer(transferID string, mux *http.Serv
 
 	// Start server in background
 	go func() {
-		fmt.Printf("🚀 Starting HTTP server on port 8080 for transfer %s\n", transferID)
+		logging.Debugf("Starting HTTP server on port 8080 for transfer %s", transferID)
 		if err := t.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("❌ HTTP server failed: %v\n", err)
+			logging.Debugf("HTTP server failed: %v", err)
 		}
 	}()
 
-	fmt.Printf("✅ Direct P2P HTTP server ready on port 8080 for transfer %s\n", transferID)
+	logging.Debugf("Direct P2P HTTP server ready on port 8080 for transfer %s", transferID)
 	return nil
 }
 
@@ -170,23 +167,23 @@ func (t *DirectHTTPSTransport) handleDirectTransfer(w http.ResponseWriter, r *ht
 	delete(t.transfers, transferID)
 	t.mutex.Unlock()
 
-	fmt.Printf("✅ Successfully served transfer %s\n", transferID)
+	logging.Debugf("Successfully served transfer %s", transferID)
 }
 
 // Receive implements direct P2P HTTPS connection
 func (t *DirectHTTPSTransport) Receive(metadata TransferMetadata) ([]byte, error) {
-	fmt.Println("🔒 Direct HTTPS: Attempting to receive from local network...")
+	logging.Debugf("Direct HTTPS: Attempting to receive from local network...")
 
 	// Wait for coordination file to appear (sender ready signal)
 	if err := t.waitForSenderReady(metadata.TransferID, 30*time.Second); err != nil {
-		fmt.Printf("⏰ Sender not ready yet: %v\n", err)
+		logging.Debugf("Sender not ready yet: %v", err)
 	}
 
 	// Get potential sender addresses from coordination file and network discovery
 	addresses := t.discoverSenderAddresses(metadata.TransferID)
 
 	for _, addr := range addresses {
-		fmt.Printf("🔍 Trying to connect to: %s\n", addr)
+		logging.Debugf("Trying to connect to: %s", addr)
 
 		// Create HTTP client with timeout
 		var client *http.Client
@@ -211,7 +208,7 @@ func (t *DirectHTTPSTransport) Receive(metadata TransferMetadata) ([]byte, error
 
 		resp, err := client.Get(url)
 		if err != nil {
-			fmt.Printf("❌ Failed to connect to %s: %v\n", addr, err)
+			logging.Debugf("Failed to connect to %s: %v", addr, err)
 			continue
 		}
 		defer resp.Body.Close()
@@ -219,15 +216,15 @@ func (t *DirectHTTPSTransport) Receive(metadata TransferMetadata) ([]byte, error
 		if resp.StatusCode == http.StatusOK {
 			data, err := io.ReadAll(resp.Body)
 			if err != nil {
-				fmt.Printf("❌ Failed to read data from %s: %v\n", addr, err)
+				logging.Debugf("Failed to read data from %s: %v", addr, err)
 				continue
 			}
 
-			fmt.Printf("✅ Successfully received %d bytes from %s\n", len(data), addr)
+			logging.Debugf("Successfully received %d bytes from %s", len(data), addr)
 			return data, nil
 		}
 
-		fmt.Printf("❌ Server at %s returned status: %d\n", addr, resp.StatusCode)
+		logging.Debugf("Server at %s returned status: %d", addr, resp.StatusCode)
 	}
 
 	return nil, fmt.Errorf("could not connect to any local HTTPS servers for transfer %s", metadata.TransferID)
@@ -235,17 +232,12 @@ func (t *DirectHTTPSTransport) Receive(metadata TransferMetadata) ([]byte, error
 
 // waitForSenderReady waits for the coordination file to appear
 func (t *DirectHTTPSTransport) waitForSenderReady(transferID string, timeout time.Duration) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-
-	coordFile := filepath.Join(homeDir, ".trustdrop", fmt.Sprintf("transfer_%s.coord", transferID))
+	coordFile := filepath.Join(internal.StateDir(), fmt.Sprintf("transfer_%s.coord", transferID))
 
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		if _, err := os.Stat(coordFile); err == nil {
-			fmt.Printf("📡 Sender ready signal detected\n")
+			logging.Debugf("Sender ready signal detected")
 			return nil
 		}
 		time.Sleep(1 * time.Second)
@@ -259,8 +251,7 @@ func (t *DirectHTTPSTransport) discoverSenderAddresses(transferID string) []stri
 	var addresses []string
 
 	// Try to read coordination file for sender IPs
-	homeDir, _ := os.UserHomeDir()
-	coordFile := filepath.Join(homeDir, ".trustdrop", fmt.Sprintf("transfer_%s.coord", transferID))
+	coordFile := filepath.Join(internal.StateDir(), fmt.Sprintf("transfer_%s.coord", transferID))
 
 	if data, err := os.ReadFile(coordFile); err == nil {
 		lines := strings.Split(string(data), "\n")
@@ -351,3 +342,8 @@ func (t *DirectHTTPSTransport) Close() error {
 	}
 	return nil
 }
+
+// setMaxPayloadSize changes the largest payload the transport sends
+func (t *DirectHTTPSTransport) setMaxPayloadSize(size int64) {
+	t.config.MaxPayloadSize = size
+}
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ICEServers lists the STUN and TURN servers the ICE transport gathers
+// candidates from. Either list left empty uses the built-in defaults.
+type ICEServers struct {
+	STUN []string     `json:"stun,omitempty"` // "stun:host:port"
+	TURN []TURNServer `json:"turn,omitempty"` // "turn:host:port" or "turns:host:port"
+}
+
+// defaultSTUNServers are used when no STUN servers are configured
+var defaultSTUNServers = []string{
+	"stun:stun.l.google.com:19302",
+	"stun:stun1.l.google.com:3478",
+	"stun:stun2.l.google.com:19302",
+	"stun:stun3.l.google.com:3478",
+	"stun:stun4.l.google.com:19302",
+}
+
+// Validate checks that every server URL has a supported scheme and a
+// host:port, and that every TURN server has credentials
+func (s ICEServers) Validate() error {
+	for _, url := range s.STUN {
+		if _, err := iceServerAddress(url, "stun:"); err != nil {
+			return fmt.Errorf("invalid STUN server %q: %w", url, err)
+		}
+	}
+	for _, server := range s.TURN {
+		if _, err := iceServerAddress(server.URL, "turn:", "turns:"); err != nil {
+			return fmt.Errorf("invalid TURN server %q: %w", server.URL, err)
+		}
+		if server.Username == "" || server.Password == "" {
+			return fmt.Errorf("invalid TURN server %q: username and password are required", server.URL)
+		}
+	}
+	return nil
+}
+
+// iceServerAddress returns the host:port of a STUN or TURN URL whose scheme
+// is one of schemes. Query parameters such as "?transport=tcp" are ignored.
+func iceServerAddress(url string, schemes ...string) (string, error) {
+	rest := ""
+	for _, scheme := range schemes {
+		if strings.HasPrefix(url, scheme) {
+			rest = strings.TrimPrefix(url, scheme)
+			break
+		}
+	}
+	if rest == "" {
+		return "", fmt.Errorf("must start with %s followed by host:port", strings.Join(schemes, " or "))
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+
+	host, port, err := net.SplitHostPort(rest)
+	if err != nil {
+		return "", fmt.Errorf("must be host:port: %w", err)
+	}
+	if host == "" {
+		return "", fmt.Errorf("host is missing")
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return "", fmt.Errorf("port must be between 1 and 65535, got %q", port)
+	}
+	return rest, nil
+}
+
+// defaultTURNServers returns the built-in TURN servers, authenticated with
+// TRUSTDROP_TURN_USERNAME and TRUSTDROP_TURN_PASSWORD when they are set
+func defaultTURNServers() []TURNServer {
+	turnUsername := os.Getenv("TRUSTDROP_TURN_USERNAME")
+	turnPassword := os.Getenv("TRUSTDROP_TURN_PASSWORD")
+
+	// Use secure default configuration if credentials not provided
+	if turnUsername == "" {
+		turnUsername = "anonymous"
+	}
+	if turnPassword == "" {
+		// Generate a session-specific password for anonymous access
+		sessionBytes := make([]byte, 16)
+		rand.Read(sessionBytes)
+		turnPassword = fmt.Sprintf("session-%x", sessionBytes)
+	}
+
+	return []TURNServer{
+		{URL: "turn:stun.l.google.com:19302", Username: turnUsername, Password: turnPassword},
+		{URL: "turns:stun1.l.google.com:19302", Username: turnUsername, Password: turnPassword}, // TLS
+		{URL: "turn:stun2.l.google.com:19302", Username: turnUsername, Password: turnPassword},  // HTTP port
+		{URL: "turns:stun3.l.google.com:19302", Username: turnUsername, Password: turnPassword}, // HTTPS port
+	}
+}
+
+// SetICEServers sets the STUN and TURN servers of the ICE transport. Empty
+// lists use the built-in defaults.
+func (ptm *ProgressiveTransportManager) SetICEServers(servers ICEServers) error {
+	for _, layer := range ptm.transports {
+		if ice, ok := layer.Transport.(*ICETransport); ok {
+			config := ice.config
+			config.ICEServers = servers
+			if err := ice.Setup(config); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
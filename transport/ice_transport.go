@@ -3,12 +3,15 @@ package transport
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"sort"
-	"strings"
 	"time"
+
+	"trustdrop-bulletproof/logging"
 )
 
 // ICETransport implements WebRTC-style connectivity establishment
@@ -18,13 +21,16 @@ type ICETransport struct {
 	turnServers []TURNServer
 	candidates  []ICECandidate
 	config      TransportConfig
+
+	// receiveProgress is told how much of a received payload has arrived
+	receiveProgress func(received, total int64)
 }
 
 // TURNServer represents a TURN relay server configuration
 type TURNServer struct {
-	URL      string
-	Username string
-	Password string
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 // ICECandidate represents a potential connection path
@@ -39,51 +45,50 @@ type ICECandidate struct {
 
 // NewICETransport creates a new ICE transport with WebRTC-proven reliability
 func NewICETransport(priority int) *ICETransport {
-	return &ICETransport{
+	transport := &ICETransport{
 		priority:    priority,
 		candidates:  make([]ICECandidate, 0),
 		stunServers: make([]string, 0),
 		turnServers: make([]TURNServer, 0),
 	}
+
+	// Setup with the default servers
+	transport.Setup(TransportConfig{})
+
+	return transport
+}
+
+// setTempDir changes where the transport writes temp files
+func (t *ICETransport) setTempDir(dir string) {
+	t.config.TempDir = dir
+}
+
+// setTimeoutMultiplier stretches the candidate test timeouts
+func (t *ICETransport) setTimeoutMultiplier(multiplier float64) {
+	t.config.TimeoutMultiplier = multiplier
 }
 
-// Setup initializes ICE transport with WebRTC-proven servers
+// Setup initializes ICE transport with the configured STUN and TURN servers,
+// or WebRTC-proven defaults when none are configured
 func (t *ICETransport) Setup(config TransportConfig) error {
+	if err := config.ICEServers.Validate(); err != nil {
+		return err
+	}
 	t.config = config
 
 	// Use proven STUN servers (Google's are most reliable in corporate networks)
-	t.stunServers = []string{
-		"stun:stun.l.google.com:19302",
-		"stun:stun1.l.google.com:3478",
-		"stun:stun2.l.google.com:19302",
-		"stun:stun3.l.google.com:3478",
-		"stun:stun4.l.google.com:19302",
+	t.stunServers = defaultSTUNServers
+	if len(config.ICEServers.STUN) > 0 {
+		t.stunServers = config.ICEServers.STUN
 	}
 
 	// Add enterprise TURN servers for maximum reliability
-	// Load TURN credentials from environment variables for security
-	turnUsername := os.Getenv("TRUSTDROP_TURN_USERNAME")
-	turnPassword := os.Getenv("TRUSTDROP_TURN_PASSWORD")
-
-	// Use secure default configuration if credentials not provided
-	if turnUsername == "" {
-		turnUsername = "anonymous"
-	}
-	if turnPassword == "" {
-		// Generate a session-specific password for anonymous access
-		sessionBytes := make([]byte, 16)
-		rand.Read(sessionBytes)
-		turnPassword = fmt.Sprintf("session-%x", sessionBytes)
-	}
-
-	t.turnServers = []TURNServer{
-		{URL: "turn:stun.l.google.com:19302", Username: turnUsername, Password: turnPassword},
-		{URL: "turns:stun1.l.google.com:19302", Username: turnUsername, Password: turnPassword}, // TLS
-		{URL: "turn:stun2.l.google.com:19302", Username: turnUsername, Password: turnPassword},  // HTTP port
-		{URL: "turns:stun3.l.google.com:19302", Username: turnUsername, Password: turnPassword}, // HTTPS port
+	t.turnServers = defaultTURNServers()
+	if len(config.ICEServers.TURN) > 0 {
+		t.turnServers = config.ICEServers.TURN
 	}
 
-	fmt.Printf("ICE transport initialized with %d STUN and %d TURN servers\n",
+	logging.Debugf("ICE transport initialized with %d STUN and %d TURN servers",
 		len(t.stunServers), len(t.turnServers))
 	return nil
 }
@@ -97,18 +102,26 @@ func (t *ICETransport) Send(data []byte, metadata TransferMetadata) error {
 	}
 	defer conn.Close()
 
-	// Send data over established connection
-	_, err = conn.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to send data over ICE connection: %w", err)
+	if err := t.sendFramed(conn, data); err != nil {
+		return err
 	}
 
-	fmt.Printf("✅ ICE transport sent %d bytes via %s\n", len(data), conn.RemoteAddr())
+	logging.Debugf("ICE transport sent %d bytes via %s", len(data), conn.RemoteAddr())
 	return nil
 }
 
 // Receive implements the Transport interface using ICE connectivity
 func (t *ICETransport) Receive(metadata TransferMetadata) ([]byte, error) {
+	stream, err := t.ReceiveStream(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return readAllAndClose(stream)
+}
+
+// ReceiveStream reads a framed payload from the ICE connection into a temp file and
+// returns a stream over it, which is removed when the stream is closed
+func (t *ICETransport) ReceiveStream(metadata TransferMetadata) (io.ReadCloser, error) {
 	// Establish connection using progressive fallback
 	conn, err := t.EstablishConnection(metadata.TransferID)
 	if err != nil {
@@ -116,31 +129,140 @@ func (t *ICETransport) Receive(metadata TransferMetadata) ([]byte, error) {
 	}
 	defer conn.Close()
 
-	// Read data from established connection
-	buffer := make([]byte, 64*1024*1024) // 64MB buffer
-	n, err := conn.Read(buffer)
+	tempFile, err := os.CreateTemp(t.config.TempDir, "ice_receive_*.tmp")
 	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	n, err := t.receiveFramed(conn, tempFile, metadata.FileSize)
+	closeErr := tempFile.Close()
+	if err != nil {
+		os.Remove(tempPath)
 		return nil, fmt.Errorf("failed to receive data over ICE connection: %w", err)
 	}
+	if closeErr != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to write received data: %w", closeErr)
+	}
+
+	logging.Debugf("ICE transport received %d bytes via %s", n, conn.RemoteAddr())
+	return newTempFileReader(tempPath, tempPath)
+}
+
+// iceAcceptTimeout bounds how long the sender waits for the receiver to
+// answer its handshake
+const iceAcceptTimeout = 30 * time.Second
+
+// sendFramed sends data over conn: the handshake announcing its size, then,
+// once the receiver accepts, the data in frames with progress interleaved
+func (t *ICETransport) sendFramed(conn net.Conn, data []byte) error {
+	conn.SetWriteDeadline(time.Time{})
+	frames := newFrameWriter(conn)
+	total := int64(len(data))
+	if err := frames.SendControl(ControlMessage{Type: ControlMetadata, Total: total}); err != nil {
+		return fmt.Errorf("failed to send metadata over ICE connection: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(t.config.scaleTimeout(iceAcceptTimeout)))
+	answer, err := readControl(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return fmt.Errorf("no answer to the ICE handshake: %w", err)
+	}
+	switch answer.Type {
+	case ControlAccept:
+	case ControlReject:
+		return fmt.Errorf("receiver rejected the transfer: %s", answer.Text)
+	default:
+		return fmt.Errorf("unexpected %q answer to the ICE handshake", answer.Type)
+	}
+
+	var lastReported int64
+	err = frames.WriteData(data, func(sent int64) {
+		if sent-lastReported >= iceProgressInterval || sent == total {
+			lastReported = sent
+			frames.SendControl(ControlMessage{Type: ControlProgress, Sent: sent, Total: total})
+			logging.Tracef("chunk", "sent %d/%d bytes via ICE", sent, total)
+		}
+	})
+	if err == nil {
+		err = frames.End()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send data over ICE connection: %w", err)
+	}
+	return nil
+}
+
+// receiveFramed reads a framed payload from conn into dst, answering the
+// sender's handshake with an accept, or a reject when the announced size is
+// not expectedSize, and returns the number of bytes received
+func (t *ICETransport) receiveFramed(conn net.Conn, dst io.Writer, expectedSize int64) (int64, error) {
+	// Clear the handshake deadline and read the framed payload
+	conn.SetReadDeadline(time.Time{})
+	return readFrames(conn, dst, func(msg ControlMessage) error {
+		switch msg.Type {
+		case ControlMetadata:
+			if expectedSize > 0 && msg.Total != expectedSize {
+				reason := fmt.Sprintf("announced size %d does not match expected size %d", msg.Total, expectedSize)
+				writeControl(conn, ControlMessage{Type: ControlReject, Text: reason})
+				return errors.New(reason)
+			}
+			if err := writeControl(conn, ControlMessage{Type: ControlAccept}); err != nil {
+				return fmt.Errorf("failed to accept the ICE handshake: %w", err)
+			}
+			if t.receiveProgress != nil {
+				t.receiveProgress(0, msg.Total)
+			}
+		case ControlProgress:
+			logging.Tracef("chunk", "received up to %d/%d bytes via ICE", msg.Sent, msg.Total)
+			if t.receiveProgress != nil {
+				t.receiveProgress(msg.Sent, msg.Total)
+			}
+		}
+		return nil
+	})
+}
 
-	fmt.Printf("✅ ICE transport received %d bytes via %s\n", n, conn.RemoteAddr())
-	return buffer[:n], nil
+// iceProgressInterval is how many bytes the ICE sender sends between
+// progress control messages
+const iceProgressInterval = 1024 * 1024
+
+// writeFull writes all of data, looping on short writes
+func writeFull(w io.Writer, data []byte) error {
+	for len(data) > 0 {
+		n, err := w.Write(data)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return io.ErrShortWrite
+		}
+		data = data[n:]
+	}
+	return nil
 }
 
 // EstablishConnection uses progressive fallback like WebRTC
 func (t *ICETransport) EstablishConnection(transferID string) (net.Conn, error) {
-	fmt.Printf("🔄 Starting ICE connection establishment for transfer %s\n", transferID)
+	logging.Debugf("Starting ICE connection establishment for transfer %s", transferID)
 
 	// Step 1: Gather all possible connection candidates
+	traced := logging.TraceSpan("ice", "gather candidates")
 	candidates, err := t.gatherCandidates()
+	traced(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to gather candidates: %w", err)
 	}
 
-	fmt.Printf("📋 Gathered %d ICE candidates\n", len(candidates))
+	logging.Debugf("Gathered %d ICE candidates", len(candidates))
 
 	// Step 2: Test candidates in priority order (like WebRTC ICE)
-	return t.testCandidates(candidates, transferID)
+	traced = logging.TraceSpan("ice", "connect to one of %d candidates", len(candidates))
+	conn, err := t.testCandidates(candidates, transferID)
+	traced(err)
+	return conn, err
 }
 
 // gatherCandidates collects all possible connection paths
@@ -151,21 +273,21 @@ func (t *ICETransport) gatherCandidates() ([]ICECandidate, error) {
 	hostCandidates, err := t.getHostCandidates()
 	if err == nil {
 		candidates = append(candidates, hostCandidates...)
-		fmt.Printf("📍 Added %d host candidates\n", len(hostCandidates))
+		logging.Debugf("Added %d host candidates", len(hostCandidates))
 	}
 
 	// 2. Server reflexive candidates (STUN)
 	stunCandidates, err := t.getSTUNCandidates()
 	if err == nil {
 		candidates = append(candidates, stunCandidates...)
-		fmt.Printf("🌐 Added %d STUN candidates\n", len(stunCandidates))
+		logging.Debugf("Added %d STUN candidates", len(stunCandidates))
 	}
 
 	// 3. Relay candidates (TURN)
 	turnCandidates, err := t.getTURNCandidates()
 	if err == nil {
 		candidates = append(candidates, turnCandidates...)
-		fmt.Printf("🔄 Added %d TURN candidates\n", len(turnCandidates))
+		logging.Debugf("Added %d TURN candidates", len(turnCandidates))
 	}
 
 	// Sort by priority (host > srflx > relay)
@@ -227,7 +349,7 @@ func (t *ICETransport) getSTUNCandidates() ([]ICECandidate, error) {
 		cancel()
 
 		if err != nil {
-			fmt.Printf("STUN server %s failed: %v\n", stunServer, err)
+			logging.Debugf("STUN server %s failed: %v", stunServer, err)
 			continue
 		}
 
@@ -242,7 +364,7 @@ func (t *ICETransport) getSTUNCandidates() ([]ICECandidate, error) {
 		}
 
 		candidates = append(candidates, candidate)
-		fmt.Printf("✅ STUN candidate: %s:%d\n", candidate.Address, candidate.Port)
+		logging.Debugf("STUN candidate: %s:%d", candidate.Address, candidate.Port)
 
 		// Only need one working STUN candidate
 		break
@@ -263,7 +385,7 @@ func (t *ICETransport) getTURNCandidates() ([]ICECandidate, error) {
 		cancel()
 
 		if err != nil {
-			fmt.Printf("TURN server %s failed: %v\n", turnServer.URL, err)
+			logging.Debugf("TURN server %s failed: %v", turnServer.URL, err)
 			continue
 		}
 
@@ -277,7 +399,7 @@ func (t *ICETransport) getTURNCandidates() ([]ICECandidate, error) {
 		}
 
 		candidates = append(candidates, candidate)
-		fmt.Printf("✅ TURN candidate: %s:%d\n", candidate.Address, candidate.Port)
+		logging.Debugf("TURN candidate: %s:%d", candidate.Address, candidate.Port)
 	}
 
 	return candidates, nil
@@ -285,7 +407,10 @@ func (t *ICETransport) getTURNCandidates() ([]ICECandidate, error) {
 
 func (t *ICETransport) querySTUNServer(ctx context.Context, stunServer string) (*net.UDPAddr, error) {
 	// Extract address from STUN URL
-	stunAddr := strings.TrimPrefix(stunServer, "stun:")
+	stunAddr, err := iceServerAddress(stunServer, "stun:")
+	if err != nil {
+		return nil, err
+	}
 
 	// Resolve STUN server address
 	serverAddr, err := net.ResolveUDPAddr("udp", stunAddr)
@@ -393,10 +518,12 @@ func (t *ICETransport) allocateTURNRelay(_ context.Context, turnServer TURNServe
 	// For now, return a mock relay address based on server
 
 	// Extract host from TURN URL
-	turnURL := strings.TrimPrefix(turnServer.URL, "turn:")
-	turnURL = strings.TrimPrefix(turnURL, "turns:")
+	turnAddr, err := iceServerAddress(turnServer.URL, "turn:", "turns:")
+	if err != nil {
+		return nil, err
+	}
 
-	host, _, err := net.SplitHostPort(turnURL)
+	host, _, err := net.SplitHostPort(turnAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -427,22 +554,22 @@ func (t *ICETransport) allocateTURNRelay(_ context.Context, turnServer TURNServe
 // testCandidates tries each candidate until one works
 func (t *ICETransport) testCandidates(candidates []ICECandidate, transferID string) (net.Conn, error) {
 	for i, candidate := range candidates {
-		fmt.Printf("Testing candidate %d/%d: %s %s:%d\n",
+		logging.Debugf("Testing candidate %d/%d: %s %s:%d",
 			i+1, len(candidates), candidate.Type, candidate.Address, candidate.Port)
 
 		// Test connection with short timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), t.config.scaleTimeout(8*time.Second))
 
 		conn, err := t.testCandidate(ctx, candidate, transferID)
 		cancel()
 
 		if err == nil {
-			fmt.Printf("✅ Connected via %s: %s:%d\n",
+			logging.Debugf("Connected via %s: %s:%d",
 				candidate.Type, candidate.Address, candidate.Port)
 			return conn, nil
 		}
 
-		fmt.Printf("❌ Candidate failed: %v\n", err)
+		logging.Debugf("Candidate failed: %v", err)
 	}
 
 	return nil, fmt.Errorf("all %d candidates failed", len(candidates))
@@ -460,12 +587,12 @@ func (t *ICETransport) testCandidate(ctx context.Context, candidate ICECandidate
 	switch candidate.Type {
 	case "host", "srflx":
 		// Direct TCP connection
-		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		dialer := &net.Dialer{Timeout: t.config.scaleTimeout(5 * time.Second)}
 		conn, err = dialer.DialContext(ctx, "tcp", address)
 
 	case "relay":
 		// TURN relay connection (simplified)
-		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		dialer := &net.Dialer{Timeout: t.config.scaleTimeout(5 * time.Second)}
 		conn, err = dialer.DialContext(ctx, "tcp", address)
 
 	default:
@@ -478,7 +605,7 @@ func (t *ICETransport) testCandidate(ctx context.Context, candidate ICECandidate
 
 	// Test connection with simple handshake
 	testMessage := fmt.Sprintf("ICE-TEST:%s", transferID)
-	conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+	conn.SetWriteDeadline(time.Now().Add(t.config.scaleTimeout(3 * time.Second)))
 	_, err = conn.Write([]byte(testMessage))
 	if err != nil {
 		conn.Close()
@@ -486,7 +613,7 @@ func (t *ICETransport) testCandidate(ctx context.Context, candidate ICECandidate
 	}
 
 	// Wait for response (in production, peer would respond)
-	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	conn.SetReadDeadline(time.Now().Add(t.config.scaleTimeout(3 * time.Second)))
 	response := make([]byte, 256)
 	_, err = conn.Read(response)
 	if err != nil {
@@ -511,7 +638,7 @@ func (t *ICETransport) sortCandidatesByPriority(candidates []ICECandidate) {
 // IsAvailable checks if ICE transport is available
 func (t *ICETransport) IsAvailable(ctx context.Context) bool {
 	// Test if we can reach at least one STUN server
-	for _, stunServer := range t.stunServers[:2] { // Test first 2 servers
+	for _, stunServer := range t.stunServers[:min(2, len(t.stunServers))] { // Test first 2 servers
 		stunCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
 		_, err := t.querySTUNServer(stunCtx, stunServer)
 		cancel()
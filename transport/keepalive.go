@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultKeepaliveInterval is how often idle relay connections are probed,
+// well under the few minutes after which corporate firewalls commonly drop
+// connections they see no traffic on
+const DefaultKeepaliveInterval = 30 * time.Second
+
+// keepaliveProbes is how many probes in a row may go unanswered before a
+// connection counts as dead
+const keepaliveProbes = 3
+
+// relayKeepalive is the keepalive interval set for relay connections made
+// through the croc relay proxy. Like the proxy it is shared by the process:
+// zero means DefaultKeepaliveInterval and a negative value turns probes off.
+var relayKeepalive atomic.Int64
+
+// KeepaliveInterval maps a configured interval onto the one to use: zero
+// gives DefaultKeepaliveInterval and a negative interval turns probes off,
+// returned as zero
+func KeepaliveInterval(interval time.Duration) time.Duration {
+	switch {
+	case interval == 0:
+		return DefaultKeepaliveInterval
+	case interval < 0:
+		return 0
+	default:
+		return interval
+	}
+}
+
+// EnableKeepalive has the OS probe conn after interval without traffic and
+// every interval after that. Firewalls then see traffic on an idle connection,
+// and a connection whose probes go unanswered keepaliveProbes times fails its
+// next read or write instead of hanging. An interval of zero turns probing
+// off. Connections other than TCP are left alone.
+func EnableKeepalive(conn net.Conn, interval time.Duration) error {
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	return tcp.SetKeepAliveConfig(net.KeepAliveConfig{
+		Enable:   interval > 0,
+		Idle:     interval,
+		Interval: interval,
+		Count:    keepaliveProbes,
+	})
+}
+
+// ConnAlive reports whether an idle connection can still be used. A
+// connection the peer closed or reset, or that missed its keepalive probes,
+// fails the brief read this makes. An idle connection has nothing to read, so
+// one with data waiting is out of step and unusable too.
+func ConnAlive(conn net.Conn) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+		return false
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	var probe [1]byte
+	_, err := conn.Read(probe[:])
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// SetKeepaliveInterval sets how often idle relay connections are probed to
+// keep firewalls from dropping them. Zero restores DefaultKeepaliveInterval
+// and a negative interval turns probing off.
+func (mtm *MultiTransportManager) SetKeepaliveInterval(interval time.Duration) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	mtm.config.KeepaliveInterval = interval
+	relayKeepalive.Store(int64(interval))
+}
+
+// relayKeepaliveInterval is the probe interval for proxied relay connections
+func relayKeepaliveInterval() time.Duration {
+	return KeepaliveInterval(time.Duration(relayKeepalive.Load()))
+}
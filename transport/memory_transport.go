@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memoryReceiveTimeout is how long a MemoryTransport receive waits for the sender
+const memoryReceiveTimeout = 30 * time.Second
+
+// MemoryExchange is the in-process mailbox shared by MemoryTransports. Data
+// sent under a transfer ID is queued until a receiver with the same ID takes it.
+type MemoryExchange struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	pending map[string][][]byte
+	closed  bool
+}
+
+// NewMemoryExchange creates an empty exchange
+func NewMemoryExchange() *MemoryExchange {
+	exchange := &MemoryExchange{pending: make(map[string][][]byte)}
+	exchange.cond = sync.NewCond(&exchange.mutex)
+	return exchange
+}
+
+// Close wakes any waiting receivers, which then fail
+func (e *MemoryExchange) Close() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.closed = true
+	e.cond.Broadcast()
+}
+
+// put queues a copy of data for transferID
+func (e *MemoryExchange) put(transferID string, data []byte) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.closed {
+		return fmt.Errorf("memory exchange is closed")
+	}
+
+	e.pending[transferID] = append(e.pending[transferID], append([]byte(nil), data...))
+	e.cond.Broadcast()
+	return nil
+}
+
+// take waits up to timeout for data queued under transferID
+func (e *MemoryExchange) take(transferID string, timeout time.Duration) ([]byte, error) {
+	timer := time.AfterFunc(timeout, func() {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		e.cond.Broadcast()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for len(e.pending[transferID]) == 0 {
+		if e.closed {
+			return nil, fmt.Errorf("memory exchange is closed")
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("no sender for transfer %s within %v", transferID, timeout)
+		}
+		e.cond.Wait()
+	}
+
+	queue := e.pending[transferID]
+	data := queue[0]
+	if len(queue) == 1 {
+		delete(e.pending, transferID)
+	} else {
+		e.pending[transferID] = queue[1:]
+	}
+	return data, nil
+}
+
+// MemoryTransport moves data through a MemoryExchange without touching the
+// network. It backs the loopback self-test, where a sender and a receiver
+// manager in the same process share one exchange.
+type MemoryTransport struct {
+	exchange *MemoryExchange
+	config   TransportConfig
+}
+
+// NewMemoryTransport creates a transport on exchange
+func NewMemoryTransport(exchange *MemoryExchange) *MemoryTransport {
+	return &MemoryTransport{exchange: exchange}
+}
+
+// Setup stores the configuration; there is nothing to connect
+func (t *MemoryTransport) Setup(config TransportConfig) error {
+	t.config = config
+	return nil
+}
+
+// Send queues data for the receiver of metadata.TransferID
+func (t *MemoryTransport) Send(data []byte, metadata TransferMetadata) error {
+	return t.exchange.put(metadata.TransferID, data)
+}
+
+// Receive waits for data sent under metadata.TransferID
+func (t *MemoryTransport) Receive(metadata TransferMetadata) ([]byte, error) {
+	return t.exchange.take(metadata.TransferID, memoryReceiveTimeout)
+}
+
+// IsAvailable is always true
+func (t *MemoryTransport) IsAvailable(ctx context.Context) bool {
+	return true
+}
+
+// GetPriority returns the transport priority
+func (t *MemoryTransport) GetPriority() int {
+	return 100
+}
+
+// GetName returns the transport name
+func (t *MemoryTransport) GetName() string {
+	return "memory"
+}
+
+// Close releases nothing; the exchange is owned by whoever created it
+func (t *MemoryTransport) Close() error {
+	return nil
+}
+
+// NewLoopbackTransportManager creates a manager whose only transport is a
+// MemoryTransport on exchange. It skips network analysis and reports an open
+// "loopback" network.
+func NewLoopbackTransportManager(exchange *MemoryExchange) *MultiTransportManager {
+	return &MultiTransportManager{
+		transports:          []Transport{NewMemoryTransport(exchange)},
+		failedTransports:    make(map[string]time.Time),
+		successHistory:      make(map[string]int),
+		networkRestrictions: make([]NetworkRestriction, 0),
+		detectionResults:    make(map[string]bool),
+		analysisComplete:    true,
+		readyTransports:     1,
+		networkProfile: NetworkProfile{
+			NetworkType:        "loopback",
+			PreferredTransport: "memory",
+			SupportsUDP:        true,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NetworkFingerprint summarizes the active interfaces and their routable
+// addresses. It changes when the machine moves between wired and WiFi, joins a
+// different network or gets a new address, so comparing fingerprints detects
+// roaming without platform-specific SSID lookups.
+func NetworkFingerprint() (string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+
+	var entries []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLinkLocalUnicast() && !ipnet.IP.IsLoopback() {
+				entries = append(entries, iface.Name+"="+ipnet.String())
+			}
+		}
+	}
+
+	sort.Strings(entries)
+	return strings.Join(entries, ","), nil
+}
+
+// ResetCooldowns forgets recent transport failures so every transport is tried
+// again. Failures seen on a previous network say nothing about the current one.
+func (mtm *MultiTransportManager) ResetCooldowns() {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+	mtm.failedTransports = make(map[string]time.Time)
+}
+
+// Reanalyze resets transport cooldowns and re-runs network analysis, for use
+// after the network has changed. It blocks until the analysis completes.
+func (mtm *MultiTransportManager) Reanalyze() {
+	mtm.ResetCooldowns()
+
+	mtm.stateMutex.Lock()
+	mtm.analysisComplete = false
+	mtm.detectionResults = make(map[string]bool)
+	mtm.stateMutex.Unlock()
+
+	mtm.analyzeNetworkEnvironment()
+}
@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/schollz/croc/v10/src/croc"
+)
+
+// Path modes, choosing whether transfers may skip the relay. Without one,
+// receivers still look for the sender on the local network but senders only
+// offer the relay, since a direct path leaves listeners open (see
+// maxDirectSends), so a direct path is only taken when the sender chose
+// PathModeAuto.
+//
+// Nothing is measured to choose a path. croc switches to the sender's local
+// address whenever it answers during the short discovery window, on the
+// grounds that a working local path beats a round trip through the relay;
+// the throughput of the two paths is never compared.
+const (
+	PathModeAuto  = "auto"  // Use a direct connection when the peer is on the local network
+	PathModeRelay = "relay" // Always go through the relay
+)
+
+// Paths a transfer's data can take, as reported to the path handler
+const (
+	PathDirect = "direct" // Straight to the peer over the local network
+	PathRelay  = "relay"  // Through the relay server
+)
+
+// localRelayPorts are the ports a sender's local relay starts looking for free
+// ones from: the first for the handshake and the second for data
+var localRelayPorts = []string{"9009", "9010"}
+
+// maxDirectSends caps how many sends in one process offer a direct path. croc
+// never stops the local relay a send starts and has no way to be told to, so
+// each keeps two ports listening on every interface for the life of the
+// process, and croc gives up looking after 200. Later sends use the relay
+// only. This is why senders only offer a direct path with PathModeAuto.
+const maxDirectSends = 50
+
+// directSends counts the sends that offered a direct path
+var directSends atomic.Int32
+
+// pathReporter is implemented by transports that can tell which path a
+// transfer took
+type pathReporter interface {
+	setPathHandler(handler func(path string))
+}
+
+// pathModeConfigurable is implemented by transports that can connect directly
+type pathModeConfigurable interface {
+	setPathMode(mode string)
+}
+
+// ValidatePathMode checks that mode is empty or one of the PathMode values
+func ValidatePathMode(mode string) error {
+	switch mode {
+	case "", PathModeAuto, PathModeRelay:
+		return nil
+	default:
+		return fmt.Errorf("unknown path mode %q (use %q or %q)", mode, PathModeAuto, PathModeRelay)
+	}
+}
+
+// SetPathMode sets whether transfers may use a direct connection when both
+// sides are on the same local network. With PathModeAuto the sender also
+// listens on the local network, and the receiver looks for it there for a
+// fraction of a second, and asks it for its local addresses over the relay,
+// while the relay connection is set up. When a local address answers in time
+// the data goes there instead of through the relay, without comparing the
+// speed of the two; otherwise the relay is used as before. The default, an
+// empty mode, only looks on the receiving side, so senders leave no
+// listeners behind. PathModeRelay always uses the relay.
+func (mtm *MultiTransportManager) SetPathMode(mode string) error {
+	if err := ValidatePathMode(mode); err != nil {
+		return err
+	}
+
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	mtm.config.PathMode = mode
+	for _, transport := range mtm.transports {
+		if configurable, ok := transport.(pathModeConfigurable); ok {
+			configurable.setPathMode(mode)
+		}
+	}
+	return nil
+}
+
+// SetPathHandler registers handler to be told which path, PathDirect or
+// PathRelay, each transfer took once it succeeds. Only croc reports it; a nil
+// handler removes it.
+func (mtm *MultiTransportManager) SetPathHandler(handler func(path string)) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	for _, transport := range mtm.transports {
+		if reporter, ok := transport.(pathReporter); ok {
+			reporter.setPathHandler(handler)
+		}
+	}
+}
+
+// directAllowed reports whether receives may look for the sender on the
+// local network
+func (c TransportConfig) directAllowed() bool {
+	return c.PathMode != PathModeRelay
+}
+
+// offerDirectPath lets a croc send also be reached directly over the local
+// network, only with PathModeAuto and while this process has not offered too
+// many
+func (c TransportConfig) offerDirectPath(options *croc.Options) {
+	if c.PathMode != PathModeAuto || directSends.Add(1) > maxDirectSends {
+		return
+	}
+	options.DisableLocal = false
+	// croc starts its local relay on free ports from these, and writes the
+	// ports it found back into the slice, so it gets a copy
+	options.RelayPorts = append([]string(nil), localRelayPorts...)
+}
+
+// crocPath tells which path a finished croc session took. croc points its
+// relay address at the local connection when it switches to one, so a host
+// other than the relay dialed means the session went direct.
+func crocPath(client *croc.Client, relayAddress string) string {
+	if hostOf(client.Options.RelayAddress) != hostOf(relayAddress) {
+		return PathDirect
+	}
+	return PathRelay
+}
+
+// hostOf returns the host of an address, with or without a port
+func hostOf(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// setPathMode changes whether sessions may connect directly
+func (t *SimpleCrocTransport) setPathMode(mode string) {
+	t.config.PathMode = mode
+}
+
+// setPathHandler sets the handler told which path each session took
+func (t *SimpleCrocTransport) setPathHandler(handler func(path string)) {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	t.pathChosen = handler
+}
+
+// reportPath tells the path handler which path a successful session took
+func (t *SimpleCrocTransport) reportPath(client *croc.Client, relayAddress string) {
+	t.sessionMutex.Lock()
+	handler := t.pathChosen
+	t.sessionMutex.Unlock()
+	if handler != nil {
+		handler(crocPath(client, relayAddress))
+	}
+}
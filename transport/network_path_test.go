@@ -0,0 +1,24 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/schollz/croc/v10/src/croc"
+)
+
+func TestSendsOfferDirectPathOnlyInAutoMode(t *testing.T) {
+	for _, test := range []struct {
+		mode  string
+		offer bool
+	}{
+		{"", false},
+		{PathModeRelay, false},
+		{PathModeAuto, true},
+	} {
+		options := croc.Options{DisableLocal: true}
+		TransportConfig{PathMode: test.mode}.offerDirectPath(&options)
+		if offered := !options.DisableLocal; offered != test.offer {
+			t.Errorf("path mode %q: direct path offered = %v, want %v", test.mode, offered, test.offer)
+		}
+	}
+}
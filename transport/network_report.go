@@ -0,0 +1,21 @@
+package transport
+
+// AnalysisComplete reports whether the background network analysis has
+// finished, so its profile and restrictions reflect the current network
+// rather than the conservative defaults used until then
+func (mtm *MultiTransportManager) AnalysisComplete() bool {
+	return mtm.isAnalysisComplete()
+}
+
+// ExpectedTransport names the transport a transfer started now would try
+// first, given the analyzed network. It is empty when none initialized.
+func (mtm *MultiTransportManager) ExpectedTransport() string {
+	mtm.mutex.RLock()
+	defer mtm.mutex.RUnlock()
+
+	ordered := mtm.getOrderedTransports()
+	if len(ordered) == 0 {
+		return ""
+	}
+	return ordered[0].GetName()
+}
@@ -0,0 +1,43 @@
+package transport
+
+import "fmt"
+
+// DefaultMaxPayloadSize is the largest payload the croc and direct HTTPS
+// transports send when no other limit is set
+const DefaultMaxPayloadSize = 100 * 1024 * 1024
+
+// payloadSizeConfigurable is implemented by transports that cap the payloads
+// they send
+type payloadSizeConfigurable interface {
+	setMaxPayloadSize(size int64)
+}
+
+// SetMaxPayloadSize sets the largest payload transports send. Non-positive
+// values restore DefaultMaxPayloadSize.
+func (mtm *MultiTransportManager) SetMaxPayloadSize(size int64) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	mtm.config.MaxPayloadSize = size
+	for _, transport := range mtm.transports {
+		if configurable, ok := transport.(payloadSizeConfigurable); ok {
+			configurable.setMaxPayloadSize(size)
+		}
+	}
+}
+
+// maxPayloadSize returns the configured payload limit or the default
+func (c TransportConfig) maxPayloadSize() int64 {
+	if c.MaxPayloadSize <= 0 {
+		return DefaultMaxPayloadSize
+	}
+	return c.MaxPayloadSize
+}
+
+// checkPayloadSize refuses a payload above the configured limit
+func (c TransportConfig) checkPayloadSize(size int64, transport string) error {
+	if maxSize := c.maxPayloadSize(); size > maxSize {
+		return fmt.Errorf("file too large for %s transport (%d bytes, max %d)", transport, size, maxSize)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package transport
+
+import "testing"
+
+func TestMaxPayloadSizeAboveDefault(t *testing.T) {
+	croc := NewCrocTransport(10)
+	https := NewHTTPSTunnelTransport(45)
+	for _, transport := range []Transport{croc, https} {
+		if err := transport.Setup(TransportConfig{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtm := &MultiTransportManager{transports: []Transport{croc, https}}
+
+	const size = 150 * 1024 * 1024
+	if err := croc.config.checkPayloadSize(size, "CROC"); err == nil {
+		t.Fatalf("default limit accepted a %d byte payload", size)
+	}
+
+	mtm.SetMaxPayloadSize(200 * 1024 * 1024)
+	if err := croc.config.checkPayloadSize(size, "CROC"); err != nil {
+		t.Fatalf("croc transport ignored the configured limit: %v", err)
+	}
+	if err := https.config.checkPayloadSize(size, "direct HTTPS"); err != nil {
+		t.Fatalf("direct HTTPS transport ignored the configured limit: %v", err)
+	}
+	if err := croc.config.checkPayloadSize(250*1024*1024, "CROC"); err == nil {
+		t.Fatal("payload above the configured limit was accepted")
+	}
+}
@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/schollz/croc/v10/src/comm"
+	"github.com/schollz/croc/v10/src/message"
+)
+
+// peerPollInterval is how often a croc session is polled for progress
+const peerPollInterval = 250 * time.Millisecond
+
+// relayHandshakeFrames is how many frames a croc relay sends a client while
+// admitting it to a room, before anything from the peer
+const relayHandshakeFrames = 3
+
+// maxWatchedFrame is the largest frame peerWatcher buffers; the frames before
+// the channel is secured are small, so a larger one ends the watch
+const maxWatchedFrame = 1024 * 1024
+
+// peerConnectedNotifier is implemented by transports that can tell when the
+// other side of a transfer has joined
+type peerConnectedNotifier interface {
+	setPeerConnectedHandler(handler func(sending bool))
+}
+
+// SetPeerConnectedHandler registers handler to be called when the other side
+// of a transfer joins and the secure channel is up, before any data moves.
+// sending is true on the sender, where it means the receiver connected. Only
+// transports with a handshake report it, currently croc; a nil handler
+// removes it.
+func (mtm *MultiTransportManager) SetPeerConnectedHandler(handler func(sending bool)) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	for _, transport := range mtm.transports {
+		if notifier, ok := transport.(peerConnectedNotifier); ok {
+			notifier.setPeerConnectedHandler(handler)
+		}
+	}
+}
+
+// peerWatcher follows the croc frames a relay sends one client and calls
+// joined once the first frame encrypted with the peer's key arrives. After
+// the relay handshake the peer's messages are unencrypted PAKE messages until
+// the key is agreed, so that frame is the sign croc has secured the channel.
+// It sees the stream through the relay proxy, so croc's own state is never
+// read while croc is writing it.
+type peerWatcher struct {
+	joined  func()
+	buffer  []byte
+	frames  int
+	stopped bool
+}
+
+// Write implements io.Writer for the bytes the relay sends
+func (w *peerWatcher) Write(p []byte) (int, error) {
+	if w.stopped {
+		return len(p), nil
+	}
+	w.buffer = append(w.buffer, p...)
+	for !w.stopped {
+		frame, ok := w.nextFrame()
+		if !ok {
+			break
+		}
+		w.frames++
+		if w.frames <= relayHandshakeFrames || bytes.Equal(frame, []byte{1}) {
+			// Relay handshake or the relay's keepalive while the peer is away
+			continue
+		}
+		if _, err := message.Decode(nil, frame); err != nil {
+			w.stopped = true
+			w.buffer = nil
+			w.joined()
+		}
+	}
+	return len(p), nil
+}
+
+// nextFrame removes and returns the next whole frame in the buffer. A stream
+// that is not croc framing, or a frame too large to be part of the
+// handshake, stops the watch.
+func (w *peerWatcher) nextFrame() ([]byte, bool) {
+	header := len(comm.MAGIC_BYTES) + 4
+	if len(w.buffer) < header {
+		return nil, false
+	}
+	length := int(binary.LittleEndian.Uint32(w.buffer[len(comm.MAGIC_BYTES):header]))
+	if !bytes.HasPrefix(w.buffer, comm.MAGIC_BYTES) || length > maxWatchedFrame {
+		w.stopped = true
+		w.buffer = nil
+		return nil, false
+	}
+	if len(w.buffer) < header+length {
+		return nil, false
+	}
+	frame := w.buffer[header : header+length]
+	w.buffer = w.buffer[header+length:]
+	return frame, true
+}
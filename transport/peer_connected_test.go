@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+
+	"github.com/schollz/croc/v10/src/comm"
+	"github.com/schollz/croc/v10/src/message"
+)
+
+// crocFrame frames payload the way croc's comm package does
+func crocFrame(payload []byte) []byte {
+	frame := append([]byte{}, comm.MAGIC_BYTES...)
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func TestPeerWatcherWaitsForSecuredChannel(t *testing.T) {
+	pake, err := message.Encode(nil, message.Message{Type: message.TypePAKE, Bytes: []byte("pake")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secured := make([]byte, 64)
+	rand.Read(secured)
+
+	var stream bytes.Buffer
+	for i := 0; i < relayHandshakeFrames; i++ {
+		stream.Write(crocFrame([]byte("relay handshake")))
+	}
+	stream.Write(crocFrame([]byte{1}))
+	stream.Write(crocFrame(pake))
+	beforeSecured := stream.Len()
+	stream.Write(crocFrame(secured))
+
+	joined := 0
+	watcher := &peerWatcher{joined: func() { joined++ }}
+	data := stream.Bytes()
+
+	// Feed it in small pieces, as a TCP stream arrives
+	for i := 0; i < beforeSecured; i += 5 {
+		watcher.Write(data[i:min(i+5, beforeSecured)])
+	}
+	if joined != 0 {
+		t.Fatal("peer reported before the channel was secured")
+	}
+	watcher.Write(data[beforeSecured:])
+	watcher.Write(crocFrame(secured))
+	if joined != 1 {
+		t.Fatalf("peer reported %d times, want once", joined)
+	}
+}
+
+func TestPeerWatcherIgnoresOtherStreams(t *testing.T) {
+	joined := false
+	watcher := &peerWatcher{joined: func() { joined = true }}
+	watcher.Write([]byte("HTTP/1.1 200 OK\r\n\r\nnot croc at all"))
+	if joined || !watcher.stopped {
+		t.Fatalf("watcher did not stop on a stream that is not croc framing")
+	}
+}
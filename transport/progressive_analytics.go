@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"trustdrop-bulletproof/internal"
+	"trustdrop-bulletproof/logging"
+)
+
+// analyticsFileName stores transport history per network type in the state directory
+const analyticsFileName = "transport_analytics.json"
+
+// networkTypeBias is added to a transport's reliability score on each network
+// type, so a first transfer on a network starts from what usually works there
+// and learned history refines it. Managed networks tend to block the croc relay
+// ports but allow WebRTC and HTTPS; open networks reach the relay directly.
+var networkTypeBias = map[string]map[string]float64{
+	"corporate": {
+		"ice-webrtc":    0.15,
+		"https-443":     0.10,
+		"websocket-443": 0.05,
+		"croc-relay":    -0.10,
+	},
+	"university": {
+		"ice-webrtc":    0.15,
+		"https-443":     0.10,
+		"websocket-443": 0.05,
+		"croc-relay":    -0.10,
+	},
+	"high-latency-international": {
+		"https-443":  0.05,
+		"croc-relay": 0.05,
+	},
+	"moderate-latency-international": {
+		"croc-relay": 0.10,
+	},
+	"home": {
+		"croc-relay": 0.15,
+	},
+	"mobile": {
+		"croc-relay": 0.10,
+		"https-443":  0.05,
+	},
+}
+
+// SetNetworkType tells the manager which network it is on, normally the
+// MultiTransportManager's detected NetworkProfile.NetworkType. Transport order
+// is biased for that network type and the history learned on it in earlier
+// sessions is loaded; Close saves it back.
+func (ptm *ProgressiveTransportManager) SetNetworkType(networkType string) {
+	ptm.analytics.NetworkType = networkType
+
+	saved, err := loadTransportAnalytics()
+	if err != nil {
+		logging.Warnf("Could not load transport analytics: %v", err)
+		return
+	}
+
+	previous, ok := saved[networkType]
+	if !ok {
+		return
+	}
+	for _, layer := range ptm.transports {
+		if history, ok := previous.SuccessHistory[layer.Name]; ok {
+			ptm.analytics.SuccessHistory[layer.Name] = history
+		}
+		if history, ok := previous.LatencyHistory[layer.Name]; ok {
+			ptm.analytics.LatencyHistory[layer.Name] = history
+		}
+		if patterns, ok := previous.ErrorPatterns[layer.Name]; ok && patterns != nil {
+			ptm.analytics.ErrorPatterns[layer.Name] = patterns
+		}
+	}
+	logging.Debugf("Loaded transport history for %s networks from %v", networkType, previous.LastUpdate)
+}
+
+// networkBias returns the ordering bias for a transport on the current network
+func (ptm *ProgressiveTransportManager) networkBias(transportName string) float64 {
+	return networkTypeBias[ptm.analytics.NetworkType][transportName]
+}
+
+// saveAnalytics stores this session's history under its network type
+func (ptm *ProgressiveTransportManager) saveAnalytics() error {
+	networkType := ptm.analytics.NetworkType
+	if networkType == "" || networkType == "unknown" || networkType == "loopback" {
+		return nil
+	}
+
+	saved, err := loadTransportAnalytics()
+	if err != nil {
+		// A corrupt file is replaced rather than blocking learning for good
+		saved = make(map[string]*TransportAnalytics)
+	}
+	saved[networkType] = ptm.analytics
+
+	dir, err := internal.EnsureStateDir()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transport analytics: %w", err)
+	}
+
+	path := filepath.Join(dir, analyticsFileName)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save transport analytics: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to save transport analytics: %w", err)
+	}
+	return nil
+}
+
+// loadTransportAnalytics reads saved history keyed by network type. A missing
+// file gives an empty map.
+func loadTransportAnalytics() (map[string]*TransportAnalytics, error) {
+	saved := make(map[string]*TransportAnalytics)
+
+	data, err := os.ReadFile(filepath.Join(internal.StateDir(), analyticsFileName))
+	if os.IsNotExist(err) {
+		return saved, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse transport analytics: %w", err)
+	}
+	return saved, nil
+}
@@ -7,6 +7,8 @@ import (
 	"net"
 	"strings"
 	"time"
+
+	"trustdrop-bulletproof/logging"
 )
 
 // ProgressiveTransportManager implements intelligent transport learning and adaptation
@@ -26,11 +28,11 @@ type TransportLayer struct {
 
 // TransportAnalytics tracks transport performance for learning
 type TransportAnalytics struct {
-	NetworkType    string
-	SuccessHistory map[string][]bool          // transport -> recent success/failures
-	LatencyHistory map[string][]time.Duration // transport -> recent latencies
-	ErrorPatterns  map[string]map[string]int  // transport -> error_type -> count
-	LastUpdate     time.Time
+	NetworkType    string                     `json:"-"`               // Set by SetNetworkType; saved history is keyed by it
+	SuccessHistory map[string][]bool          `json:"success_history"` // transport -> recent success/failures
+	LatencyHistory map[string][]time.Duration `json:"latency_history"` // transport -> recent latencies
+	ErrorPatterns  map[string]map[string]int  `json:"error_patterns"`  // transport -> error_type -> count
+	LastUpdate     time.Time                  `json:"last_update"`
 }
 
 // NewProgressiveTransportManager creates a new learning transport manager
@@ -60,7 +62,7 @@ func NewProgressiveTransportManager() *ProgressiveTransportManager {
 		ptm.analytics.ErrorPatterns[layer.Name] = make(map[string]int)
 	}
 
-	fmt.Printf("Progressive transport manager initialized with %d transports\n", len(ptm.transports))
+	logging.Debugf("Progressive transport manager initialized with %d transports", len(ptm.transports))
 	return ptm
 }
 
@@ -68,11 +70,11 @@ func (ptm *ProgressiveTransportManager) SendWithIntelligentFallback(data []byte,
 	// INTERNATIONAL RELIABILITY STRATEGY
 	orderedTransports := ptm.getInternationalOptimizedOrder()
 
-	fmt.Printf("🌍 Starting international intelligent transport fallback with %d options\n", len(orderedTransports))
+	logging.Debugf("Starting international intelligent transport fallback with %d options", len(orderedTransports))
 
 	// Pre-transfer network quality assessment
 	networkQuality := ptm.assessNetworkQuality()
-	fmt.Printf("📊 Network quality score: %.1f/10\n", networkQuality)
+	logging.Debugf("Network quality score: %.1f/10", networkQuality)
 
 	for i, layer := range orderedTransports {
 		startTime := time.Now()
@@ -83,7 +85,7 @@ func (ptm *ProgressiveTransportManager) SendWithIntelligentFallback(data []byte,
 			timeoutMultiplier *= 2.0 // Double timeouts for poor networks
 		}
 
-		fmt.Printf("🚀 International attempt %d/%d: %s (quality-adjusted timeout: %.1fx)\n",
+		logging.Debugf("International attempt %d/%d: %s (quality-adjusted timeout: %.1fx)",
 			i+1, len(orderedTransports), layer.Name, timeoutMultiplier)
 
 		// Create context with quality-adjusted timeout
@@ -112,18 +114,18 @@ func (ptm *ProgressiveTransportManager) SendWithIntelligentFallback(data []byte,
 		ptm.recordInternationalAttempt(layer.Name, err == nil, latency, err, networkQuality)
 
 		if err == nil {
-			fmt.Printf("✅ International success via %s in %v (quality: %.1f)\n", layer.Name, latency, networkQuality)
+			logging.Debugf("International success via %s in %v (quality: %.1f)", layer.Name, latency, networkQuality)
 			ptm.updateSuccessRate(layer.Name, true)
 			return nil
 		}
 
-		fmt.Printf("❌ %s failed in %v: %v\n", layer.Name, latency, err)
+		logging.Debugf("%s failed in %v: %v", layer.Name, latency, err)
 		ptm.updateSuccessRate(layer.Name, false)
 
 		// International-aware progressive backoff
 		if i < len(orderedTransports)-1 {
 			delay := ptm.calculateInternationalBackoff(i, networkQuality, err)
-			fmt.Printf("⏳ Waiting %v before next international attempt...\n", delay)
+			logging.Debugf("Waiting %v before next international attempt...", delay)
 			time.Sleep(delay)
 		}
 	}
@@ -135,12 +137,12 @@ func (ptm *ProgressiveTransportManager) SendWithIntelligentFallback(data []byte,
 func (ptm *ProgressiveTransportManager) ReceiveWithIntelligentFallback(metadata TransferMetadata) ([]byte, error) {
 	orderedTransports := ptm.getNetworkOptimizedOrder()
 
-	fmt.Printf("🎯 Starting intelligent receive with %d options\n", len(orderedTransports))
+	logging.Debugf("Starting intelligent receive with %d options", len(orderedTransports))
 
 	for i, layer := range orderedTransports {
 		startTime := time.Now()
 
-		fmt.Printf("🔄 Receive attempt %d/%d: %s\n", i+1, len(orderedTransports), layer.Name)
+		logging.Debugf("Receive attempt %d/%d: %s", i+1, len(orderedTransports), layer.Name)
 
 		data, err := layer.Transport.Receive(metadata)
 		latency := time.Since(startTime)
@@ -149,13 +151,13 @@ func (ptm *ProgressiveTransportManager) ReceiveWithIntelligentFallback(metadata
 		ptm.recordAttempt(layer.Name, err == nil && len(data) > 0, latency, err)
 
 		if err == nil && len(data) > 0 {
-			fmt.Printf("✅ Received %d bytes via %s in %v\n", len(data), layer.Name, latency)
+			logging.Debugf("Received %d bytes via %s in %v", len(data), layer.Name, latency)
 			ptm.updateSuccessRate(layer.Name, true)
 			return data, nil
 		}
 
 		if err != nil {
-			fmt.Printf("❌ %s receive failed in %v: %v\n", layer.Name, latency, err)
+			logging.Debugf("%s receive failed in %v: %v", layer.Name, latency, err)
 		}
 		ptm.updateSuccessRate(layer.Name, false)
 
@@ -183,13 +185,14 @@ func (ptm *ProgressiveTransportManager) getNetworkOptimizedOrder() []TransportLa
 		recentSuccess := ptm.getRecentSuccessRate(name)
 		avgLatency := ptm.getAverageLatency(name)
 
-		// Weighted scoring: 70% success rate, 30% speed
-		reliabilityScore := recentSuccess*0.7 + (1.0-ptm.normalizeLatency(avgLatency))*0.3
+		// Weighted scoring: 70% success rate, 30% speed, plus what usually
+		// works on this type of network
+		reliabilityScore := recentSuccess*0.7 + (1.0-ptm.normalizeLatency(avgLatency))*0.3 + ptm.networkBias(name)
 		optimized[i].ReliabilityScore = reliabilityScore
 		optimized[i].SuccessRate = recentSuccess
 		optimized[i].AvgLatency = avgLatency
 
-		fmt.Printf("📊 %s: Success=%.1f%%, Latency=%v, Score=%.2f\n",
+		logging.Debugf("%s: Success=%.1f%%, Latency=%v, Score=%.2f",
 			name, recentSuccess*100, avgLatency, reliabilityScore)
 	}
 
@@ -333,15 +336,12 @@ func (ptm *ProgressiveTransportManager) classifyError(err error) string {
 	}
 }
 
-// contains checks if any of the patterns exist in the string
+// contains checks if any of the patterns exist in the string, ignoring case
 func contains(str string, patterns ...string) bool {
+	str = strings.ToLower(str)
 	for _, pattern := range patterns {
-		if len(str) >= len(pattern) {
-			for i := 0; i <= len(str)-len(pattern); i++ {
-				if str[i:i+len(pattern)] == pattern {
-					return true
-				}
-			}
+		if strings.Contains(str, strings.ToLower(pattern)) {
+			return true
 		}
 	}
 	return false
@@ -374,8 +374,13 @@ func (ptm *ProgressiveTransportManager) GetTransportStatus() map[string]interfac
 	return status
 }
 
-// Close cleans up the progressive transport manager
+// Close saves the learned history for the network type and cleans up the
+// progressive transport manager
 func (ptm *ProgressiveTransportManager) Close() error {
+	if err := ptm.saveAnalytics(); err != nil {
+		logging.Warnf("Could not save transport analytics: %v", err)
+	}
+
 	for _, layer := range ptm.transports {
 		if err := layer.Transport.Close(); err != nil {
 			log.Printf("Error closing transport %s: %v", layer.Name, err)
@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	ptm := &ProgressiveTransportManager{}
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "success"},
+		{errors.New("dial tcp 1.2.3.4:9009: connect: connection refused"), "network_blocked"},
+		{errors.New("Network Unreachable"), "network_blocked"},
+		{errors.New("i/o timeout"), "timeout"},
+		{errors.New("context deadline exceeded"), "timeout"},
+		{errors.New("TIMEOUT waiting for peer"), "timeout"},
+		{errors.New("lookup relay.example: no such host"), "dns_failure"},
+		{errors.New("DNS resolution failed"), "dns_failure"},
+		{errors.New("Proxy returned 407"), "proxy_issue"},
+		{errors.New("authentication required"), "proxy_issue"},
+		{errors.New("failed to Decrypt payload"), "security_error"},
+		{errors.New("Security check failed"), "security_error"},
+		{errors.New("something else went wrong"), "unknown"},
+	}
+
+	for _, test := range tests {
+		if got := ptm.classifyError(test.err); got != test.want {
+			t.Errorf("classifyError(%v) = %q, want %q", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package transport
+
+// recordInitResult keeps the outcome of initializeTransports for Readiness
+func (mtm *MultiTransportManager) recordInitResult(ready int, failures []string) {
+	mtm.stateMutex.Lock()
+	defer mtm.stateMutex.Unlock()
+	mtm.readyTransports = ready
+	mtm.initFailures = failures
+}
+
+// Readiness returns how many transports initialized and, for each that did
+// not, "name: error". It does not wait for a transfer in progress.
+func (mtm *MultiTransportManager) Readiness() (int, []string) {
+	mtm.stateMutex.RLock()
+	defer mtm.stateMutex.RUnlock()
+	return mtm.readyTransports, append([]string(nil), mtm.initFailures...)
+}
@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"time"
+
+	"github.com/schollz/croc/v10/src/croc"
+)
+
+// receiveProgressNotifier is implemented by transports that learn the size of
+// an incoming payload before it arrives and can report it arriving
+type receiveProgressNotifier interface {
+	setReceiveProgressHandler(handler func(received, total int64))
+}
+
+// SetReceiveProgressHandler registers handler to be called as a payload is
+// received: first with 0 bytes once the sender has announced the payload
+// size, then as bytes arrive. total is the size of the payload on the wire,
+// which includes encryption and framing. croc reports it from the file info
+// exchanged before the data, and ICE from the sender's announcement. A nil
+// handler removes it.
+func (mtm *MultiTransportManager) SetReceiveProgressHandler(handler func(received, total int64)) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	for _, transport := range mtm.transports {
+		if notifier, ok := transport.(receiveProgressNotifier); ok {
+			notifier.setReceiveProgressHandler(handler)
+		}
+	}
+}
+
+// setReceiveProgressHandler sets the handler told how much of a received
+// payload has arrived
+func (t *SimpleCrocTransport) setReceiveProgressHandler(handler func(received, total int64)) {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	t.receiveProgress = handler
+}
+
+// watchReceiveProgress reports a receiving croc session's progress through
+// the receive progress handler: the payload size once croc's file info has
+// been exchanged, then the bytes received. Like watchForPeer it polls, as croc
+// has no callbacks, until stop is closed.
+func (t *SimpleCrocTransport) watchReceiveProgress(client *croc.Client, stop <-chan struct{}) {
+	t.sessionMutex.Lock()
+	handler := t.receiveProgress
+	t.sessionMutex.Unlock()
+	if handler == nil {
+		return
+	}
+
+	ticker := time.NewTicker(peerPollInterval)
+	defer ticker.Stop()
+	reported := int64(-1)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if !client.Step2FileInfoTransferred || len(client.FilesToTransfer) == 0 {
+			continue
+		}
+		var total int64
+		for _, file := range client.FilesToTransfer {
+			total += file.Size
+		}
+		if received := min(client.TotalSent, total); received != reported {
+			reported = received
+			handler(received, total)
+		}
+	}
+}
+
+// setReceiveProgressHandler sets the handler told how much of a received
+// payload has arrived
+func (t *ICETransport) setReceiveProgressHandler(handler func(received, total int64)) {
+	t.receiveProgress = handler
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// ErrConnectionDropped is matched by errors.Is when a transfer's connection
+// failed after the other side had joined. Retrying with the same code
+// reconnects to the same peer, and a receive retried with
+// TransferMetadata.Resume set continues from the data it already has.
+var ErrConnectionDropped = errors.New("connection dropped mid-transfer")
+
+// crocPayloadName is the name every payload is sent under. It stays the same
+// across attempts so a reconnected receiver matches it against its partial
+// file, and croc requests only the chunks still missing from it.
+const crocPayloadName = "trustdrop-payload.bin"
+
+// crocReceivePrefix names the per-transfer receive directories
+const crocReceivePrefix = "croc_receive_"
+
+// staleReceiveDirAge is how long a dropped receive is kept for a reconnect
+// before its partial data is removed
+const staleReceiveDirAge = 24 * time.Hour
+
+// droppedAfterPeerJoined marks a croc failure as a dropped connection when
+// the peer had joined, since the session then failed mid-transfer rather than
+// never starting
+func droppedAfterPeerJoined(peerJoined bool, err error) error {
+	if err == nil || !peerJoined {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrConnectionDropped, err)
+}
+
+// crocReceiveDir returns the directory receives of transferID write into. It
+// is derived from the transfer code, which it must not reveal.
+func crocReceiveDir(base, transferID string) string {
+	if base == "" {
+		base = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(transferID))
+	return filepath.Join(base, crocReceivePrefix+hex.EncodeToString(sum[:8]))
+}
+
+// prepareCrocReceiveDir creates the receive directory for transferID. Unless
+// resume is set, anything an earlier receive left there is cleared first so
+// croc cannot mistake it for part of this transfer.
+func prepareCrocReceiveDir(base, transferID string, resume bool) (string, error) {
+	removeStaleReceiveDirs(base)
+
+	dir := crocReceiveDir(base, transferID)
+	if !resume {
+		if err := os.RemoveAll(dir); err != nil {
+			return "", fmt.Errorf("failed to clear receive dir: %w", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return dir, nil
+}
+
+// removeStaleReceiveDirs removes receive directories left by dropped
+// transfers that were never resumed
+func removeStaleReceiveDirs(base string) {
+	if base == "" {
+		base = os.TempDir()
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), crocReceivePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < staleReceiveDirAge {
+			continue
+		}
+		path := filepath.Join(base, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			logging.Debugf("Could not remove stale receive dir %s: %v", path, err)
+		}
+	}
+}
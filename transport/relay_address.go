@@ -0,0 +1,177 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// relayResolveTimeout bounds the DNS lookup of each configured relay host
+const relayResolveTimeout = 3 * time.Second
+
+// RelayAddressError reports a configured relay address that was skipped
+type RelayAddressError struct {
+	Address string
+	Err     error
+}
+
+func (e *RelayAddressError) Error() string {
+	return fmt.Sprintf("relay %q skipped: %v", e.Address, e.Err)
+}
+
+func (e *RelayAddressError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateRelayAddresses checks configured relays, which must be written as
+// host:port. Entries with a missing or out-of-range port, a host that does
+// not exist, or a host that resolves to this machine are left out and
+// returned as RelayAddressErrors rather than rewritten; dialing this machine
+// in LAN mode would only loop back. A host that cannot be looked up for any
+// other reason, such as being offline at launch, is kept. Entries that reach
+// the same address and port as an earlier one, such as a relay listed by both
+// IP and name, are dropped so it is not tried twice.
+func ValidateRelayAddresses(addresses []string) (valid []string, invalid []error) {
+	// Parse everything first so each distinct host is looked up once, in parallel
+	hosts := make([]string, len(addresses))
+	ports := make([]string, len(addresses))
+	errs := make([]error, len(addresses))
+	lookups := make(map[string]*relayLookup)
+	for i, address := range addresses {
+		hosts[i], ports[i], errs[i] = parseRelayAddress(address)
+		if errs[i] != nil || net.ParseIP(hosts[i]) != nil {
+			continue
+		}
+		if _, ok := lookups[hosts[i]]; !ok {
+			lookups[hosts[i]] = startRelayLookup(hosts[i])
+		}
+	}
+
+	local := localAddresses()
+	seen := make(map[string]string) // ip:port, or host:port when unresolved -> entry that claimed it
+	for i, address := range addresses {
+		err := errs[i]
+		ips := []string{hosts[i]}
+		if lookup, ok := lookups[hosts[i]]; ok && err == nil {
+			<-lookup.done
+			var dnsErr *net.DNSError
+			if errors.As(lookup.err, &dnsErr) && dnsErr.IsNotFound {
+				err = fmt.Errorf("host %s does not exist", hosts[i])
+			} else if lookup.err != nil {
+				logging.Warnf("Could not look up relay %s, keeping it: %v", address, lookup.err)
+			} else {
+				ips = lookup.addrs
+			}
+		}
+		if err == nil && resolvesToLocal(ips, local) {
+			err = fmt.Errorf("host %s is this machine", hosts[i])
+		}
+
+		if err != nil {
+			invalid = append(invalid, &RelayAddressError{Address: address, Err: err})
+			continue
+		}
+
+		address = strings.TrimSpace(address)
+		if earlier, ok := firstSeen(seen, ips, ports[i]); ok {
+			logging.Debugf("Relay %s reaches the same server as %s, skipping it", address, earlier)
+			continue
+		}
+		for _, ip := range ips {
+			seen[net.JoinHostPort(strings.ToLower(ip), ports[i])] = address
+		}
+		valid = append(valid, address)
+	}
+	return valid, invalid
+}
+
+// firstSeen returns the earlier relay entry that claimed any of ips on port
+func firstSeen(seen map[string]string, ips []string, port string) (string, bool) {
+	for _, ip := range ips {
+		if earlier, ok := seen[net.JoinHostPort(strings.ToLower(ip), port)]; ok {
+			return earlier, true
+		}
+	}
+	return "", false
+}
+
+// localAddresses returns the IP addresses of this machine's interfaces
+func localAddresses() map[string]bool {
+	local := make(map[string]bool)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return local
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			local[ipNet.IP.String()] = true
+		}
+	}
+	return local
+}
+
+// resolvesToLocal reports whether any of a relay's addresses is loopback,
+// unspecified or one of this machine's own
+func resolvesToLocal(ips []string, local map[string]bool) bool {
+	for _, address := range ips {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			continue
+		}
+		if ip.IsLoopback() || ip.IsUnspecified() || local[ip.String()] {
+			return true
+		}
+	}
+	return false
+}
+
+// relayLookup is a DNS lookup of a relay host running in the background
+type relayLookup struct {
+	addrs []string
+	err   error
+	done  chan struct{}
+}
+
+// startRelayLookup resolves host, closing done when finished
+func startRelayLookup(host string) *relayLookup {
+	lookup := &relayLookup{done: make(chan struct{})}
+	go func() {
+		defer close(lookup.done)
+		ctx, cancel := context.WithTimeout(context.Background(), relayResolveTimeout)
+		defer cancel()
+		lookup.addrs, lookup.err = net.DefaultResolver.LookupHost(ctx, host)
+	}()
+	return lookup
+}
+
+// parseRelayAddress checks the form of a host:port relay address and returns
+// the host and port
+func parseRelayAddress(address string) (string, string, error) {
+	host, port, err := net.SplitHostPort(strings.TrimSpace(address))
+	if err != nil {
+		return "", "", fmt.Errorf("expected host:port: %w", err)
+	}
+	if host == "" {
+		return "", "", errors.New("missing host")
+	}
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return "", "", fmt.Errorf("port %q is not a number", port)
+	}
+	if number < 1 || number > 65535 {
+		return "", "", fmt.Errorf("port %d is out of range", number)
+	}
+	return host, strconv.Itoa(number), nil
+}
+
+// RelayConfigProblems returns the configured relays that were skipped at
+// startup because their address is invalid or is this machine
+func (mtm *MultiTransportManager) RelayConfigProblems() []error {
+	return mtm.relayProblems
+}
@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultRelayPassword is the password of the public croc relays
+const DefaultRelayPassword = "pass123"
+
+// ErrRelayRejected is matched by errors.Is when a croc relay turned the
+// connection away, usually because the relay password does not match the one
+// the relay was started with
+var ErrRelayRejected = errors.New("relay rejected the connection")
+
+// relayRejectedKeywords appear in croc errors when a relay refuses the
+// password; croc relays answer a wrong one with "bad password"
+var relayRejectedKeywords = []string{
+	"bad password",
+	"incorrect password",
+	"invalid password",
+}
+
+// relayPasswordConfigurable is implemented by transports that authenticate to a relay
+type relayPasswordConfigurable interface {
+	setRelayPassword(password string)
+}
+
+// SetRelayPassword sets the password used to authenticate to croc relays, for
+// private relays started with their own password. An empty password uses
+// DefaultRelayPassword.
+func (mtm *MultiTransportManager) SetRelayPassword(password string) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	mtm.config.RelayPassword = password
+	for _, transport := range mtm.transports {
+		if configurable, ok := transport.(relayPasswordConfigurable); ok {
+			configurable.setRelayPassword(password)
+		}
+	}
+}
+
+// relayPassword returns the configured relay password or the public default
+func (c TransportConfig) relayPassword() string {
+	if c.RelayPassword == "" {
+		return DefaultRelayPassword
+	}
+	return c.RelayPassword
+}
+
+// IsRelayRejected reports whether err shows a relay refusing the connection
+// rather than the network failing to reach it
+func IsRelayRejected(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrRelayRejected) {
+		return true
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	for _, keyword := range relayRejectedKeywords {
+		if strings.Contains(errorStr, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// relayRejected marks a croc session error from relay as a rejection when it
+// is one, and returns other errors unchanged
+func relayRejected(relay string, err error) error {
+	if errors.Is(err, ErrRelayRejected) || !IsRelayRejected(err) {
+		return err
+	}
+	return fmt.Errorf("%w by %s (check the relay password): %w", ErrRelayRejected, relay, err)
+}
+
+// failoverCause picks the error to report after a transport fails with err,
+// given the one kept so far. A relay rejection is kept over later failures,
+// since it names a setting the user can fix.
+func failoverCause(kept, err error) error {
+	if errors.Is(kept, ErrRelayRejected) {
+		return kept
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// crocBadPassword is what croc returns when a relay refuses the relay password
+var crocBadPassword = errors.New("bad response: bad password")
+
+func TestIsRelayRejected(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{crocBadPassword, true},
+		{fmt.Errorf("croc session: %w", crocBadPassword), true},
+		{errors.New("Incorrect Password"), true},
+		{ErrRelayRejected, true},
+		{errors.New("dial tcp 1.2.3.4:9009: connect: connection refused"), false},
+		{errors.New("room full"), false},
+	} {
+		if got := IsRelayRejected(test.err); got != test.want {
+			t.Errorf("IsRelayRejected(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestRelayRejectedWrapsRejections(t *testing.T) {
+	err := relayRejected("relay.example:9009", crocBadPassword)
+	if !errors.Is(err, ErrRelayRejected) || !errors.Is(err, crocBadPassword) {
+		t.Fatalf("rejection not wrapped with its cause: %v", err)
+	}
+	if again := relayRejected("relay.example:9009", err); again != err {
+		t.Fatalf("rejection wrapped twice: %v", again)
+	}
+
+	refused := errors.New("connection refused")
+	if got := relayRejected("relay.example:9009", refused); got != refused {
+		t.Fatalf("other failure changed to %v", got)
+	}
+}
+
+func TestFailoverKeepsRelayRejection(t *testing.T) {
+	rejected := relayRejected("relay.example:9009", crocBadPassword)
+	later := errors.New("https: no route to host")
+	if got := failoverCause(rejected, later); got != rejected {
+		t.Fatalf("failover reported %v over the relay rejection", got)
+	}
+	if got := failoverCause(later, rejected); got != rejected {
+		t.Fatalf("failover reported %v, want the relay rejection", got)
+	}
+}
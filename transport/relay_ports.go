@@ -0,0 +1,166 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/schollz/croc/v10/src/comm"
+
+	"trustdrop-bulletproof/logging"
+)
+
+// crocRelayPorts are the relay ports probed, most likely to pass a corporate
+// firewall first
+var crocRelayPorts = []string{
+	"443",                  // HTTPS - highest success rate in corporate networks
+	"80",                   // HTTP - second highest success rate
+	"8080",                 // Alternative HTTP - common corporate allowlist
+	"8443",                 // Alternative HTTPS - backup option
+	"9009", "9010", "9011", // CROC standard ports
+}
+
+// relayPortProbeTimeout bounds each relay port probe, before the timeout
+// multiplier is applied
+const relayPortProbeTimeout = 4 * time.Second
+
+// relayPortGrace is how long slower ports get to answer after the first one
+const relayPortGrace = 500 * time.Millisecond
+
+// relayPortProbe is the outcome of probing one relay port
+type relayPortProbe struct {
+	port string
+	err  error
+}
+
+// pingRelay checks that a croc relay answers on address. A port that accepts
+// connections but is not a relay, such as a web server, fails.
+func pingRelay(ctx context.Context, address string, timeout time.Duration) error {
+	conn, err := comm.NewConnection(address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// comm sets its own read deadlines, so closing is the way to give up
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, conn.Close)
+	defer stop()
+
+	if err := conn.Send([]byte("ping")); err != nil {
+		return err
+	}
+	reply, err := conn.Receive()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(reply, []byte("pong")) {
+		return fmt.Errorf("not a croc relay")
+	}
+	return nil
+}
+
+// probeRelayPorts checks every port of host in parallel with ping and
+// returns the ones that answered, fastest first. Once one port answers, the
+// others get relayPortGrace to do the same rather than the full probe
+// timeout.
+func probeRelayPorts(ctx context.Context, host string, ports []string, ping func(ctx context.Context, address string) error) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan relayPortProbe, len(ports))
+	for _, port := range ports {
+		go func() {
+			err := ping(ctx, net.JoinHostPort(host, port))
+			results <- relayPortProbe{port: port, err: err}
+		}()
+	}
+
+	var answered, failed []string
+	var grace <-chan time.Time
+collect:
+	for range ports {
+		select {
+		case probe := <-results:
+			if probe.err != nil {
+				failed = append(failed, probe.port)
+				continue
+			}
+			// Answers arrive fastest first
+			answered = append(answered, probe.port)
+			if grace == nil {
+				grace = time.After(relayPortGrace)
+			}
+		case <-grace:
+			break collect
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if len(answered) == 0 {
+		return nil, fmt.Errorf("relay %s did not answer on any port (tried %s)", host, strings.Join(failed, ", "))
+	}
+	logging.Debugf("Relay %s answered on ports %s (fastest first); no answer on: %s",
+		host, strings.Join(answered, ", "), strings.Join(failed, ", "))
+	return answered, nil
+}
+
+// relayPorts returns the ports of relay to hand croc, the one to connect on
+// first. The port that last carried a session is reused while it still
+// answers; otherwise every candidate is probed. Ports that answered within
+// the connectivity cache's TTL are not pinged again.
+func (t *SimpleCrocTransport) relayPorts(ctx context.Context, relay string) ([]string, error) {
+	t.relayPortMutex.Lock()
+	winner := t.relayPortWinners[relay]
+	t.relayPortMutex.Unlock()
+
+	timeout := t.config.scaleTimeout(relayPortProbeTimeout)
+	ping := func(ctx context.Context, address string) error {
+		return t.connectivity.Confirm(ctx, address, func(ctx context.Context) error {
+			return pingRelay(ctx, address, timeout)
+		})
+	}
+	if winner != "" {
+		traced := logging.TraceSpan("relay", "dial %s on remembered port %s", relay, winner)
+		err := ping(ctx, net.JoinHostPort(relay, winner))
+		traced(err)
+		if err == nil {
+			return []string{winner}, nil
+		}
+		t.forgetRelayPort(relay)
+	}
+	traced := logging.TraceSpan("relay", "dial %s on ports %s", relay, strings.Join(crocRelayPorts, ", "))
+	ports, err := probeRelayPorts(ctx, relay, crocRelayPorts, ping)
+	traced(err)
+	if err == nil {
+		logging.Tracef("relay", "%s answered on ports %s", relay, strings.Join(ports, ", "))
+	}
+	return ports, err
+}
+
+// rememberRelayPort records the port a session on relay succeeded through,
+// so later attempts go straight to it
+func (t *SimpleCrocTransport) rememberRelayPort(relay, port string) {
+	t.relayPortMutex.Lock()
+	defer t.relayPortMutex.Unlock()
+	if t.relayPortWinners == nil {
+		t.relayPortWinners = make(map[string]string)
+	}
+	t.relayPortWinners[relay] = port
+}
+
+// forgetRelayPort drops the remembered port of relay after it failed, along
+// with the cached answers of its ports
+func (t *SimpleCrocTransport) forgetRelayPort(relay string) {
+	t.relayPortMutex.Lock()
+	delete(t.relayPortWinners, relay)
+	t.relayPortMutex.Unlock()
+	for _, port := range crocRelayPorts {
+		t.connectivity.Invalidate(net.JoinHostPort(relay, port))
+	}
+}
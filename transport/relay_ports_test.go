@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingPing answers for the ports in delays after their delay and fails
+// for every other port, counting the pings each address gets
+type countingPing struct {
+	delays map[string]time.Duration
+	mutex  sync.Mutex
+	pings  map[string]int
+}
+
+func (p *countingPing) ping(ctx context.Context, address string) error {
+	p.mutex.Lock()
+	p.pings[address]++
+	p.mutex.Unlock()
+
+	_, port, _ := net.SplitHostPort(address)
+	delay, ok := p.delays[port]
+	if !ok {
+		return errors.New("no relay here")
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *countingPing) count(address string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.pings[address]
+}
+
+func TestProbeRelayPortsFastestFirst(t *testing.T) {
+	relay := &countingPing{
+		delays: map[string]time.Duration{"443": 60 * time.Millisecond, "9009": 5 * time.Millisecond},
+		pings:  make(map[string]int),
+	}
+	ports, err := probeRelayPorts(context.Background(), "relay.test", crocRelayPorts, relay.ping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"9009", "443"}; !slices.Equal(ports, want) {
+		t.Errorf("ports = %v, want %v", ports, want)
+	}
+
+	relay.delays = nil
+	if _, err := probeRelayPorts(context.Background(), "relay.test", crocRelayPorts, relay.ping); err == nil {
+		t.Error("probe succeeded with no port answering")
+	}
+}
+
+func TestRelayProbesCachedBetweenAttempts(t *testing.T) {
+	relay := &countingPing{
+		delays: map[string]time.Duration{"443": time.Millisecond},
+		pings:  make(map[string]int),
+	}
+	cache := NewConnectivityCache(time.Minute)
+	ping := func(ctx context.Context, address string) error {
+		return cache.Confirm(ctx, address, func(ctx context.Context) error {
+			return relay.ping(ctx, address)
+		})
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, err := probeRelayPorts(context.Background(), "relay.test", crocRelayPorts, ping); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := relay.count("relay.test:443"); n != 1 {
+		t.Errorf("answering port pinged %d times in three attempts, want once", n)
+	}
+	if n := relay.count("relay.test:80"); n != 3 {
+		t.Errorf("silent port pinged %d times in three attempts, want every time", n)
+	}
+
+	cache.Invalidate("relay.test:443")
+	if _, err := probeRelayPorts(context.Background(), "relay.test", crocRelayPorts, ping); err != nil {
+		t.Fatal(err)
+	}
+	if n := relay.count("relay.test:443"); n != 2 {
+		t.Errorf("invalidated port pinged %d times, want it pinged again", n)
+	}
+}
@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// tempFileReader streams a received payload from disk and removes the
+// backing file (or directory) once the caller closes it
+type tempFileReader struct {
+	file    *os.File
+	cleanup string
+}
+
+// newTempFileReader opens path for reading; cleanup is removed on Close
+func newTempFileReader(path, cleanup string) (*tempFileReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		os.RemoveAll(cleanup)
+		return nil, fmt.Errorf("failed to open received file: %w", err)
+	}
+	return &tempFileReader{file: file, cleanup: cleanup}, nil
+}
+
+// Read implements io.Reader
+func (r *tempFileReader) Read(p []byte) (int, error) {
+	return r.file.Read(p)
+}
+
+// Name returns the path of the file backing the stream
+func (r *tempFileReader) Name() string {
+	return r.file.Name()
+}
+
+// Close closes the file and removes the temporary data
+func (r *tempFileReader) Close() error {
+	err := r.file.Close()
+	if removeErr := os.RemoveAll(r.cleanup); removeErr != nil && err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// readAllAndClose drains a received stream into memory for callers that
+// still need the payload as a byte slice
+func readAllAndClose(stream io.ReadCloser) ([]byte, error) {
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read received data: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"fmt"
+	"os"
+)
+
+// tempDirConfigurable is implemented by transports that write temp files
+type tempDirConfigurable interface {
+	setTempDir(dir string)
+}
+
+// SetTempDir changes where transports write temp files. An empty dir uses the
+// OS temp directory.
+func (mtm *MultiTransportManager) SetTempDir(dir string) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	mtm.config.TempDir = dir
+	for _, transport := range mtm.transports {
+		if configurable, ok := transport.(tempDirConfigurable); ok {
+			configurable.setTempDir(dir)
+		}
+	}
+}
+
+// ValidateTempDir checks that dir exists (creating it if needed) and that temp
+// files can be written there. An empty dir checks the OS temp directory.
+func ValidateTempDir(dir string) error {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create temp directory %s: %w", dir, err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".trustdrop-write-test-*")
+	if err != nil {
+		return fmt.Errorf("temp directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
@@ -0,0 +1,32 @@
+package transport
+
+import "time"
+
+// timeoutScalable is implemented by transports whose dial and operation
+// timeouts follow the network's timeout multiplier
+type timeoutScalable interface {
+	setTimeoutMultiplier(multiplier float64)
+}
+
+// SetTimeoutMultiplier scales the transports' dial and operation timeouts,
+// such as relay probes, croc sessions and ICE candidate tests, so slow or
+// restrictive networks get more time. 1 or less keeps the built-in timeouts.
+func (mtm *MultiTransportManager) SetTimeoutMultiplier(multiplier float64) {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	mtm.config.TimeoutMultiplier = multiplier
+	for _, transport := range mtm.transports {
+		if scalable, ok := transport.(timeoutScalable); ok {
+			scalable.setTimeoutMultiplier(multiplier)
+		}
+	}
+}
+
+// scaleTimeout returns base stretched by the configured timeout multiplier
+func (c TransportConfig) scaleTimeout(base time.Duration) time.Duration {
+	if c.TimeoutMultiplier <= 1 {
+		return base
+	}
+	return time.Duration(float64(base) * c.TimeoutMultiplier)
+}
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScaleTimeout(t *testing.T) {
+	const base = 10 * time.Second
+	for _, test := range []struct {
+		multiplier float64
+		want       time.Duration
+	}{
+		{0, base},
+		{0.5, base},
+		{1, base},
+		{2.5, 25 * time.Second},
+		{3.5, 35 * time.Second},
+	} {
+		if got := (TransportConfig{TimeoutMultiplier: test.multiplier}).scaleTimeout(base); got != test.want {
+			t.Errorf("multiplier %v: got %v, want %v", test.multiplier, got, test.want)
+		}
+	}
+}
+
+func TestSetTimeoutMultiplierReachesTransports(t *testing.T) {
+	croc := NewCrocTransport(60)
+	ice := NewICETransport(70)
+	mtm := &MultiTransportManager{transports: []Transport{croc, ice}}
+
+	mtm.SetTimeoutMultiplier(3.5)
+
+	if got, want := croc.config.scaleTimeout(relayPortProbeTimeout), relayPortProbeTimeout*7/2; got != want {
+		t.Errorf("croc relay probe timeout %v, want %v", got, want)
+	}
+	if got, want := ice.config.scaleTimeout(iceAcceptTimeout), iceAcceptTimeout*7/2; got != want {
+		t.Errorf("ICE accept timeout %v, want %v", got, want)
+	}
+
+	mtm.SetTimeoutMultiplier(1)
+	if got := croc.config.scaleTimeout(relayPortProbeTimeout); got != relayPortProbeTimeout {
+		t.Errorf("croc relay probe timeout %v after resetting the multiplier", got)
+	}
+}
@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Traffic is how many bytes transports carried: the encrypted, framed
+// payloads rather than the files inside them
+type Traffic struct {
+	Sent     int64
+	Received int64
+}
+
+// trafficCounter accumulates Traffic across sessions
+type trafficCounter struct {
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// Traffic returns the bytes carried since the manager was created. Take the
+// difference of two readings to measure one transfer. A send only counts once
+// its transport reports success, since a failed attempt cannot say how much
+// of the payload got through.
+func (mtm *MultiTransportManager) Traffic() Traffic {
+	return Traffic{
+		Sent:     mtm.traffic.sent.Load(),
+		Received: mtm.traffic.received.Load(),
+	}
+}
+
+// Since returns the traffic between an earlier reading and t
+func (t Traffic) Since(earlier Traffic) Traffic {
+	return Traffic{Sent: t.Sent - earlier.Sent, Received: t.Received - earlier.Received}
+}
+
+// countingReadCloser adds the bytes read through it to a counter
+type countingReadCloser struct {
+	io.ReadCloser
+	counter *atomic.Int64
+}
+
+// Read implements io.Reader
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.counter.Add(int64(n))
+	return n, err
+}
@@ -1,8 +1,11 @@
 package transport
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -11,6 +14,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"trustdrop-bulletproof/logging"
 )
 
 // Transport defines the interface for different transport protocols
@@ -24,6 +29,19 @@ type Transport interface {
 	Close() error
 }
 
+// StreamingTransport is implemented by transports that can hand received data
+// back as a stream backed by a temporary file instead of an in-memory buffer
+type StreamingTransport interface {
+	ReceiveStream(metadata TransferMetadata) (io.ReadCloser, error)
+}
+
+// ContextTransport is implemented by transports whose sessions stop when a
+// context is cancelled instead of being left to run out
+type ContextTransport interface {
+	SendContext(ctx context.Context, data []byte, metadata TransferMetadata) error
+	ReceiveStreamContext(ctx context.Context, metadata TransferMetadata) (io.ReadCloser, error)
+}
+
 // TransferMetadata contains information about the transfer
 type TransferMetadata struct {
 	TransferID  string `json:"transfer_id"`
@@ -32,16 +50,41 @@ type TransferMetadata struct {
 	Checksum    string `json:"checksum"`
 	ChunkIndex  int    `json:"chunk_index,omitempty"`
 	TotalChunks int    `json:"total_chunks,omitempty"`
+
+	// Resume keeps the data an earlier receive of the same transfer got before
+	// its connection dropped, so only the rest is fetched
+	Resume bool `json:"resume,omitempty"`
 }
 
 // TransportConfig holds configuration for transports
 type TransportConfig struct {
 	RelayServers  []string      `json:"relay_servers"`
+	RelayPassword string        `json:"relay_password,omitempty"` // Empty uses DefaultRelayPassword
 	TorProxy      string        `json:"tor_proxy,omitempty"`
 	HTTPSProxy    string        `json:"https_proxy,omitempty"`
 	WebRTCServers []string      `json:"webrtc_servers,omitempty"`
 	EncryptionKey []byte        `json:"-"`
 	Timeout       time.Duration `json:"timeout"`
+	TempDir       string        `json:"temp_dir,omitempty"` // Where transports write temp files; empty uses the OS temp dir
+
+	// MaxPayloadSize caps what the croc and direct HTTPS transports send;
+	// zero uses DefaultMaxPayloadSize
+	MaxPayloadSize int64 `json:"max_payload_size,omitempty"`
+
+	// TimeoutMultiplier stretches transport timeouts on slow networks; 1 or less keeps them
+	TimeoutMultiplier float64 `json:"timeout_multiplier,omitempty"`
+
+	// ICEServers replaces the ICE transport's default STUN and TURN servers
+	ICEServers ICEServers `json:"ice_servers"`
+
+	// KeepaliveInterval is how often idle relay connections are probed; zero
+	// uses DefaultKeepaliveInterval and a negative value turns probes off
+	KeepaliveInterval time.Duration `json:"keepalive_interval,omitempty"`
+
+	// PathMode is PathModeAuto to connect directly to peers on the local
+	// network, PathModeRelay to always use the relay, or empty to only look
+	// for a direct path when receiving
+	PathMode string `json:"path_mode,omitempty"`
 }
 
 // NetworkProfile describes the network environment characteristics
@@ -58,11 +101,15 @@ type NetworkProfile struct {
 	NetworkType        string   `json:"network_type"` // "home", "corporate", "university", "public", "mobile"
 	ProxyDetected      bool     `json:"proxy_detected"`
 	DPIDetected        bool     `json:"dpi_detected"` // Deep Packet Inspection
+	VPNDetected        bool     `json:"vpn_detected"`
+	EndpointSecurity   bool     `json:"endpoint_security"` // Antivirus or endpoint protection interference
+
+	Directional DirectionalConnectivity `json:"directional"` // Which side of a relay rendezvous works
 }
 
 // NetworkRestriction represents detected network limitations
 type NetworkRestriction struct {
-	Type        string  `json:"type"` // "firewall", "proxy", "port_block", "domain_block", "dpi", "whitelist"
+	Type        string  `json:"type"` // "firewall", "proxy", "port_block", "domain_block", "dpi", "whitelist", "endpoint_security"
 	Description string  `json:"description"`
 	Severity    string  `json:"severity"` // "low", "medium", "high", "critical"
 	Workaround  string  `json:"workaround"`
@@ -81,6 +128,16 @@ type MultiTransportManager struct {
 	successHistory      map[string]int
 	analysisComplete    bool
 	detectionResults    map[string]bool
+	relayProblems       []error // configured relays skipped as invalid, fixed at creation
+	traffic             trafficCounter
+
+	// stateMutex guards networkProfile, networkRestrictions, detectionResults
+	// and analysisComplete, which the background analysis writes while
+	// transfers holding mutex read them, and the init results below
+	stateMutex sync.RWMutex
+
+	readyTransports int      // transports that initialized
+	initFailures    []string // "name: error" for each that did not
 }
 
 // NewMultiTransportManager creates a new multi-transport manager
@@ -93,7 +150,15 @@ func NewMultiTransportManager(config TransportConfig) (*MultiTransportManager, e
 		detectionResults:    make(map[string]bool),
 	}
 
-	fmt.Printf("Initializing production-ready transport manager...\n")
+	logging.Debugf("Initializing production-ready transport manager...")
+
+	// Skip malformed relay entries up front so a typo is reported, not dialed
+	mtm.config.RelayServers, mtm.relayProblems = ValidateRelayAddresses(config.RelayServers)
+	for _, problem := range mtm.relayProblems {
+		logging.Warnf("Invalid relay config: %v", problem)
+	}
+	logging.Infof("Effective relays (%d of %d configured): %s", len(mtm.config.RelayServers),
+		len(config.RelayServers), strings.Join(mtm.config.RelayServers, ", "))
 
 	// Initialize with comprehensive defaults
 	mtm.networkProfile = NetworkProfile{
@@ -111,13 +176,13 @@ func NewMultiTransportManager(config TransportConfig) (*MultiTransportManager, e
 	// Initialize transports in production-ready priority order
 	if err := mtm.initializeTransports(); err != nil {
 		// Don't fail completely - some transports may work
-		fmt.Printf("Some transports failed to initialize: %v\n", err)
+		logging.Debugf("Some transports failed to initialize: %v", err)
 	}
 
 	// Start comprehensive network analysis
 	go mtm.analyzeNetworkEnvironment()
 
-	fmt.Printf("Transport manager ready with %d transports\n", len(mtm.transports))
+	logging.Debugf("Transport manager ready with %d transports", len(mtm.transports))
 	return mtm, nil
 }
 
@@ -129,43 +194,45 @@ func (mtm *MultiTransportManager) initializeTransports() error {
 	httpsTransport := NewHTTPSTunnelTransport(45) // Lower priority as fallback only
 	if err := httpsTransport.Setup(mtm.config); err == nil {
 		mtm.transports = append(mtm.transports, httpsTransport)
-		fmt.Printf("HTTPS International transport initialized as FALLBACK (priority: %d)\n", httpsTransport.GetPriority())
+		logging.Debugf("HTTPS International transport initialized as FALLBACK (priority: %d)", httpsTransport.GetPriority())
 	} else {
 		initErrors = append(initErrors, fmt.Sprintf("HTTPS-International: %v", err))
-		fmt.Printf("Warning: HTTPS International failed to initialize: %v\n", err)
+		logging.Warnf("HTTPS International failed to initialize: %v", err)
 	}
 
 	// DISABLE WebSocket transport - echo services don't support file storage
 	// websocketTransport := NewWebSocketTransport(70)
 	// if err := websocketTransport.Setup(mtm.config); err == nil {
 	//     mtm.transports = append(mtm.transports, websocketTransport)
-	//     fmt.Printf("WebSocket transport initialized as SECONDARY (priority: %d)\n", websocketTransport.GetPriority())
+	//     logging.Debugf("WebSocket transport initialized as SECONDARY (priority: %d)", websocketTransport.GetPriority())
 	// } else {
 	//     initErrors = append(initErrors, fmt.Sprintf("WebSocket: %v", err))
-	//     fmt.Printf("Warning: WebSocket failed to initialize: %v\n", err)
+	//     logging.Warnf("WebSocket failed to initialize: %v", err)
 	// }
-	fmt.Printf("WebSocket transport disabled - not suitable for file storage\n")
+	logging.Debugf("WebSocket transport disabled - not suitable for file storage")
 
 	// CROC TRANSPORT - FALLBACK for when others fail
 	crocTransport := NewCrocTransport(60) // Lower priority as fallback
 	if err := crocTransport.Setup(mtm.config); err == nil {
 		mtm.transports = append(mtm.transports, crocTransport)
-		fmt.Printf("CROC transport initialized as FALLBACK (priority: %d)\n", crocTransport.GetPriority())
+		logging.Debugf("CROC transport initialized as FALLBACK (priority: %d)", crocTransport.GetPriority())
 	} else {
 		initErrors = append(initErrors, fmt.Sprintf("CROC: %v", err))
-		fmt.Printf("Warning: CROC failed to initialize: %v\n", err)
+		logging.Warnf("CROC failed to initialize: %v", err)
 	}
 
 	// TOR TRANSPORT - Optional for maximum security
 	torTransport := &TorTransport{priority: 50}
 	if err := torTransport.Setup(mtm.config); err == nil {
 		mtm.transports = append(mtm.transports, torTransport)
-		fmt.Printf("Tor transport initialized as OPTIONAL (priority: %d)\n", torTransport.GetPriority())
+		logging.Debugf("Tor transport initialized as OPTIONAL (priority: %d)", torTransport.GetPriority())
 	} else {
 		initErrors = append(initErrors, fmt.Sprintf("Tor: %v", err))
-		fmt.Printf("Warning: Tor transport failed to initialize: %v\n", err)
+		logging.Warnf("Tor transport failed to initialize: %v", err)
 	}
 
+	mtm.recordInitResult(len(mtm.transports), initErrors)
+
 	// Ensure we have at least one working transport
 	if len(mtm.transports) == 0 {
 		return fmt.Errorf("CRITICAL: No transports available for Europe-to-US file transfer. Errors: %s", strings.Join(initErrors, "; "))
@@ -173,27 +240,27 @@ func (mtm *MultiTransportManager) initializeTransports() error {
 
 	// Log successful initialization
 	totalTransports := 4 // HTTPS, WebSocket, CROC, Tor
-	fmt.Printf("Transport manager ready with %d/%d transports successfully initialized\n",
+	logging.Debugf("Transport manager ready with %d/%d transports successfully initialized",
 		len(mtm.transports), totalTransports)
 
 	if len(initErrors) > 0 {
-		fmt.Printf("Some transports failed to initialize (will continue with available ones): %s\n",
+		logging.Debugf("Some transports failed to initialize (will continue with available ones): %s",
 			strings.Join(initErrors, "; "))
 	}
 
 	// Provide network guidance for Europe-to-US transfers
-	fmt.Printf("\n🌍 LAB-TO-LAB TRANSFER CONFIGURATION:\n")
-	fmt.Printf("   Priority 1 (60): CROC - P2P with international relay servers\n")
-	fmt.Printf("   Priority 2 (45): HTTPS - Secure local relay fallback\n")
-	fmt.Printf("   Priority 3 (50): Tor - Maximum privacy (if installed)\n")
-	fmt.Printf("   🔥 FIREWALL TRAVERSAL: Multiple methods for maximum reliability\n")
-	fmt.Printf("   🔒 SECURITY: End-to-end encryption with blockchain audit trail\n\n")
+	logging.Debugf("LAB-TO-LAB TRANSFER CONFIGURATION:")
+	logging.Debugf("   Priority 1 (60): CROC - P2P with international relay servers")
+	logging.Debugf("   Priority 2 (45): HTTPS - Secure local relay fallback")
+	logging.Debugf("   Priority 3 (50): Tor - Maximum privacy (if installed)")
+	logging.Debugf("   🔥 FIREWALL TRAVERSAL: Multiple methods for maximum reliability")
+	logging.Debugf("   🔒 SECURITY: End-to-end encryption with blockchain audit trail")
 
 	return nil
 }
 
 func (mtm *MultiTransportManager) analyzeNetworkEnvironment() {
-	fmt.Printf("Starting international network environment analysis...\n")
+	logging.Debugf("Starting international network environment analysis...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second) // Extended for international
 	defer cancel()
@@ -207,34 +274,37 @@ func (mtm *MultiTransportManager) analyzeNetworkEnvironment() {
 	mtm.detectProxyEnvironment(&profile)
 	mtm.detectFirewallRestrictions(&profile)
 	mtm.detectDPIInterference(&profile)
+	mtm.detectEndpointSecurity(&profile)
 	mtm.detectInternationalLatency(&profile, ctx) // New international latency detection
 	mtm.testPortConnectivity(&profile, ctx)
 	mtm.testDNSFiltering(&profile, ctx)
 	mtm.testInternationalConnectivity(&profile, ctx) // New international connectivity test
 	mtm.testP2PCapability(&profile, ctx)
+	mtm.detectDirectionalConnectivity(&profile)
 
 	// Determine overall network type and restrictions
 	mtm.classifyNetworkType(&profile)
 	mtm.generateNetworkRestrictions(&profile)
 
-	mtm.mutex.Lock()
+	mtm.stateMutex.Lock()
 	mtm.networkProfile = profile
 	mtm.analysisComplete = true
-	mtm.mutex.Unlock()
+	mtm.stateMutex.Unlock()
 
 	restrictiveness := mtm.calculateRestrictiveness()
-	fmt.Printf("International network analysis complete: %s network, restrictiveness: %.1f%%, international ready: %t\n",
+	logging.Debugf("International network analysis complete: %s network, restrictiveness: %.1f%%, international ready: %t",
 		profile.NetworkType, restrictiveness*100, profile.SupportsUDP)
 
-	if len(mtm.networkRestrictions) > 0 {
-		fmt.Printf("Detected %d network restrictions for international transfer\n", len(mtm.networkRestrictions))
-		for _, restriction := range mtm.networkRestrictions {
-			fmt.Printf("  - %s (%s): %s\n", restriction.Type, restriction.Severity, restriction.Description)
+	restrictions := mtm.GetNetworkRestrictions()
+	if len(restrictions) > 0 {
+		logging.Debugf("Detected %d network restrictions for international transfer", len(restrictions))
+		for _, restriction := range restrictions {
+			logging.Debugf("  - %s (%s): %s", restriction.Type, restriction.Severity, restriction.Description)
 		}
 	}
 
 	// Log recommended transport for international
-	fmt.Printf("Recommended transport for international transfer: %s\n", profile.PreferredTransport)
+	logging.Debugf("Recommended transport for international transfer: %s", profile.PreferredTransport)
 }
 
 // detectInstitutionalNetwork detects corporate/university networks
@@ -245,42 +315,42 @@ func (mtm *MultiTransportManager) detectInstitutionalNetwork(profile *NetworkPro
 	// Test DNS servers (corporate networks often use internal DNS)
 	if mtm.detectCorporateDNS() {
 		indicators++
-		mtm.detectionResults["corporate_dns"] = true
+		mtm.setDetection("corporate_dns")
 	}
 	totalTests++
 
 	// Test for proxy auto-config
 	if mtm.detectProxyAutoConfig() {
 		indicators++
-		mtm.detectionResults["proxy_autoconfig"] = true
+		mtm.setDetection("proxy_autoconfig")
 	}
 	totalTests++
 
 	// Test common corporate domains
 	if mtm.detectCorporateDomains() {
 		indicators++
-		mtm.detectionResults["corporate_domains"] = true
+		mtm.setDetection("corporate_domains")
 	}
 	totalTests++
 
 	// Test network latency patterns (corporate networks often have higher latency)
 	if mtm.detectHighLatency() {
 		indicators++
-		mtm.detectionResults["high_latency"] = true
+		mtm.setDetection("high_latency")
 	}
 	totalTests++
 
 	// Test for common institutional IP ranges
 	if mtm.detectInstitutionalIPRanges() {
 		indicators++
-		mtm.detectionResults["institutional_ip"] = true
+		mtm.setDetection("institutional_ip")
 	}
 	totalTests++
 
 	// If 3 or more indicators, likely institutional
 	if indicators >= 3 {
 		profile.IsRestrictive = true
-		if mtm.detectionResults["corporate_dns"] || mtm.detectionResults["corporate_domains"] {
+		if mtm.hasDetection("corporate_dns") || mtm.hasDetection("corporate_domains") {
 			profile.NetworkType = "corporate"
 		} else {
 			profile.NetworkType = "university"
@@ -413,7 +483,7 @@ func (mtm *MultiTransportManager) detectProxyEnvironment(profile *NetworkProfile
 	for _, envVar := range proxyVars {
 		if value := os.Getenv(envVar); value != "" {
 			profile.ProxyDetected = true
-			mtm.detectionResults["env_proxy"] = true
+			mtm.setDetection("env_proxy")
 			break
 		}
 	}
@@ -421,7 +491,7 @@ func (mtm *MultiTransportManager) detectProxyEnvironment(profile *NetworkProfile
 	// Test for transparent proxy
 	if mtm.detectTransparentProxy() {
 		profile.ProxyDetected = true
-		mtm.detectionResults["transparent_proxy"] = true
+		mtm.setDetection("transparent_proxy")
 	}
 }
 
@@ -469,7 +539,7 @@ func (mtm *MultiTransportManager) detectFirewallRestrictions(profile *NetworkPro
 	restrictiveness := float64(blockedPorts) / float64(len(restrictivePorts))
 	if restrictiveness > 0.5 {
 		profile.IsRestrictive = true
-		mtm.detectionResults["port_blocking"] = true
+		mtm.setDetection("port_blocking")
 	}
 }
 
@@ -479,7 +549,7 @@ func (mtm *MultiTransportManager) detectDPIInterference(profile *NetworkProfile)
 	if mtm.testDPIDetection() {
 		profile.DPIDetected = true
 		profile.IsRestrictive = true
-		mtm.detectionResults["dpi_detected"] = true
+		mtm.setDetection("dpi_detected")
 	}
 }
 
@@ -538,7 +608,7 @@ func (mtm *MultiTransportManager) testPortConnectivity(profile *NetworkProfile,
 	// If most P2P ports are blocked, likely restrictive
 	if float64(p2pBlocked)/float64(len(p2pPorts)) > 0.7 {
 		profile.IsRestrictive = true
-		mtm.detectionResults["p2p_blocking"] = true
+		mtm.setDetection("p2p_blocking")
 	}
 }
 
@@ -547,7 +617,7 @@ func (mtm *MultiTransportManager) testSinglePortConnectivity(port int, timeout t
 	testHosts := []string{"8.8.8.8", "1.1.1.1", "google.com"}
 
 	for _, host := range testHosts {
-		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprintf("%d", port)), timeout)
+		conn, err := DialTCP(context.Background(), net.JoinHostPort(host, fmt.Sprintf("%d", port)), timeout)
 		if err == nil {
 			conn.Close()
 			return true
@@ -576,7 +646,7 @@ func (mtm *MultiTransportManager) testDNSFiltering(profile *NetworkProfile, _ co
 
 	if blockedDomains > 0 {
 		profile.IsRestrictive = true
-		mtm.detectionResults["dns_filtering"] = true
+		mtm.setDetection("dns_filtering")
 	}
 }
 
@@ -592,7 +662,7 @@ func (mtm *MultiTransportManager) testP2PCapability(profile *NetworkProfile, _ c
 	conn, err := net.DialTimeout("udp", "8.8.8.8:53", 5*time.Second)
 	if err != nil {
 		profile.SupportsUDP = false
-		mtm.detectionResults["udp_blocked"] = true
+		mtm.setDetection("udp_blocked")
 	} else {
 		conn.Close()
 		profile.SupportsUDP = true
@@ -651,12 +721,12 @@ func (mtm *MultiTransportManager) detectInternationalLatency(profile *NetworkPro
 		// Classify network quality for international transfers
 		if avgLatency > 500*time.Millisecond {
 			profile.NetworkType = "high-latency-international"
-			mtm.detectionResults["high_international_latency"] = true
+			mtm.setDetection("high_international_latency")
 		} else if avgLatency > 200*time.Millisecond {
 			profile.NetworkType = "moderate-latency-international"
 		}
 
-		fmt.Printf("International latency: %v (avg from %d endpoints)\n", avgLatency, successfulTests)
+		logging.Debugf("International latency: %v (avg from %d endpoints)", avgLatency, successfulTests)
 	}
 }
 
@@ -671,7 +741,7 @@ func (mtm *MultiTransportManager) testInternationalConnectivity(profile *Network
 
 	var workingRelays int
 	for _, relay := range internationalRelays {
-		conn, err := net.DialTimeout("tcp", relay, 10*time.Second)
+		conn, err := DialTCP(context.Background(), relay, 10*time.Second)
 
 		if err == nil {
 			conn.Close()
@@ -682,11 +752,11 @@ func (mtm *MultiTransportManager) testInternationalConnectivity(profile *Network
 	profile.HasWebRTC = workingRelays > 0 // Repurpose this field for relay connectivity
 
 	if workingRelays == 0 {
-		mtm.detectionResults["international_relays_blocked"] = true
+		mtm.setDetection("international_relays_blocked")
 		profile.IsRestrictive = true
 	}
 
-	fmt.Printf("International relay connectivity: %d/%d relays accessible\n", workingRelays, len(internationalRelays))
+	logging.Debugf("International relay connectivity: %d/%d relays accessible", workingRelays, len(internationalRelays))
 }
 
 // classifyNetworkType determines the overall network type
@@ -699,7 +769,13 @@ func (mtm *MultiTransportManager) classifyNetworkType(profile *NetworkProfile) {
 	// Check for mobile network indicators
 	if mtm.detectMobileNetwork() {
 		profile.NetworkType = "mobile"
-		mtm.detectionResults["mobile_network"] = true
+		mtm.setDetection("mobile_network")
+	}
+
+	// A VPN changes routing regardless of the underlying network type
+	if names, err := activeInterfaceNames(); err == nil && hasVPNInterface(names) {
+		profile.VPNDetected = true
+		mtm.setDetection("vpn_active")
 	}
 
 	// Determine preferred transport based on network type
@@ -734,11 +810,58 @@ func (mtm *MultiTransportManager) detectMobileNetwork() bool {
 	return false
 }
 
+// vpnInterfacePrefixes are interface name prefixes used by common VPN clients
+var vpnInterfacePrefixes = []string{
+	"tun", "tap", "wg", "utun", "ipsec", "gpd", "nordlynx", "tailscale", "zt",
+	"wireguard", "openvpn", "tap-windows", "cisco anyconnect", "globalprotect",
+}
+
+// activeInterfaceNames lists interfaces that are up and carry a routable address.
+// macOS keeps idle utun interfaces with only link-local addresses, so those are skipped.
+func activeInterfaceNames() ([]string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLinkLocalUnicast() && !ipnet.IP.IsLoopback() {
+				names = append(names, iface.Name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// hasVPNInterface reports whether any of the interface names looks like a VPN adapter
+func hasVPNInterface(names []string) bool {
+	for _, name := range names {
+		nameLower := strings.ToLower(name)
+		for _, prefix := range vpnInterfacePrefixes {
+			if strings.HasPrefix(nameLower, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // generateNetworkRestrictions creates restriction descriptions
 func (mtm *MultiTransportManager) generateNetworkRestrictions(profile *NetworkProfile) {
 	restrictions := make([]NetworkRestriction, 0)
 
-	if mtm.detectionResults["port_blocking"] {
+	if mtm.hasDetection("port_blocking") {
 		restrictions = append(restrictions, NetworkRestriction{
 			Type:        "firewall",
 			Description: "P2P ports blocked by institutional firewall",
@@ -748,7 +871,7 @@ func (mtm *MultiTransportManager) generateNetworkRestrictions(profile *NetworkPr
 		})
 	}
 
-	if mtm.detectionResults["dns_filtering"] {
+	if mtm.hasDetection("dns_filtering") {
 		restrictions = append(restrictions, NetworkRestriction{
 			Type:        "domain_block",
 			Description: "DNS filtering detected - some domains blocked",
@@ -758,7 +881,7 @@ func (mtm *MultiTransportManager) generateNetworkRestrictions(profile *NetworkPr
 		})
 	}
 
-	if mtm.detectionResults["dpi_detected"] {
+	if mtm.hasDetection("dpi_detected") {
 		restrictions = append(restrictions, NetworkRestriction{
 			Type:        "dpi",
 			Description: "Deep Packet Inspection detected",
@@ -768,7 +891,7 @@ func (mtm *MultiTransportManager) generateNetworkRestrictions(profile *NetworkPr
 		})
 	}
 
-	if mtm.detectionResults["transparent_proxy"] || mtm.detectionResults["env_proxy"] {
+	if mtm.hasDetection("transparent_proxy") || mtm.hasDetection("env_proxy") {
 		restrictions = append(restrictions, NetworkRestriction{
 			Type:        "proxy",
 			Description: "HTTP proxy detected in network path",
@@ -778,6 +901,30 @@ func (mtm *MultiTransportManager) generateNetworkRestrictions(profile *NetworkPr
 		})
 	}
 
+	if mtm.hasDetection(RestrictionEndpointSecurity) {
+		restrictions = append(restrictions, NetworkRestriction{
+			Type:        RestrictionEndpointSecurity,
+			Description: "Antivirus or endpoint security software appears to be interfering with transfers",
+			Severity:    "high",
+			Workaround:  "Add TrustDrop to the allow list of your antivirus or endpoint protection",
+			Confidence:  0.6,
+		})
+	}
+
+	if profile.Directional.Asymmetric() {
+		description := "This network allows receiving files but blocks creating a transfer to send"
+		if profile.Directional.CanSend {
+			description = "This network allows sending files but blocks joining a transfer to receive"
+		}
+		restrictions = append(restrictions, NetworkRestriction{
+			Type:        RestrictionAsymmetric,
+			Description: description,
+			Severity:    "high",
+			Workaround:  "Use a different network for the blocked direction, or have the other side send or receive instead",
+			Confidence:  0.7,
+		})
+	}
+
 	if profile.IsRestrictive && len(restrictions) == 0 {
 		restrictions = append(restrictions, NetworkRestriction{
 			Type:        "whitelist",
@@ -788,7 +935,40 @@ func (mtm *MultiTransportManager) generateNetworkRestrictions(profile *NetworkPr
 		})
 	}
 
+	if profile.VPNDetected {
+		restrictions = append(restrictions, NetworkRestriction{
+			Type:        "vpn",
+			Description: "VPN active - transfers may be routed through a remote network",
+			Severity:    "low",
+			Workaround:  "Disconnect the VPN or use split tunneling if transfers fail",
+			Confidence:  0.7,
+		})
+	}
+
+	mtm.stateMutex.Lock()
 	mtm.networkRestrictions = restrictions
+	mtm.stateMutex.Unlock()
+}
+
+// setDetection records a positive detection result
+func (mtm *MultiTransportManager) setDetection(key string) {
+	mtm.stateMutex.Lock()
+	defer mtm.stateMutex.Unlock()
+	mtm.detectionResults[key] = true
+}
+
+// hasDetection reports whether a detection result was recorded
+func (mtm *MultiTransportManager) hasDetection(key string) bool {
+	mtm.stateMutex.RLock()
+	defer mtm.stateMutex.RUnlock()
+	return mtm.detectionResults[key]
+}
+
+// isAnalysisComplete reports whether the background network analysis finished
+func (mtm *MultiTransportManager) isAnalysisComplete() bool {
+	mtm.stateMutex.RLock()
+	defer mtm.stateMutex.RUnlock()
+	return mtm.analysisComplete
 }
 
 // calculateRestrictiveness calculates overall network restrictiveness
@@ -803,7 +983,7 @@ func (mtm *MultiTransportManager) calculateRestrictiveness() float64 {
 
 	for _, factor := range factors {
 		totalFactors++
-		if mtm.detectionResults[factor] {
+		if mtm.hasDetection(factor) {
 			restrictiveFactors++
 		}
 	}
@@ -818,6 +998,11 @@ func (mtm *MultiTransportManager) calculateRestrictiveness() float64 {
 
 // SendWithFailover attempts to send data using the best available transport
 func (mtm *MultiTransportManager) SendWithFailover(data []byte, metadata TransferMetadata) error {
+	return mtm.SendWithFailoverContext(context.Background(), data, metadata)
+}
+
+// SendWithFailoverContext is SendWithFailover with cancellation taken from ctx
+func (mtm *MultiTransportManager) SendWithFailoverContext(parent context.Context, data []byte, metadata TransferMetadata) error {
 	mtm.mutex.Lock()
 	defer mtm.mutex.Unlock()
 
@@ -826,10 +1011,10 @@ func (mtm *MultiTransportManager) SendWithFailover(data []byte, metadata Transfe
 	analysisTicker := time.NewTicker(200 * time.Millisecond)
 	defer analysisTicker.Stop()
 
-	for !mtm.analysisComplete {
+	for !mtm.isAnalysisComplete() {
 		select {
 		case <-analysisTimeout:
-			fmt.Printf("Network analysis timeout, proceeding with CROC-first strategy\n")
+			logging.Debugf("Network analysis timeout, proceeding with CROC-first strategy")
 			goto proceed
 		case <-analysisTicker.C:
 			continue
@@ -838,108 +1023,189 @@ func (mtm *MultiTransportManager) SendWithFailover(data []byte, metadata Transfe
 
 proceed:
 	// Get ordered transports with HTTPS prioritized for institutional networks
-	orderedTransports := mtm.getOrderedTransports()
+	orderedTransports, overridden, err := mtm.transportsFor(parent)
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Attempting send with %d transports (network: %s, restrictive: %t)\n",
-		len(orderedTransports), mtm.networkProfile.NetworkType, mtm.networkProfile.IsRestrictive)
+	profile := mtm.GetNetworkProfile()
+	logging.Debugf("Attempting send with %d transports (network: %s, restrictive: %t, overridden: %t)",
+		len(orderedTransports), profile.NetworkType, profile.IsRestrictive, overridden)
 
 	var lastErr error
 	for transportIndex, transport := range orderedTransports {
+		if parent.Err() != nil {
+			return parent.Err()
+		}
 		transportName := transport.GetName()
 
-		// Skip recently failed transports (except first attempt always tries HTTPS)
-		if transportIndex > 0 && transportName != "https-tunnel" {
+		// Skip recently failed transports (except first attempt always tries
+		// HTTPS); transports the user chose are always tried
+		if transportIndex > 0 && transportName != "https-tunnel" && !overridden {
 			if failTime, exists := mtm.failedTransports[transportName]; exists {
 				cooldownPeriod := 3 * time.Minute
 				if time.Since(failTime) < cooldownPeriod {
-					fmt.Printf("Skipping %s (cooldown period)\n", transportName)
+					logging.Debugf("Skipping %s (cooldown period)", transportName)
 					continue
 				}
 			}
 		}
 
 		// Test transport availability
-		fmt.Printf("Trying transport: %s (priority: %d)\n", transportName, transport.GetPriority())
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		if !transport.IsAvailable(ctx) {
+		logging.Debugf("Trying transport: %s (priority: %d)", transportName, transport.GetPriority())
+		ctx, cancel := context.WithTimeout(parent, 20*time.Second)
+		if !overridden && !transport.IsAvailable(ctx) {
 			cancel()
-			fmt.Printf("Transport %s not available\n", transportName)
+			logging.Debugf("Transport %s not available", transportName)
 			continue
 		}
 		cancel()
 
 		// Attempt transfer
-		fmt.Printf("Sending via %s...\n", transportName)
-		err := transport.Send(data, metadata)
+		logging.Debugf("Sending via %s...", transportName)
+		traced := logging.TraceSpan("transport", "send %d bytes via %s", len(data), transportName)
+		err := sendData(parent, transport, data, metadata)
+		traced(err)
 		if err == nil {
+			mtm.traffic.sent.Add(int64(len(data)))
 			// Success
 			mtm.successHistory[transportName]++
 			delete(mtm.failedTransports, transportName)
 			mtm.currentTransport = transport
-			fmt.Printf("Send successful via %s\n", transportName)
+			logging.Debugf("Send successful via %s", transportName)
 			return nil
 		}
+		if errors.Is(err, ErrConnectionDropped) {
+			// The receiver is on this transport; another one cannot reach it
+			return fmt.Errorf("send via %s interrupted: %w", transportName, err)
+		}
 
 		// Mark as failed and continue
 		mtm.failedTransports[transportName] = time.Now()
-		lastErr = err
-		fmt.Printf("Transport %s failed: %v\n", transportName, err)
+		lastErr = failoverCause(lastErr, err)
+		logging.Debugf("Transport %s failed: %v", transportName, err)
 	}
 
 	// All transports failed
-	errorMsg := mtm.buildFailureErrorMessage(lastErr)
-	return fmt.Errorf("%s", errorMsg)
+	return &FailoverError{Message: mtm.buildFailureErrorMessage(lastErr), Last: lastErr}
 }
 
 // ReceiveWithFailover attempts to receive data using available transports
 func (mtm *MultiTransportManager) ReceiveWithFailover(metadata TransferMetadata) ([]byte, error) {
+	return mtm.ReceiveWithFailoverContext(context.Background(), metadata)
+}
+
+// ReceiveWithFailoverContext is ReceiveWithFailover with cancellation taken from ctx
+func (mtm *MultiTransportManager) ReceiveWithFailoverContext(ctx context.Context, metadata TransferMetadata) ([]byte, error) {
+	stream, err := mtm.ReceiveStreamWithFailoverContext(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return readAllAndClose(stream)
+}
+
+// ReceiveStreamWithFailover attempts to receive data using available transports,
+// streaming from disk when the transport supports it. The caller must close the stream.
+func (mtm *MultiTransportManager) ReceiveStreamWithFailover(metadata TransferMetadata) (io.ReadCloser, error) {
+	return mtm.ReceiveStreamWithFailoverContext(context.Background(), metadata)
+}
+
+// ReceiveStreamWithFailoverContext is ReceiveStreamWithFailover with
+// cancellation taken from ctx
+func (mtm *MultiTransportManager) ReceiveStreamWithFailoverContext(parent context.Context, metadata TransferMetadata) (io.ReadCloser, error) {
 	mtm.mutex.Lock()
 	defer mtm.mutex.Unlock()
 
-	orderedTransports := mtm.getOrderedTransports()
+	orderedTransports, overridden, err := mtm.transportsFor(parent)
+	if err != nil {
+		return nil, err
+	}
 
-	fmt.Printf("Attempting receive with %d transports\n", len(orderedTransports))
+	logging.Debugf("Attempting receive with %d transports (overridden: %t)", len(orderedTransports), overridden)
 
 	var lastErr error
 	for _, transport := range orderedTransports {
+		if parent.Err() != nil {
+			return nil, parent.Err()
+		}
 		transportName := transport.GetName()
 
 		// Test availability
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		if !transport.IsAvailable(ctx) {
+		ctx, cancel := context.WithTimeout(parent, 15*time.Second)
+		if !overridden && !transport.IsAvailable(ctx) {
 			cancel()
 			continue
 		}
 		cancel()
 
-		fmt.Printf("Receiving via %s...\n", transportName)
-		data, err := transport.Receive(metadata)
+		logging.Debugf("Receiving via %s...", transportName)
+		traced := logging.TraceSpan("transport", "receive via %s", transportName)
+		stream, err := receiveStream(parent, transport, metadata)
+		traced(err)
 		if err == nil {
 			mtm.successHistory[transportName]++
 			delete(mtm.failedTransports, transportName)
-			fmt.Printf("Receive successful via %s\n", transportName)
-			return data, nil
+			logging.Debugf("Receive successful via %s", transportName)
+			return &countingReadCloser{ReadCloser: stream, counter: &mtm.traffic.received}, nil
+		}
+		if errors.Is(err, ErrConnectionDropped) {
+			return nil, fmt.Errorf("receive via %s interrupted: %w", transportName, err)
 		}
 
 		mtm.failedTransports[transportName] = time.Now()
-		lastErr = err
-		fmt.Printf("Transport %s receive failed: %v\n", transportName, err)
+		lastErr = failoverCause(lastErr, err)
+		logging.Debugf("Transport %s receive failed: %v", transportName, err)
+	}
+
+	return nil, &FailoverError{Message: mtm.buildFailureErrorMessage(lastErr), Last: lastErr}
+}
+
+// sendData sends through the transport, letting ctx stop it when the
+// transport supports that
+func sendData(ctx context.Context, transport Transport, data []byte, metadata TransferMetadata) error {
+	if cancellable, ok := transport.(ContextTransport); ok {
+		return cancellable.SendContext(ctx, data, metadata)
+	}
+	return transport.Send(data, metadata)
+}
+
+// receiveStream uses the transport's streaming receive when available and
+// wraps the in-memory result otherwise
+func receiveStream(ctx context.Context, transport Transport, metadata TransferMetadata) (io.ReadCloser, error) {
+	if cancellable, ok := transport.(ContextTransport); ok {
+		return cancellable.ReceiveStreamContext(ctx, metadata)
+	}
+	if streamer, ok := transport.(StreamingTransport); ok {
+		return streamer.ReceiveStream(metadata)
 	}
 
-	errorMsg := mtm.buildFailureErrorMessage(lastErr)
-	return nil, fmt.Errorf("%s", errorMsg)
+	data, err := transport.Receive(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
 }
 
 // buildFailureErrorMessage creates helpful error messages
 func (mtm *MultiTransportManager) buildFailureErrorMessage(lastErr error) string {
 	var errorMsg strings.Builder
 
-	if mtm.networkProfile.IsRestrictive {
+	profile := mtm.GetNetworkProfile()
+	restrictions := mtm.GetNetworkRestrictions()
+
+	if IsRelayRejected(lastErr) {
+		errorMsg.WriteString("Transfer failed because a relay rejected the connection.\n\n")
+		errorMsg.WriteString("The relay was reached but refused this app, which usually means the relay password is wrong.\n")
+		errorMsg.WriteString("Troubleshooting steps:\n")
+		errorMsg.WriteString("• Check the relay password matches the one the private relay was started with\n")
+		errorMsg.WriteString("• Check the relay address points at the relay you expect\n")
+		errorMsg.WriteString("• Remove the custom relay settings to use the public relays\n")
+	} else if profile.IsRestrictive {
 		errorMsg.WriteString("Transfer failed in institutional network environment.\n\n")
 
-		if len(mtm.networkRestrictions) > 0 {
+		if len(restrictions) > 0 {
 			errorMsg.WriteString("Detected network restrictions:\n")
-			for _, restriction := range mtm.networkRestrictions {
+			for _, restriction := range restrictions {
 				errorMsg.WriteString(fmt.Sprintf("• %s: %s (confidence: %.0f%%)\n",
 					restriction.Type, restriction.Description, restriction.Confidence*100))
 			}
@@ -992,9 +1258,10 @@ func (mtm *MultiTransportManager) getOrderedTransports() []Transport {
 func (mtm *MultiTransportManager) getInternationalEffectivePriority(transport Transport) int {
 	basePriority := transport.GetPriority()
 	transportName := transport.GetName()
+	profile := mtm.GetNetworkProfile()
 
 	// INTERNATIONAL NETWORK ADJUSTMENTS
-	if mtm.networkProfile.IsRestrictive {
+	if profile.IsRestrictive {
 		switch transportName {
 		case "simple-croc":
 			basePriority += 25 // Boost CROC for restrictive international networks
@@ -1006,7 +1273,7 @@ func (mtm *MultiTransportManager) getInternationalEffectivePriority(transport Tr
 	}
 
 	// LATENCY-BASED ADJUSTMENTS
-	if mtm.networkProfile.Latency > 300 { // High international latency
+	if profile.Latency > 300 { // High international latency
 		switch transportName {
 		case "simple-croc":
 			basePriority += 20 // CROC handles high latency well
@@ -1023,7 +1290,7 @@ func (mtm *MultiTransportManager) getInternationalEffectivePriority(transport Tr
 	}
 
 	// BANDWIDTH CONSIDERATIONS
-	if mtm.networkProfile.Bandwidth > 0 && mtm.networkProfile.Bandwidth < 10*1024*1024 { // < 10 Mbps
+	if profile.Bandwidth > 0 && profile.Bandwidth < 10*1024*1024 { // < 10 Mbps
 		switch transportName {
 		case "simple-croc":
 			basePriority += 15 // CROC has good compression
@@ -1040,16 +1307,18 @@ func (mtm *MultiTransportManager) getEffectivePriority(transport Transport) int
 
 // GetNetworkProfile returns the analyzed network profile
 func (mtm *MultiTransportManager) GetNetworkProfile() NetworkProfile {
-	mtm.mutex.RLock()
-	defer mtm.mutex.RUnlock()
+	mtm.stateMutex.RLock()
+	defer mtm.stateMutex.RUnlock()
 	return mtm.networkProfile
 }
 
 // GetNetworkRestrictions returns detected network restrictions
 func (mtm *MultiTransportManager) GetNetworkRestrictions() []NetworkRestriction {
-	mtm.mutex.RLock()
-	defer mtm.mutex.RUnlock()
-	return mtm.networkRestrictions
+	mtm.stateMutex.RLock()
+	defer mtm.stateMutex.RUnlock()
+	restrictions := make([]NetworkRestriction, len(mtm.networkRestrictions))
+	copy(restrictions, mtm.networkRestrictions)
+	return restrictions
 }
 
 // GetTransportStatus returns status of all transports
@@ -1058,6 +1327,7 @@ func (mtm *MultiTransportManager) GetTransportStatus() map[string]interface{} {
 	defer mtm.mutex.RUnlock()
 
 	status := make(map[string]interface{})
+	preferred := mtm.GetNetworkProfile().PreferredTransport
 
 	for _, transport := range mtm.transports {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -1071,7 +1341,7 @@ func (mtm *MultiTransportManager) GetTransportStatus() map[string]interface{} {
 			"effective_priority": mtm.getEffectivePriority(transport),
 			"success_count":      mtm.successHistory[transportName],
 			"last_failure":       mtm.failedTransports[transportName],
-			"recommended":        transportName == mtm.networkProfile.PreferredTransport,
+			"recommended":        transportName == preferred,
 		}
 	}
 
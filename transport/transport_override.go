@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// transportOverrideKey carries the transports one operation is limited to
+type transportOverrideKey struct{}
+
+// WithTransports returns a context whose sends and receives use only the
+// named transports, in the order given, instead of the failover order. The
+// named transports are tried even when cooling down after a failure or when
+// their availability check fails, for networks where the user knows the one
+// thing that works. No names gives full failover again.
+func WithTransports(ctx context.Context, names ...string) context.Context {
+	return context.WithValue(ctx, transportOverrideKey{}, names)
+}
+
+// TransportOverride returns the transports ctx limits operations to, or nil
+// for full failover
+func TransportOverride(ctx context.Context) []string {
+	names, _ := ctx.Value(transportOverrideKey{}).([]string)
+	return names
+}
+
+// TransportNames lists the transports that initialized, by name
+func (mtm *MultiTransportManager) TransportNames() []string {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	names := make([]string, 0, len(mtm.transports))
+	for _, transport := range mtm.transports {
+		names = append(names, transport.GetName())
+	}
+	return names
+}
+
+// CheckTransportOverride fails when a transport ctx names did not initialize
+func (mtm *MultiTransportManager) CheckTransportOverride(ctx context.Context) error {
+	mtm.mutex.Lock()
+	defer mtm.mutex.Unlock()
+
+	_, _, err := mtm.transportsFor(ctx)
+	return err
+}
+
+// transportsFor returns the transports to try for an operation with ctx and
+// whether they were chosen by an override rather than failover ordering.
+// The caller holds mtm.mutex.
+func (mtm *MultiTransportManager) transportsFor(ctx context.Context) ([]Transport, bool, error) {
+	names := TransportOverride(ctx)
+	if len(names) == 0 {
+		return mtm.getOrderedTransports(), false, nil
+	}
+
+	transports := make([]Transport, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, transport := range mtm.transports {
+			if transport.GetName() == name {
+				transports = append(transports, transport)
+				found = true
+				break
+			}
+		}
+		if !found {
+			available := make([]string, 0, len(mtm.transports))
+			for _, transport := range mtm.transports {
+				available = append(available, transport.GetName())
+			}
+			return nil, true, fmt.Errorf("transport %q is not available (available: %s)", name, strings.Join(available, ", "))
+		}
+	}
+	return transports, true, nil
+}
@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryTransport accepts every send, standing in for a real transport
+type memoryTransport struct {
+	name     string
+	priority int
+}
+
+func (t *memoryTransport) Send(data []byte, metadata TransferMetadata) error { return nil }
+func (t *memoryTransport) Receive(metadata TransferMetadata) ([]byte, error) {
+	return nil, fmt.Errorf("nothing to receive")
+}
+func (t *memoryTransport) IsAvailable(ctx context.Context) bool { return true }
+func (t *memoryTransport) GetPriority() int                     { return t.priority }
+func (t *memoryTransport) GetName() string                      { return t.name }
+func (t *memoryTransport) Setup(config TransportConfig) error   { return nil }
+func (t *memoryTransport) Close() error                         { return nil }
+
+// TestAnalysisConcurrentWithSend runs the state updates of the network
+// analysis while sends read the profile; run with -race to check the locking.
+func TestAnalysisConcurrentWithSend(t *testing.T) {
+	mtm := &MultiTransportManager{
+		transports: []Transport{
+			&memoryTransport{name: "simple-croc", priority: 60},
+			&memoryTransport{name: "https-tunnel", priority: 45},
+		},
+		failedTransports: make(map[string]time.Time),
+		successHistory:   make(map[string]int),
+		detectionResults: make(map[string]bool),
+		analysisComplete: true,
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			profile := NetworkProfile{IsRestrictive: i%2 == 0, NetworkType: "corporate"}
+			mtm.setDetection(fmt.Sprintf("detection_%d", i%5))
+			mtm.setDetection("port_blocking")
+			mtm.generateNetworkRestrictions(&profile)
+			mtm.stateMutex.Lock()
+			mtm.networkProfile = profile
+			mtm.analysisComplete = true
+			mtm.stateMutex.Unlock()
+			mtm.calculateRestrictiveness()
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		if err := mtm.SendWithFailover([]byte("data"), TransferMetadata{TransferID: "code"}); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+		mtm.GetTransportStatus()
+		mtm.GetNetworkRestrictions()
+	}
+	wg.Wait()
+
+	if len(mtm.GetNetworkRestrictions()) == 0 {
+		t.Fatal("analysis results were lost")
+	}
+}
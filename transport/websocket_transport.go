@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"trustdrop-bulletproof/logging"
 )
 
 // WebSocketTransport provides firewall-friendly WebSocket-based file transfer
@@ -52,7 +54,7 @@ func (t *WebSocketTransport) Setup(config TransportConfig) error {
 		EnableCompression: true,
 	}
 
-	fmt.Printf("WebSocket transport initialized with %d echo services\n", len(t.echoServiceURLs))
+	logging.Debugf("WebSocket transport initialized with %d echo services", len(t.echoServiceURLs))
 	return nil
 }
 
@@ -81,16 +83,16 @@ func (t *WebSocketTransport) Send(data []byte, metadata TransferMetadata) error
 	// Try each WebSocket echo service
 	var lastErr error
 	for i, wsURL := range t.echoServiceURLs {
-		fmt.Printf("Attempting WebSocket service %d/%d: %s\n", i+1, len(t.echoServiceURLs), wsURL)
+		logging.Debugf("Attempting WebSocket service %d/%d: %s", i+1, len(t.echoServiceURLs), wsURL)
 
 		err := t.sendViaWebSocket(message, wsURL)
 		if err == nil {
-			fmt.Printf("✅ WebSocket send successful via: %s\n", wsURL)
+			logging.Debugf("WebSocket send successful via: %s", wsURL)
 			return nil
 		}
 
 		lastErr = err
-		fmt.Printf("❌ WebSocket service failed: %v\n", err)
+		logging.Debugf("WebSocket service failed: %v", err)
 
 		// Brief delay between attempts
 		if i < len(t.echoServiceURLs)-1 {
@@ -140,7 +142,7 @@ func (t *WebSocketTransport) sendViaWebSocket(message WebSocketMessage, wsURL st
 		return fmt.Errorf("WebSocket echo response mismatch")
 	}
 
-	fmt.Printf("WebSocket echo confirmed for transfer %s\n", message.TransferID)
+	logging.Debugf("WebSocket echo confirmed for transfer %s", message.TransferID)
 	return nil
 }
 
@@ -150,17 +152,17 @@ func (t *WebSocketTransport) Receive(metadata TransferMetadata) ([]byte, error)
 	var lastErr error
 
 	for i, wsURL := range t.echoServiceURLs {
-		fmt.Printf("Checking WebSocket service %d/%d for transfer %s\n", i+1, len(t.echoServiceURLs), metadata.TransferID)
+		logging.Debugf("Checking WebSocket service %d/%d for transfer %s", i+1, len(t.echoServiceURLs), metadata.TransferID)
 
 		data, err := t.receiveViaWebSocket(metadata, wsURL)
 		if err == nil && len(data) > 0 {
-			fmt.Printf("✅ WebSocket receive successful via: %s (%d bytes)\n", wsURL, len(data))
+			logging.Debugf("WebSocket receive successful via: %s (%d bytes)", wsURL, len(data))
 			return data, nil
 		}
 
 		if err != nil {
 			lastErr = err
-			fmt.Printf("❌ WebSocket service check failed: %v\n", err)
+			logging.Debugf("WebSocket service check failed: %v", err)
 		}
 
 		// Brief delay between checks
@@ -232,12 +234,12 @@ func (t *WebSocketTransport) IsAvailable(ctx context.Context) bool {
 
 	conn, _, err := t.dialer.DialContext(testCtx, testURL, nil)
 	if err != nil {
-		fmt.Printf("WebSocket availability test failed: %v\n", err)
+		logging.Debugf("WebSocket availability test failed: %v", err)
 		return false
 	}
 	defer conn.Close()
 
-	fmt.Printf("WebSocket transport available via: %s\n", testURL)
+	logging.Debugf("WebSocket transport available via: %s", testURL)
 	return true
 }
 
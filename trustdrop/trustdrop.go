@@ -0,0 +1,429 @@
+// Package trustdrop exposes TrustDrop's secure file transfers as a library.
+//
+// It wraps the transfer manager used by the desktop app without pulling in the
+// GUI, and reports progress and status over channels instead of callbacks:
+//
+//	client, err := trustdrop.New(trustdrop.Options{DataDir: dir})
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	go func() {
+//		for status := range client.Status() {
+//			log.Println(status)
+//		}
+//	}()
+//
+//	result, err := client.Send(ctx, []string{"report.pdf"}, code)
+package trustdrop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"trustdrop-bulletproof/security"
+	"trustdrop-bulletproof/transfer"
+)
+
+// channelBufferSize is how many undelivered updates are kept before new ones are dropped
+const channelBufferSize = 64
+
+// Options configures a Client
+type Options struct {
+	// DataDir holds the audit log, ledger and the default "received" folder
+	DataDir string
+
+	// MaxInMemorySize is the largest file loaded fully into memory for sending.
+	// Zero uses transfer.DefaultMaxInMemorySize.
+	MaxInMemorySize int64
+
+	// MaxEmbedSize is the largest file in a sent folder whose contents are
+	// sent; larger ones arrive as placeholders. The whole folder is held in
+	// memory while sending, so this bounds how much each file adds. Zero
+	// uses transfer.DefaultMaxEmbedSize.
+	MaxEmbedSize int64
+
+	// MaxFolderFiles and MaxFolderBytes bound the files and bytes the folders
+	// in one send may hold. A send past them is refused unless its context
+	// comes from transfer.ConfirmFolderLimits. Zero uses
+	// transfer.DefaultMaxFolderFiles and transfer.DefaultMaxFolderBytes, and
+	// a negative value turns that limit off.
+	MaxFolderFiles int
+	MaxFolderBytes int64
+
+	// MaxReceiveSize is the most file data one receive accepts, in bytes.
+	// Larger transfers are refused with an error matching
+	// transfer.ErrTransferTooLarge before anything is written. Zero accepts
+	// any size.
+	MaxReceiveSize int64
+
+	// Archive chooses when folders are sent as a single tar archive, which is
+	// much faster for folders of many small files. Receivers need a release
+	// that understands archives. The zero value always sends a manifest.
+	Archive transfer.ArchiveConfig
+
+	// BinaryFiles sends single files in a binary frame rather than a JSON
+	// payload, avoiding the encoded copy of a large file. Receivers need a release
+	// that understands the frame.
+	BinaryFiles bool
+
+	// KeepPartialReceives keeps files written before a receive failed
+	KeepPartialReceives bool
+
+	// ReceiveLayout organizes received files: transfer.ReceiveLayoutFlat
+	// (the default), transfer.ReceiveLayoutByDate or transfer.ReceiveLayoutByCode
+	ReceiveLayout string
+
+	// TempDir is where temp files and receive staging are written. Empty uses
+	// the OS temp directory. It must be writable.
+	TempDir string
+
+	// ShareIdentity attaches this device's key fingerprint, and DisplayName
+	// if set, to sent transfers
+	ShareIdentity bool
+	DisplayName   string
+
+	// RelayPassword authenticates to a private croc relay. Empty uses the
+	// public relays' password.
+	RelayPassword string
+
+	// PathMode is "relay" to send everything through the relay, or "auto" to
+	// connect directly when the peer answers on the same local network; the
+	// speed of the two paths is not measured. Empty only looks for a direct
+	// path when receiving, from a sender using "auto".
+	PathMode string
+
+	// Retry is the retry baseline, scaled per network during transfers. Nil
+	// uses transfer.DefaultRetryStrategy().
+	Retry *transfer.RetryStrategy
+
+	// DisableAuditLogging keeps no record of transfers in the audit ledger,
+	// and creates no ledger files in DataDir
+	DisableAuditLogging bool
+
+	// SummaryPath, if set, receives a JSON summary of each finished transfer
+	// (see Result.ToJSON). transfer.SummaryStdout writes it to standard output.
+	SummaryPath string
+
+	// MinCodeBits is the least estimated code entropy Send accepts (see
+	// transfer.EstimateCodeEntropy). Nil uses transfer.DefaultMinCodeBits and
+	// zero accepts any code.
+	MinCodeBits *float64
+
+	// FileTypePolicy limits the kinds of files Receive writes. Blocked files
+	// are listed in Result.BlockedFiles, or fail the receive with an error
+	// matching transfer.ErrFileTypeBlocked when the policy rejects.
+	FileTypePolicy transfer.FileTypePolicy
+
+	// Quarantine, if set, receives into a quarantine folder and releases the
+	// files to their destination only once they pass its check. Held files
+	// fail the receive with an error matching transfer.ErrQuarantined.
+	Quarantine *transfer.QuarantineConfig
+
+	// Hooks are commands run after a send or receive succeeds, with the
+	// transfer summary on standard input. A failing hook is reported in
+	// Result.HookError.
+	Hooks transfer.TransferHooks
+
+	// Trace writes a protocol trace, a timeline of each transfer's phases, to
+	// the state directory for debugging stuck transfers (see
+	// Client.TracePath). Setting TRUSTDROP_TRACE=1 does the same.
+	Trace bool
+}
+
+// Progress is a progress update for the transfer in flight, covering both the
+// current file and the transfer as a whole
+type Progress = transfer.TransferProgress
+
+// Result describes a completed transfer
+type Result = transfer.TransferResult
+
+// VerifyReport is the result of re-checking received files
+type VerifyReport = transfer.VerifyReport
+
+// SendEstimate is the size and expected duration of a send
+type SendEstimate = transfer.SendEstimate
+
+// TransferStatus describes a transfer in flight
+type TransferStatus = transfer.TransferStatus
+
+// SelfTestResult reports how a self-test went
+type SelfTestResult = transfer.SelfTestResult
+
+// ShutdownError lists what Shutdown or Close could not stop or release
+type ShutdownError = transfer.ShutdownError
+
+// ModeThroughput is the measured encryption rate of one mode on this device
+type ModeThroughput = security.ModeThroughput
+
+// SelfTest sends a known folder between two in-process clients over an
+// in-memory transport and checks that it arrives intact. It needs no network
+// and leaves nothing behind.
+func SelfTest(ctx context.Context) *SelfTestResult {
+	return transfer.SelfTest(ctx)
+}
+
+// Client sends and receives files. A Client runs one transfer at a time.
+type Client struct {
+	manager *transfer.BulletproofTransferManager
+
+	progress chan Progress
+	status   chan string
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+// New creates a Client. The data directory is created if it does not exist.
+func New(opts Options) (*Client, error) {
+	if opts.DataDir == "" {
+		return nil, fmt.Errorf("data directory is required")
+	}
+	if err := os.MkdirAll(opts.DataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	manager, err := transfer.NewBulletproofTransferManager(opts.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer manager: %w", err)
+	}
+	manager.SetMaxInMemorySize(opts.MaxInMemorySize)
+	manager.SetMaxEmbedSize(opts.MaxEmbedSize)
+	manager.SetFolderLimits(opts.MaxFolderFiles, opts.MaxFolderBytes)
+	manager.SetMaxReceiveSize(opts.MaxReceiveSize)
+	manager.SetKeepPartialReceives(opts.KeepPartialReceives)
+	manager.SetRelayPassword(opts.RelayPassword)
+	manager.SetAuditLogging(!opts.DisableAuditLogging)
+	manager.SetSummaryPath(opts.SummaryPath)
+	if err := manager.SetPathMode(opts.PathMode); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if err := manager.SetReceiveLayout(opts.ReceiveLayout); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if err := manager.SetTempDir(opts.TempDir); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if err := manager.SetSenderIdentity(opts.ShareIdentity, opts.DisplayName); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if opts.MinCodeBits != nil {
+		if err := manager.SetMinCodeBits(*opts.MinCodeBits); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	if err := manager.SetFileTypePolicy(opts.FileTypePolicy); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if err := manager.SetArchiveConfig(opts.Archive); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	manager.SetBinaryFiles(opts.BinaryFiles)
+	if err := manager.SetQuarantine(opts.Quarantine); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if err := manager.SetHooks(opts.Hooks); err != nil {
+		manager.Close()
+		return nil, err
+	}
+	if opts.Retry != nil {
+		if err := manager.SetRetryStrategy(*opts.Retry); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	if opts.Trace {
+		if err := manager.SetTrace(true); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+
+	c := &Client{
+		manager:  manager,
+		progress: make(chan Progress, channelBufferSize),
+		status:   make(chan string, channelBufferSize),
+	}
+
+	manager.SetProgressCallback(c.publishProgress)
+	manager.SetStatusCallback(c.publishStatus)
+
+	return c, nil
+}
+
+// Progress returns the channel of progress updates. Updates are dropped rather
+// than blocking the transfer when the channel is full. It is closed by Close.
+func (c *Client) Progress() <-chan Progress {
+	return c.progress
+}
+
+// Status returns the channel of human-readable status messages. Messages are
+// dropped rather than blocking the transfer when the channel is full. It is
+// closed by Close.
+func (c *Client) Status() <-chan string {
+	return c.status
+}
+
+// Send sends files and folders using the given transfer code. Cancelling ctx
+// cancels the transfer. Selected items and folder files that cannot be read,
+// and folder files larger than Options.MaxEmbedSize, are left out and listed
+// in Result.UnsentFiles rather than failing the send; an empty selection, or
+// one with nothing readable, fails with an error matching
+// transfer.ErrNothingToSend. A code too weak
+// for Options.MinCodeBits is refused with an error matching transfer.ErrWeakCode,
+// and folders past the folder limits with one matching transfer.ErrFolderTooLarge.
+// Client.PreflightSend reports the latter before sending.
+func (c *Client) Send(ctx context.Context, files []string, code string) (*Result, error) {
+	return c.manager.SendFilesContext(ctx, files, code)
+}
+
+// Receive receives the transfer for code into dest, rebuilding the sender's
+// folder structure under it. An empty dest uses the "received" folder inside
+// the data directory. Cancelling ctx cancels the transfer. When a folder
+// arrives without some of its files, the error matches
+// transfer.ErrIncompleteTransfer and the returned Result lists both the
+// received and the missing files.
+func (c *Client) Receive(ctx context.Context, code, dest string) (*Result, error) {
+	if code == "" {
+		return nil, fmt.Errorf("transfer code is required")
+	}
+
+	if dest == "" {
+		return c.manager.ReceiveFilesContext(ctx, code)
+	}
+	return c.manager.ReceiveFilesToContext(ctx, code, dest)
+}
+
+// ReceiveSession keeps code active and receives one transfer after another
+// into the "received" folder until ctx is cancelled, for a sender who reuses
+// one code for several sends. onReceive is called after each receive; it
+// returns how many transfers arrived.
+func (c *Client) ReceiveSession(ctx context.Context, code string, onReceive func(*Result, error)) (int, error) {
+	if code == "" {
+		return 0, fmt.Errorf("transfer code is required")
+	}
+	return c.manager.ReceiveSessionContext(ctx, code, onReceive)
+}
+
+// PreflightSend reports how much Send would transfer for files and roughly
+// how long it would take, without sending anything
+func (c *Client) PreflightSend(files []string) (*SendEstimate, error) {
+	return c.manager.PreflightSend(files)
+}
+
+// VerifyReceived re-checks the files received for code against the checksums
+// recorded when they arrived. It works offline at any time after the receive.
+func (c *Client) VerifyReceived(code string) (*VerifyReport, error) {
+	return c.manager.VerifyReceived(code)
+}
+
+// ActiveTransfers lists the transfers in flight with their code, direction,
+// latest progress and transport
+func (c *Client) ActiveTransfers() []TransferStatus {
+	return c.manager.ActiveTransfers()
+}
+
+// CancelTransfer cancels the transfer in flight with the given ID, as listed
+// by ActiveTransfers. It returns an error matching transfer.ErrTransferNotFound
+// once that transfer has finished.
+func (c *Client) CancelTransfer(id string) error {
+	return c.manager.CancelTransfer(id)
+}
+
+// Ready reports whether at least one transport initialized, without which
+// every transfer fails, and lists the transports that did not as
+// "name: error"
+func (c *Client) Ready() (bool, []string) {
+	return c.manager.Ready()
+}
+
+// LoggingAvailable reports whether transfers are recorded in the audit
+// ledger. When it can't be opened, transfers still work without an audit trail.
+func (c *Client) LoggingAvailable() bool {
+	return c.manager.LoggingAvailable()
+}
+
+// TransportNames lists the transports that initialized. Pass some of them to
+// transfer.WithTransports to limit one Send or Receive to them instead of
+// automatic failover.
+func (c *Client) TransportNames() []string {
+	return c.manager.TransportNames()
+}
+
+// TracePath returns the protocol trace file, or "" when tracing is off.
+// Attach it when reporting a stuck or failed transfer.
+func (c *Client) TracePath() string {
+	return c.manager.TracePath()
+}
+
+// EncryptionThroughput returns how fast each encryption mode has run on this
+// device. Large payloads use the faster authenticated mode by these figures.
+func (c *Client) EncryptionThroughput() []ModeThroughput {
+	return c.manager.EncryptionThroughput()
+}
+
+// Close cancels any transfer in flight, releases resources and closes the
+// progress and status channels
+func (c *Client) Close() error {
+	err := c.manager.Close()
+	c.closeChannels()
+	return err
+}
+
+// Shutdown cancels every transfer in flight and waits, until ctx ends, for
+// them to stop before releasing resources and closing the progress and status
+// channels. New transfers fail once it starts. What could not be stopped or
+// closed is listed in a *ShutdownError.
+func (c *Client) Shutdown(ctx context.Context) error {
+	err := c.manager.Shutdown(ctx)
+	c.closeChannels()
+	return err
+}
+
+// closeChannels closes the progress and status channels once
+func (c *Client) closeChannels() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.progress)
+		close(c.status)
+	}
+}
+
+// publishProgress delivers a progress update without blocking
+func (c *Client) publishProgress(update Progress) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.progress <- update:
+	default:
+	}
+}
+
+// publishStatus delivers a status message without blocking
+func (c *Client) publishStatus(status string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.status <- status:
+	default:
+	}
+}